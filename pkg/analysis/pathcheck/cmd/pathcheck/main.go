@@ -0,0 +1,14 @@
+// Command pathcheck reports invalid constant JSONPath expressions. It can be run on its own or
+// through go vet:
+//
+//	go vet -vettool=$(which pathcheck) ./...
+package main
+
+import (
+	"github.com/pb33f/jsonpath/pkg/analysis/pathcheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(pathcheck.Analyzer)
+}
@@ -0,0 +1,39 @@
+package pathcheck
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOptions checks that the analyzer knows every config option taking no arguments, so that calls
+// using a new one are checked rather than skipped
+func TestOptions(t *testing.T) {
+	files, err := filepath.Glob("../../jsonpath/config/*.go")
+	require.NoError(t, err)
+
+	var names []string
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), name, nil, 0)
+		require.NoError(t, err)
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if ok && fn.Recv == nil && strings.HasPrefix(fn.Name.Name, "With") && fn.Type.Params.NumFields() == 0 {
+				names = append(names, fn.Name.Name)
+			}
+		}
+	}
+	require.NotEmpty(t, names)
+	for _, name := range names {
+		assert.Contains(t, options, name)
+	}
+}
@@ -0,0 +1,113 @@
+// Package pathcheck defines an analyzer that reports JSONPath syntax errors in string constants
+// passed to jsonpath.NewPath and jsonpath.MustNewPath, so broken expressions fail at vet time
+// rather than at run time.
+//
+// Options from the config package that take no arguments (such as config.WithStrictRFC9535()) are
+// applied when checking the expression. Calls passing any other option are skipped, since the
+// syntax they accept cannot be known statically.
+package pathcheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const (
+	jsonpathPackage = "github.com/pb33f/jsonpath/pkg/jsonpath"
+	configPackage   = "github.com/pb33f/jsonpath/pkg/jsonpath/config"
+)
+
+// Analyzer reports invalid constant JSONPath expressions.
+var Analyzer = &analysis.Analyzer{
+	Name:     "pathcheck",
+	Doc:      "check constant JSONPath expressions passed to jsonpath.NewPath and jsonpath.MustNewPath",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// options are the config options the analyzer can apply, which are all those taking no arguments
+var options = map[string]func() config.Option{
+	"WithPropertyNameExtension": config.WithPropertyNameExtension,
+	"WithStrictRFC9535":         config.WithStrictRFC9535,
+	"WithDeduplicatedResults":   config.WithDeduplicatedResults,
+	"WithLenientParsing":        config.WithLenientParsing,
+	"WithYAML11Numbers":         config.WithYAML11Numbers,
+	"WithNumericStrings":        config.WithNumericStrings,
+	"WithCopiedResults":         config.WithCopiedResults,
+	"WithGlobNames":             config.WithGlobNames,
+	"WithCaseInsensitiveKeys":   config.WithCaseInsensitiveKeys,
+	"WithAliasExpansion":        config.WithAliasExpansion,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn := calledFunction(pass.TypesInfo, call.Fun)
+		if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != jsonpathPackage {
+			return
+		}
+		if fn.Name() != "NewPath" && fn.Name() != "MustNewPath" {
+			return
+		}
+		if len(call.Args) == 0 || call.Ellipsis.IsValid() {
+			return
+		}
+		value := pass.TypesInfo.Types[call.Args[0]].Value
+		if value == nil || value.Kind() != constant.String {
+			return
+		}
+
+		var opts []config.Option
+		for _, arg := range call.Args[1:] {
+			opt, ok := knownOption(pass.TypesInfo, arg)
+			if !ok {
+				return
+			}
+			opts = append(opts, opt)
+		}
+
+		if _, err := jsonpath.NewPath(constant.StringVal(value), opts...); err != nil {
+			pass.Reportf(call.Args[0].Pos(), "invalid JSONPath expression: %v", err)
+		}
+	})
+	return nil, nil
+}
+
+// knownOption resolves an argument of the form config.WithX() to the option it creates.
+func knownOption(info *types.Info, arg ast.Expr) (config.Option, bool) {
+	call, ok := arg.(*ast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return nil, false
+	}
+	fn := calledFunction(info, call.Fun)
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != configPackage {
+		return nil, false
+	}
+	option, ok := options[fn.Name()]
+	if !ok {
+		return nil, false
+	}
+	return option(), true
+}
+
+func calledFunction(info *types.Info, fun ast.Expr) *types.Func {
+	var ident *ast.Ident
+	switch f := fun.(type) {
+	case *ast.Ident:
+		ident = f
+	case *ast.SelectorExpr:
+		ident = f.Sel
+	default:
+		return nil
+	}
+	fn, _ := info.Uses[ident].(*types.Func)
+	return fn
+}
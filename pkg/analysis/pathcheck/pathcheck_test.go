@@ -0,0 +1,20 @@
+package pathcheck_test
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/analysis/pathcheck"
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), pathcheck.Analyzer, "example")
+}
+
+func TestAnalyzer_Plus(t *testing.T) {
+	if !config.PlusAvailable {
+		t.Skip("JSONPath Plus extensions are not built in")
+	}
+	analysistest.Run(t, analysistest.TestData(), pathcheck.Analyzer, "plus")
+}
@@ -0,0 +1,24 @@
+package example
+
+import (
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+)
+
+const operations = `$.paths.*[get`
+
+var (
+	valid   = jsonpath.MustNewPath(`$.paths.*.get`)
+	invalid = jsonpath.MustNewPath(`$.paths[`)                                         // want `invalid JSONPath expression`
+	named   = jsonpath.MustNewPath(operations)                                         // want `invalid JSONPath expression`
+	strict  = jsonpath.MustNewPath(`$[?@property == 'a']`, config.WithStrictRFC9535()) // want `invalid JSONPath expression`
+	lenient = jsonpath.MustNewPath(`$."quoted"`, config.WithLenientParsing())
+	globs   = jsonpath.MustNewPath(`$.paths[`, config.WithGlobNames()) // want `invalid JSONPath expression`
+	custom  = jsonpath.MustNewPath(`$.paths[`, config.Custom("unknown"))
+)
+
+func dynamic(expr string) {
+	_, _ = jsonpath.NewPath(expr)
+	_, _ = jsonpath.NewPath(`$..[`) // want `invalid JSONPath expression`
+	_, _ = jsonpath.NewPath(expr + `[`)
+}
@@ -0,0 +1,11 @@
+package config
+
+type Option func()
+
+func WithStrictRFC9535() Option { return nil }
+
+func WithLenientParsing() Option { return nil }
+
+func WithGlobNames() Option { return nil }
+
+func Custom(name string) Option { return nil }
@@ -0,0 +1,6 @@
+package plus
+
+import "github.com/pb33f/jsonpath/pkg/jsonpath"
+
+// JSONPath Plus is enabled by default, in builds without the jsonpath_core tag
+var property = jsonpath.MustNewPath(`$[?@property == 'get']`)
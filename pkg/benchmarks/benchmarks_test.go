@@ -0,0 +1,85 @@
+package benchmarks
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkQuery(b *testing.B) {
+	for _, workload := range Workloads() {
+		root := workload.Document()
+		for _, name := range sortedNames(workload.Queries) {
+			path := jsonpath.MustNewPath(workload.Queries[name])
+			b.Run(workload.Name+"/"+name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					path.Query(root)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkCompile(b *testing.B) {
+	for _, workload := range Workloads() {
+		for _, name := range sortedNames(workload.Queries) {
+			expr := workload.Queries[name]
+			b.Run(workload.Name+"/"+name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := jsonpath.NewPath(expr); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestWorkloadQueries(t *testing.T) {
+	for _, workload := range Workloads() {
+		root := workload.Document()
+		for name, expr := range workload.Queries {
+			path, err := jsonpath.NewPath(expr)
+			require.NoError(t, err, "%s/%s", workload.Name, name)
+			assert.NotEmpty(t, path.Query(root), "%s/%s matches nothing", workload.Name, name)
+			_, ok := Thresholds[workload.Name+"/"+name]
+			assert.True(t, ok, "%s/%s has no threshold", workload.Name, name)
+		}
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	if os.Getenv("JSONPATH_BENCH_THRESHOLDS") == "" {
+		t.Skip("set JSONPATH_BENCH_THRESHOLDS=1 to check benchmark thresholds")
+	}
+	for _, workload := range Workloads() {
+		root := workload.Document()
+		for _, name := range sortedNames(workload.Queries) {
+			key := workload.Name + "/" + name
+			path := jsonpath.MustNewPath(workload.Queries[name])
+			result := testing.Benchmark(func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					path.Query(root)
+				}
+			})
+			t.Log(key, result.String(), result.MemString())
+			assert.NoError(t, Thresholds[key].Check(result), key)
+		}
+	}
+}
+
+func sortedNames(queries map[string]string) []string {
+	names := make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,49 @@
+package benchmarks
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Threshold is the most time and allocations a query of a workload may take. The bounds leave room
+// for slower machines, so a query exceeding them has regressed rather than run on a busy host.
+type Threshold struct {
+	MaxNsPerOp     int64
+	MaxAllocsPerOp int64
+}
+
+// Thresholds holds the threshold of each query, keyed by workload and query name, e.g.
+// openapi/filter. Lower a threshold when an improvement lands, so it is not lost later.
+var Thresholds = map[string]Threshold{
+	"openapi/descendant-refs": {MaxNsPerOp: 95000000, MaxAllocsPerOp: 180000},
+	"openapi/filter":          {MaxNsPerOp: 13000000, MaxAllocsPerOp: 18000},
+	"openapi/function":        {MaxNsPerOp: 4500000, MaxAllocsPerOp: 7600},
+	"openapi/member":          {MaxNsPerOp: 8300, MaxAllocsPerOp: 26},
+	"openapi/nested-filter":   {MaxNsPerOp: 33000000, MaxAllocsPerOp: 48000},
+	"openapi/operations":      {MaxNsPerOp: 8200000, MaxAllocsPerOp: 7500},
+	"openapi/property-name":   {MaxNsPerOp: 29000000, MaxAllocsPerOp: 50000},
+
+	"kubernetes/descendant":  {MaxNsPerOp: 15000000, MaxAllocsPerOp: 37000},
+	"kubernetes/images":      {MaxNsPerOp: 4700000, MaxAllocsPerOp: 7600},
+	"kubernetes/kinds":       {MaxNsPerOp: 1800000, MaxAllocsPerOp: 3000},
+	"kubernetes/labels":      {MaxNsPerOp: 4600000, MaxAllocsPerOp: 8400},
+	"kubernetes/match":       {MaxNsPerOp: 20000000, MaxAllocsPerOp: 36000},
+	"kubernetes/root-filter": {MaxNsPerOp: 3900000, MaxAllocsPerOp: 15000},
+	"kubernetes/slice":       {MaxNsPerOp: 500000, MaxAllocsPerOp: 1100},
+
+	"deep-config/descendant": {MaxNsPerOp: 1900000, MaxAllocsPerOp: 4700},
+	"deep-config/filter":     {MaxNsPerOp: 5500000, MaxAllocsPerOp: 11000},
+	"deep-config/leaf":       {MaxNsPerOp: 36000, MaxAllocsPerOp: 110},
+	"deep-config/wildcard":   {MaxNsPerOp: 430000, MaxAllocsPerOp: 500},
+}
+
+// Check returns an error if result exceeds the threshold.
+func (t Threshold) Check(result testing.BenchmarkResult) error {
+	if ns := result.NsPerOp(); t.MaxNsPerOp > 0 && ns > t.MaxNsPerOp {
+		return fmt.Errorf("took %d ns/op, above the threshold of %d ns/op", ns, t.MaxNsPerOp)
+	}
+	if allocs := result.AllocsPerOp(); t.MaxAllocsPerOp > 0 && allocs > t.MaxAllocsPerOp {
+		return fmt.Errorf("made %d allocs/op, above the threshold of %d allocs/op", allocs, t.MaxAllocsPerOp)
+	}
+	return nil
+}
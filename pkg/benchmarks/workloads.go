@@ -0,0 +1,206 @@
+// Package benchmarks measures the JSONPath evaluator against representative documents: a large
+// OpenAPI description, a bundle of Kubernetes manifests and a deeply nested configuration. The
+// documents are generated, so every run measures the same input without large fixtures.
+//
+// Run the suite with:
+//
+//	go test ./pkg/benchmarks -run '^$' -bench . -benchmem
+//
+// The standard go test profiling flags write profiles for go tool pprof:
+//
+//	go test ./pkg/benchmarks -run '^$' -bench 'Query/openapi' -cpuprofile cpu.out -memprofile mem.out
+//	go tool pprof -http :8080 cpu.out
+//
+// Compare runs before and after a change with golang.org/x/perf/cmd/benchstat, using -count 10 for
+// each. Thresholds records upper bounds for each query; set JSONPATH_BENCH_THRESHOLDS=1 to fail the
+// tests of this package when a query exceeds them.
+package benchmarks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"go.yaml.in/yaml/v4"
+)
+
+// Workload is a document with the queries typically run against it.
+type Workload struct {
+	Name string
+	// Document returns the parsed document. Each call parses it again, so callers may modify it.
+	Document func() *yaml.Node
+	// Queries maps a short name for each query to its expression.
+	Queries map[string]string
+}
+
+// Workloads returns the representative workloads at their default sizes.
+func Workloads() []Workload {
+	return []Workload{
+		OpenAPIWorkload(500),
+		KubernetesWorkload(300),
+		DeepConfigWorkload(12, 3),
+	}
+}
+
+// OpenAPIWorkload is an OpenAPI description with the given number of paths, each with get and post
+// operations, parameters, responses and a component schema. The query of property names needs the
+// JSONPath Plus extensions, so builds with the jsonpath_core tag leave it out.
+func OpenAPIWorkload(paths int) Workload {
+	workload := Workload{
+		Name:     "openapi",
+		Document: parsed(OpenAPI(paths)),
+		Queries: map[string]string{
+			"member":          `$.info.title`,
+			"operations":      `$.paths.*.*`,
+			"descendant-refs": `$..['$ref']`,
+			"filter":          `$.paths.*[?@.deprecated == true].operationId`,
+			"nested-filter":   `$.paths.*.*.parameters[?@.in == 'query' && @.required == true].name`,
+			"function":        `$.components.schemas[?length(@.required) > 2]`,
+		},
+	}
+	if config.PlusAvailable {
+		workload.Queries["property-name"] = `$.paths[?match(@property, '/users/.*')]`
+	}
+	return workload
+}
+
+// KubernetesWorkload is a multi-resource bundle of Deployments, Services and ConfigMaps.
+func KubernetesWorkload(resources int) Workload {
+	return Workload{
+		Name:     "kubernetes",
+		Document: parsed(KubernetesBundle(resources)),
+		Queries: map[string]string{
+			"kinds":       `$.items[*].kind`,
+			"images":      `$.items[?@.kind == 'Deployment'].spec.template.spec.containers[*].image`,
+			"descendant":  `$..containers[?@.resources.limits.memory == '512Mi'].name`,
+			"labels":      `$.items[?@.metadata.labels.tier == 'backend'].metadata.name`,
+			"slice":       `$.items[10:200:3].metadata.name`,
+			"match":       `$.items[?match(@.metadata.name, 'service-1[0-9]*')]`,
+			"root-filter": `$.items[?@.metadata.namespace == $.items[0].metadata.namespace].kind`,
+		},
+	}
+}
+
+// DeepConfigWorkload is a configuration nested depth levels deep, with breadth children at each
+// level.
+func DeepConfigWorkload(depth, breadth int) Workload {
+	return Workload{
+		Name:     "deep-config",
+		Document: parsed(DeepConfig(depth, breadth)),
+		Queries: map[string]string{
+			"leaf":       `$` + strings.Repeat(`.child0`, depth) + `.value`,
+			"descendant": `$..value`,
+			"filter":     `$..[?@.enabled == true && @.weight > 5].name`,
+			"wildcard":   `$.*.*.*.*.name`,
+		},
+	}
+}
+
+// OpenAPI generates an OpenAPI description with the given number of paths.
+func OpenAPI(paths int) string {
+	var b strings.Builder
+	b.WriteString("openapi: 3.1.0\ninfo:\n  title: Generated API\n  version: 1.0.0\npaths:\n")
+	for i := 0; i < paths; i++ {
+		resource := [...]string{"users", "orders", "products", "invoices"}[i%4]
+		fmt.Fprintf(&b, "  /%s/r%d/{id}:\n", resource, i)
+		for _, method := range []string{"get", "post"} {
+			fmt.Fprintf(&b, "    %s:\n", method)
+			fmt.Fprintf(&b, "      operationId: %s%s%d\n", method, resource, i)
+			fmt.Fprintf(&b, "      deprecated: %t\n", i%7 == 0)
+			b.WriteString("      parameters:\n")
+			b.WriteString("        - {name: id, in: path, required: true, schema: {type: string}}\n")
+			fmt.Fprintf(&b, "        - {name: limit, in: query, required: %t, schema: {type: integer}}\n", i%3 == 0)
+			b.WriteString("      responses:\n")
+			b.WriteString("        '200':\n          description: OK\n          content:\n            application/json:\n")
+			fmt.Fprintf(&b, "              schema: {$ref: '#/components/schemas/Model%d'}\n", i)
+			b.WriteString("        '404': {description: Not found}\n")
+		}
+	}
+	b.WriteString("components:\n  schemas:\n")
+	for i := 0; i < paths; i++ {
+		fmt.Fprintf(&b, "    Model%d:\n      type: object\n", i)
+		b.WriteString("      required: [id, name")
+		for r := 0; r < i%4; r++ {
+			fmt.Fprintf(&b, ", field%d", r)
+		}
+		b.WriteString("]\n      properties:\n")
+		b.WriteString("        id: {type: string, format: uuid}\n        name: {type: string}\n")
+		for r := 0; r < 4; r++ {
+			fmt.Fprintf(&b, "        field%d: {type: integer}\n", r)
+		}
+	}
+	return b.String()
+}
+
+// KubernetesBundle generates a list of the given number of Kubernetes resources.
+func KubernetesBundle(resources int) string {
+	var b strings.Builder
+	b.WriteString("apiVersion: v1\nkind: List\nitems:\n")
+	for i := 0; i < resources; i++ {
+		tier := [...]string{"frontend", "backend", "cache"}[i%3]
+		switch i % 3 {
+		case 0:
+			b.WriteString("  - apiVersion: apps/v1\n    kind: Deployment\n")
+		case 1:
+			b.WriteString("  - apiVersion: v1\n    kind: Service\n")
+		default:
+			b.WriteString("  - apiVersion: v1\n    kind: ConfigMap\n")
+		}
+		fmt.Fprintf(&b, "    metadata:\n      name: service-%d\n      namespace: ns-%d\n", i, i%5)
+		fmt.Fprintf(&b, "      labels: {app: service-%d, tier: %s}\n", i, tier)
+		switch i % 3 {
+		case 0:
+			fmt.Fprintf(&b, "    spec:\n      replicas: %d\n      template:\n        spec:\n          containers:\n", i%4+1)
+			for c := 0; c < 2; c++ {
+				fmt.Fprintf(&b, "            - name: container-%d\n", c)
+				fmt.Fprintf(&b, "              image: registry.example.com/service-%d:%d.%d\n", i, c, i%10)
+				fmt.Fprintf(&b, "              resources: {limits: {memory: %s, cpu: 500m}}\n", [...]string{"256Mi", "512Mi"}[c])
+				b.WriteString("              ports: [{containerPort: 8080}]\n")
+			}
+		case 1:
+			b.WriteString("    spec:\n      ports: [{port: 80, targetPort: 8080}]\n")
+			fmt.Fprintf(&b, "      selector: {app: service-%d}\n", i)
+		default:
+			fmt.Fprintf(&b, "    data:\n      LOG_LEVEL: info\n      SERVICE_INDEX: '%d'\n", i)
+		}
+	}
+	return b.String()
+}
+
+// DeepConfig generates a configuration nested depth levels deep, with breadth children at each
+// level.
+func DeepConfig(depth, breadth int) string {
+	var b strings.Builder
+	deepConfigLevel(&b, depth, breadth, 0, 0)
+	return b.String()
+}
+
+func deepConfigLevel(b *strings.Builder, depth, breadth, level, seed int) {
+	indent := strings.Repeat("  ", level)
+	fmt.Fprintf(b, "%sname: node-%d-%d\n", indent, level, seed)
+	fmt.Fprintf(b, "%senabled: %t\n", indent, seed%2 == 0)
+	fmt.Fprintf(b, "%sweight: %d\n", indent, seed%10)
+	fmt.Fprintf(b, "%svalue: %d\n", indent, level*1000+seed)
+	if level == depth {
+		return
+	}
+	// only the first child branches, so the document grows linearly with depth
+	children := breadth
+	if seed != 0 {
+		children = 1
+	}
+	for c := 0; c < children; c++ {
+		fmt.Fprintf(b, "%schild%d:\n", indent, c)
+		deepConfigLevel(b, depth, breadth, level+1, seed*breadth+c)
+	}
+}
+
+func parsed(document string) func() *yaml.Node {
+	return func() *yaml.Node {
+		var node yaml.Node
+		if err := yaml.Unmarshal([]byte(document), &node); err != nil {
+			panic(err)
+		}
+		return &node
+	}
+}
@@ -0,0 +1,244 @@
+// Package document holds a parsed YAML or JSON document together with the indexes that querying and
+// editing it need: the parent of every node, the normalized path of every node, and a cache of query
+// prefixes. Edits made through the document keep them consistent, so callers never rebuild them.
+//
+//	doc, err := document.Parse(data)
+//	ids := doc.Query(jsonpath.MustNewPath(`$.paths.*.*.operationId`))
+//	path := doc.Path(ids[0]) // $['paths']['/users']['get']['operationId']
+//	_, err = doc.Set(jsonpath.MustNewPath(`$.info['x-owner']`), &yaml.Node{Kind: yaml.ScalarNode, Value: "team"})
+//	err = doc.ApplyOverlay(o)
+//
+// Edits apply to the nodes queries select, so paths compiled with config.WithCopiedResults, whose
+// results are copies, edit nothing. A Document is not safe for concurrent use, and the document must
+// only be modified through it.
+package document
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/overlay"
+	"go.yaml.in/yaml/v4"
+)
+
+// Document is a parsed document with its indexes.
+type Document struct {
+	root    *yaml.Node
+	queries *jsonpath.QueryCache
+	parents map[*yaml.Node]*yaml.Node
+	paths   map[*yaml.Node]string
+}
+
+// New returns a document for root, which it modifies in place.
+func New(root *yaml.Node) *Document {
+	d := &Document{root: root}
+	d.reindex()
+	return d
+}
+
+// Parse parses a YAML or JSON document.
+func Parse(data []byte) (*Document, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	return New(&root), nil
+}
+
+// Root returns the root node of the document.
+func (d *Document) Root() *yaml.Node {
+	return d.root
+}
+
+// Query returns the nodes path selects, as path.Query does, reusing the results of the query
+// prefixes earlier queries shared with it.
+func (d *Document) Query(path *jsonpath.JSONPath) []*yaml.Node {
+	return d.queries.Query(path)
+}
+
+// Parent returns the mapping or sequence that holds node, or nil for the root and nodes not in the
+// document.
+func (d *Document) Parent(node *yaml.Node) *yaml.Node {
+	parent := d.parents[node]
+	if parent != nil && parent.Kind == yaml.DocumentNode {
+		return nil
+	}
+	return parent
+}
+
+// Path returns the normalized path of node, such as $['paths']['/users'], or "" if node is not in the
+// document. Mapping keys have the path of their value with the JSONPath Plus ~ suffix.
+func (d *Document) Path(node *yaml.Node) string {
+	return d.paths[node]
+}
+
+// Set replaces each node path selects with a copy of value and returns how many it replaced. If path
+// selects nothing and ends with a member name, such as $.info.summary, the member is added to each
+// mapping the rest of the path selects instead.
+func (d *Document) Set(path *jsonpath.JSONPath, value *yaml.Node) (int, error) {
+	if value == nil {
+		return 0, errors.New("no value to set")
+	}
+	count := 0
+	for _, node := range d.Query(path) {
+		parent := d.parents[node]
+		if node == d.root || parent != nil && parent.Kind == yaml.DocumentNode {
+			d.replaceContent(node, value)
+			count++
+			continue
+		}
+		// nodes an earlier replacement removed from the document, and mapping keys, are left alone
+		i := childIndex(parent, node)
+		if i < 0 || parent.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		d.update(parent, func() {
+			parent.Content[i] = copyNode(value)
+		})
+		count++
+	}
+	if count > 0 {
+		return count, nil
+	}
+
+	parentPath, name, ok := path.SplitMember()
+	if !ok {
+		return 0, nil
+	}
+	for _, parent := range d.Query(parentPath) {
+		if parent.Kind != yaml.MappingNode {
+			continue
+		}
+		d.update(parent, func() {
+			parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, copyNode(value))
+		})
+		count++
+	}
+	return count, nil
+}
+
+// Delete removes each node path selects from the mapping or sequence that holds it and returns how
+// many it removed. The root cannot be deleted.
+func (d *Document) Delete(path *jsonpath.JSONPath) (int, error) {
+	nodes := d.Query(path)
+	count := 0
+	for _, node := range nodes {
+		parent := d.parents[node]
+		if node == d.root || parent != nil && parent.Kind == yaml.DocumentNode {
+			return count, errors.New("cannot delete the root of the document")
+		}
+		i := childIndex(parent, node)
+		if i < 0 {
+			// an earlier deletion removed it from the document
+			continue
+		}
+		d.update(parent, func() {
+			if parent.Kind == yaml.MappingNode {
+				// remove the key with its value
+				i -= i % 2
+				parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			} else {
+				parent.Content = append(parent.Content[:i], parent.Content[i+1:]...)
+			}
+		})
+		count++
+	}
+	return count, nil
+}
+
+// ApplyOverlay applies an overlay to the document, as overlay.Overlay.ApplyTo does, and then rebuilds
+// the indexes. A failed overlay may have applied some of its actions.
+func (d *Document) ApplyOverlay(o *overlay.Overlay, opts ...overlay.ApplyOption) error {
+	err := o.ApplyTo(d.root, opts...)
+	d.reindex()
+	return err
+}
+
+// update modifies the children of parent with fn, updating the indexes for everything below it
+func (d *Document) update(parent *yaml.Node, fn func()) {
+	d.queries.Invalidate(parent)
+	d.unindex(parent)
+	fn()
+	d.index(parent)
+}
+
+// replaceContent replaces the top-level node of the document
+func (d *Document) replaceContent(node *yaml.Node, value *yaml.Node) {
+	if node == d.root {
+		*d.root = *copyNode(value)
+	} else {
+		d.root.Content[0] = copyNode(value)
+	}
+	d.reindex()
+}
+
+// reindex rebuilds every index
+func (d *Document) reindex() {
+	d.queries = jsonpath.NewQueryCache(d.root)
+	d.parents = make(map[*yaml.Node]*yaml.Node)
+	d.paths = jsonpath.NormalizedPaths(d.root)
+	indexParents(d.parents, d.root)
+}
+
+// unindex removes the nodes below parent from the indexes
+func (d *Document) unindex(parent *yaml.Node) {
+	for _, child := range parent.Content {
+		if d.parents[child] != parent {
+			continue
+		}
+		delete(d.parents, child)
+		delete(d.paths, child)
+		d.unindex(child)
+	}
+}
+
+// index adds the nodes below parent to the indexes
+func (d *Document) index(parent *yaml.Node) {
+	indexParents(d.parents, parent)
+	base, ok := d.paths[parent]
+	if !ok {
+		return
+	}
+	for node, path := range jsonpath.NormalizedPaths(parent) {
+		if node != parent {
+			d.paths[node] = base + strings.TrimPrefix(path, "$")
+		}
+	}
+}
+
+// childIndex returns the position of node among the children of parent, or -1
+func childIndex(parent *yaml.Node, node *yaml.Node) int {
+	if parent == nil {
+		return -1
+	}
+	for i, child := range parent.Content {
+		if child == node {
+			return i
+		}
+	}
+	return -1
+}
+
+func indexParents(parents map[*yaml.Node]*yaml.Node, parent *yaml.Node) {
+	for _, child := range parent.Content {
+		if _, seen := parents[child]; seen {
+			continue
+		}
+		parents[child] = parent
+		indexParents(parents, child)
+	}
+}
+
+// copyNode returns a deep copy of node, sharing nothing with it, or of the content of a document node
+func copyNode(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) == 1 {
+		node = node.Content[0]
+	}
+	out := *node
+	out.Content = nil
+	for _, child := range node.Content {
+		out.Content = append(out.Content, copyNode(child))
+	}
+	return &out
+}
@@ -0,0 +1,169 @@
+package document_test
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/document"
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/overlay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const spec = `openapi: 3.1.0
+info:
+  title: pets
+tags:
+  - name: pets
+  - name: store
+  - name: users
+paths:
+  /pets:
+    get:
+      operationId: listPets
+  /store:
+    get:
+      operationId: getInventory
+`
+
+func parse(t *testing.T) *document.Document {
+	doc, err := document.Parse([]byte(spec))
+	require.NoError(t, err)
+	return doc
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
+
+func values(nodes []*yaml.Node) []string {
+	var out []string
+	for _, node := range nodes {
+		out = append(out, node.Value)
+	}
+	return out
+}
+
+// assertIndexed checks the indexes against ones built from scratch
+func assertIndexed(t *testing.T, doc *document.Document) {
+	out, err := yaml.Marshal(doc.Root())
+	require.NoError(t, err)
+	fresh, err := document.Parse(out)
+	require.NoError(t, err)
+	paths := jsonpath.NormalizedPaths(doc.Root())
+	for node, path := range paths {
+		assert.Equal(t, path, doc.Path(node))
+		for _, child := range node.Content {
+			if node.Kind != yaml.DocumentNode {
+				assert.Same(t, node, doc.Parent(child), path)
+			}
+		}
+	}
+	ids := jsonpath.MustNewPath(`$..operationId`)
+	assert.Equal(t, values(fresh.Query(ids)), values(doc.Query(ids)))
+}
+
+func TestDocument_Query(t *testing.T) {
+	doc := parse(t)
+	ids := doc.Query(jsonpath.MustNewPath(`$.paths.*.get.operationId`))
+	assert.Equal(t, []string{"listPets", "getInventory"}, values(ids))
+	assert.Equal(t, "$['paths']['/store']['get']['operationId']", doc.Path(ids[1]))
+	get := doc.Parent(ids[1])
+	assert.Equal(t, "$['paths']['/store']['get']", doc.Path(get))
+	assert.Nil(t, doc.Parent(doc.Root().Content[0]))
+	assert.Empty(t, doc.Path(scalar("x")))
+}
+
+func TestDocument_Set(t *testing.T) {
+	doc := parse(t)
+
+	n, err := doc.Set(jsonpath.MustNewPath(`$.paths.*.get.operationId`), scalar("renamed"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"renamed", "renamed"}, values(doc.Query(jsonpath.MustNewPath(`$.paths.*.get.operationId`))))
+	assertIndexed(t, doc)
+
+	// a member that does not exist is added to every mapping that lacks it
+	n, err = doc.Set(jsonpath.MustNewPath(`$.paths.*.get.summary`), scalar("an operation"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	summaries := doc.Query(jsonpath.MustNewPath(`$.paths['/pets'].get.summary`))
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "$['paths']['/pets']['get']['summary']", doc.Path(summaries[0]))
+	assertIndexed(t, doc)
+
+	// the value is copied, so the nodes set are independent of it and of each other
+	value := scalar("shared")
+	_, err = doc.Set(jsonpath.MustNewPath(`$.tags[*].name`), value)
+	require.NoError(t, err)
+	value.Value = "changed"
+	assert.Equal(t, []string{"shared", "shared", "shared"}, values(doc.Query(jsonpath.MustNewPath(`$.tags[*].name`))))
+
+	// paths that select nothing and do not end with a member name change nothing
+	n, err = doc.Set(jsonpath.MustNewPath(`$.tags[5]`), scalar("x"))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	_, err = doc.Set(jsonpath.MustNewPath(`$.info`), nil)
+	assert.Error(t, err)
+}
+
+func TestDocument_SetRoot(t *testing.T) {
+	doc := parse(t)
+	n, err := doc.Set(jsonpath.MustNewPath(`$`), &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{scalar("openapi"), scalar("3.0.0")}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"3.0.0"}, values(doc.Query(jsonpath.MustNewPath(`$.openapi`))))
+	assert.Empty(t, doc.Query(jsonpath.MustNewPath(`$.info`)))
+	assertIndexed(t, doc)
+}
+
+func TestDocument_Delete(t *testing.T) {
+	doc := parse(t)
+	// warm the cache, which the deletion must invalidate
+	assert.Len(t, doc.Query(jsonpath.MustNewPath(`$.tags[*].name`)), 3)
+
+	n, err := doc.Delete(jsonpath.MustNewPath(`$.tags[?@.name != 'users']`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	names := doc.Query(jsonpath.MustNewPath(`$.tags[*].name`))
+	assert.Equal(t, []string{"users"}, values(names))
+	// the remaining tag moved to the front
+	assert.Equal(t, "$['tags'][0]['name']", doc.Path(names[0]))
+	assertIndexed(t, doc)
+
+	// nested matches removed with their ancestors are not counted again
+	n, err = doc.Delete(jsonpath.MustNewPath(`$..[?@.operationId || @.get]`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Empty(t, doc.Query(jsonpath.MustNewPath(`$..operationId`)))
+	assertIndexed(t, doc)
+
+	_, err = doc.Delete(jsonpath.MustNewPath(`$`))
+	assert.Error(t, err)
+}
+
+func TestDocument_ApplyOverlay(t *testing.T) {
+	doc := parse(t)
+	assert.Len(t, doc.Query(jsonpath.MustNewPath(`$.paths.*.get.operationId`)), 2)
+
+	var o overlay.Overlay
+	require.NoError(t, yaml.Unmarshal([]byte(`overlay: 1.0.0
+info:
+  title: rename
+  version: 1.0.0
+actions:
+  - target: $.paths['/store']
+    remove: true
+  - target: $.paths['/pets'].get
+    update:
+      operationId: listAllPets
+`), &o))
+	require.NoError(t, doc.ApplyOverlay(&o))
+
+	ids := doc.Query(jsonpath.MustNewPath(`$.paths.*.get.operationId`))
+	assert.Equal(t, []string{"listAllPets"}, values(ids))
+	assert.Equal(t, "$['paths']['/pets']['get']['operationId']", doc.Path(ids[0]))
+	assertIndexed(t, doc)
+}
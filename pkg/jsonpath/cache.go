@@ -0,0 +1,189 @@
+package jsonpath
+
+import (
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"go.yaml.in/yaml/v4"
+)
+
+// QueryCache evaluates many queries against the same document, sharing the work of common prefixes.
+// The leading child segments of a query that only select by name, index, slice or wildcard (such as
+// $.paths.*.*) are evaluated once and their results reused by every later query with that prefix.
+//
+// Callers that modify the document must report it with Invalidate so the affected prefixes are
+// evaluated again. A QueryCache is not safe for concurrent use.
+type QueryCache struct {
+	root       *yaml.Node
+	entries    map[prefixKey]*prefixEntry
+	dependents map[*yaml.Node][]prefixKey
+}
+
+// prefixKey identifies a prefix by the settings it was evaluated with and its printed segments
+type prefixKey struct {
+	config any
+	path   string
+}
+
+// prefixEntry holds the results of a prefix, and the nodes whose children produced them
+type prefixEntry struct {
+	results  []*yaml.Node
+	inputs   []*yaml.Node
+	children []prefixKey
+}
+
+// NewQueryCache returns an empty cache for queries against the document rooted at root.
+func NewQueryCache(root *yaml.Node) *QueryCache {
+	if root != nil && root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		root = root.Content[0]
+	}
+	return &QueryCache{
+		root:       root,
+		entries:    make(map[prefixKey]*prefixEntry),
+		dependents: make(map[*yaml.Node][]prefixKey),
+	}
+}
+
+// Query returns the same nodes as p.Query on the cached document.
+func (c *QueryCache) Query(p *JSONPath) []*yaml.Node {
+	return copyResults(p.config, c.query(p))
+}
+
+// query evaluates p on the cached document, returning the document's own nodes
+func (c *QueryCache) query(p *JSONPath) []*yaml.Node {
+	segments := p.ast.segments
+	prefix := 0
+	for prefix < len(segments) && segments[prefix].isStatic() {
+		prefix++
+	}
+	for _, seg := range segments[prefix:] {
+		if seg.usesPrefixState() {
+			// the rest of the query relies on state built up while evaluating the prefix
+			prefix = 0
+			break
+		}
+	}
+	if prefix == 0 || c.root == nil || len(p.ast.union) > 0 {
+		return p.ast.query(c.root, c.root, p.config)
+	}
+
+	key := prefixKey{config: config.Identity(p.config), path: "$"}
+	inputs := []*yaml.Node{c.root}
+	var parent *prefixEntry
+	for _, seg := range segments[:prefix] {
+		childKey := prefixKey{config: key.config, path: key.path + seg.ToString()}
+		entry, ok := c.entries[childKey]
+		if !ok {
+			entry = &prefixEntry{inputs: inputs}
+			ctx := newFilterContext(c.root, p.config)
+			for _, value := range inputs {
+				entry.results = append(entry.results, seg.Query(ctx, value, c.root)...)
+			}
+			if p.config.DeduplicateResults() {
+				entry.results = unique(entry.results)
+			}
+			c.entries[childKey] = entry
+			for _, input := range inputs {
+				c.dependents[input] = append(c.dependents[input], childKey)
+			}
+			if parent != nil {
+				parent.children = append(parent.children, childKey)
+			}
+		}
+		key, inputs, parent = childKey, entry.results, entry
+	}
+
+	rest := jsonPathAST{segments: segments[prefix:]}
+	ctx := newFilterContext(c.root, p.config)
+	if rest.hasParentReferences() {
+		ctx.EnableParentTracking()
+	}
+	result := inputs
+	for _, seg := range rest.segments {
+		next := []*yaml.Node{}
+		for _, value := range result {
+			next = append(next, seg.Query(ctx, value, c.root)...)
+		}
+		if p.config.DeduplicateResults() {
+			next = unique(next)
+		}
+		result = next
+	}
+	// callers may modify the returned slice, so never hand out the cached one
+	return append(make([]*yaml.Node, 0, len(result)), result...)
+}
+
+// Invalidate reports that node, or anything below it, has been modified. When a node is removed from
+// a mapping or sequence, the mapping or sequence is the node to report.
+func (c *QueryCache) Invalidate(node *yaml.Node) {
+	if len(c.entries) == 0 {
+		return
+	}
+	seen := make(map[*yaml.Node]bool)
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n == nil || seen[n] {
+			return
+		}
+		seen[n] = true
+		for _, key := range c.dependents[n] {
+			c.evict(key)
+		}
+		delete(c.dependents, n)
+		for _, child := range n.Content {
+			walk(child)
+		}
+	}
+	walk(node)
+}
+
+// Reset empties the cache.
+func (c *QueryCache) Reset() {
+	c.entries = make(map[prefixKey]*prefixEntry)
+	c.dependents = make(map[*yaml.Node][]prefixKey)
+}
+
+func (c *QueryCache) evict(key prefixKey) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	delete(c.entries, key)
+	for _, child := range entry.children {
+		c.evict(child)
+	}
+}
+
+// isStatic reports whether the segment selects children by name, index, slice or wildcard only, so
+// its results depend on nothing but the contents of the nodes it is applied to.
+func (s *segment) isStatic() bool {
+	if s.kind != segmentKindChild {
+		return false
+	}
+	if s.child.kind != segmentLongHand {
+		return true
+	}
+	for _, sel := range s.child.selectors {
+		if sel.kind == selectorSubKindFilter || sel.kind == selectorSubKindCustom {
+			return false
+		}
+	}
+	return true
+}
+
+// usesPrefixState reports whether evaluating the segment may depend on state built up while
+// evaluating earlier segments: the key and parent selectors, including those in the queries of its
+// filters, and context variables such as @path.
+func (s *segment) usesPrefixState() bool {
+	uses := false
+	w := &astWalker{
+		segment: func(seg *segment) {
+			if seg.kind == segmentKindProperyName || seg.kind == segmentKindParent {
+				uses = true
+			}
+		},
+		variable: func(*contextVariable) {
+			uses = true
+		},
+	}
+	w.segments([]*segment{s})
+	return uses
+}
@@ -0,0 +1,127 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const cacheDoc = `
+paths:
+  /users:
+    get: {operationId: listUsers, tags: [users]}
+    post: {operationId: createUser}
+  /orders:
+    get: {operationId: listOrders, tags: [orders, users]}
+`
+
+func TestQueryCache(t *testing.T) {
+	requirePlus(t)
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(cacheDoc), &node))
+	cache := NewQueryCache(&node)
+
+	queries := []string{
+		`$.paths.*.*`,
+		`$.paths.*.get`,
+		`$.paths.*.*.operationId`,
+		`$.paths.*.*[?@.operationId == 'createUser']`,
+		`$.paths.*.*.tags[?@ == 'users']`,
+		`$.paths.*.*[?@property == 'get']`,
+		`$.paths.*.*~`,
+		`$.paths['/users'].get^`,
+		`$..operationId`,
+		`$.paths[*][*].tags[0:1]`,
+	}
+	for _, q := range queries {
+		path, err := NewPath(q, config.WithPropertyNameExtension())
+		require.NoError(t, err, q)
+		assert.Equal(t, path.Query(&node), cache.Query(path), q)
+		// a second run is served from the cache
+		assert.Equal(t, path.Query(&node), cache.Query(path), q)
+	}
+	path, err := NewPath(`$.paths.*`, config.WithPropertyNameExtension())
+	require.NoError(t, err)
+	assert.Contains(t, cache.entries, prefixKey{config: config.Identity(path.config), path: "$.paths.*"})
+}
+
+func TestQueryCache_Config(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+defaults: &defaults {name: default}
+servers:
+  main: *defaults
+`), &node))
+	cache := NewQueryCache(&node)
+
+	for _, opts := range [][]config.Option{
+		nil,
+		{config.WithAliasExpansion()},
+		nil,
+		{config.WithAliasExpansion()},
+		{config.WithCaseInsensitiveKeys()},
+		{config.WithTypeErrorHandler(func(*yaml.Node, string, string) {})},
+	} {
+		path, err := NewPath(`$.servers.main.name`, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, path.Query(&node), cache.Query(path))
+	}
+	// paths compiled separately with the same options share their prefixes
+	assert.Len(t, cache.entries, 4*3)
+}
+
+func TestQueryCache_PrefixState(t *testing.T) {
+	requirePlus(t)
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(cacheDoc), &node))
+	cache := NewQueryCache(&node)
+
+	queries := []string{
+		`$.paths.*[?@.operationId^.tags]`,
+		`$.paths.*[?@^.post]`,
+		`$.paths.*[?@property == 'get']`,
+		`$.paths.*[?@.tags[?@path == "$['paths']['/orders']['get']['tags'][1]"]]`,
+	}
+	for _, q := range queries {
+		path, err := NewPath(q)
+		require.NoError(t, err, q)
+		// evaluate the prefix on its own first
+		prefix, err := NewPath(`$.paths.*`)
+		require.NoError(t, err)
+		cache.Query(prefix)
+		assert.NotEmpty(t, path.Query(&node), q)
+		assert.Equal(t, path.Query(&node), cache.Query(path), q)
+	}
+}
+
+func TestQueryCache_Invalidate(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(cacheDoc), &node))
+	cache := NewQueryCache(&node)
+
+	path, err := NewPath(`$.paths.*.*`)
+	require.NoError(t, err)
+	require.Len(t, cache.Query(path), 3)
+
+	// add an operation below /orders
+	orders := node.Content[0].Content[1].Content[3]
+	orders.Content = append(orders.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "delete"},
+		&yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+	assert.Len(t, cache.Query(path), 3, "stale until invalidated")
+	cache.Invalidate(orders)
+	assert.Len(t, cache.Query(path), 4)
+
+	// remove /users from paths
+	paths := node.Content[0].Content[1]
+	paths.Content = paths.Content[2:]
+	cache.Invalidate(paths)
+	assert.Equal(t, path.Query(&node), cache.Query(path))
+	assert.Len(t, cache.Query(path), 2)
+
+	cache.Reset()
+	assert.Empty(t, cache.entries)
+}
@@ -0,0 +1,374 @@
+package jsonpath
+
+import "strconv"
+
+// DependsOn reports whether changing the node at the normalized path, whether by replacing, adding
+// or removing it, could change the nodes the query returns or their values. It is judged from the
+// query alone, without a document, so it errs on the side of true: a query depends on a change at
+// or below a node it may return, at or above one, or within the nodes its filters may test or read.
+// Tools applying a stream of edits can use it to re-run only the queries an edit may affect.
+// Adding or removing an array element shifts the elements after it, so a query selecting an element
+// by index or slice depends on changes to the elements before it as well, as $.a[1] does on $['a'][0].
+//
+// Changes to anchored nodes also change their aliases elsewhere in the document, which the path of
+// the change does not reveal, so callers should treat those as affecting every query.
+func (p *JSONPath) DependsOn(path string) bool {
+	location := splitNormalizedPath(path)
+	for _, query := range p.ast.queries() {
+		if dependsOn(query.segments, location) {
+			return true
+		}
+	}
+	return false
+}
+
+// reach describes where the nodes selected by a prefix of a query may be, relative to the location
+// of a change: on the path from the root down to it, beside that path, below the location, or
+// further away
+type reach struct {
+	// on[d] is set if the node at the first d elements of the location may be selected
+	on []bool
+	// beside[d] is set if a node at depth d may be selected whose parent is on[d-1] but which is
+	// not on the path itself
+	beside []bool
+	below  bool
+	far    bool
+}
+
+func newReach(location []pathElement) reach {
+	return reach{on: make([]bool, len(location)+1), beside: make([]bool, len(location)+1)}
+}
+
+func (r reach) any() bool {
+	return r.onPath() || r.below || r.offPath()
+}
+
+// onPath reports whether a node on the path down to the location, or the location itself, may be
+// selected
+func (r reach) onPath() bool {
+	for _, on := range r.on {
+		if on {
+			return true
+		}
+	}
+	return false
+}
+
+// offPath reports whether a node neither on the path nor below the location may be selected
+func (r reach) offPath() bool {
+	for _, beside := range r.beside {
+		if beside {
+			return true
+		}
+	}
+	return r.far
+}
+
+// dependsOn reports whether the nodes selected by segments, applied to the root, may be affected
+// by a change at location
+func dependsOn(segments []*segment, location []pathElement) bool {
+	r := newReach(location)
+	r.on[0] = true
+	for _, seg := range segments {
+		var affected bool
+		r, affected = r.step(seg, location)
+		if affected {
+			return true
+		}
+	}
+	// the change is within a selected node, or replaces or removes one
+	return r.onPath() || r.below
+}
+
+// step applies a segment, reporting whether a filter it evaluates may already be affected
+func (r reach) step(seg *segment, location []pathElement) (reach, bool) {
+	switch seg.kind {
+	case segmentKindChild:
+		return r.children(seg.child, location)
+	case segmentKindDescendant:
+		// the descendant segment applies its selectors to a node and every node below it
+		from := newReach(location)
+		from.below = r.below || r.onPath()
+		from.far = r.far || r.offPath()
+		for d, on := range r.on {
+			if on {
+				for e := d; e < len(location); e++ {
+					from.on[e] = true
+					from.beside[e+1] = true
+				}
+				from.on[len(location)] = true
+				break
+			}
+		}
+		if seg.descendant.kind == segmentContainers {
+			return from, false
+		}
+		return from.children(seg.descendant, location)
+	case segmentKindParent:
+		if seg.parentKeys != nil {
+			// a keyed ascent may stop at any ancestor
+			ancestors := newReach(location)
+			for i := 0; i <= len(location)+1; i++ {
+				r = r.parents(location)
+				ancestors = ancestors.union(r)
+			}
+			return ancestors, false
+		}
+		for level := 0; level < max(seg.parentLevels, 1); level++ {
+			r = r.parents(location)
+		}
+		return r, false
+	}
+	// property names and type selectors select among the nodes themselves
+	return r, false
+}
+
+// parents applies a parent selector ascending one level
+func (r reach) parents(location []pathElement) reach {
+	parents := newReach(location)
+	parents.below = r.below
+	parents.far = r.far
+	for d := 1; d < len(r.on); d++ {
+		parents.on[d-1] = r.on[d] || r.beside[d]
+	}
+	if r.below {
+		parents.on[len(location)] = true
+	}
+	if r.far {
+		// a node further away may be a child of one beside the path
+		for d := 1; d < len(parents.beside); d++ {
+			parents.beside[d] = true
+		}
+	}
+	return parents
+}
+
+// union returns the reach of the nodes selected by either r or other
+func (r reach) union(other reach) reach {
+	u := reach{on: make([]bool, len(r.on)), beside: make([]bool, len(r.beside))}
+	for d := range r.on {
+		u.on[d] = r.on[d] || other.on[d]
+		u.beside[d] = r.beside[d] || other.beside[d]
+	}
+	u.below = r.below || other.below
+	u.far = r.far || other.far
+	return u
+}
+
+// children applies the selectors of a child segment
+func (r reach) children(inner *innerSegment, location []pathElement) (reach, bool) {
+	next := newReach(location)
+	next.below = r.below
+	next.far = r.far || r.offPath()
+	selectors := inner.selectors
+	switch inner.kind {
+	case segmentDotWildcard:
+		selectors = []*selector{{kind: selectorSubKindWildcard}}
+	case segmentDotMemberName:
+		selectors = []*selector{{kind: selectorSubKindName, name: inner.dotName}}
+	}
+	for _, sel := range selectors {
+		switch sel.kind {
+		case selectorSubKindFilter:
+			if r.filterDependsOn(sel.filter, location) {
+				return next, true
+			}
+		case selectorSubKindCustom:
+			// custom selectors may read anything below the nodes they are given
+			if r.onPath() || r.below {
+				return next, true
+			}
+		}
+		for d, on := range r.on {
+			if !on {
+				continue
+			}
+			if d == len(location) {
+				next.below = true
+				continue
+			}
+			if sel.selects(location[d]) {
+				next.on[d+1] = true
+			}
+			next.beside[d+1] = true
+		}
+	}
+	return next, false
+}
+
+// selects reports whether the selector may select the member or element at a location element
+func (s *selector) selects(element pathElement) bool {
+	switch s.kind {
+	case selectorSubKindName:
+		// lenient parsing reads a quoted index such as ['0'] as an index too
+		if element.isIndex {
+			return s.name == strconv.Itoa(element.index)
+		}
+		return s.name == element.name
+	case selectorSubKindArrayIndex:
+		// negative indices depend on the length of the array, and others on the elements before them,
+		// which adding or removing shifts
+		return element.isIndex && (s.index < 0 || s.index >= int64(element.index))
+	case selectorSubKindArraySlice:
+		return element.isIndex && s.slice.mayShift(int64(element.index))
+	case selectorSubKindScript:
+		// the index depends on the length of the array
+		return element.isIndex
+	case selectorSubKindGlob, selectorSubKindKeyPattern:
+		return !element.isIndex
+	}
+	return true
+}
+
+// mayShift reports whether the slice may include the element at index i or one after it, which
+// adding or removing the element at i shifts. It certainly does not when it counts forwards and
+// ends at a non-negative bound at or before i.
+func (s *slice) mayShift(i int64) bool {
+	step := int64(1)
+	if s.step != nil {
+		step = *s.step
+	}
+	if step <= 0 || s.start != nil && *s.start < 0 || s.end != nil && *s.end < 0 {
+		return step != 0
+	}
+	return s.end == nil || i < *s.end
+}
+
+// filterDependsOn reports whether a filter tested against the children of the selected nodes may
+// read a node affected by a change at location
+func (r reach) filterDependsOn(filter *filterSelector, location []pathElement) bool {
+	if !r.any() {
+		return false
+	}
+	reads := &filterReads{}
+	reads.logical(filter.expression)
+	// a filter reads below the nodes it tests, and the children of the nodes it is applied to through
+	// @parent, @sibling, @prev and @next, but a parent selector may take it anywhere
+	if r.onPath() || r.below || reads.climbs {
+		return true
+	}
+	for _, query := range reads.absolute {
+		if dependsOn(query, location) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterReads collects what a filter reads beyond the nodes it is applied to: the segments of its
+// absolute queries and @root, and whether it climbs out of them with a parent selector
+type filterReads struct {
+	absolute [][]*segment
+	climbs   bool
+	// paths is set if it reads @path, @propertyPath, @pathKeys or @parentProperty
+	paths bool
+}
+
+func (f *filterReads) segments(segments []*segment) {
+	for _, seg := range segments {
+		if seg.kind == segmentKindParent {
+			f.climbs = true
+		}
+		for _, inner := range []*innerSegment{seg.child, seg.descendant} {
+			if inner == nil {
+				continue
+			}
+			for _, sel := range inner.selectors {
+				if sel.kind == selectorSubKindFilter {
+					f.logical(sel.filter.expression)
+				}
+			}
+		}
+	}
+}
+
+func (f *filterReads) logical(expr *logicalOrExpr) {
+	if expr == nil {
+		return
+	}
+	for _, and := range expr.expressions {
+		for _, basic := range and.expressions {
+			switch {
+			case basic.parenExpr != nil:
+				f.logical(basic.parenExpr.expr)
+			case basic.comparisonExpr != nil:
+				f.comparable(basic.comparisonExpr.left)
+				f.comparable(basic.comparisonExpr.right)
+			case basic.testExpr != nil:
+				f.filterQuery(basic.testExpr.filterQuery)
+				f.function(basic.testExpr.functionExpr)
+				f.contextVariable(basic.testExpr.contextVar)
+			}
+		}
+	}
+	if expr.conditional != nil {
+		f.logical(expr.conditional.then)
+		f.logical(expr.conditional.otherwise)
+	}
+}
+
+func (f *filterReads) comparable(c *comparable) {
+	if c == nil {
+		return
+	}
+	if c.singularQuery != nil {
+		if c.singularQuery.relQuery != nil {
+			f.segments(c.singularQuery.relQuery.segments)
+		}
+		if abs := c.singularQuery.absQuery; abs != nil {
+			f.absolute = append(f.absolute, abs.segments)
+			if n := len(abs.segments); n > 0 && abs.segments[n-1].isMember("length") {
+				// the .length of an array depends on all of its elements
+				f.absolute = append(f.absolute, abs.segments[:n-1])
+			}
+			f.segments(abs.segments)
+		}
+	}
+	f.function(c.functionExpr)
+	f.contextVariable(c.contextVar)
+	if c.arithmetic != nil {
+		f.comparable(c.arithmetic.left)
+		f.comparable(c.arithmetic.right)
+	}
+}
+
+func (f *filterReads) filterQuery(q *filterQuery) {
+	if q == nil {
+		return
+	}
+	if q.relQuery != nil {
+		f.segments(q.relQuery.segments)
+	}
+	if q.jsonPathQuery != nil {
+		for _, query := range q.jsonPathQuery.queries() {
+			f.absolute = append(f.absolute, query.segments)
+			f.segments(query.segments)
+		}
+	}
+	f.contextVariable(q.contextVar)
+}
+
+func (f *filterReads) function(e *functionExpr) {
+	if e == nil {
+		return
+	}
+	for _, arg := range e.args {
+		f.filterQuery(arg.filterQuery)
+		f.logical(arg.logicalExpr)
+		f.function(arg.functionExpr)
+		f.contextVariable(arg.contextVar)
+	}
+}
+
+func (f *filterReads) contextVariable(cv *contextVariable) {
+	if cv == nil {
+		return
+	}
+	switch cv.kind {
+	case contextVarRoot:
+		f.absolute = append(f.absolute, cv.segments)
+	case contextVarPath, contextVarPropertyPath, contextVarPathKeys, contextVarParentProperty:
+		f.paths = true
+	}
+	f.segments(cv.segments)
+}
@@ -0,0 +1,67 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependsOn(t *testing.T) {
+	requirePlus(t)
+	tests := []struct {
+		query    string
+		change   string
+		expected bool
+	}{
+		{query: `$.info.title`, change: `$['info']['title']`, expected: true},
+		{query: `$.info.title`, change: `$['info']`, expected: true},
+		{query: `$.info`, change: `$['info']['title']`, expected: true},
+		{query: `$.info.title`, change: `$['info']['version']`, expected: false},
+		{query: `$.info.title`, change: `$['paths']`, expected: false},
+		{query: `$.paths.*.get`, change: `$['paths']['/users']['get']['summary']`, expected: true},
+		{query: `$.paths.*.get`, change: `$['paths']['/users']['post']`, expected: false},
+		{query: `$.tags[0]`, change: `$['tags'][0]['name']`, expected: true},
+		{query: `$.tags[0]`, change: `$['tags'][1]`, expected: false},
+		{query: `$.tags[-1]`, change: `$['tags'][1]`, expected: true},
+		{query: `$.tags[1:3]`, change: `$['tags'][2]`, expected: true},
+		{query: `$.tags[1:3]`, change: `$['tags'][3]`, expected: false},
+		{query: `$.tags[::2]`, change: `$['tags'][3]`, expected: true},
+		// adding or removing an element shifts the elements after it
+		{query: `$.tags[1]`, change: `$['tags'][0]`, expected: true},
+		{query: `$.tags[1:]`, change: `$['tags'][0]`, expected: true},
+		{query: `$.tags[2:5]`, change: `$['tags'][0]`, expected: true},
+		{query: `$.tags[:3]`, change: `$['tags'][3]`, expected: false},
+		{query: `$.tags[0]`, change: `$['tags']['0']`, expected: false},
+		{query: `$..summary`, change: `$['paths']['/users']['get']['summary']`, expected: true},
+		{query: `$..summary`, change: `$['paths']['/users']['get']`, expected: true},
+		{query: `$..summary`, change: `$['paths']['/users']['get']['tags']`, expected: true},
+		{query: `$.paths..summary`, change: `$['info']['summary']`, expected: false},
+		{query: `$.paths..`, change: `$['info']`, expected: false},
+		{query: `$.paths[?@.get].x`, change: `$['paths']['/users']['post']`, expected: true},
+		{query: `$.paths[?@.get].x`, change: `$['info']`, expected: false},
+		{query: `$.paths[?@.get.x == $.info.version]`, change: `$['info']['version']`, expected: true},
+		{query: `$.paths[?@.get.x == $.info.version]`, change: `$['info']['title']`, expected: false},
+		{query: `$.paths[?count($.tags[*]) > 1]`, change: `$['tags'][4]`, expected: true},
+		{query: `$.paths[?@root.servers[0] == 'a']`, change: `$['servers'][0]`, expected: true},
+		{query: `$.paths[?$.tags.length > 1]`, change: `$['tags'][4]`, expected: true},
+		{query: `$.paths[?$.tags.length > 1]`, change: `$['info']`, expected: false},
+		{query: `$.paths.*[?@parent.x == 1]`, change: `$['paths']['/users']`, expected: true},
+		{query: `$.paths.*[?@parent.x == 1]`, change: `$['info']`, expected: false},
+		{query: `$.paths.*[?@.a^^.x == 1]`, change: `$['info']`, expected: true},
+		{query: `$.paths.a.b^`, change: `$['paths']['a']['c']`, expected: true},
+		{query: `$.paths.a.b^`, change: `$['info']`, expected: false},
+		{query: `$.paths.a.b.c^2`, change: `$['paths']['a']['d']`, expected: true},
+		{query: `$.paths.a^2`, change: `$['info']`, expected: true},
+		{query: `$.paths.a.b.c^('paths')`, change: `$['paths']['e']`, expected: true},
+		{query: `$.info.title | $.servers`, change: `$['servers'][0]`, expected: true},
+		{query: `$`, change: `$['anything']`, expected: true},
+	}
+	for _, test := range tests {
+		t.Run(test.query+" "+test.change, func(t *testing.T) {
+			path, err := NewPath(test.query)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, path.DependsOn(test.change))
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package jsonpath
+
+import (
+	"go.yaml.in/yaml/v4"
+)
+
+// collectionView returns the node that selectors should traverse in place of value. YAML sets and
+// ordered maps are collections whose node kinds do not match their meaning:
+//
+//   - A !!set is a mapping whose keys are the members and whose values are null. It is traversed as a
+//     sequence of its members, so [*], indices, slices and filters select members, and a name
+//     selector selects the member with that name.
+//   - An !!omap is a sequence of single-pair mappings. It is traversed as a mapping, so name
+//     selectors, [*] and filters reach its values and ~ its keys.
+//
+// Malformed collections are traversed without their nil nodes and keys without a value.
+//
+// The view holds the collection's own nodes, so results are nodes of the document. Any other node
+// is returned as is.
+func collectionView(idx index, value *yaml.Node) *yaml.Node {
+	view := wellFormed(expandAliases(configOf(idx), value))
+	switch {
+	case view.Kind == yaml.MappingNode && view.Tag == "!!set":
+		members := view
+		view = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!set", Line: value.Line, Column: value.Column}
+		for i := 0; i < len(members.Content); i += 2 {
+			view.Content = append(view.Content, members.Content[i])
+		}
+	case view.Kind == yaml.SequenceNode && view.Tag == "!!omap":
+		entries := orderedMapEntries(view)
+		if len(view.Content) == 0 || len(entries) != len(view.Content) {
+			view = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!omap", Line: value.Line, Column: value.Column, Content: entries}
+		}
+		// otherwise it is not a well-formed ordered map, so it stays a sequence
+	}
+	if view == value {
+		return value
+	}
+
+	// pending state recorded for the collection carries over to its view
+	if fc, ok := idx.(FilterContext); ok {
+		if pending := fc.GetAndClearPendingPathSegment(value); pending != "" {
+			fc.SetPendingPathSegment(view, pending)
+		}
+		if pending := fc.GetAndClearPendingPropertyName(value); pending != "" {
+			fc.SetPendingPropertyName(view, pending)
+		}
+	}
+	if parent := idx.getParentNode(value); parent != nil {
+		idx.setParentNode(view, parent)
+	}
+	if key := idx.getPropertyKey(value); key != nil {
+		idx.setPropertyKey(view, key)
+	}
+	return view
+}
+
+// setMember returns the member of a set view with the given name, or nil
+func setMember(view *yaml.Node, name string) *yaml.Node {
+	if view.Kind != yaml.SequenceNode || view.Tag != "!!set" {
+		return nil
+	}
+	for _, member := range view.Content {
+		if member.Value == name {
+			return member
+		}
+	}
+	return nil
+}
+
+// orderedMapEntries returns the keys and values of an !!omap node in order, as they would appear
+// in a mapping, skipping the single-pair mappings that hold them. For any other node, or an !!omap
+// that is not a sequence of single-pair mappings, it returns the node's content.
+func orderedMapEntries(value *yaml.Node) []*yaml.Node {
+	if value.Kind != yaml.SequenceNode || value.Tag != "!!omap" {
+		return value.Content
+	}
+	entries := make([]*yaml.Node, 0, 2*len(value.Content))
+	for _, pair := range value.Content {
+		if pair == nil || pair.Kind != yaml.MappingNode || len(pair.Content) != 2 || pair.Content[0] == nil || pair.Content[1] == nil {
+			return value.Content
+		}
+		entries = append(entries, pair.Content...)
+	}
+	return entries
+}
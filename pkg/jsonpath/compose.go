@@ -0,0 +1,153 @@
+package jsonpath
+
+// Segment is a segment that can be appended to a compiled path with Append.
+type Segment struct {
+	segment *segment
+}
+
+// MemberSegment selects the member with the given name, which may contain any characters: it is
+// stored as is and escaped when the path is printed.
+func MemberSegment(name string) Segment {
+	return Segment{&segment{kind: segmentKindChild, child: &innerSegment{
+		kind:      segmentLongHand,
+		selectors: []*selector{{kind: selectorSubKindName, name: name}},
+	}}}
+}
+
+// IndexSegment selects the array element at index i, counting from the end if i is negative.
+func IndexSegment(i int) Segment {
+	return Segment{&segment{kind: segmentKindChild, child: &innerSegment{
+		kind:      segmentLongHand,
+		selectors: []*selector{{kind: selectorSubKindArrayIndex, index: int64(i)}},
+	}}}
+}
+
+// WildcardSegment selects every member or element.
+func WildcardSegment() Segment {
+	return Segment{&segment{kind: segmentKindChild, child: &innerSegment{kind: segmentDotWildcard}}}
+}
+
+// DescendantSegment selects the members with the given name at any depth, like ..name.
+func DescendantSegment(name string) Segment {
+	return Segment{&segment{kind: segmentKindDescendant, descendant: &innerSegment{
+		kind:      segmentLongHand,
+		selectors: []*selector{{kind: selectorSubKindName, name: name}},
+	}}}
+}
+
+// Append returns a new path that applies segments to the results of p, or to the results of each
+// query of a union such as $.a | $.b. p is not modified.
+//
+//	base, _ := jsonpath.NewPath(result.Path) // e.g. $['paths']['/users']
+//	child := base.Append(jsonpath.MemberSegment("get"), jsonpath.MemberSegment("x-owner"))
+//	child.String() // $['paths']['/users']['get']['x-owner']
+func (p *JSONPath) Append(segments ...Segment) *JSONPath {
+	var queries []jsonPathAST
+	for _, query := range p.ast.queries() {
+		composed := make([]*segment, 0, len(query.segments)+len(segments))
+		composed = append(composed, query.segments...)
+		for _, s := range segments {
+			composed = append(composed, s.segment)
+		}
+		queries = append(queries, jsonPathAST{segments: composed})
+	}
+	return &JSONPath{ast: unionOf(queries), config: p.config}
+}
+
+// Join returns a new path that applies relative to the results of base: the root identifier of
+// relative stands for each node base matches, so Join($.paths.*, $.get.tags) is $.paths.*.get.tags.
+// Unions join every query of base with every query of relative.
+// The result evaluates with the configuration base was compiled with. Neither path is modified.
+func Join(base *JSONPath, relative *JSONPath) *JSONPath {
+	var queries []jsonPathAST
+	for _, query := range relative.ast.queries() {
+		segments := make([]Segment, len(query.segments))
+		for i, s := range query.segments {
+			segments[i] = Segment{s}
+		}
+		queries = append(queries, base.Append(segments...).ast.queries()...)
+	}
+	return &JSONPath{ast: unionOf(queries), config: base.config}
+}
+
+// unionOf returns the union of queries, or the only query if there is one
+func unionOf(queries []jsonPathAST) jsonPathAST {
+	ast := jsonPathAST{segments: queries[0].segments}
+	if len(queries) > 1 {
+		ast.union = queries[1:]
+	}
+	return ast
+}
+
+// SplitMember splits a path whose last segment selects one member by name, such as $.info.title or
+// $.paths['/users'], into the path of the nodes the member belongs to and the member's name. It
+// reports false for unions and any other path.
+func (p *JSONPath) SplitMember() (*JSONPath, string, bool) {
+	segments := p.ast.segments
+	if len(p.ast.union) > 0 || len(segments) == 0 || segments[len(segments)-1].kind != segmentKindChild {
+		return nil, "", false
+	}
+	var name string
+	switch last := segments[len(segments)-1].child; {
+	case last.kind == segmentDotMemberName:
+		name = last.dotName
+	case last.kind == segmentLongHand && len(last.selectors) == 1 && last.selectors[0].kind == selectorSubKindName:
+		name = last.selectors[0].name
+	default:
+		return nil, "", false
+	}
+	parent := segments[:len(segments)-1 : len(segments)-1]
+	return &JSONPath{ast: jsonPathAST{segments: parent}, config: p.config}, name, true
+}
+
+// Rebase returns p with the segments it begins with that from consists of replaced by those of to,
+// so that it selects from under the location to instead, as after moving a member: rebasing
+// $.components.schemas.Pet.properties from $.components.schemas.Pet to $.definitions.Animal gives
+// $.definitions.Animal.properties. from must consist of segments selecting one member by name or
+// one element by non-negative index, which p must begin with, in any notation. It reports false
+// otherwise, and for unions. p is not modified.
+func (p *JSONPath) Rebase(from *JSONPath, to *JSONPath) (*JSONPath, bool) {
+	if len(p.ast.union) > 0 || len(from.ast.union) > 0 || len(to.ast.union) > 0 || len(from.ast.segments) > len(p.ast.segments) {
+		return nil, false
+	}
+	for i, seg := range from.ast.segments {
+		element, ok := seg.element()
+		if !ok {
+			return nil, false
+		}
+		if other, ok := p.ast.segments[i].element(); !ok || other != element {
+			return nil, false
+		}
+	}
+	rest := p.ast.segments[len(from.ast.segments):]
+	segments := make([]*segment, 0, len(to.ast.segments)+len(rest))
+	segments = append(segments, to.ast.segments...)
+	segments = append(segments, rest...)
+	return &JSONPath{ast: jsonPathAST{segments: segments}, config: p.config}, true
+}
+
+// element returns the member or element a child segment selects, if it selects one by name or by
+// non-negative index
+func (s *segment) element() (pathElement, bool) {
+	if s.kind != segmentKindChild {
+		return pathElement{}, false
+	}
+	switch inner := s.child; {
+	case inner.kind == segmentDotMemberName:
+		return pathElement{name: inner.dotName}, true
+	case inner.kind == segmentLongHand && len(inner.selectors) == 1:
+		switch sel := inner.selectors[0]; {
+		case sel.kind == selectorSubKindName:
+			return pathElement{name: sel.name}, true
+		case sel.kind == selectorSubKindArrayIndex && sel.index >= 0:
+			return pathElement{index: int(sel.index), isIndex: true}, true
+		}
+	}
+	return pathElement{}, false
+}
+
+// isMember reports whether s selects the single member named name
+func (s *segment) isMember(name string) bool {
+	element, ok := s.element()
+	return ok && !element.isIndex && element.name == name
+}
@@ -0,0 +1,129 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const composeDoc = `
+paths:
+  /users:
+    get:
+      x-owner: team-a
+      tags: [users]
+    post:
+      x-owner: team-b
+  "it's":
+    get:
+      x-owner: team-c
+`
+
+func TestAppend(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(composeDoc), &node))
+
+	base := MustNewPath(`$.paths`)
+	for _, result := range base.Append(WildcardSegment()).QueryResults(&node) {
+		matchPath := MustNewPath(result.Path)
+		owner := matchPath.Append(MemberSegment("get"), MemberSegment("x-owner"))
+		reparsed, err := NewPath(owner.String())
+		require.NoError(t, err, owner.String())
+		assert.Equal(t, owner.String(), reparsed.String())
+		assert.Len(t, owner.Query(&node), 1)
+	}
+
+	quoted := base.Append(MemberSegment("it's"), WildcardSegment(), MemberSegment("x-owner"))
+	assert.Equal(t, `$.paths['it\'s'].*['x-owner']`, quoted.String())
+	result := quoted.Query(&node)
+	require.Len(t, result, 1)
+	assert.Equal(t, "team-c", result[0].Value)
+
+	tag := base.Append(DescendantSegment("tags"), IndexSegment(-1))
+	assert.Equal(t, `$.paths..['tags'][-1]`, tag.String())
+	require.Len(t, tag.Query(&node), 1)
+
+	// the base path is unchanged
+	assert.Equal(t, `$.paths`, base.String())
+}
+
+func TestJoin(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(composeDoc), &node))
+
+	base := MustNewPath(`$.paths.*`)
+	joined := Join(base, MustNewPath(`$[?@['x-owner'] != 'team-a']['x-owner']`))
+	assert.Equal(t, `$.paths.*[?@['x-owner'] != 'team-a']['x-owner']`, joined.String())
+
+	var owners []string
+	for _, n := range joined.Query(&node) {
+		owners = append(owners, n.Value)
+	}
+	assert.Equal(t, []string{"team-b", "team-c"}, owners)
+}
+
+func TestSplitMember(t *testing.T) {
+	requirePlus(t)
+	tests := []struct {
+		input  string
+		parent string
+		name   string
+		ok     bool
+	}{
+		{input: `$.info.title`, parent: `$.info`, name: "title", ok: true},
+		{input: `$.paths['/users']`, parent: `$.paths`, name: "/users", ok: true},
+		{input: `$.paths.*[?@.deprecated]['x-owner']`, parent: `$.paths.*[?@.deprecated]`, name: "x-owner", ok: true},
+		{input: `$.info`, parent: `$`, name: "info", ok: true},
+		{input: `$`},
+		{input: `$.tags[0]`},
+		{input: `$.paths.*`},
+		{input: `$..title`},
+		{input: `$['a','b']`},
+		{input: `$.a | $.b`},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			parent, name, ok := MustNewPath(test.input).SplitMember()
+			require.Equal(t, test.ok, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, test.parent, parent.String())
+			assert.Equal(t, test.name, name)
+		})
+	}
+}
+
+func TestRebase(t *testing.T) {
+	requirePlus(t)
+	tests := []struct {
+		input    string
+		from     string
+		to       string
+		expected string
+		ok       bool
+	}{
+		{input: `$.components.schemas.Pet.properties`, from: `$.components.schemas.Pet`, to: `$.definitions.Animal`, expected: `$.definitions.Animal.properties`, ok: true},
+		{input: `$['components']['schemas']['Pet'][?@.type]`, from: `$.components.schemas.Pet`, to: `$.components.schemas.Animal`, expected: `$.components.schemas.Animal[?@.type]`, ok: true},
+		{input: `$.tags[1].name`, from: `$['tags'][1]`, to: `$.labels[0]`, expected: `$.labels[0].name`, ok: true},
+		{input: `$.components.schemas.Pet`, from: `$.components.schemas.Pet`, to: `$.Pet`, expected: `$.Pet`, ok: true},
+		{input: `$.components.schemas.Pets`, from: `$.components.schemas.Pet`, to: `$.Pet`},
+		{input: `$.components.schemas`, from: `$.components.schemas.Pet`, to: `$.Pet`},
+		{input: `$.components.*.Pet`, from: `$.components.schemas.Pet`, to: `$.Pet`},
+		{input: `$.components.schemas.*`, from: `$.components.*`, to: `$.Pet`},
+		{input: `$.tags[-1]`, from: `$.tags[-1]`, to: `$.labels[0]`},
+		{input: `$..Pet`, from: `$.Pet`, to: `$.Animal`},
+		{input: `$.a | $.b`, from: `$.a`, to: `$.c`},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			rebased, ok := MustNewPath(test.input).Rebase(MustNewPath(test.from), MustNewPath(test.to))
+			require.Equal(t, test.ok, ok)
+			if ok {
+				assert.Equal(t, test.expected, rebased.String())
+			}
+		})
+	}
+}
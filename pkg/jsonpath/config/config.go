@@ -1,9 +1,53 @@
 package config
 
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
 type Option func(*config)
 
+// StringOrder selects how the < <= > and >= operators order strings.
+type StringOrder int
+
+const (
+	// StringOrderBytes orders strings by their UTF-8 bytes, as RFC 9535 requires. It is the default.
+	StringOrderBytes StringOrder = iota
+	// StringOrderNatural orders runs of digits by their numeric value, so item2 < item10 and
+	// v1.9 < v1.10. Everything else is ordered by bytes.
+	StringOrderNatural
+)
+
+// CycleHandling selects what descendant segments do on reaching a node they are already inside of,
+// which a YAML alias to one of its own ancestors, or a hand-built tree sharing nodes, makes possible.
+type CycleHandling int
+
+const (
+	// CycleSkip stops descending at a node that is already being descended, so every node on a cycle
+	// is visited once. It is the default.
+	CycleSkip CycleHandling = iota
+	// CycleError makes a query on a cyclic document fail. Query returns no results, while TryQuery and
+	// Engine.Query return an error matching jsonpath.ErrCyclicDocument.
+	CycleError
+	// CycleExpand descends into a cycle again, up to the limit set with WithCycleExpansionLimit, as if
+	// the document were expanded into a tree that many levels deep.
+	CycleExpand
+)
+
+// DefaultCycleExpansionLimit is how many times CycleExpand descends into a cycle again by default.
+const DefaultCycleExpansionLimit = 1
+
+// MinParallelElements is the length a sequence needs for WithParallelism to filter it in parallel.
+// Shorter sequences are filtered faster than goroutines can be started for them.
+const MinParallelElements = 4096
+
 // WithPropertyNameExtension enables the use of the "~" character to access a property key.
-// It is not enabled by default as this is outside of RFC 9535, but is important for several use-cases
+// JSONPath Plus mode, the default, always has it, so it only matters alongside WithStrictRFC9535,
+// where it is the one extension allowed.
 func WithPropertyNameExtension() Option {
 	return func(cfg *config) {
 		cfg.propertyNameExtension = true
@@ -19,31 +63,431 @@ func WithStrictRFC9535() Option {
 	}
 }
 
+// WithDeduplicatedResults enables the "practical" result mode, where a node is returned at most once
+// even when several selectors (for example the union $..['a','a']) select it.
+// By default, results follow RFC 9535 exactly: unions may produce duplicate nodes, in selector order.
+func WithDeduplicatedResults() Option {
+	return func(cfg *config) {
+		cfg.deduplicateResults = true
+	}
+}
+
+// WithLenientParsing accepts common deviations from the RFC 9535 grammar that appear in hand-written
+// and copy-pasted expressions, such as surrounding whitespace or a byte order mark. Each deviation
+// that is accepted is reported as a warning on the parsed path.
+// By default, parsing is strict and these expressions are rejected.
+func WithLenientParsing() Option {
+	return func(cfg *config) {
+		cfg.lenientParsing = true
+	}
+}
+
+// WithStringOrder sets how strings are ordered in < <= > and >= comparisons.
+// By default, strings are ordered by their UTF-8 bytes.
+func WithStringOrder(order StringOrder) Option {
+	return func(cfg *config) {
+		cfg.stringOrder = order
+		cfg.collator = nil
+	}
+}
+
+// WithStringCollator orders strings in < <= > and >= comparisons with compare, which returns a
+// negative number, zero or a positive number as a sorts before, with or after b. Use it for
+// locale-aware ordering, e.g. with the CompareString method of a golang.org/x/text/collate.Collator.
+// Strings the collator considers equal satisfy <= and >=, while == still compares them exactly.
+func WithStringCollator(compare func(a, b string) int) Option {
+	return func(cfg *config) {
+		cfg.collator = compare
+	}
+}
+
+// WithValueResolver makes filters see string values matching pattern, such as vault:... secret
+// references, as the value resolve returns for them. Filters compare, match and measure resolved
+// values, while query results are still the document's own nodes, so they keep the reference and
+// never expose the resolved value. If resolve returns an error the value is treated as Nothing, and
+// no filter matches on it. resolve may be called many times for the same reference.
+func WithValueResolver(pattern *regexp.Regexp, resolve func(reference string) (string, error)) Option {
+	return func(cfg *config) {
+		cfg.resolverPattern = pattern
+		cfg.resolver = resolve
+	}
+}
+
+// TypeErrorHandler receives filter comparisons between values of incompatible types, such as an
+// object compared with a number. node is the node the filter was tested against, expression the
+// comparison and message describes the mismatch.
+type TypeErrorHandler func(node *yaml.Node, expression string, message string)
+
+// WithTypeErrorHandler reports filter comparisons between values of incompatible types to handler.
+// Such comparisons are false, as RFC 9535 requires, so without a handler a rule written against the
+// wrong shape of document silently matches nothing. Comparisons with a missing value are not
+// reported.
+func WithTypeErrorHandler(handler TypeErrorHandler) Option {
+	return func(cfg *config) {
+		cfg.typeErrorHandler = handler
+	}
+}
+
+// WithYAML11Numbers makes filters read plain scalars the way YAML 1.1 resolves them, as documents
+// converted by older tooling expect: 010 is the octal number 8, 1:30 is the sexagesimal number 90,
+// and hexadecimal, binary and _-separated digits are numbers too.
+// By default, 010 is the decimal number 10, 1:30 is a string, and integers and floats that are not
+// decimal numbers, such as 0o17 and 1_000, are compared as the strings they are written as.
+func WithYAML11Numbers() Option {
+	return func(cfg *config) {
+		cfg.yaml11Numbers = true
+	}
+}
+
+// WithNumericStrings makes filters compare strings holding JSON numbers, such as "42" and "1.5",
+// numerically with numbers, as documents whose authoring tools quote some numbers and not others
+// need: "42" == 42, "8" < 10 and "200" in [200, 201] are true. Strings are still compared with
+// strings as strings, so "8" < "10" remains false.
+// By default, a string never equals a number and is never ordered against one.
+func WithNumericStrings() Option {
+	return func(cfg *config) {
+		cfg.numericStrings = true
+	}
+}
+
+// WithCopiedResults makes queries return deep copies of the nodes they match, so callers can modify
+// results without changing the queried document, as servers sharing one parsed document across
+// requests need. Each query makes its own copies, and results that share nodes, such as a mapping and
+// one of its values, share their copies too.
+// By default, results are the document's own nodes, which costs nothing to return.
+func WithCopiedResults() Option {
+	return func(cfg *config) {
+		cfg.copiedResults = true
+	}
+}
+
+// WithGlobNames makes name selectors holding * or ? glob patterns, selecting every member whose
+// name matches: * matches any run of characters, including none, and ? a single character, as in
+// $.paths['/users*'] or $.components.schemas['*Error']. A backslash makes the character after it
+// literal, so $['a\\*'] selects the member a*. In JSONPath Plus mode patterns using only * may be
+// unquoted too, as in $.components.schemas[*Error].
+// By default, * and ? in a name selector are literal characters, as RFC 9535 requires.
+func WithGlobNames() Option {
+	return func(cfg *config) {
+		cfg.globNames = true
+	}
+}
+
+// WithCaseInsensitiveKeys makes member names match keys regardless of case, so $.Info.Title selects
+// info.title, as documents written by hand with inconsistent casing need. A key spelled exactly as
+// the name is still preferred, and otherwise the first key equal to it ignoring case is selected, so
+// a name still selects at most one member. Glob patterns ignore case too, while regular expressions
+// keep their own flags.
+// By default, member names are matched exactly, as RFC 9535 requires.
+func WithCaseInsensitiveKeys() Option {
+	return func(cfg *config) {
+		cfg.caseInsensitiveKeys = true
+	}
+}
+
+// Function is a filter function extension registered with WithFunction.
+type Function struct {
+	// Args is the number of arguments the function takes, checked when a path is parsed.
+	Args int
+	// Call evaluates a call of the function. It returns a bool, string, int, float64 or *yaml.Node
+	// value, or nil for Nothing. Used as a test expression, as in $[?hasExample(@)], the function
+	// only selects the node if it returns true.
+	Call func(call FunctionCall) any
+}
+
+// FunctionCall holds the arguments of a call to a custom function and the nodes it was made on.
+type FunctionCall struct {
+	// Args holds the nodelist of each argument: the nodes a query selects, or a single scalar node
+	// holding a literal or the result of another function. A Nothing result is an empty nodelist.
+	Args [][]*yaml.Node
+	// Current is the node the filter is testing, @.
+	Current *yaml.Node
+	// Root is the root node of the document, $.
+	Root *yaml.Node
+}
+
+// FunctionRegistry maps the names of custom filter functions to their definitions.
+type FunctionRegistry map[string]Function
+
+// WithFunction registers fn as a filter function called name, such as hasExample or validRef. Names
+// start with a lowercase letter followed by letters, digits and underscores, and cannot be those of
+// the built-in functions. Paths using the function are compiled with it, so registering another
+// function with the same name only affects paths parsed afterwards.
+func WithFunction(name string, fn Function) Option {
+	return func(cfg *config) {
+		if cfg.functions == nil {
+			cfg.functions = make(FunctionRegistry)
+		}
+		cfg.functions[name] = fn
+	}
+}
+
+// WithAliasExpansion makes queries read YAML aliases as the nodes they refer to, as if each alias
+// were replaced by a copy of its anchored node. $..name then also finds the names under an alias, and
+// an alias to one of its own ancestors makes the document cyclic: see WithCycleHandling.
+// By default, an alias is a leaf node holding the name of its anchor. A small document can alias
+// the same node a great many times over, so evaluate untrusted documents through an Engine with a
+// timeout or result limit.
+func WithAliasExpansion() Option {
+	return func(cfg *config) {
+		cfg.aliasExpansion = true
+	}
+}
+
+// WithCycleHandling sets what descendant segments do on reaching a node they are already inside of.
+// By default, they skip it, so queries on cyclic documents terminate.
+func WithCycleHandling(handling CycleHandling) Option {
+	return func(cfg *config) {
+		cfg.cycleHandling = handling
+	}
+}
+
+// WithCycleExpansionLimit sets how many times descendant segments descend into a cycle again with
+// CycleExpand, which it also selects. By default, the limit is DefaultCycleExpansionLimit.
+func WithCycleExpansionLimit(limit int) Option {
+	return func(cfg *config) {
+		cfg.cycleHandling = CycleExpand
+		cfg.cycleExpansionLimit = max(limit, 0)
+	}
+}
+
+// WithParallelism filters sequences of at least MinParallelElements elements with up to n goroutines,
+// each testing a chunk of the elements, for filters over huge arrays where the filter is the
+// bottleneck. Results keep the order of the sequence. Value resolvers, type error handlers and custom
+// functions are then called concurrently, so they must be safe for concurrent use. Filters using
+// @parent, and queries recording the nodes filters read, are still evaluated one element at a time.
+// By default, n is 1 and filters test one element at a time.
+func WithParallelism(n int) Option {
+	return func(cfg *config) {
+		cfg.parallelism = max(n, 1)
+	}
+}
+
 type Config interface {
 	PropertyNameEnabled() bool
 	JSONPathPlusEnabled() bool
+	DeduplicateResults() bool
+	LenientParsingEnabled() bool
+	CompareStrings(a, b string) int
+	ResolveValue(value string) (string, bool)
+	TypeErrorHandler() TypeErrorHandler
+	YAML11Numbers() bool
+	NumericStrings() bool
+	Functions() FunctionRegistry
+	AliasExpansion() bool
+	CycleHandling() CycleHandling
+	CycleExpansionLimit() int
+	CopiedResults() bool
+	GlobNamesEnabled() bool
+	CaseInsensitiveKeys() bool
+	Parallelism() int
 }
 
 type config struct {
 	propertyNameExtension bool
 	strictRFC9535         bool
+	deduplicateResults    bool
+	lenientParsing        bool
+	stringOrder           StringOrder
+	collator              func(a, b string) int
+	resolverPattern       *regexp.Regexp
+	resolver              func(reference string) (string, error)
+	typeErrorHandler      TypeErrorHandler
+	yaml11Numbers         bool
+	numericStrings        bool
+	functions             FunctionRegistry
+	aliasExpansion        bool
+	cycleHandling         CycleHandling
+	cycleExpansionLimit   int
+	copiedResults         bool
+	globNames             bool
+	caseInsensitiveKeys   bool
+	parallelism           int
 }
 
+// PropertyNameEnabled returns true if "~" selects property keys, as it does in JSONPath Plus mode or
+// with WithPropertyNameExtension().
 func (c *config) PropertyNameEnabled() bool {
-	return c.propertyNameExtension
+	return c.propertyNameExtension || PlusAvailable && !c.strictRFC9535
 }
 
 // JSONPathPlusEnabled returns true if JSONPath Plus extensions are enabled.
 // JSONPath Plus is ON by default (true superset, no conflicts with RFC 9535).
-// Returns false only if WithStrictRFC9535() was explicitly called.
+// Returns false only if WithStrictRFC9535() was explicitly called, or the extensions are not
+// built in (see PlusAvailable).
 func (c *config) JSONPathPlusEnabled() bool {
-	return !c.strictRFC9535
+	return PlusAvailable && !c.strictRFC9535
+}
+
+// DeduplicateResults returns true if duplicate nodes should be removed from results.
+func (c *config) DeduplicateResults() bool {
+	return c.deduplicateResults
+}
+
+// LenientParsingEnabled returns true if lenient parsing was enabled with WithLenientParsing().
+func (c *config) LenientParsingEnabled() bool {
+	return c.lenientParsing
+}
+
+// CompareStrings compares a and b in the configured string order, returning a negative number, zero
+// or a positive number as a sorts before, with or after b.
+func (c *config) CompareStrings(a, b string) int {
+	if c.collator != nil {
+		return c.collator(a, b)
+	}
+	if c.stringOrder == StringOrderNatural {
+		return naturalCompare(a, b)
+	}
+	return strings.Compare(a, b)
+}
+
+// ResolveValue returns the value filters see for a string value: the resolved value for a reference
+// matching the WithValueResolver pattern, or the value itself. ok is false if resolving failed.
+func (c *config) ResolveValue(value string) (resolved string, ok bool) {
+	if c.resolver == nil || !c.resolverPattern.MatchString(value) {
+		return value, true
+	}
+	resolved, err := c.resolver(value)
+	if err != nil {
+		return "", false
+	}
+	return resolved, true
+}
+
+// TypeErrorHandler returns the handler set with WithTypeErrorHandler, or nil.
+func (c *config) TypeErrorHandler() TypeErrorHandler {
+	return c.typeErrorHandler
+}
+
+// YAML11Numbers returns true if plain scalars are read as YAML 1.1 numbers, set with WithYAML11Numbers().
+func (c *config) YAML11Numbers() bool {
+	return c.yaml11Numbers
+}
+
+// NumericStrings returns true if strings holding numbers compare numerically with numbers, set with
+// WithNumericStrings().
+func (c *config) NumericStrings() bool {
+	return c.numericStrings
+}
+
+// Functions returns the custom filter functions registered with WithFunction.
+func (c *config) Functions() FunctionRegistry {
+	return c.functions
+}
+
+// AliasExpansion returns true if aliases are read as the nodes they refer to, set with WithAliasExpansion().
+func (c *config) AliasExpansion() bool {
+	return c.aliasExpansion
+}
+
+// CycleHandling returns what descendant segments do on reaching a node they are already inside of.
+func (c *config) CycleHandling() CycleHandling {
+	return c.cycleHandling
+}
+
+// CycleExpansionLimit returns how many times CycleExpand descends into a cycle again.
+func (c *config) CycleExpansionLimit() int {
+	return c.cycleExpansionLimit
+}
+
+// CopiedResults returns true if queries return deep copies of their results, set with WithCopiedResults().
+func (c *config) CopiedResults() bool {
+	return c.copiedResults
+}
+
+// GlobNamesEnabled returns true if name selectors may be glob patterns, set with WithGlobNames().
+func (c *config) GlobNamesEnabled() bool {
+	return c.globNames
+}
+
+// CaseInsensitiveKeys returns true if member names match keys regardless of case, set with
+// WithCaseInsensitiveKeys().
+func (c *config) CaseInsensitiveKeys() bool {
+	return c.caseInsensitiveKeys
+}
+
+// Parallelism returns the most goroutines a filter over a sequence is evaluated with, set with
+// WithParallelism(). It is 1 unless filters are evaluated in parallel.
+func (c *config) Parallelism() int {
+	return max(c.parallelism, 1)
+}
+
+// naturalCompare compares runs of ASCII digits by numeric value and everything else by bytes.
+// Strings that only differ in leading zeros are ordered by bytes, so only equal strings compare equal.
+func naturalCompare(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if !isDigit(a[i]) || !isDigit(b[j]) {
+			if a[i] != b[j] {
+				return int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+			continue
+		}
+		startA, startB := i, j
+		for i < len(a) && isDigit(a[i]) {
+			i++
+		}
+		for j < len(b) && isDigit(b[j]) {
+			j++
+		}
+		numA := strings.TrimLeft(a[startA:i], "0")
+		numB := strings.TrimLeft(b[startB:j], "0")
+		if len(numA) != len(numB) {
+			return len(numA) - len(numB)
+		}
+		if c := strings.Compare(numA, numB); c != 0 {
+			return c
+		}
+	}
+	if c := (len(a) - i) - (len(b) - j); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
 }
 
 func New(opts ...Option) Config {
-	cfg := &config{}
+	cfg := &config{cycleExpansionLimit: DefaultCycleExpansionLimit}
 	for _, opt := range opts {
 		opt(cfg)
 	}
 	return cfg
 }
+
+// Identity returns a comparable value that is equal for two configs only if they were created with
+// the same settings, so results cached for one path can be shared with paths compiled separately with
+// the same options. Every setting is compared, including ones added later. A config holding a
+// function, registry or pattern is identical only to itself.
+func Identity(cfg Config) any {
+	c, ok := cfg.(*config)
+	if !ok {
+		return cfg
+	}
+	v := reflect.ValueOf(c).Elem()
+	var b strings.Builder
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.Bool:
+			b.WriteString(strconv.FormatBool(field.Bool()))
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			b.WriteString(strconv.FormatInt(field.Int(), 10))
+		case reflect.String:
+			b.WriteString(strconv.Quote(field.String()))
+		case reflect.Func, reflect.Map, reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Chan:
+			if !field.IsNil() {
+				return cfg
+			}
+			b.WriteString("nil")
+		default:
+			return cfg
+		}
+		b.WriteByte(',')
+	}
+	return b.String()
+}
@@ -0,0 +1,7 @@
+//go:build jsonpath_core
+
+package config
+
+// PlusAvailable is false as the binary is built with the jsonpath_core tag, which turns the JSONPath
+// Plus extensions and the filter functions RFC 9535 does not define off.
+const PlusAvailable = false
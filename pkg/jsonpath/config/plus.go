@@ -0,0 +1,10 @@
+//go:build !jsonpath_core
+
+package config
+
+// PlusAvailable reports whether the JSONPath Plus extensions can be enabled. Building with the
+// jsonpath_core tag turns them off, along with the filter functions RFC 9535 does not define, for
+// tools that must only ever accept strict RFC 9535 queries: every path is then parsed and evaluated
+// as with WithStrictRFC9535, whatever the options, while functions registered with WithFunction and
+// the ~ of WithPropertyNameExtension are still available. The extension code is still linked in.
+const PlusAvailable = true
@@ -0,0 +1,59 @@
+package jsonpath
+
+import (
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"go.yaml.in/yaml/v4"
+)
+
+// resultCopier deep copies the nodes a query returns when config.WithCopiedResults is set. Each node
+// is copied once, so results that share nodes, such as a mapping and one of its values, still share
+// them. A nil copier returns the document's own nodes.
+type resultCopier struct {
+	copies map[*yaml.Node]*yaml.Node
+}
+
+// newResultCopier returns a copier for the results of one query with cfg, or nil if results are not
+// copied
+func newResultCopier(cfg config.Config) *resultCopier {
+	if !cfg.CopiedResults() {
+		return nil
+	}
+	return &resultCopier{copies: make(map[*yaml.Node]*yaml.Node)}
+}
+
+// node returns the copy of node, including the nodes its aliases refer to
+func (c *resultCopier) node(node *yaml.Node) *yaml.Node {
+	if c == nil || node == nil {
+		return node
+	}
+	if copied, ok := c.copies[node]; ok {
+		return copied
+	}
+	copied := *node
+	c.copies[node] = &copied
+	if node.Content != nil {
+		copied.Content = make([]*yaml.Node, len(node.Content))
+		for i, child := range node.Content {
+			copied.Content[i] = c.node(child)
+		}
+	}
+	copied.Alias = c.node(node.Alias)
+	return &copied
+}
+
+// nodes returns the copies of nodes
+func (c *resultCopier) nodes(nodes []*yaml.Node) []*yaml.Node {
+	if c == nil {
+		return nodes
+	}
+	copied := make([]*yaml.Node, len(nodes))
+	for i, node := range nodes {
+		copied[i] = c.node(node)
+	}
+	return copied
+}
+
+// copyResults returns the results of a query with cfg, deep copied if config.WithCopiedResults is set
+func copyResults(cfg config.Config, nodes []*yaml.Node) []*yaml.Node {
+	return newResultCopier(cfg).nodes(nodes)
+}
@@ -0,0 +1,98 @@
+package jsonpath
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const copiedResultsDoc = `
+info:
+  title: Pets
+  contact: &contact {name: API team}
+owner: *contact
+`
+
+func TestCopiedResults(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(copiedResultsDoc), &root))
+	info := root.Content[0].Content[1]
+
+	// by default results are the document's own nodes
+	result := MustNewPath(`$.info`).Query(&root)
+	require.Len(t, result, 1)
+	assert.Same(t, info, result[0])
+
+	path := MustNewPath(`$..*`, config.WithCopiedResults())
+	result = path.Query(&root)
+	require.NotEmpty(t, result)
+	assert.NotSame(t, info, result[0])
+	assert.Equal(t, info.Line, result[0].Line)
+
+	// results share copies as the document's nodes are shared
+	assert.Same(t, result[0].Content[1], result[2])
+	owner := result[1]
+	require.Equal(t, yaml.AliasNode, owner.Kind)
+	assert.Same(t, result[0].Content[3], owner.Alias)
+
+	// modifying results leaves the document as it was
+	for _, node := range result {
+		node.Value = "changed"
+		node.Content = nil
+	}
+	assert.Equal(t, "Pets", info.Content[1].Value)
+	assert.Len(t, info.Content, 4)
+	assert.Equal(t, "API team", root.Content[0].Content[3].Alias.Content[1].Value)
+}
+
+func TestCopiedResultsAPIs(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(copiedResultsDoc), &root))
+	title := root.Content[0].Content[1].Content[1]
+	opts := []config.Option{config.WithCopiedResults()}
+	path := MustNewPath(`$.info.title`, opts...)
+
+	nodes := map[string]*yaml.Node{}
+	results := path.QueryResults(&root)
+	require.Len(t, results, 1)
+	assert.Equal(t, "$['info']['title']", results[0].Path)
+	nodes["QueryResults"] = results[0].Node
+
+	matches := path.QueryMatches(&root)
+	require.Len(t, matches, 1)
+	assert.Equal(t, 2, matches[0].Depth)
+	assert.Equal(t, "$['info']['title']", matches[0].Path)
+	nodes["QueryMatches"] = matches[0].Node
+
+	dependencies := MustNewPath(`$.info[?@ == $.info.title]`, opts...).QueryDependencies(&root)
+	require.Len(t, dependencies, 1)
+	require.Len(t, dependencies[0].Reads, 1)
+	assert.Equal(t, "$['info']['title']", dependencies[0].Reads[0].Path)
+	nodes["QueryDependencies"] = dependencies[0].Result.Node
+	nodes["QueryDependencies reads"] = dependencies[0].Reads[0].Node
+
+	result, err := path.TryQuery(&root)
+	require.NoError(t, err)
+	nodes["TryQuery"] = result[0]
+
+	result, err = NewEngine(WithEngineConfig(opts...)).Query(context.Background(), `$.info.title`, &root)
+	require.NoError(t, err)
+	nodes["Engine.Query"] = result[0]
+
+	cache := NewQueryCache(&root)
+	cached := MustNewPath(`$.info.*`, opts...)
+	cache.Query(cached)[0].Value = "changed"
+	nodes["QueryCache.Query"] = cache.Query(cached)[0]
+
+	result, _ = path.QueryWithDiagnostics(&root)
+	nodes["QueryWithDiagnostics"] = result[0]
+
+	for api, node := range nodes {
+		assert.NotSame(t, title, node, api)
+		assert.Equal(t, "Pets", node.Value, api)
+	}
+}
@@ -0,0 +1,41 @@
+//go:build jsonpath_core
+
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+// TestCoreBuild runs with go test -tags jsonpath_core, or make test-core, which skips the tests of
+// the JSONPath Plus extensions
+func TestCoreBuild(t *testing.T) {
+	assert.False(t, config.PlusAvailable)
+	assert.False(t, config.New().JSONPathPlusEnabled())
+
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`{store: {book: [{price: 8}, {price: 12}]}}`), &root))
+	path, err := NewPath(`$.store.book[?@.price < 10 && length(@) == 1]`)
+	require.NoError(t, err)
+	assert.Len(t, path.Query(&root), 1)
+
+	for _, query := range []string{
+		`$.store.book[?@property == '0']`,
+		`$.store.book[?contains(@.title, 'Go')]`,
+		`$.store.book[?(@.price * 2 > 20)]`,
+		`$..price^`,
+		`$.store.*@number()`,
+	} {
+		_, err := NewPath(query)
+		assert.Error(t, err, query)
+	}
+
+	// ~ is still available as an option
+	path, err = NewPath(`$.store.*~`, config.WithPropertyNameExtension())
+	require.NoError(t, err)
+	assert.Equal(t, "book", path.Query(&root)[0].Value)
+}
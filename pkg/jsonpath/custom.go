@@ -0,0 +1,128 @@
+package jsonpath
+
+import (
+	"fmt"
+	"strconv"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// Selector selects children of a node with logic that cannot be written in JSONPath, such as
+// matching IP addresses against a CIDR range. It is inserted into a compiled path with
+// CustomSegment.
+type Selector interface {
+	// Select returns the members of the mapping or elements of the sequence node that it selects,
+	// in the order they should be returned. It is not called for scalar nodes. Returned nodes that
+	// are not children of node are dropped, so results are always part of the document.
+	Select(node *yaml.Node) []*yaml.Node
+	// String describes the selector in the path's String form, where it appears in brackets, e.g.
+	// cidr(10.0.0.0/8) in $.servers[cidr(10.0.0.0/8)]. Such a path cannot be parsed again.
+	String() string
+}
+
+// CustomSegment selects the children of each node that sel selects. It can be appended to a path
+// with Append, or placed anywhere in it with Insert.
+func CustomSegment(sel Selector) Segment {
+	return Segment{&segment{kind: segmentKindChild, child: &innerSegment{
+		kind:      segmentLongHand,
+		selectors: []*selector{{kind: selectorSubKindCustom, custom: sel}},
+	}}}
+}
+
+// Insert returns a new path with segments placed before the segment at index at, where 0 places
+// them straight after the root identifier and the number of segments in p appends them. It fails
+// if at is out of range, a segment has no selector or p is a union of queries. p is not modified.
+//
+//	names, _ := jsonpath.NewPath(`$.regions.*.servers.name`)
+//	internal, err := names.Insert(3, jsonpath.CustomSegment(cidr("10.0.0.0/8")))
+//	internal.String() // $.regions.*.servers[cidr(10.0.0.0/8)].name
+func (p *JSONPath) Insert(at int, segments ...Segment) (*JSONPath, error) {
+	if len(p.ast.union) > 0 {
+		return nil, fmt.Errorf("cannot insert into a union of queries")
+	}
+	if at < 0 || at > len(p.ast.segments) {
+		return nil, fmt.Errorf("cannot insert at segment %d of a path with %d segments", at, len(p.ast.segments))
+	}
+	for i, s := range segments {
+		if err := s.validate(); err != nil {
+			return nil, fmt.Errorf("segment %d: %w", i, err)
+		}
+	}
+	composed := make([]*segment, 0, len(p.ast.segments)+len(segments))
+	composed = append(composed, p.ast.segments[:at]...)
+	for _, s := range segments {
+		composed = append(composed, s.segment)
+	}
+	composed = append(composed, p.ast.segments[at:]...)
+	return &JSONPath{ast: jsonPathAST{segments: composed}, config: p.config}, nil
+}
+
+// validate checks that the segment can be evaluated
+func (s Segment) validate() error {
+	if s.segment == nil {
+		return fmt.Errorf("empty segment")
+	}
+	inner := s.segment.child
+	if s.segment.kind == segmentKindDescendant {
+		inner = s.segment.descendant
+	}
+	if inner == nil {
+		return nil
+	}
+	for _, sel := range inner.selectors {
+		if sel.kind == selectorSubKindCustom && sel.custom == nil {
+			return fmt.Errorf("custom segment has no selector")
+		}
+	}
+	return nil
+}
+
+// queryCustom runs a custom selector on value, keeping only the children of value it returns
+func (s selector) queryCustom(idx index, value *yaml.Node) []*yaml.Node {
+	if value.Kind != yaml.MappingNode && value.Kind != yaml.SequenceNode {
+		return nil
+	}
+	selected := s.custom.Select(value)
+	if len(selected) == 0 {
+		return nil
+	}
+
+	positions := make(map[*yaml.Node]int, len(value.Content))
+	for i, child := range value.Content {
+		if value.Kind == yaml.SequenceNode || i%2 == 1 {
+			positions[child] = i
+		}
+	}
+
+	var inheritedPending string
+	fc, tracksPaths := idx.(FilterContext)
+	if tracksPaths {
+		inheritedPending = fc.GetAndClearPendingPathSegment(value)
+	}
+	trackParents := parentTrackingEnabled(idx)
+
+	result := make([]*yaml.Node, 0, len(selected))
+	for _, child := range selected {
+		i, ok := positions[child]
+		if !ok {
+			continue
+		}
+		if trackParents {
+			idx.setParentNode(child, value)
+		}
+		if value.Kind == yaml.MappingNode {
+			keyNode := value.Content[i-1]
+			idx.setPropertyKey(keyNode, value)
+			idx.setPropertyKey(child, keyNode)
+			if tracksPaths {
+				fc.SetPendingPathSegment(child, inheritedPending+normalizePathSegment(keyNode.Value))
+				fc.SetPendingPropertyName(child, keyNode.Value)
+			}
+		} else if tracksPaths {
+			fc.SetPendingPathSegment(child, inheritedPending+normalizeIndexSegment(i))
+			fc.SetPendingPropertyName(child, strconv.Itoa(i))
+		}
+		result = append(result, child)
+	}
+	return result
+}
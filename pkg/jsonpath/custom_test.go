@@ -0,0 +1,109 @@
+package jsonpath
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const customDoc = `
+regions:
+  eu:
+    servers:
+      - {name: web-1, address: 10.0.1.5}
+      - {name: web-2, address: 192.168.0.7}
+  us:
+    servers:
+      - {name: db-1, address: 10.8.0.2}
+      - {name: bastion, address: 203.0.113.9}
+`
+
+// cidrSelector selects the elements whose address is in a network
+type cidrSelector struct {
+	prefix netip.Prefix
+}
+
+func (s cidrSelector) Select(node *yaml.Node) []*yaml.Node {
+	var selected []*yaml.Node
+	for _, element := range node.Content {
+		for i := 0; i+1 < len(element.Content); i += 2 {
+			if element.Content[i].Value != "address" {
+				continue
+			}
+			if addr, err := netip.ParseAddr(element.Content[i+1].Value); err == nil && s.prefix.Contains(addr) {
+				selected = append(selected, element)
+			}
+		}
+	}
+	return selected
+}
+
+func (s cidrSelector) String() string {
+	return "cidr(" + s.prefix.String() + ")"
+}
+
+// strangerSelector returns a node that is not part of the document
+type strangerSelector struct{}
+
+func (strangerSelector) Select(node *yaml.Node) []*yaml.Node {
+	return append([]*yaml.Node{{Kind: yaml.ScalarNode, Value: "stranger"}}, node.Content...)
+}
+
+func (strangerSelector) String() string {
+	return "stranger"
+}
+
+func TestCustomSegment(t *testing.T) {
+	requirePlus(t)
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(customDoc), &node))
+	internal := CustomSegment(cidrSelector{netip.MustParsePrefix("10.0.0.0/8")})
+
+	names := MustNewPath(`$.regions.*.servers.name`)
+	path, err := names.Insert(3, internal)
+	require.NoError(t, err)
+	assert.Equal(t, `$.regions.*.servers[cidr(10.0.0.0/8)].name`, path.String())
+	assert.Equal(t, `$.regions.*.servers.name`, names.String())
+
+	var values []string
+	for _, result := range path.Query(&node) {
+		values = append(values, result.Value)
+	}
+	assert.Equal(t, []string{"web-1", "db-1"}, values)
+
+	var paths []string
+	for _, result := range path.QueryResults(&node) {
+		paths = append(paths, result.Path)
+	}
+	assert.Equal(t, []string{"$['regions']['eu']['servers'][0]['name']", "$['regions']['us']['servers'][0]['name']"}, paths)
+
+	appended := MustNewPath(`$.regions.eu.servers`).Append(internal)
+	require.Len(t, appended.Query(&node), 1)
+
+	// nodes outside the document are dropped
+	all := MustNewPath(`$.regions.eu.servers`).Append(CustomSegment(strangerSelector{}))
+	assert.Equal(t, MustNewPath(`$.regions.eu.servers[*]`).Query(&node), all.Query(&node))
+}
+
+func TestInsertValidation(t *testing.T) {
+	path := MustNewPath(`$.a.b`)
+	for _, at := range []int{0, 1, 2} {
+		_, err := path.Insert(at, WildcardSegment())
+		assert.NoError(t, err, at)
+	}
+	_, err := path.Insert(-1, WildcardSegment())
+	assert.Error(t, err)
+	_, err = path.Insert(3, WildcardSegment())
+	assert.Error(t, err)
+	_, err = path.Insert(1, CustomSegment(nil))
+	assert.Error(t, err)
+	_, err = path.Insert(1, Segment{})
+	assert.Error(t, err)
+
+	inserted, err := path.Insert(0, MemberSegment("root"), IndexSegment(-1))
+	require.NoError(t, err)
+	assert.Equal(t, `$['root'][-1].a.b`, inserted.String())
+}
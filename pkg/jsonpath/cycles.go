@@ -0,0 +1,165 @@
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"go.yaml.in/yaml/v4"
+)
+
+// ErrCyclicDocument is matched by the errors returned for queries that reach a cycle in the document
+// with config.CycleError.
+var ErrCyclicDocument = errors.New("jsonpath: cyclic document")
+
+// CyclicDocumentError describes the node a descendant segment reached while already inside of it.
+type CyclicDocumentError struct {
+	// Anchor is the anchor of the node, if it has one.
+	Anchor string
+	// Line and Column locate the node in the source document, if it was parsed from one.
+	Line, Column int
+}
+
+func (e *CyclicDocumentError) Error() string {
+	if e.Anchor != "" {
+		return fmt.Sprintf("jsonpath: cyclic document: node &%s at line %d, column %d contains an alias to itself", e.Anchor, e.Line, e.Column)
+	}
+	return fmt.Sprintf("jsonpath: cyclic document: node at line %d, column %d contains itself", e.Line, e.Column)
+}
+
+func (e *CyclicDocumentError) Unwrap() error {
+	return ErrCyclicDocument
+}
+
+// expandAlias returns the node value refers to if it is an alias and aliases are being expanded
+func expandAlias(cfg config.Config, value *yaml.Node) *yaml.Node {
+	if cfg.AliasExpansion() && value.Kind == yaml.AliasNode && value.Alias != nil {
+		return value.Alias
+	}
+	return value
+}
+
+// expandAliases returns value, or if aliases are being expanded and value is or holds an alias, the
+// node it refers to or a copy of value holding the nodes its aliases refer to.
+func expandAliases(cfg config.Config, value *yaml.Node) *yaml.Node {
+	if !cfg.AliasExpansion() {
+		return value
+	}
+	value = expandAlias(cfg, value)
+	for i, child := range value.Content {
+		if child == nil || child.Kind != yaml.AliasNode || child.Alias == nil {
+			continue
+		}
+		expanded := *value
+		expanded.Content = make([]*yaml.Node, len(value.Content))
+		copy(expanded.Content, value.Content[:i])
+		for j := i; j < len(value.Content); j++ {
+			if child := value.Content[j]; child != nil {
+				expanded.Content[j] = expandAlias(cfg, child)
+			}
+		}
+		return &expanded
+	}
+	return value
+}
+
+// descent collects the nodes a descendant segment visits, keeping count of the nodes it is inside of
+// so that it terminates on cyclic documents.
+type descent struct {
+	idx    index
+	cfg    config.Config
+	inside map[*yaml.Node]int
+	result []*yaml.Node
+	failed bool
+	// parents is set if the parents of the nodes visited are recorded, for parent selectors that
+	// ascend past the node the descent started from
+	parents bool
+	// tracksPaths is set if the path of each node visited is recorded in paths, for filters reading
+	// @path, @propertyPath, @pathKeys or @parentProperty
+	tracksPaths bool
+	paths       []descentPath
+}
+
+// descentPath locates a node a descent visited relative to the last node whose path the filter
+// context holds, as the pending path segment and property name wildcards leave for the nodes they
+// select
+type descentPath struct {
+	segment string
+	name    string
+}
+
+func (d *descent) visit(value *yaml.Node, path descentPath) {
+	d.result = append(d.result, value)
+	if d.tracksPaths {
+		d.paths = append(d.paths, path)
+	}
+	d.inside[value]++
+	entries := orderedMapEntries(value)
+	for i, child := range entries {
+		if child == nil {
+			continue
+		}
+		child = expandAlias(d.cfg, child)
+		if d.inside[child] > 0 && !d.reenter(child) {
+			if d.failed {
+				break
+			}
+			continue
+		}
+		if d.parents {
+			d.idx.setParentNode(child, value)
+		}
+		var childPath descentPath
+		if d.tracksPaths {
+			childPath = path.child(value, entries, i)
+		}
+		d.visit(child, childPath)
+	}
+	d.inside[value]--
+}
+
+// child returns the path of the i-th of the entries of value, whose path is p. Mapping keys, and
+// the values of missing keys in malformed documents, have no path of their own.
+func (p descentPath) child(value *yaml.Node, entries []*yaml.Node, i int) descentPath {
+	switch {
+	case value.Kind == yaml.SequenceNode && len(entries) == len(value.Content):
+		return descentPath{segment: p.segment + normalizeIndexSegment(i), name: strconv.Itoa(i)}
+	case i%2 == 0 || entries[i-1] == nil:
+		return descentPath{}
+	case value.Kind == yaml.SequenceNode:
+		// an ordered map, whose entries are the members of its single-member mappings
+		p.segment += normalizeIndexSegment(i / 2)
+	}
+	return descentPath{segment: p.segment + normalizePathSegment(entries[i-1].Value), name: entries[i-1].Value}
+}
+
+// leavePending records the path as pending for node, which selectors applied to node consume
+func (p descentPath) leavePending(fc *filterContext, node *yaml.Node) {
+	if p.segment != "" {
+		fc.SetPendingPathSegment(node, p.segment)
+		fc.SetPendingPropertyName(node, p.name)
+	}
+}
+
+// reenter reports whether to descend into child, a node the descent is already inside of
+func (d *descent) reenter(child *yaml.Node) bool {
+	switch d.cfg.CycleHandling() {
+	case config.CycleError:
+		if !d.failed {
+			d.failed = true
+			failQuery(d.idx, &CyclicDocumentError{Anchor: child.Anchor, Line: child.Line, Column: child.Column})
+		}
+		return false
+	case config.CycleExpand:
+		return d.inside[child] <= d.cfg.CycleExpansionLimit()
+	}
+	return false
+}
+
+// failQuery stops the evaluation idx belongs to, which fails with err
+func failQuery(idx index, err error) {
+	if fc, ok := idx.(*filterContext); ok {
+		fc.fail(err)
+	}
+}
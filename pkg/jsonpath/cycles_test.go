@@ -0,0 +1,130 @@
+package jsonpath
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const aliasDoc = `
+servers:
+  - &primary {name: primary, url: https://api.example.com}
+  - *primary
+  - {name: legacy, url: https://legacy.example.com}
+`
+
+const cyclicDoc = `
+tree: &tree
+  name: root
+  child:
+    name: leaf
+    back: *tree
+`
+
+func queryValues(t *testing.T, doc string, query string, opts ...config.Option) []string {
+	t.Helper()
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(doc), &node))
+	path, err := NewPath(query, opts...)
+	require.NoError(t, err)
+	var values []string
+	for _, result := range path.Query(&node) {
+		values = append(values, result.Value)
+	}
+	return values
+}
+
+func TestAliasExpansion(t *testing.T) {
+	tests := []struct {
+		query    string
+		leaf     []string
+		expanded []string
+	}{
+		{
+			query:    `$..url`,
+			leaf:     []string{"https://api.example.com", "https://legacy.example.com"},
+			expanded: []string{"https://api.example.com", "https://api.example.com", "https://legacy.example.com"},
+		},
+		{
+			query:    `$.servers[1].url`,
+			leaf:     nil,
+			expanded: []string{"https://api.example.com"},
+		},
+		{
+			query:    `$.servers[1]`,
+			leaf:     []string{"primary"},
+			expanded: []string{""},
+		},
+		{
+			query:    `$.servers[?@.name == 'primary'].url`,
+			leaf:     []string{"https://api.example.com"},
+			expanded: []string{"https://api.example.com", "https://api.example.com"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			assert.Equal(t, test.leaf, queryValues(t, aliasDoc, test.query))
+			assert.Equal(t, test.expanded, queryValues(t, aliasDoc, test.query, config.WithAliasExpansion()))
+		})
+	}
+}
+
+func TestCycleHandling(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []config.Option
+		expected []string
+	}{
+		{name: "aliases not expanded", expected: []string{"root", "leaf"}},
+		{name: "skip", opts: []config.Option{config.WithAliasExpansion()}, expected: []string{"root", "leaf"}},
+		{name: "expand once", opts: []config.Option{config.WithAliasExpansion(), config.WithCycleHandling(config.CycleExpand)}, expected: []string{"root", "leaf", "root", "leaf"}},
+		{name: "expand twice", opts: []config.Option{config.WithAliasExpansion(), config.WithCycleExpansionLimit(2)}, expected: []string{"root", "leaf", "root", "leaf", "root", "leaf"}},
+		{name: "expand none", opts: []config.Option{config.WithAliasExpansion(), config.WithCycleExpansionLimit(0)}, expected: []string{"root", "leaf"}},
+		{name: "error", opts: []config.Option{config.WithAliasExpansion(), config.WithCycleHandling(config.CycleError)}, expected: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, queryValues(t, cyclicDoc, `$..name`, test.opts...))
+		})
+	}
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(cyclicDoc), &node))
+	opts := []config.Option{config.WithAliasExpansion(), config.WithCycleHandling(config.CycleError)}
+
+	_, err := MustNewPath(`$..name`, opts...).TryQuery(&node)
+	require.ErrorIs(t, err, ErrCyclicDocument)
+	assert.EqualError(t, err, "jsonpath: cyclic document: node &tree at line 2, column 7 contains an alias to itself")
+
+	_, err = MustNewPath(`$[?$..back]`, opts...).TryQuery(&node)
+	assert.ErrorIs(t, err, ErrCyclicDocument)
+
+	_, err = NewEngine(WithEngineConfig(opts...)).Query(context.Background(), `$..name`, &node)
+	assert.ErrorIs(t, err, ErrCyclicDocument)
+
+	// queries without a descendant segment never reach the cycle
+	result, err := MustNewPath(`$.tree.child.back.child.name`, opts...).TryQuery(&node)
+	require.NoError(t, err)
+	assert.Len(t, result, 1)
+}
+
+func TestCycleHandlingSharedNodes(t *testing.T) {
+	// a hand-built tree whose mapping holds itself
+	root := mapping(scalar("name"), scalar("x"))
+	root.Content = append(root.Content, scalar("self"), root)
+
+	values := MustNewPath(`$..name`).Query(root)
+	require.Len(t, values, 1)
+	assert.Equal(t, "x", values[0].Value)
+
+	assert.Len(t, MustNewPath(`$..name`, config.WithCycleExpansionLimit(3)).Query(root), 4)
+
+	_, err := MustNewPath(`$..name`, config.WithCycleHandling(config.CycleError)).TryQuery(root)
+	assert.EqualError(t, err, "jsonpath: cyclic document: node at line 0, column 0 contains itself")
+}
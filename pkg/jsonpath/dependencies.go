@@ -0,0 +1,82 @@
+package jsonpath
+
+import (
+	"go.yaml.in/yaml/v4"
+)
+
+// Dependency links a query result to the nodes its filters read from elsewhere in the document,
+// through absolute queries ($ or @root), @parent or @sibling. Reads made by a filter that matched
+// one of the result's ancestors are included, so the dependency covers the whole match.
+type Dependency struct {
+	// Result is the matched node.
+	Result Result
+	// Reads are the auxiliary nodes the match depended on, in the order they were first read.
+	Reads []Result
+}
+
+// QueryDependencies runs the query against root like QueryResults, additionally recording which
+// auxiliary nodes each match depended on. Impact analysis can use it to find the results that may
+// change when a node such as $.threshold is edited. Recording has a cost, so Query does not do it.
+func (p *JSONPath) QueryDependencies(root *yaml.Node) []Dependency {
+	node := root
+	if node != nil && node.Kind == yaml.DocumentNode && len(node.Content) == 1 {
+		node = node.Content[0]
+	}
+	if node == nil {
+		return nil
+	}
+	ctx := newFilterContext(node, p.config)
+	ctx.dependencies = newDependencyTracker()
+	nodes := p.ast.evaluate(ctx, node)
+
+	paths := NormalizedPaths(root)
+	parents := map[*yaml.Node]*yaml.Node{node: nil}
+	indexParents(parents, node)
+
+	copier := newResultCopier(p.config)
+	dependencies := make([]Dependency, len(nodes))
+	for i, n := range nodes {
+		var reads []Result
+		var seen []*yaml.Node
+		for ancestor := n; ancestor != nil; ancestor = parents[ancestor] {
+			for _, read := range ctx.dependencies.reads[ancestor] {
+				if containsNode(seen, read) {
+					continue
+				}
+				seen = append(seen, read)
+				reads = append(reads, Result{Node: copier.node(read), Path: paths[read], Line: read.Line, Column: read.Column})
+			}
+		}
+		dependencies[i] = Dependency{
+			Result: Result{Node: copier.node(n), Path: paths[n], Line: n.Line, Column: n.Column},
+			Reads:  reads,
+		}
+	}
+	return dependencies
+}
+
+// indexParents records the parent of every value in the document (keys are not included).
+func indexParents(parents map[*yaml.Node]*yaml.Node, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			if node.Content[i] == nil {
+				continue
+			}
+			if _, seen := parents[node.Content[i]]; !seen {
+				parents[node.Content[i]] = node
+				indexParents(parents, node.Content[i])
+			}
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			if child == nil {
+				continue
+			}
+			if _, seen := parents[child]; !seen {
+				parents[child] = node
+				indexParents(parents, child)
+			}
+		}
+	}
+}
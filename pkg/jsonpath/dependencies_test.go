@@ -0,0 +1,87 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const dependencyDoc = `
+threshold: 10
+tags: [beta]
+items:
+  - name: a
+    size: 5
+  - name: b
+    size: 15
+    labels: [x, y]
+`
+
+func dependencyPaths(t *testing.T, query string) map[string][]string {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(dependencyDoc), &node))
+	path, err := NewPath(query)
+	require.NoError(t, err)
+
+	deps := path.QueryDependencies(&node)
+	assert.Len(t, deps, len(path.Query(&node)))
+	result := make(map[string][]string)
+	for _, dep := range deps {
+		reads := []string{}
+		for _, read := range dep.Reads {
+			reads = append(reads, read.Path)
+		}
+		result[dep.Result.Path] = reads
+	}
+	return result
+}
+
+func TestQueryDependencies(t *testing.T) {
+	requirePlus(t)
+	t.Run("absolute query in filter", func(t *testing.T) {
+		deps := dependencyPaths(t, `$.items[?(@.size > $.threshold)]`)
+		assert.Equal(t, map[string][]string{
+			"$['items'][1]": {"$['threshold']"},
+		}, deps)
+	})
+
+	t.Run("root context variable", func(t *testing.T) {
+		deps := dependencyPaths(t, `$.items[?(@.size > @root.threshold)].name`)
+		assert.Equal(t, map[string][]string{
+			"$['items'][1]['name']": {"$['threshold']"},
+		}, deps)
+	})
+
+	t.Run("reads of matched ancestors are inherited", func(t *testing.T) {
+		deps := dependencyPaths(t, `$.items[?(@.size > $.threshold)].labels[?(@ == 'x' || @ == $.tags[0])]`)
+		assert.Equal(t, map[string][]string{
+			"$['items'][1]['labels'][0]": {"$['threshold']"},
+		}, deps)
+	})
+
+	t.Run("parent context variable", func(t *testing.T) {
+		deps := dependencyPaths(t, `$.items[*].labels[?(@parent[0] == 'x')]`)
+		assert.Equal(t, map[string][]string{
+			"$['items'][1]['labels'][0]": {"$['items'][1]['labels']"},
+			"$['items'][1]['labels'][1]": {"$['items'][1]['labels']"},
+		}, deps)
+	})
+
+	t.Run("ancestors reached from the parent", func(t *testing.T) {
+		deps := dependencyPaths(t, `$.items[*].labels[?(@parent^.size > 10)]`)
+		assert.Equal(t, map[string][]string{
+			"$['items'][1]['labels'][0]": {"$['items'][1]['labels']", "$['items'][1]"},
+			"$['items'][1]['labels'][1]": {"$['items'][1]['labels']", "$['items'][1]"},
+		}, deps)
+	})
+
+	t.Run("no filters means no reads", func(t *testing.T) {
+		deps := dependencyPaths(t, `$.items[*].name`)
+		assert.Equal(t, map[string][]string{
+			"$['items'][0]['name']": {},
+			"$['items'][1]['name']": {},
+		}, deps)
+	})
+}
@@ -0,0 +1,138 @@
+package jsonpath
+
+import (
+	"fmt"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"go.yaml.in/yaml/v4"
+)
+
+// Diagnostic describes a filter comparison that was false because its values have incompatible
+// types, such as an object compared with a number.
+type Diagnostic struct {
+	// Node is the node the filter was tested against.
+	Node *yaml.Node
+	// Path is the normalized path of Node.
+	Path string
+	// Expression is the comparison, e.g. @.price > 10.
+	Expression string
+	// Message describes the mismatch.
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s (line %d, column %d): %s: %s", d.Path, d.Node.Line, d.Node.Column, d.Expression, d.Message)
+}
+
+// QueryWithDiagnostics runs the query against root like Query, and also returns a diagnostic for
+// every comparison between incompatible types made while testing filters, in evaluation order.
+// A handler set with config.WithTypeErrorHandler is still called.
+func (p *JSONPath) QueryWithDiagnostics(root *yaml.Node) ([]*yaml.Node, []Diagnostic) {
+	var diagnostics []Diagnostic
+	cfg := newDiagnosticConfig(p.config, p.config.TypeErrorHandler(), &diagnostics)
+	result := p.ast.query(root, root, cfg)
+	if len(diagnostics) > 0 {
+		paths := NormalizedPaths(root)
+		for i := range diagnostics {
+			diagnostics[i].Path = paths[diagnostics[i].Node]
+		}
+	}
+	copier := newResultCopier(p.config)
+	for i := range diagnostics {
+		diagnostics[i].Node = copier.node(diagnostics[i].Node)
+	}
+	return copier.nodes(result), diagnostics
+}
+
+// diagnosticConfig overrides the type error handler of a config, collecting diagnostics before
+// calling the handler set with config.WithTypeErrorHandler, next, if there is one
+type diagnosticConfig struct {
+	config.Config
+	next        config.TypeErrorHandler
+	diagnostics *[]Diagnostic
+	handler     config.TypeErrorHandler
+}
+
+func newDiagnosticConfig(cfg config.Config, next config.TypeErrorHandler, diagnostics *[]Diagnostic) diagnosticConfig {
+	c := diagnosticConfig{Config: cfg, next: next, diagnostics: diagnostics}
+	c.handler = func(node *yaml.Node, expression string, message string) {
+		*diagnostics = append(*diagnostics, Diagnostic{Node: node, Expression: expression, Message: message})
+		if next != nil {
+			next(node, expression, message)
+		}
+	}
+	return c
+}
+
+// bufferDiagnostics returns cfg for a worker filtering a chunk of a sequence in parallel: if cfg
+// collects diagnostics, the worker collects its own into buffer, to be added in the order of the
+// chunks by flushDiagnostics once every worker is done
+func bufferDiagnostics(cfg config.Config, buffer *[]Diagnostic) config.Config {
+	if c, ok := cfg.(diagnosticConfig); ok {
+		return newDiagnosticConfig(c.Config, c.next, buffer)
+	}
+	return cfg
+}
+
+// flushDiagnostics adds the diagnostics a worker buffered to those cfg collects
+func flushDiagnostics(cfg config.Config, buffer []Diagnostic) {
+	if c, ok := cfg.(diagnosticConfig); ok {
+		*c.diagnostics = append(*c.diagnostics, buffer...)
+	}
+}
+
+func (c diagnosticConfig) TypeErrorHandler() config.TypeErrorHandler {
+	return c.handler
+}
+
+// typeMismatch describes why comparing left and right with op is a type error, or returns "" if
+// it is not one. Ordering is only defined between two numbers, two strings, two date-times or two
+// versions, and a container is never equal to a scalar. Comparisons with Nothing are not type errors.
+func typeMismatch(op comparisonOperator, left literal, right literal) string {
+	l, r := literalKind(left), literalKind(right)
+	if l == "Nothing" || r == "Nothing" {
+		return ""
+	}
+	switch op {
+	case equalTo, notEqualTo:
+		if isContainerKind(l) == isContainerKind(r) {
+			return ""
+		}
+	default:
+		if l == r && (l == "number" || l == "string" || l == "date-time" || l == "version") {
+			return ""
+		}
+	}
+	return fmt.Sprintf("cannot compare %s with %s using %s", l, r, op.ToString())
+}
+
+func isContainerKind(kind string) bool {
+	return kind == "object" || kind == "array"
+}
+
+// literalKind names the JSON type of a literal
+func literalKind(lit literal) string {
+	switch {
+	case lit.integer != nil, lit.float64 != nil:
+		return "number"
+	case lit.string != nil:
+		return "string"
+	case lit.time != nil:
+		return "date-time"
+	case lit.version != nil:
+		return "version"
+	case lit.bool != nil:
+		return "boolean"
+	case lit.null != nil:
+		return "null"
+	case lit.node != nil:
+		switch lit.node.Kind {
+		case yaml.MappingNode:
+			return "object"
+		case yaml.SequenceNode:
+			return "array"
+		}
+		return lit.node.Tag
+	}
+	return "Nothing"
+}
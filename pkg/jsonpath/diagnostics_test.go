@@ -0,0 +1,87 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const diagnosticsDoc = `
+items:
+  - {name: a, price: 5}
+  - {name: b, price: {amount: 15}}
+  - {name: c, price: "25"}
+  - {name: d}
+  - {name: e, price: 30, tags: [x]}
+`
+
+func TestQueryWithDiagnostics(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(diagnosticsDoc), &node))
+
+	path, err := NewPath(`$.items[?@.tags == 'x' || @.price > 10]`)
+	require.NoError(t, err)
+	result, diagnostics := path.QueryWithDiagnostics(&node)
+
+	require.Len(t, result, 1)
+	assert.Equal(t, path.Query(&node), result)
+
+	var messages []string
+	for _, d := range diagnostics {
+		messages = append(messages, d.String())
+	}
+	assert.Equal(t, []string{
+		"$['items'][1] (line 4, column 5): @.price > 10: cannot compare object with number using >",
+		"$['items'][2] (line 5, column 5): @.price > 10: cannot compare string with number using >",
+		"$['items'][4] (line 7, column 5): @.tags == 'x': cannot compare array with string using ==",
+	}, messages)
+	assert.Same(t, result[0], diagnostics[2].Node)
+}
+
+func TestTypeErrorHandler(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(diagnosticsDoc), &node))
+
+	var reported []string
+	handler := config.WithTypeErrorHandler(func(node *yaml.Node, expression string, message string) {
+		reported = append(reported, message)
+	})
+	path, err := NewPath(`$.items[?@.price >= true]`, handler)
+	require.NoError(t, err)
+
+	assert.Empty(t, path.Query(&node))
+	assert.Equal(t, []string{
+		"cannot compare number with boolean using >=",
+		"cannot compare object with boolean using >=",
+		"cannot compare string with boolean using >=",
+		"cannot compare number with boolean using >=",
+	}, reported)
+
+	reported = nil
+	_, diagnostics := path.QueryWithDiagnostics(&node)
+	assert.Len(t, diagnostics, 4)
+	assert.Len(t, reported, 4)
+}
+
+func TestArithmeticDiagnostics(t *testing.T) {
+	requirePlus(t)
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(diagnosticsDoc), &node))
+
+	path, err := NewPath(`$.items[?@.price * 2 > 20]`)
+	require.NoError(t, err)
+	result, diagnostics := path.QueryWithDiagnostics(&node)
+
+	require.Len(t, result, 1)
+	var messages []string
+	for _, d := range diagnostics {
+		messages = append(messages, d.String())
+	}
+	assert.Equal(t, []string{
+		"$['items'][1] (line 4, column 5): @.price * 2: cannot apply * to object and number",
+		"$['items'][2] (line 5, column 5): @.price * 2: cannot apply * to string and number",
+	}, messages)
+}
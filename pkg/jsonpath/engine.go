@@ -0,0 +1,270 @@
+package jsonpath
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"go.yaml.in/yaml/v4"
+)
+
+// DefaultEngineCacheSize is the number of compiled expressions an Engine keeps by default.
+const DefaultEngineCacheSize = 1024
+
+// ErrTooManyResults is returned by Engine.Query when a query matches more nodes than its limit allows.
+var ErrTooManyResults = errors.New("jsonpath: too many results")
+
+// Limits bound the work a single Engine query may do. Zero values mean no limit.
+type Limits struct {
+	// Timeout is how long a query may run before it is abandoned.
+	Timeout time.Duration
+	// MaxResults is the largest number of nodes a query may return.
+	MaxResults int
+}
+
+// Engine compiles and evaluates expressions with shared configuration, for servers that evaluate
+// many expressions on behalf of many callers. Compiled expressions are cached, and every query runs
+// with the engine's limits unless the call overrides them. An Engine is safe for concurrent use.
+type Engine struct {
+	opts      []config.Option
+	limits    Limits
+	metrics   *Metrics
+	cacheSize int
+	logger    *slog.Logger
+	slowQuery time.Duration
+
+	// current is the configuration the engine runs with, replaced as a whole by Swap; opts and
+	// limits are those it was created with
+	current atomic.Pointer[engineState]
+}
+
+// EngineSnapshot is a configuration of an Engine, which Swap can replace while the engine is in use,
+// for services that reload custom functions or limits at runtime.
+type EngineSnapshot struct {
+	// Options are the options expressions are compiled with, including the custom functions
+	// registered with config.WithFunction.
+	Options []config.Option
+	// Limits are the limits queries run with unless a call overrides them.
+	Limits Limits
+}
+
+// engineState is a configuration of an Engine, with the cache of the expressions compiled under it
+type engineState struct {
+	snapshot EngineSnapshot
+
+	mu    sync.RWMutex
+	paths map[string]*JSONPath
+}
+
+func newEngineState(snapshot EngineSnapshot) *engineState {
+	snapshot.Options = slices.Clone(snapshot.Options)
+	return &engineState{snapshot: snapshot, paths: make(map[string]*JSONPath)}
+}
+
+// EngineOption configures an Engine.
+type EngineOption func(*Engine)
+
+// WithEngineConfig sets the options every expression is compiled with.
+func WithEngineConfig(opts ...config.Option) EngineOption {
+	return func(e *Engine) {
+		e.opts = append(e.opts, opts...)
+	}
+}
+
+// WithEngineLimits sets the limits queries run with unless a call overrides them.
+func WithEngineLimits(limits Limits) EngineOption {
+	return func(e *Engine) {
+		e.limits = limits
+	}
+}
+
+// WithEngineMetrics records the latency and result count of every query in m.
+func WithEngineMetrics(m *Metrics) EngineOption {
+	return func(e *Engine) {
+		e.metrics = m
+	}
+}
+
+// WithEngineCacheSize sets how many compiled expressions the engine keeps, DefaultEngineCacheSize by
+// default. When the cache is full an arbitrary entry is dropped. A size of 0 disables caching.
+func WithEngineCacheSize(size int) EngineOption {
+	return func(e *Engine) {
+		e.cacheSize = size
+	}
+}
+
+// WithEngineLogger logs compilations, cache hits and slow queries to logger at debug level.
+func WithEngineLogger(logger *slog.Logger) EngineOption {
+	return func(e *Engine) {
+		e.logger = logger
+	}
+}
+
+// WithEngineSlowQueryThreshold sets how long a query may take before the engine's logger reports it
+// as slow. Slow queries are not reported without a threshold.
+func WithEngineSlowQueryThreshold(threshold time.Duration) EngineOption {
+	return func(e *Engine) {
+		e.slowQuery = threshold
+	}
+}
+
+// NewEngine returns an engine configured with opts.
+func NewEngine(opts ...EngineOption) *Engine {
+	e := &Engine{
+		cacheSize: DefaultEngineCacheSize,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.current.Store(newEngineState(EngineSnapshot{Options: e.opts, Limits: e.limits}))
+	return e
+}
+
+// Snapshot returns the configuration the engine compiles expressions and runs queries with.
+func (e *Engine) Snapshot() EngineSnapshot {
+	snapshot := e.current.Load().snapshot
+	snapshot.Options = slices.Clone(snapshot.Options)
+	return snapshot
+}
+
+// Swap atomically replaces the configuration of the engine with snapshot, and returns the one it
+// replaced. Compiled expressions are pinned to the configuration they were compiled under: those
+// compiled before the swap, and queries already running, go on using the functions and limits they
+// started with, while the engine compiles every expression afresh once swapped. If a function of
+// snapshot is invalid, Swap returns an error and leaves the engine as it was.
+func (e *Engine) Swap(snapshot EngineSnapshot) (EngineSnapshot, error) {
+	if err := validateFunctions(config.New(snapshot.Options...).Functions()); err != nil {
+		return EngineSnapshot{}, err
+	}
+	return e.current.Swap(newEngineState(snapshot)).snapshot, nil
+}
+
+// CallOption overrides the engine's limits for a single query.
+type CallOption func(*Limits)
+
+// WithTimeout overrides the engine's timeout for a query. A timeout of 0 removes the limit.
+func WithTimeout(timeout time.Duration) CallOption {
+	return func(l *Limits) {
+		l.Timeout = timeout
+	}
+}
+
+// WithMaxResults overrides the engine's result limit for a query. A limit of 0 removes it.
+func WithMaxResults(n int) CallOption {
+	return func(l *Limits) {
+		l.MaxResults = n
+	}
+}
+
+// Compile returns the compiled form of expr, from the cache if it has been compiled before.
+func (e *Engine) Compile(expr string) (*JSONPath, error) {
+	return e.compile(e.current.Load(), expr)
+}
+
+// compile is Compile under the configuration of state
+func (e *Engine) compile(state *engineState, expr string) (*JSONPath, error) {
+	state.mu.RLock()
+	p, ok := state.paths[expr]
+	state.mu.RUnlock()
+	if ok {
+		if e.logger != nil {
+			e.logger.Debug("jsonpath: compiled expression cache hit", "expr", expr)
+		}
+		return p, nil
+	}
+
+	start := time.Now()
+	p, err := NewPath(expr, state.snapshot.Options...)
+	if err != nil {
+		if e.logger != nil {
+			e.logger.Debug("jsonpath: expression failed to compile", "expr", expr, "error", err)
+		}
+		return nil, err
+	}
+	if e.logger != nil {
+		e.logger.Debug("jsonpath: compiled expression", "expr", expr, "duration", time.Since(start))
+	}
+	if e.cacheSize > 0 {
+		state.mu.Lock()
+		if len(state.paths) >= e.cacheSize {
+			for evict := range state.paths {
+				delete(state.paths, evict)
+				break
+			}
+		}
+		state.paths[expr] = p
+		state.mu.Unlock()
+	}
+	return p, nil
+}
+
+// Query compiles expr and evaluates it against root. The query stops early if ctx is cancelled or
+// the timeout passes, returning the context's error, and fails with ErrTooManyResults if it matches
+// more nodes than allowed. Results are only returned if the query completes within its limits. A
+// panic during evaluation is returned as an error rather than crashing the caller.
+func (e *Engine) Query(ctx context.Context, expr string, root *yaml.Node, opts ...CallOption) (result []*yaml.Node, err error) {
+	state := e.current.Load()
+	p, err := e.compile(state, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := state.snapshot.Limits
+	for _, opt := range opts {
+		opt(&limits)
+	}
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("jsonpath: query %q failed: %v", expr, r)
+		}
+	}()
+	start := time.Now()
+	result, err = p.queryUntil(root, ctx.Done())
+	elapsed := time.Since(start)
+	if e.metrics != nil {
+		e.metrics.Observe(p.String(), elapsed, len(result))
+	}
+	if e.logger != nil && e.slowQuery > 0 && elapsed >= e.slowQuery {
+		e.logger.DebugContext(ctx, "jsonpath: slow query", "expr", expr, "duration", elapsed, "results", len(result))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("jsonpath: query %q abandoned: %w", expr, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if limits.MaxResults > 0 && len(result) > limits.MaxResults {
+		return nil, fmt.Errorf("%w: %q matched %d nodes, the limit is %d", ErrTooManyResults, expr, len(result), limits.MaxResults)
+	}
+	return copyResults(p.config, result), nil
+}
+
+// queryUntil is Query, abandoning evaluation once done is closed, and returning the error evaluation
+// failed with
+func (p *JSONPath) queryUntil(root *yaml.Node, done <-chan struct{}) ([]*yaml.Node, error) {
+	if root != nil && root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		root = root.Content[0]
+	}
+	if root == nil {
+		return nil, nil
+	}
+	ctx := newFilterContext(root, p.config)
+	ctx.done = done
+	result := p.ast.evaluate(ctx, root)
+	if err := ctx.err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,211 @@
+package jsonpath
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func engineDoc(t *testing.T, items int) *yaml.Node {
+	var b strings.Builder
+	b.WriteString("items:\n")
+	for i := 0; i < items; i++ {
+		fmt.Fprintf(&b, "  - {id: %d, name: item%d}\n", i, i)
+	}
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(b.String()), &node))
+	return &node
+}
+
+func TestEngine_Query(t *testing.T) {
+	doc := engineDoc(t, 10)
+	e := NewEngine(WithEngineConfig(config.WithStringOrder(config.StringOrderNatural)))
+
+	result, err := e.Query(context.Background(), `$.items[?@.name > 'item7'].id`, doc)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, "8", result[0].Value)
+	assert.Equal(t, "9", result[1].Value)
+
+	p1, err := e.Compile(`$.items[*].id`)
+	require.NoError(t, err)
+	p2, err := e.Compile(`$.items[*].id`)
+	require.NoError(t, err)
+	assert.Same(t, p1, p2)
+
+	_, err = e.Query(context.Background(), `$.items[`, doc)
+	assert.Error(t, err)
+}
+
+func TestEngine_Limits(t *testing.T) {
+	doc := engineDoc(t, 10)
+	e := NewEngine(WithEngineLimits(Limits{MaxResults: 5}))
+
+	_, err := e.Query(context.Background(), `$.items[*]`, doc)
+	assert.True(t, errors.Is(err, ErrTooManyResults))
+
+	result, err := e.Query(context.Background(), `$.items[*]`, doc, WithMaxResults(0))
+	require.NoError(t, err)
+	assert.Len(t, result, 10)
+
+	result, err = e.Query(context.Background(), `$.items[:5]`, doc)
+	require.NoError(t, err)
+	assert.Len(t, result, 5)
+}
+
+func TestEngine_Cancellation(t *testing.T) {
+	doc := engineDoc(t, 1000)
+	e := NewEngine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := e.Query(ctx, `$.items[?@.id > 10]`, doc)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	_, err = e.Query(context.Background(), `$.items[?@.id > 10]`, doc, WithTimeout(time.Nanosecond))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// evaluation stops once cancelled rather than running to completion
+	p, err := e.Compile(`$..*`)
+	require.NoError(t, err)
+	done := make(chan struct{})
+	close(done)
+	result, err := p.queryUntil(doc, done)
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestEngine_Concurrent(t *testing.T) {
+	doc := engineDoc(t, 100)
+	metrics := NewMetrics()
+	e := NewEngine(WithEngineCacheSize(2), WithEngineMetrics(metrics))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				expr := fmt.Sprintf(`$.items[?@.id < %d]`, (i+j)%4)
+				result, err := e.Query(context.Background(), expr, doc, WithMaxResults(3))
+				if assert.NoError(t, err) {
+					assert.Len(t, result, (i+j)%4)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for _, stats := range metrics.Report() {
+		total += stats.Count
+	}
+	assert.Equal(t, 400, total)
+}
+
+func TestEngine_Logger(t *testing.T) {
+	doc := engineDoc(t, 10)
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	e := NewEngine(WithEngineLogger(logger), WithEngineSlowQueryThreshold(time.Nanosecond))
+
+	_, err := e.Query(context.Background(), `$.items[*].id`, doc)
+	require.NoError(t, err)
+	_, err = e.Compile(`$.items[*].id`)
+	require.NoError(t, err)
+	_, err = e.Compile(`$.items[`)
+	require.Error(t, err)
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[0], `msg="jsonpath: compiled expression" expr=$.items[*].id`)
+	assert.Contains(t, lines[1], `msg="jsonpath: slow query" expr=$.items[*].id`)
+	assert.Contains(t, lines[1], "results=10")
+	assert.Contains(t, lines[2], `msg="jsonpath: compiled expression cache hit"`)
+	assert.Contains(t, lines[3], `msg="jsonpath: expression failed to compile" expr=$.items[`)
+
+	logs.Reset()
+	e = NewEngine(WithEngineLogger(logger))
+	_, err = e.Query(context.Background(), `$.items[*].id`, doc)
+	require.NoError(t, err)
+	assert.NotContains(t, logs.String(), "slow query")
+}
+
+func TestEngine_Swap(t *testing.T) {
+	doc := engineDoc(t, 10)
+	below := func(n int) config.Function {
+		return config.Function{Args: 1, Call: func(call config.FunctionCall) any {
+			id, err := strconv.Atoi(call.Args[0][0].Value)
+			return err == nil && id < n
+		}}
+	}
+	e := NewEngine(WithEngineConfig(config.WithFunction("selected", below(3))))
+
+	before, err := e.Compile(`$.items[?selected(@.id)]`)
+	require.NoError(t, err)
+	result, err := e.Query(context.Background(), `$.items[?selected(@.id)]`, doc)
+	require.NoError(t, err)
+	assert.Len(t, result, 3)
+
+	previous, err := e.Swap(EngineSnapshot{
+		Options: []config.Option{config.WithFunction("selected", below(6))},
+		Limits:  Limits{MaxResults: 5},
+	})
+	require.NoError(t, err)
+	assert.Len(t, previous.Options, 1)
+	assert.Equal(t, Limits{}, previous.Limits)
+	assert.Equal(t, Limits{MaxResults: 5}, e.Snapshot().Limits)
+
+	// the engine compiles afresh, while paths compiled before the swap keep their functions
+	after, err := e.Compile(`$.items[?selected(@.id)]`)
+	require.NoError(t, err)
+	assert.NotSame(t, before, after)
+	assert.Len(t, before.Query(doc), 3)
+	assert.Len(t, after.Query(doc), 6)
+	_, err = e.Query(context.Background(), `$.items[?selected(@.id)]`, doc)
+	assert.True(t, errors.Is(err, ErrTooManyResults))
+
+	// an invalid configuration is rejected, leaving the engine as it was
+	_, err = e.Swap(EngineSnapshot{Options: []config.Option{config.WithFunction("length", below(1))}})
+	assert.ErrorContains(t, err, "built in")
+	assert.Equal(t, Limits{MaxResults: 5}, e.Snapshot().Limits)
+	same, err := e.Compile(`$.items[?selected(@.id)]`)
+	require.NoError(t, err)
+	assert.Same(t, after, same)
+}
+
+func TestEngine_SwapConcurrent(t *testing.T) {
+	doc := engineDoc(t, 100)
+	e := NewEngine()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				result, err := e.Query(context.Background(), `$.items[?@.id < 10]`, doc)
+				if assert.NoError(t, err) {
+					assert.Len(t, result, 10)
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		_, err := e.Swap(EngineSnapshot{Limits: Limits{MaxResults: 10 + i}})
+		require.NoError(t, err)
+	}
+	wg.Wait()
+}
@@ -1,9 +1,11 @@
 package jsonpath
 
 import (
+    "github.com/pb33f/jsonpath/pkg/jsonpath/config"
     "go.yaml.in/yaml/v4"
     "strconv"
     "strings"
+    "time"
 )
 
 // filter-selector     = "?" S logical-expr
@@ -19,6 +21,8 @@ func (s filterSelector) ToString() string {
 // logical-or-expr     = logical-and-expr *(S "||" S logical-and-expr)
 type logicalOrExpr struct {
     expressions []*logicalAndExpr
+    // conditional makes the expressions the condition choosing between two branches (JSONPath Plus)
+    conditional *conditionalExpr
 }
 
 func (e logicalOrExpr) ToString() string {
@@ -29,9 +33,24 @@ func (e logicalOrExpr) ToString() string {
         }
         builder.WriteString(expr.ToString())
     }
+    if e.conditional != nil {
+        builder.WriteString(" ? ")
+        builder.WriteString(e.conditional.then.ToString())
+        builder.WriteString(" : ")
+        builder.WriteString(e.conditional.otherwise.ToString())
+    }
     return builder.String()
 }
 
+// conditionalExpr is the JSONPath Plus extension choosing the expression a node must match by a
+// condition, as in @.kind == 'pro' ? @.limit > 100 : @.limit > 10. It binds more loosely than ||.
+//
+//	conditional-expr    = logical-or-expr S "?" S logical-expr S ":" S logical-expr
+type conditionalExpr struct {
+    then      *logicalOrExpr
+    otherwise *logicalOrExpr
+}
+
 // logical-and-expr    = basic-expr *(S "&&" S basic-expr)
 type logicalAndExpr struct {
     expressions []*basicExpr
@@ -67,6 +86,9 @@ func (q relQuery) ToString() string {
 type filterQuery struct {
     relQuery      *relQuery
     jsonPathQuery *jsonPathAST
+    // contextVar is a JSONPath Plus context variable followed by segments that may select several
+    // nodes, e.g. @parent.* or @parent..name
+    contextVar *contextVariable
 }
 
 func (q filterQuery) ToString() string {
@@ -74,6 +96,8 @@ func (q filterQuery) ToString() string {
         return q.relQuery.ToString()
     } else if q.jsonPathQuery != nil {
         return q.jsonPathQuery.ToString()
+    } else if q.contextVar != nil {
+        return q.contextVar.ToString()
     }
     return ""
 }
@@ -91,6 +115,15 @@ type functionArgument struct {
     contextVar   *contextVariable // JSONPath Plus context variables
 }
 
+// isValue reports whether the argument can be passed as a value (ValueType): a literal, a singular
+// query, a context variable or a function returning a value
+func (a functionArgument) isValue() bool {
+    if a.logicalExpr != nil {
+        return false
+    }
+    return a.functionExpr == nil || a.functionExpr.funcType.returnsValue()
+}
+
 type functionArgType int
 
 const (
@@ -111,7 +144,7 @@ func (a functionArgument) Eval(idx index, node *yaml.Node, root *yaml.Node) reso
         result := a.filterQuery.Query(idx, node, root)
         lits := make([]*literal, len(result))
         for i, node := range result {
-            lit := nodeToLiteral(node)
+            lit := documentValue(idx, node)
             lits[i] = &lit
         }
         if len(result) != 1 {
@@ -125,7 +158,7 @@ func (a functionArgument) Eval(idx index, node *yaml.Node, root *yaml.Node) reso
     } else if a.functionExpr != nil {
         res := a.functionExpr.Evaluate(idx, node, root)
         return resolvedArgument{kind: functionArgTypeLiteral, literal: &res}
-    } else if a.contextVar != nil {
+    } else if config.PlusAvailable && a.contextVar != nil {
         // Evaluate context variable and return as literal
         res := a.contextVar.Evaluate(idx, node, root)
         return resolvedArgument{kind: functionArgTypeLiteral, literal: &res}
@@ -171,6 +204,33 @@ const (
     functionTypeIsArray
     functionTypeIsObject
     functionTypeIsInteger
+    // binary payload functions
+    functionTypeDecodeBase64
+    functionTypeByteLength
+    // substring and element membership test
+    functionTypeContains
+    // string prefix and suffix tests
+    functionTypeStartsWith
+    functionTypeEndsWith
+    // aggregations over the numbers of a nodelist
+    functionTypeMin
+    functionTypeMax
+    functionTypeSum
+    functionTypeAvg
+    // string normalization
+    functionTypeLowercase
+    functionTypeUppercase
+    functionTypeTrim
+    // conversion between strings and arrays of strings
+    functionTypeSplit
+    functionTypeJoin
+    // date and time parsing
+    functionTypeDate
+    functionTypeDateTime
+    // semantic version parsing
+    functionTypeSemver
+    // functions registered with config.WithFunction
+    functionTypeCustom
 )
 
 var functionTypeMap = map[string]functionType{
@@ -179,6 +239,41 @@ var functionTypeMap = map[string]functionType{
     "match":  functionTypeMatch,
     "search": functionTypeSearch,
     "value":  functionTypeValue,
+    // extensions for validating binary payloads embedded as base64 strings
+    "decodeBase64": functionTypeDecodeBase64,
+    "byteLength":   functionTypeByteLength,
+    // extension testing whether a string or array contains a value
+    "contains": functionTypeContains,
+    // extensions testing the start and end of strings
+    "starts_with": functionTypeStartsWith,
+    "ends_with":   functionTypeEndsWith,
+    // extensions aggregating the numbers a query selects
+    "min": functionTypeMin,
+    "max": functionTypeMax,
+    "sum": functionTypeSum,
+    "avg": functionTypeAvg,
+    // extensions normalizing strings for comparison
+    "lowercase": functionTypeLowercase,
+    "uppercase": functionTypeUppercase,
+    "trim":      functionTypeTrim,
+    // extensions converting string-encoded lists to arrays and back
+    "split": functionTypeSplit,
+    "join":  functionTypeJoin,
+    // extensions parsing dates and date-times so they compare chronologically
+    "date":     functionTypeDate,
+    "datetime": functionTypeDateTime,
+    // extension parsing semantic versions so they compare by precedence
+    "semver": functionTypeSemver,
+}
+
+// isStandardFunction reports whether name is one of the functions RFC 9535 defines. The other
+// functions of functionTypeMap are extensions, available in JSONPath Plus mode.
+func isStandardFunction(name string) bool {
+    switch name {
+    case "length", "count", "match", "search", "value":
+        return true
+    }
+    return false
 }
 
 // typeSelectorFunctionMap maps JSONPath Plus type selector function names to their types.
@@ -193,6 +288,19 @@ var typeSelectorFunctionMap = map[string]functionType{
     "isInteger": functionTypeIsInteger,
 }
 
+// returnsValue reports whether the function's result is a value (ValueType), rather than a logical
+// result, such as the result of match()
+func (f functionType) returnsValue() bool {
+    switch f {
+    case functionTypeLength, functionTypeCount, functionTypeValue, functionTypeDecodeBase64, functionTypeByteLength, functionTypeCustom,
+        functionTypeMin, functionTypeMax, functionTypeSum, functionTypeAvg,
+        functionTypeLowercase, functionTypeUppercase, functionTypeTrim, functionTypeSplit, functionTypeJoin,
+        functionTypeDate, functionTypeDateTime, functionTypeSemver:
+        return true
+    }
+    return false
+}
+
 func (f functionType) String() string {
     for k, v := range functionTypeMap {
         if v == f {
@@ -212,11 +320,18 @@ func (f functionType) String() string {
 type functionExpr struct {
     funcType functionType
     args     []*functionArgument
+    // name and custom are set for functions registered with config.WithFunction
+    name   string
+    custom config.Function
 }
 
 func (e functionExpr) ToString() string {
     builder := strings.Builder{}
-    builder.WriteString(e.funcType.String())
+    if e.funcType == functionTypeCustom {
+        builder.WriteString(e.name)
+    } else {
+        builder.WriteString(e.funcType.String())
+    }
     builder.WriteString("(")
     for i, arg := range e.args {
         if i > 0 {
@@ -236,6 +351,7 @@ type testExpr struct {
     not          bool
     filterQuery  *filterQuery
     functionExpr *functionExpr
+    contextVar   *contextVariable // JSONPath Plus extension
 }
 
 func (e testExpr) ToString() string {
@@ -247,6 +363,8 @@ func (e testExpr) ToString() string {
         builder.WriteString(e.filterQuery.ToString())
     } else if e.functionExpr != nil {
         builder.WriteString(e.functionExpr.ToString())
+    } else if e.contextVar != nil {
+        builder.WriteString(e.contextVar.ToString())
     }
     return builder.String()
 }
@@ -284,6 +402,10 @@ type literal struct {
     bool    *bool
     null    *bool
     node    *yaml.Node
+    // time is only produced by evaluation, by date() and datetime()
+    time *time.Time
+    // version is only produced by evaluation, by semver()
+    version *semanticVersion
 }
 
 func (l literal) ToString() string {
@@ -297,6 +419,10 @@ func (l literal) ToString() string {
         builder.WriteString(escapeString(*l.string))
         builder.WriteString("'")
         return builder.String()
+    } else if l.time != nil {
+        return "'" + l.time.Format(time.RFC3339Nano) + "'"
+    } else if l.version != nil {
+        return "'" + escapeString(l.version.text) + "'"
     } else if l.bool != nil {
         if *l.bool {
             return "true"
@@ -340,20 +466,9 @@ func (l literal) ToString() string {
     return ""
 }
 
+// escapeString escapes a string for a single-quoted string literal, in the form normalized paths use
 func escapeString(value string) string {
-    b := strings.Builder{}
-    for i := 0; i < len(value); i++ {
-        if value[i] == '\n' {
-            b.WriteString("\\\\n")
-        } else if value[i] == '\\' {
-            b.WriteString("\\\\")
-        } else if value[i] == '\'' {
-            b.WriteString("\\'")
-        } else {
-            b.WriteByte(value[i])
-        }
-    }
-    return b.String()
+    return escapePathSegment(value)
 }
 
 type absQuery jsonPathAST
@@ -392,15 +507,40 @@ const (
     contextVarParentProperty                       // @parentProperty - parent's property name
     contextVarPath                                 // @path - absolute path to current node
     contextVarIndex                                // @index - current array index
+    contextVarPropertyPath                         // @propertyPath - keys/indices from the root to the current node
+    contextVarSibling                              // @sibling('key') - sibling of the current node
+    contextVarPrev                                 // @prev - previous element of the current sequence
+    contextVarNext                                 // @next - next element of the current sequence
+    contextVarLine                                 // @line - line of the current node in its source
+    contextVarColumn                               // @column - column of the current node in its source
+    contextVarPathKeys                             // @pathKeys - another name for @propertyPath
 )
 
 // contextVariable represents a JSONPath Plus context variable in filter expressions.
 // These provide access to metadata about the current node being evaluated.
 type contextVariable struct {
     kind contextVarKind
+    // sibling is the key (string) or index (integer) argument of @sibling
+    sibling *literal
+    // segments are singular segments applied to the value of the variable, e.g. @propertyPath[0]
+    segments []*segment
 }
 
 func (cv contextVariable) ToString() string {
+    builder := strings.Builder{}
+    builder.WriteString(cv.name())
+    if cv.sibling != nil {
+        builder.WriteString("(")
+        builder.WriteString(cv.sibling.ToString())
+        builder.WriteString(")")
+    }
+    for _, seg := range cv.segments {
+        builder.WriteString(seg.ToString())
+    }
+    return builder.String()
+}
+
+func (cv contextVariable) name() string {
     switch cv.kind {
     case contextVarProperty:
         return "@property"
@@ -414,6 +554,20 @@ func (cv contextVariable) ToString() string {
         return "@path"
     case contextVarIndex:
         return "@index"
+    case contextVarPropertyPath:
+        return "@propertyPath"
+    case contextVarPathKeys:
+        return "@pathKeys"
+    case contextVarSibling:
+        return "@sibling"
+    case contextVarPrev:
+        return "@prev"
+    case contextVarNext:
+        return "@next"
+    case contextVarLine:
+        return "@line"
+    case contextVarColumn:
+        return "@column"
     default:
         return "@unknown"
     }
@@ -424,12 +578,14 @@ func (cv contextVariable) ToString() string {
 //	comparable = literal /
 //	singular-query / ; singular query value
 //	function-expr    ; ValueType
-//	context-variable ; JSONPath Plus extension
+//	context-variable / ; JSONPath Plus extension
+//	arithmetic-expr    ; JSONPath Plus extension
 type comparable struct {
     literal       *literal
     singularQuery *singularQuery
     functionExpr  *functionExpr
     contextVar    *contextVariable // JSONPath Plus extension
+    arithmetic    *arithmeticExpr  // JSONPath Plus extension
 }
 
 func (c comparable) ToString() string {
@@ -441,10 +597,79 @@ func (c comparable) ToString() string {
         return c.functionExpr.ToString()
     } else if c.contextVar != nil {
         return c.contextVar.ToString()
+    } else if c.arithmetic != nil {
+        return c.arithmetic.ToString()
     }
     return ""
 }
 
+// arithmeticExpr applies an arithmetic operator to two comparables. JSONPath Plus extension.
+//
+//	arithmetic-expr = comparable S arithmetic-op S comparable
+//	arithmetic-op   = "+" / "-" / "*" / "/" / "%"
+type arithmeticExpr struct {
+    left  *comparable
+    op    arithmeticOperator
+    right *comparable
+}
+
+func (e arithmeticExpr) ToString() string {
+    builder := strings.Builder{}
+    builder.WriteString(e.operand(e.left, false))
+    builder.WriteString(" ")
+    builder.WriteString(e.op.ToString())
+    builder.WriteString(" ")
+    builder.WriteString(e.operand(e.right, true))
+    return builder.String()
+}
+
+// operand returns an operand of e as a string, in parentheses if it would otherwise bind differently
+func (e arithmeticExpr) operand(c *comparable, right bool) string {
+    if c.arithmetic == nil {
+        return c.ToString()
+    }
+    precedence := c.arithmetic.op.precedence()
+    if precedence < e.op.precedence() || right && precedence == e.op.precedence() {
+        return "(" + c.ToString() + ")"
+    }
+    return c.ToString()
+}
+
+// arithmeticOperator represents an arithmetic operator
+type arithmeticOperator int
+
+const (
+    add arithmeticOperator = iota
+    subtract
+    multiply
+    divide
+    modulo
+)
+
+func (o arithmeticOperator) ToString() string {
+    switch o {
+    case add:
+        return "+"
+    case subtract:
+        return "-"
+    case multiply:
+        return "*"
+    case divide:
+        return "/"
+    case modulo:
+        return "%"
+    }
+    return ""
+}
+
+// precedence returns how tightly the operator binds, multiplication before addition
+func (o arithmeticOperator) precedence() int {
+    if o == add || o == subtract {
+        return 1
+    }
+    return 2
+}
+
 // comparisonExpr represents a comparison expression
 //
 //	comparison-expr     = comparable S comparison-op S comparable
@@ -456,10 +681,15 @@ func (c comparable) ToString() string {
 //	comparison-op       = "==" / "!=" /
 //	                      "<=" / ">=" /
 //	                      "<"  / ">"
+//
+// JSONPath Plus adds comparable "=~" pattern, where pattern is a regular expression, in which case
+// right is nil.
 type comparisonExpr struct {
-    left  *comparable
-    op    comparisonOperator
-    right *comparable
+    left    *comparable
+    op      comparisonOperator
+    right   *comparable
+    pattern *regexPattern
+    values  []*literal // the list of an in operator, such as ['A', 'B']
 }
 
 func (e comparisonExpr) ToString() string {
@@ -468,7 +698,20 @@ func (e comparisonExpr) ToString() string {
     builder.WriteString(" ")
     builder.WriteString(e.op.ToString())
     builder.WriteString(" ")
-    builder.WriteString(e.right.ToString())
+    if e.pattern != nil {
+        builder.WriteString(e.pattern.source)
+    } else if e.op == in && e.right == nil {
+        builder.WriteString("[")
+        for i, value := range e.values {
+            if i > 0 {
+                builder.WriteString(", ")
+            }
+            builder.WriteString(value.ToString())
+        }
+        builder.WriteString("]")
+    } else {
+        builder.WriteString(e.right.ToString())
+    }
     return builder.String()
 }
 
@@ -508,6 +751,10 @@ const (
     lessThanEqualTo
     greaterThan
     greaterThanEqualTo
+    // matches is the JSONPath Plus =~ operator
+    matches
+    // in is the JSONPath Plus membership operator
+    in
 )
 
 func (o comparisonOperator) ToString() string {
@@ -524,6 +771,10 @@ func (o comparisonOperator) ToString() string {
         return ">"
     case greaterThanEqualTo:
         return ">="
+    case matches:
+        return "=~"
+    case in:
+        return "in"
     }
     return ""
 }
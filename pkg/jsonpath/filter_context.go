@@ -1,9 +1,12 @@
 package jsonpath
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
 	"go.yaml.in/yaml/v4"
 )
 
@@ -61,10 +64,21 @@ type filterContext struct {
 	root                  *yaml.Node
 	arrayIndex            int
 	parentTrackingActive  bool
+	pathTrackingActive    bool // set when filters read @path, @propertyPath, @pathKeys or @parentProperty
+	config                config.Config
+	dependencies          *dependencyTracker // nil unless reads are being recorded
+	profile               *queryProfile      // nil unless segments are being timed
+	done                  <-chan struct{}    // closed when the query is cancelled, nil if it cannot be
+	failure               *error             // the error the query failed with, shared with clones
 }
 
 // NewFilterContext creates a new FilterContext with the given root node
 func NewFilterContext(root *yaml.Node) FilterContext {
+	return newFilterContext(root, config.New())
+}
+
+// newFilterContext creates a new filterContext that evaluates with the given config
+func newFilterContext(root *yaml.Node, cfg config.Config) *filterContext {
 	return &filterContext{
 		_index: _index{
 			propertyKeys: make(map[*yaml.Node]*yaml.Node),
@@ -75,6 +89,8 @@ func NewFilterContext(root *yaml.Node) FilterContext {
 		pendingPropertyNames: make(map[*yaml.Node]string),
 		root:                 root,
 		arrayIndex:           -1,
+		config:               cfg,
+		failure:              new(error),
 	}
 }
 
@@ -215,9 +231,142 @@ func (fc *filterContext) Clone() FilterContext {
 		root:                 fc.root,
 		arrayIndex:           fc.arrayIndex,
 		parentTrackingActive: fc.parentTrackingActive,
+		pathTrackingActive:   fc.pathTrackingActive,
+		config:               fc.config,
+		dependencies:         fc.dependencies,
+		profile:              fc.profile,
+		done:                 fc.done,
+		failure:              fc.failure,
+	}
+}
+
+// filterState is the part of a filterContext describing the node a filter is testing
+type filterState struct {
+	propertyName       string
+	parent             *yaml.Node
+	parentPropertyName string
+	pathDepth          int
+	arrayIndex         int
+}
+
+// saveState returns the description of the node under test, which queries within the filter
+// overwrite as they select nodes, for restoreState to bring back once they are done
+func (fc *filterContext) saveState() filterState {
+	return filterState{
+		propertyName:       fc.propertyName,
+		parent:             fc.parent,
+		parentPropertyName: fc.parentPropertyName,
+		pathDepth:          len(fc.pathSegments),
+		arrayIndex:         fc.arrayIndex,
+	}
+}
+
+// restoreState describes the node under test as saveState found it
+func (fc *filterContext) restoreState(state filterState) {
+	fc.propertyName = state.propertyName
+	fc.parent = state.parent
+	fc.parentPropertyName = state.parentPropertyName
+	if state.pathDepth < len(fc.pathSegments) {
+		fc.pathSegments = fc.pathSegments[:state.pathDepth]
+	}
+	fc.arrayIndex = state.arrayIndex
+}
+
+// interrupted reports whether the query has been cancelled or has failed, in which case evaluation
+// stops early and its results are incomplete.
+func (fc *filterContext) interrupted() bool {
+	if fc.err() != nil {
+		return true
+	}
+	if fc.done == nil {
+		return false
+	}
+	select {
+	case <-fc.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// fail makes the query fail with err, unless it has already failed
+func (fc *filterContext) fail(err error) {
+	if fc.failure != nil && *fc.failure == nil {
+		*fc.failure = err
+	}
+}
+
+// err returns the error the query failed with, or nil
+func (fc *filterContext) err() error {
+	if fc.failure == nil {
+		return nil
+	}
+	return *fc.failure
+}
+
+// configOf returns the config the query is being evaluated with, or the default config
+// if idx does not carry one
+func configOf(idx index) config.Config {
+	if fc, ok := idx.(*filterContext); ok && fc.config != nil {
+		return fc.config
+	}
+	return defaultConfig
+}
+
+var defaultConfig = config.New()
+
+// dependencyTracker records the nodes outside of the current node that filters read (through
+// absolute queries, @root or @parent), and attributes them to the nodes those filters matched.
+type dependencyTracker struct {
+	pending []*yaml.Node
+	marks   []int
+	reads   map[*yaml.Node][]*yaml.Node
+}
+
+func newDependencyTracker() *dependencyTracker {
+	return &dependencyTracker{reads: make(map[*yaml.Node][]*yaml.Node)}
+}
+
+// begin starts the evaluation of a filter against a node
+func (d *dependencyTracker) begin() {
+	d.marks = append(d.marks, len(d.pending))
+}
+
+// end finishes the evaluation of a filter against node, attributing the reads made since the
+// matching begin to it when it matched. Reads stay pending so enclosing filters see them too.
+func (d *dependencyTracker) end(node *yaml.Node, matched bool) {
+	mark := d.marks[len(d.marks)-1]
+	d.marks = d.marks[:len(d.marks)-1]
+	if matched {
+		for _, read := range d.pending[mark:] {
+			if !containsNode(d.reads[node], read) {
+				d.reads[node] = append(d.reads[node], read)
+			}
+		}
+	}
+	if len(d.marks) == 0 {
+		d.pending = d.pending[:0]
 	}
 }
 
+func containsNode(nodes []*yaml.Node, node *yaml.Node) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+// recordReads notes that a filter read the given nodes, if idx is recording dependencies
+func recordReads(idx index, nodes ...*yaml.Node) {
+	fc, ok := idx.(*filterContext)
+	if !ok || fc.dependencies == nil || len(fc.dependencies.marks) == 0 {
+		return
+	}
+	fc.dependencies.pending = append(fc.dependencies.pending, nodes...)
+}
+
 // Helper function to create a normalized path segment for a property name
 func normalizePathSegment(name string) string {
 	return "['" + escapePathSegment(name) + "']"
@@ -228,18 +377,118 @@ func normalizeIndexSegment(idx int) string {
 	return "[" + strconv.Itoa(idx) + "]"
 }
 
-// escapePathSegment escapes special characters in path segment names
+// escapePathSegment escapes a name for a single-quoted string as normalized paths do: ' and \ are
+// escaped with a backslash, control characters as \b, \f, \n, \r, \t or \u00XX, and everything
+// else is kept as is. Bytes that are not valid UTF-8 are kept too, so the name parses back unchanged.
 func escapePathSegment(s string) string {
 	var b strings.Builder
-	for _, r := range s {
-		switch r {
-		case '\'':
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '\'':
 			b.WriteString("\\'")
-		case '\\':
+		case r == '\\':
 			b.WriteString("\\\\")
+		case r == '\b':
+			b.WriteString("\\b")
+		case r == '\f':
+			b.WriteString("\\f")
+		case r == '\n':
+			b.WriteString("\\n")
+		case r == '\r':
+			b.WriteString("\\r")
+		case r == '\t':
+			b.WriteString("\\t")
+		case r < 0x20:
+			fmt.Fprintf(&b, "\\u%04x", r)
 		default:
-			b.WriteRune(r)
+			b.WriteString(s[i : i+size])
 		}
+		i += size
 	}
 	return b.String()
 }
+
+// propertyPathNode converts a normalized path into a sequence of its member names (as strings)
+// and array indices (as integers), from the root downwards.
+func propertyPathNode(path string) *yaml.Node {
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, element := range splitNormalizedPath(path) {
+		if element.isIndex {
+			seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(element.index)})
+		} else {
+			seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: element.name})
+		}
+	}
+	return seq
+}
+
+// pathElement is a single member name or array index of a normalized path.
+type pathElement struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+// unescapePathRune writes the character escaped by the escape sequence at the start of s, which
+// follows a backslash, and returns the length of the sequence
+func unescapePathRune(b *strings.Builder, s string) int {
+	switch s[0] {
+	case 'b':
+		b.WriteByte('\b')
+	case 'f':
+		b.WriteByte('\f')
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case 'u':
+		if len(s) >= 5 {
+			if r, err := strconv.ParseUint(s[1:5], 16, 16); err == nil {
+				b.WriteRune(rune(r))
+				return 5
+			}
+		}
+		b.WriteByte(s[0])
+	default:
+		b.WriteByte(s[0])
+	}
+	return 1
+}
+
+// splitNormalizedPath splits a normalized path such as $['a'][0] into its elements.
+func splitNormalizedPath(path string) []pathElement {
+	var elements []pathElement
+	for i := 0; i < len(path); i++ {
+		if path[i] != '[' || i+1 >= len(path) {
+			continue
+		}
+		if path[i+1] == '\'' {
+			var name strings.Builder
+			j := i + 2
+			for ; j < len(path) && path[j] != '\''; j++ {
+				if path[j] == '\\' && j+1 < len(path) {
+					j++
+					j += unescapePathRune(&name, path[j:]) - 1
+					continue
+				}
+				name.WriteByte(path[j])
+			}
+			elements = append(elements, pathElement{name: name.String()})
+			i = j + 1
+			continue
+		}
+		end := strings.IndexByte(path[i:], ']')
+		if end < 0 {
+			break
+		}
+		index, err := strconv.Atoi(path[i+1 : i+end])
+		if err == nil {
+			elements = append(elements, pathElement{index: index, isIndex: true})
+		}
+		i += end
+	}
+	return elements
+}
@@ -0,0 +1,71 @@
+package jsonpath
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"strings"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+)
+
+// Fingerprint parses expr and returns its shape for telemetry: the canonical form of the query with
+// every string and number literal in its filters, and every regular expression, replaced by a hash,
+// so that queries differing only in the values they look for can be counted together without
+// storing those values. Member names, indices and slices are kept, as they describe the documents
+// rather than the people querying them; true, false and null are kept too, as hashing them hides
+// nothing. Strings hash to eight hex digits and numbers to integers, so the fingerprint still parses.
+// With the key "secret":
+//
+//	$.users[?@.email == 'ada@example.com' && @.age > 30]
+//	$.users[?@.email == '0b647851' && @.age > 791828107]
+//
+// The hashes are HMAC-SHA256 keyed with key. Literals such as email addresses can be recovered
+// from unkeyed hashes by hashing guesses, so key should be a secret kept out of the telemetry, and
+// the same key must be used for fingerprints that are to be compared.
+func Fingerprint(expr string, key []byte, opts ...config.Option) (string, error) {
+	p, err := NewPath(expr, opts...)
+	if err != nil {
+		return "", err
+	}
+	f := &fingerprinter{mac: hmac.New(sha256.New, key)}
+	w := &astWalker{literal: f.literal, pattern: f.pattern}
+	w.query(&p.ast)
+	return p.String(), nil
+}
+
+// fingerprinter replaces the literals of a freshly parsed AST with their hashes
+type fingerprinter struct {
+	mac hash.Hash
+}
+
+// hash returns the first four bytes of the keyed hash of a literal of the given type
+func (f *fingerprinter) hash(kind string, value string) []byte {
+	f.mac.Reset()
+	_, _ = f.mac.Write([]byte(kind + ":" + value))
+	return f.mac.Sum(nil)[:4]
+}
+
+// literal replaces a string with the hex digits of its hash and a number with its hash as an integer
+func (f *fingerprinter) literal(l *literal) {
+	switch {
+	case l.string != nil:
+		hashed := hex.EncodeToString(f.hash("string", *l.string))
+		l.string = &hashed
+	case l.integer != nil || l.float64 != nil:
+		hashed := int(binary.BigEndian.Uint32(f.hash("number", l.ToString())))
+		l.integer, l.float64 = &hashed, nil
+	}
+}
+
+// pattern replaces a regular expression with its hash, keeping its flags
+func (f *fingerprinter) pattern(p *regexPattern) {
+	if strings.HasPrefix(p.source, "/") {
+		end := strings.LastIndexByte(p.source, '/')
+		p.source = "/" + hex.EncodeToString(f.hash("regex", p.source[:end+1])) + p.source[end:]
+		return
+	}
+	p.source = "'" + hex.EncodeToString(f.hash("regex", p.source)) + "'"
+}
@@ -0,0 +1,46 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	requirePlus(t)
+	key := []byte("secret")
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{`$.users[?@.email == 'ada@example.com' && @.age > 30]`, `$.users[?@.email == '0b647851' && @.age > 791828107]`},
+		{`$.users[?(@.email=="ada@example.com")]`, `$.users[?(@.email == '0b647851')]`},
+		{`$.a[?@.x =~ /^ab/i]`, `$.a[?@.x =~ /aae8644c/i]`},
+		{`$.paths[/secretkey/]`, `$.paths[/462eb1ff/]`},
+		{`$.a[?@.x =~ /^ab/i].b[/^ab/i, 'c']`, `$.a[?@.x =~ /aae8644c/i].b[/aae8644c/i, 'c']`},
+		{`$.a[?@.b[?@.c == 'z']] | $.b[?@.d == true]`, `$.a[?@.b[?@.c == '9555ef00']] | $.b[?@.d == true]`},
+		{`$['x-logo'][0:2].*`, `$['x-logo'][0:2].*`},
+		{`$.a[?@sibling('k') == null]`, `$.a[?@sibling('k') == null]`},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			actual, err := Fingerprint(test.expr, key)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+			_, err = NewPath(actual)
+			assert.NoError(t, err)
+		})
+	}
+
+	// the same literal hashes the same wherever it appears, and differently under another key
+	fingerprint, err := Fingerprint(`$.a[?match(@.x, 'ada@example.com') || @.y in ['ada@example.com', 2.5]]`, key)
+	require.NoError(t, err)
+	assert.Equal(t, `$.a[?match(@.x, '0b647851') || @.y in ['0b647851', 1619727269]]`, fingerprint)
+	other, err := Fingerprint(`$.users[?@.email == 'ada@example.com']`, []byte("other"))
+	require.NoError(t, err)
+	assert.NotContains(t, other, "0b647851")
+
+	_, err = Fingerprint(`$[`, key)
+	assert.Error(t, err)
+}
@@ -0,0 +1,139 @@
+package jsonpath
+
+import (
+	"reflect"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// mapEntryOverhead approximates the bytes a map spends on each entry beyond its key and value
+const mapEntryOverhead = 16
+
+var yamlNodeType = reflect.TypeOf(yaml.Node{})
+
+// Size returns the approximate number of bytes held by the compiled path, including its tokens and
+// syntax tree. Memory shared with other paths, such as compiled regular expressions from the
+// standard library's cache, is counted in full.
+func (p *JSONPath) Size() int {
+	return approximateSize(reflect.ValueOf(p), make(map[uintptr]bool))
+}
+
+// CacheStats describes the memory held by a QueryCache.
+type CacheStats struct {
+	// Entries is the number of cached query prefixes.
+	Entries int
+	// Nodes is the number of references to document nodes held by the entries.
+	Nodes int
+	// Bytes is the approximate number of bytes held by the cache, not counting the document.
+	Bytes int
+}
+
+// Stats reports the memory held by the cache, so callers keeping caches for many documents can decide
+// which to Reset.
+func (c *QueryCache) Stats() CacheStats {
+	stats := CacheStats{Entries: len(c.entries)}
+	for _, entry := range c.entries {
+		stats.Nodes += len(entry.results) + len(entry.inputs)
+	}
+	stats.Bytes = approximateSize(reflect.ValueOf(c), make(map[uintptr]bool))
+	return stats
+}
+
+// Size returns the approximate number of bytes held by the cache, not counting the document.
+func (c *QueryCache) Size() int {
+	return c.Stats().Bytes
+}
+
+// EngineStats describes the memory held by an Engine.
+type EngineStats struct {
+	// Paths is the number of compiled expressions in the cache.
+	Paths int
+	// Bytes is the approximate number of bytes held by the compiled expressions.
+	Bytes int
+}
+
+// Stats reports the memory held by the engine's cache of compiled expressions, for sizing it with
+// WithEngineCacheSize.
+func (e *Engine) Stats() EngineStats {
+	state := e.current.Load()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	seen := make(map[uintptr]bool)
+	stats := EngineStats{Paths: len(state.paths)}
+	for expr, p := range state.paths {
+		stats.Bytes += len(expr) + mapEntryOverhead + approximateSize(reflect.ValueOf(p), seen)
+	}
+	return stats
+}
+
+// approximateSize returns the bytes held by v and everything it references, counting memory reached
+// through more than one pointer once. Document nodes are not counted, only the references to them.
+// Functions and channels are counted as references.
+func approximateSize(v reflect.Value, seen map[uintptr]bool) int {
+	return int(v.Type().Size()) + referencedSize(v, seen)
+}
+
+// referencedSize returns the bytes held outside of v itself by what v references
+func referencedSize(v reflect.Value, seen map[uintptr]bool) int {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || v.Type().Elem() == yamlNodeType || seen[v.Pointer()] {
+			return 0
+		}
+		seen[v.Pointer()] = true
+		return approximateSize(v.Elem(), seen)
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		elem := v.Elem()
+		if elem.Kind() == reflect.Pointer {
+			return referencedSize(elem, seen)
+		}
+		return approximateSize(elem, seen)
+	case reflect.String:
+		if v.Len() == 0 {
+			return 0
+		}
+		// strings sharing memory, such as substrings of an expression, are counted more than once
+		return v.Len()
+	case reflect.Slice:
+		if v.IsNil() || seen[v.Pointer()] {
+			return 0
+		}
+		seen[v.Pointer()] = true
+		size := v.Cap() * int(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			size += referencedSize(v.Index(i), seen)
+		}
+		return size
+	case reflect.Map:
+		if v.IsNil() || seen[v.Pointer()] {
+			return 0
+		}
+		seen[v.Pointer()] = true
+		entry := int(v.Type().Key().Size()+v.Type().Elem().Size()) + mapEntryOverhead
+		size := v.Len() * entry
+		iter := v.MapRange()
+		for iter.Next() {
+			size += referencedSize(iter.Key(), seen) + referencedSize(iter.Value(), seen)
+		}
+		return size
+	case reflect.Array:
+		size := 0
+		for i := 0; i < v.Len(); i++ {
+			size += referencedSize(v.Index(i), seen)
+		}
+		return size
+	case reflect.Struct:
+		if v.Type() == yamlNodeType {
+			return 0
+		}
+		size := 0
+		for i := 0; i < v.NumField(); i++ {
+			size += referencedSize(v.Field(i), seen)
+		}
+		return size
+	}
+	return 0
+}
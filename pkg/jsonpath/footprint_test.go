@@ -0,0 +1,60 @@
+package jsonpath
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestJSONPath_Size(t *testing.T) {
+	short := MustNewPath(`$.a`)
+	long := MustNewPath(`$.paths.*.*[?@.operationId == 'createUser' && length(@.tags) > 1].responses['200', '201']`)
+	assert.Greater(t, short.Size(), 0)
+	assert.Greater(t, long.Size(), short.Size())
+
+	// the size does not depend on the documents the path has been run against
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(cacheDoc), &node))
+	before := long.Size()
+	long.Query(&node)
+	assert.Equal(t, before, long.Size())
+}
+
+func TestQueryCache_Stats(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(cacheDoc), &node))
+	cache := NewQueryCache(&node)
+	empty := cache.Stats()
+	assert.Equal(t, 0, empty.Entries)
+	assert.Equal(t, 0, empty.Nodes)
+
+	cache.Query(MustNewPath(`$.paths.*.*.operationId`))
+	stats := cache.Stats()
+	assert.Equal(t, 4, stats.Entries)
+	// $ -> paths -> 2 paths -> 3 operations -> 3 ids, each result also an input of the next prefix
+	assert.Equal(t, 1+1+1+2+2+3+3+3, stats.Nodes)
+	assert.Greater(t, stats.Bytes, empty.Bytes)
+	assert.Equal(t, stats.Bytes, cache.Size())
+
+	cache.Reset()
+	assert.Equal(t, empty, cache.Stats())
+}
+
+func TestEngine_Stats(t *testing.T) {
+	engine := NewEngine()
+	assert.Equal(t, EngineStats{}, engine.Stats())
+
+	for i := 0; i < 10; i++ {
+		_, err := engine.Compile(fmt.Sprintf(`$.items[%d].name`, i))
+		require.NoError(t, err)
+	}
+	stats := engine.Stats()
+	assert.Equal(t, 10, stats.Paths)
+
+	one, err := engine.Compile(`$.items[0].name`)
+	require.NoError(t, err)
+	assert.Greater(t, stats.Bytes, 9*one.Size())
+}
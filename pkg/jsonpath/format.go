@@ -0,0 +1,261 @@
+package jsonpath
+
+import (
+	"strings"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/pb33f/jsonpath/pkg/jsonpath/token"
+)
+
+// FormatWidth is the line width Format reflows filter expressions to fit.
+const FormatWidth = 80
+
+// Format parses expr and prints it in canonical form: single spaces around operators and after
+// commas, and single-quoted, minimally escaped strings and member names. Filters that do not fit in
+// FormatWidth columns are broken at their || and && operators, one operand per line, with the
+// contents of each broken parenthesis indented by two spaces. The result parses to the same query.
+func Format(expr string, opts ...config.Option) (string, error) {
+	p, err := NewPath(expr, opts...)
+	if err != nil {
+		return "", err
+	}
+	f := &formatter{}
+	for i, query := range p.ast.queries() {
+		if i > 0 {
+			f.write(" | ")
+		}
+		f.write("$")
+		for _, seg := range query.segments {
+			f.segment(seg)
+		}
+	}
+	return f.String(), nil
+}
+
+// formatter prints an AST, tracking the current column so it knows when to reflow
+type formatter struct {
+	strings.Builder
+	column int
+}
+
+func (f *formatter) write(s string) {
+	f.WriteString(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		f.column = len(s) - i - 1
+	} else {
+		f.column += len(s)
+	}
+}
+
+func (f *formatter) newline(indent int) {
+	f.write("\n" + strings.Repeat("  ", indent))
+}
+
+func (f *formatter) segment(s *segment) {
+	switch s.kind {
+	case segmentKindChild:
+		if s.child.kind != segmentLongHand {
+			f.write("." + s.child.ToString())
+			return
+		}
+		f.selectors(s.child)
+	case segmentKindDescendant:
+		f.write("..")
+		if s.descendant.kind != segmentLongHand {
+			f.write(s.descendant.ToString())
+			return
+		}
+		f.selectors(s.descendant)
+	default:
+		f.write(s.ToString())
+	}
+}
+
+func (f *formatter) selectors(s *innerSegment) {
+	f.write("[")
+	for i, sel := range s.selectors {
+		if i > 0 {
+			f.write(", ")
+		}
+		if sel.kind != selectorSubKindFilter {
+			f.write(sel.ToString())
+			continue
+		}
+		f.write("?")
+		expr := sel.filter.expression
+		if f.fits(expr.ToString()) {
+			f.write(expr.ToString())
+		} else if paren := soleParenExpr(expr); paren != nil {
+			f.paren(paren, 0)
+		} else {
+			f.newline(1)
+			f.logicalOr(expr, 1)
+			f.newline(0)
+		}
+	}
+	f.write("]")
+}
+
+// fits reports whether s can be written on the current line, leaving room for a closing "]"
+func (f *formatter) fits(s string) bool {
+	return f.column+len(s)+1 <= FormatWidth
+}
+
+// logicalOr writes the operands of e one per line. The current line is assumed to be indented.
+func (f *formatter) logicalOr(e *logicalOrExpr, indent int) {
+	for i, and := range e.expressions {
+		if i > 0 {
+			f.write(" ||")
+			f.newline(indent)
+		}
+		f.logicalAnd(and, indent)
+	}
+	if e.conditional != nil {
+		f.write(" ?")
+		f.newline(indent + 1)
+		f.logicalOr(e.conditional.then, indent+1)
+		f.write(" :")
+		f.newline(indent + 1)
+		f.logicalOr(e.conditional.otherwise, indent+1)
+	}
+}
+
+func (f *formatter) logicalAnd(e *logicalAndExpr, indent int) {
+	if f.fits(e.ToString()) {
+		f.write(e.ToString())
+		return
+	}
+	for i, basic := range e.expressions {
+		if i > 0 {
+			f.write(" &&")
+			f.newline(indent + 1)
+		}
+		if basic.parenExpr != nil && !f.fits(basic.ToString()) {
+			f.paren(basic.parenExpr, indent+1)
+			continue
+		}
+		f.write(basic.ToString())
+	}
+}
+
+func (f *formatter) paren(e *parenExpr, indent int) {
+	if f.fits(e.ToString()) {
+		f.write(e.ToString())
+		return
+	}
+	if e.not {
+		f.write("!")
+	}
+	f.write("(")
+	f.newline(indent + 1)
+	f.logicalOr(e.expr, indent+1)
+	f.newline(indent)
+	f.write(")")
+}
+
+// soleParenExpr returns the parenthesized expression if it makes up the whole of e, as in ?(...)
+func soleParenExpr(e *logicalOrExpr) *parenExpr {
+	if e.conditional == nil && len(e.expressions) == 1 && len(e.expressions[0].expressions) == 1 {
+		return e.expressions[0].expressions[0].parenExpr
+	}
+	return nil
+}
+
+// Minify parses expr and prints it as compactly as possible: insignificant blank space is removed,
+// and bracketed single member names and wildcards are shortened to dot notation where the name is
+// a valid member-name-shorthand, e.g. $['paths'][*] becomes $.paths.*. Only segments are shortened,
+// so array literals such as ['a'] in a filter are kept. String and regular expression literals, as in
+// =~ /a b/ or $.paths[/^x-/], are copied as they are. The result parses to the same query.
+func Minify(expr string, opts ...config.Option) (string, error) {
+	p, err := NewPath(expr, opts...)
+	if err != nil {
+		return "", err
+	}
+	w := &astWalker{segment: func(seg *segment) {
+		shortenSegment(seg.child)
+		shortenSegment(seg.descendant)
+	}}
+	w.query(&p.ast)
+	canonical := p.String()
+
+	// the end of each string and regular expression literal, by where it starts
+	literals := make(map[int]int)
+	for _, tok := range token.NewTokenizer(canonical, opts...).Tokenize() {
+		switch tok.Token {
+		case token.STRING_LITERAL:
+			literals[tok.Column] = stringLiteralEnd(canonical, tok.Column) + 1
+		case token.REGEX:
+			literals[tok.Column] = tok.Column + tok.Len
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(canonical); i++ {
+		c := canonical[i]
+		if end, ok := literals[i]; ok {
+			b.WriteString(canonical[i:end])
+			i = end - 1
+			continue
+		}
+		if c == ' ' {
+			// keep words apart, e.g. operators spelled with letters
+			out := b.String()
+			if i+1 < len(canonical) && len(out) > 0 && isWordByte(out[len(out)-1]) && isWordByte(canonical[i+1]) {
+				b.WriteByte(' ')
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), nil
+}
+
+// shortenSegment rewrites a bracketed segment holding a single wildcard, or a single member name that
+// can be written as a member-name-shorthand, in dot notation
+func shortenSegment(s *innerSegment) {
+	if s == nil || s.kind != segmentLongHand || len(s.selectors) != 1 {
+		return
+	}
+	switch sel := s.selectors[0]; {
+	case sel.kind == selectorSubKindWildcard:
+		s.kind, s.selectors = segmentDotWildcard, nil
+	case sel.kind == selectorSubKindName && isShorthandName(sel.name):
+		s.kind, s.dotName, s.selectors = segmentDotMemberName, sel.name, nil
+	}
+}
+
+// stringLiteralEnd returns the index of the quote closing the single-quoted string starting at start.
+func stringLiteralEnd(s string, start int) int {
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '\'':
+			return i
+		}
+	}
+	return len(s) - 1
+}
+
+// isShorthandName reports whether name can be written as a member-name-shorthand, as in $.name
+func isShorthandName(name string) bool {
+	if name == "" {
+		return false
+	}
+	switch name {
+	case "true", "false", "null":
+		// the tokenizer reads these as literals after a dot
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !(c == '_' || c >= 0x80 || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
@@ -0,0 +1,164 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "spacing and quoting",
+			input:    `$["paths"][ *, 0 ,1:3][?@.a=="it's"&&@.b!=1]`,
+			expected: `$['paths'][*, 0, 1:3][?@.a == 'it\'s' && @.b != 1]`,
+		},
+		{
+			name:     "short filters stay on one line",
+			input:    `$.paths.*[?(@.deprecated==true)]`,
+			expected: `$.paths.*[?(@.deprecated == true)]`,
+		},
+		{
+			name:  "long parenthesized filter",
+			input: `$.paths.*.*[?(@.deprecated == true || @.summary == 'an operation summary' || @['x-internal'] == true && @.operationId)]`,
+			expected: `$.paths.*.*[?(
+  @.deprecated == true ||
+  @.summary == 'an operation summary' ||
+  @['x-internal'] == true && @.operationId
+)]`,
+		},
+		{
+			name:  "long filter without parentheses and nested groups",
+			input: `$..parameters[?@.in == 'query' && (@.name == 'a-very-long-parameter-name' || @.name == 'another-long-parameter-name') && @.required]`,
+			expected: `$..parameters[?
+  @.in == 'query' &&
+    (
+      @.name == 'a-very-long-parameter-name' ||
+      @.name == 'another-long-parameter-name'
+    ) &&
+    @.required
+]`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			formatted, err := Format(test.input)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, formatted)
+
+			// formatting is stable and preserves the query
+			again, err := Format(formatted)
+			require.NoError(t, err)
+			assert.Equal(t, formatted, again)
+			original, err := NewPath(test.input)
+			require.NoError(t, err)
+			reparsed, err := NewPath(formatted)
+			require.NoError(t, err)
+			assert.Equal(t, original.String(), reparsed.String())
+		})
+	}
+
+	_, err := Format(`$.paths[`)
+	assert.Error(t, err)
+}
+
+func TestMinify(t *testing.T) {
+	requirePlus(t)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: `$['paths'][*]['get']`, expected: `$.paths.*.get`},
+		{input: `$.paths['/users'][ 'get' ]`, expected: `$.paths['/users'].get`},
+		{input: `$..['x-internal']..[*]..['name']`, expected: `$..['x-internal']..*..name`},
+		{input: `$['a', 'b'][0][1:3]['9lives']['_ok']['ünïcode']`, expected: `$['a','b'][0][1:3]['9lives']._ok.ünïcode`},
+		{input: `$[?(@['price'] < 10 && @.tags[0] == 'it\'s')]`, expected: `$[?(@.price<10&&@.tags[0]=='it\'s')]`},
+		{input: `$.store[?@property == 'book' || length(@['a b']) > 2]`, expected: `$.store[?@property=='book'||length(@['a b'])>2]`},
+		{input: `$["it's"]`, expected: `$['it\'s']`},
+		{input: `$['true']['null']`, expected: `$['true']['null']`},
+		// regular expressions are copied as they are
+		{input: `$..[?(@.name =~ /a +b/)]`, expected: `$..[?(@.name=~/a +b/)]`},
+		{input: `$..[?(@.name =~ /x[*]y/)]`, expected: `$..[?(@.name=~/x[*]y/)]`},
+		{input: `$..[?(@.name =~ /it's ['a']/i || @.name == 'x y')]`, expected: `$..[?(@.name=~/it's ['a']/i||@.name=='x y')]`},
+		{input: `$.paths[/^a [*]/]`, expected: `$.paths[/^a [*]/]`},
+		{input: `$['paths'][/^\/u/i, 'x y'][*]`, expected: `$.paths[/^\/u/i,'x y'].*`},
+	}
+
+	doc := `{"paths": {"/users": {"get": 1}}, "store": {"book": [{"price": 5, "tags": ["it's"]}]}, "a": {"_ok": 1},
+"names": [{"name": "a  b"}, {"name": "ab"}, {"name": "x*y"}, {"name": "x[*]y"}, {"name": "xzzy"}, {"name": "IT'S 'A'"}, {"name": "x y"}]}`
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			minified, err := Minify(test.input)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, minified)
+
+			// minifying is stable and the result still parses to an equivalent query
+			again, err := Minify(minified)
+			require.NoError(t, err)
+			assert.Equal(t, minified, again)
+			var node yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(doc), &node))
+			original, err := NewPath(test.input)
+			require.NoError(t, err)
+			reparsed, err := NewPath(minified)
+			require.NoError(t, err)
+			assert.Equal(t, original.Query(&node), reparsed.Query(&node))
+		})
+	}
+
+	_, err := Minify(`$.paths[`)
+	assert.Error(t, err)
+}
+
+// TestMinifyReparses checks that the minified form of a query using each operator, function and
+// context variable parses back to the same query
+func TestMinifyReparses(t *testing.T) {
+	requirePlus(t)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: `$.l[?@ in ['a']]`, expected: `$.l[?@in['a']]`},
+		{input: `$[?@.a == 1 && @.b in ['x']]`, expected: `$[?@.a==1&&@.b in['x']]`},
+		{input: `$[?@.a in ['x', 'y'] || @.a in [1] || @.in in @root.allowed]`},
+		{input: `$[?@.a != 'x' || !(@.b < 1) || @.c <= 2 || @.d > 3 || @.e >= 4]`},
+		{input: `$[?@.a =~ /^x y/i && @.b =~ 'x.*' && @.c == -1.5]`},
+		{input: `$[?(@.a + 1) * 2 - @.b / 3 % 4 == 0]`},
+		{input: `$[?@.a ? @.b == true : @.c == null]`},
+		{input: `$[?length(@.a) > 1 && count(@.b.*) == 2 && match(@.c, 'x') && search(@.c, 'y') && value(@.d) == 'z']`},
+		{input: `$[?contains(@.tags, 'x') && starts_with(@.a, 'x') && ends_with(@.a, 'y')]`},
+		{input: `$[?min(@.a.*) < max(@.a.*) && sum(@.a.*) > avg(@.a.*)]`},
+		{input: `$[?lowercase(@.a) == uppercase(@.b) && trim(@.c) == join(split(@.d, ','), ';')]`},
+		{input: `$[?date(@.a) < datetime(@.b) && semver(@.v) >= semver('1.2.0')]`},
+		{input: `$[?decodeBase64(@.a) == 'x' && byteLength(@.a) > 2]`},
+		{input: `$[?isString(@.a) || isNumber(@.a) || isNull(@.a) || isArray(@.a) || isObject(@.a)]`},
+		{input: `$.a[?@property == 'x' && @parentProperty != 'y' && @path != 'z' && @index > 0]`},
+		{input: `$.a[?@parent.b == @root.c && @propertyPath == 'x']`},
+		{input: `$..*[?@.a].b~`},
+		{input: `$.a.b^.c^2^('a', 'b c').d`},
+		{input: `$.a.*@number()`},
+		{input: `$.a | $['b c'].d`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			original, err := NewPath(test.input)
+			require.NoError(t, err)
+			minified, err := Minify(test.input)
+			require.NoError(t, err)
+			if test.expected != "" {
+				assert.Equal(t, test.expected, minified)
+			}
+			reparsed, err := NewPath(minified)
+			require.NoError(t, err, minified)
+			assert.Equal(t, original.String(), reparsed.String())
+		})
+	}
+}
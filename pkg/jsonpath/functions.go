@@ -0,0 +1,119 @@
+package jsonpath
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/pb33f/jsonpath/pkg/jsonpath/token"
+	"go.yaml.in/yaml/v4"
+)
+
+// customFunctionName matches the names custom functions may be registered with
+var customFunctionName = regexp.MustCompile(`^[a-z][A-Za-z0-9_]*$`)
+
+// validateFunctions checks the functions registered with config.WithFunction
+func validateFunctions(functions config.FunctionRegistry) error {
+	for name, fn := range functions {
+		_, builtin := functionTypeMap[name]
+		_, typeSelector := typeSelectorFunctionMap[name]
+		switch {
+		case !customFunctionName.MatchString(name) || name == "true" || name == "false" || name == "null":
+			return fmt.Errorf("invalid function name %q", name)
+		case builtin || typeSelector:
+			return fmt.Errorf("function %q is built in and cannot be replaced", name)
+		case fn.Call == nil:
+			return fmt.Errorf("function %q has no Call", name)
+		case fn.Args < 0:
+			return fmt.Errorf("function %q takes %d arguments", name, fn.Args)
+		}
+	}
+	return nil
+}
+
+// parseCustomFunction parses the arguments of a call to a registered function, after its '('
+func (p *JSONPath) parseCustomFunction(name string, fn config.Function) (*functionExpr, error) {
+	var args []*functionArgument
+	for p.current < len(p.tokens) && p.tokens[p.current].Token != token.PAREN_RIGHT {
+		if len(args) > 0 {
+			if p.tokens[p.current].Token != token.COMMA {
+				return nil, p.parseFailure(&p.tokens[p.current], "expected ','")
+			}
+			p.current++
+		}
+		arg, err := p.parseFunctionArgument(false)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if p.current >= len(p.tokens) {
+		return nil, p.parseFailure(&p.tokens[p.current-1], "expected ')'")
+	}
+	if len(args) != fn.Args {
+		return nil, p.parseFailure(&p.tokens[p.current], fmt.Sprintf("%s function takes %d argument(s), got %d", name, fn.Args, len(args)))
+	}
+	p.current++
+	return &functionExpr{funcType: functionTypeCustom, args: args, name: name, custom: fn}, nil
+}
+
+// callCustom evaluates a call to a registered function on node
+func (e functionExpr) callCustom(idx index, node *yaml.Node, root *yaml.Node) literal {
+	call := config.FunctionCall{Args: make([][]*yaml.Node, len(e.args)), Current: node, Root: root}
+	for i, arg := range e.args {
+		call.Args[i] = arg.nodelist(idx, node, root)
+	}
+
+	switch result := e.custom.Call(call).(type) {
+	case bool:
+		return literal{bool: &result}
+	case string:
+		return literal{string: &result}
+	case int:
+		return literal{integer: &result}
+	case float64:
+		return literal{float64: &result}
+	case *yaml.Node:
+		if result != nil {
+			return nodeToLiteral(result)
+		}
+	}
+	return literal{}
+}
+
+// nodelist evaluates the argument as the nodelist passed to a custom function: the nodes of a query,
+// or a scalar node holding any other value
+func (a functionArgument) nodelist(idx index, node *yaml.Node, root *yaml.Node) []*yaml.Node {
+	if a.filterQuery != nil {
+		return a.filterQuery.Query(idx, node, root)
+	}
+	resolved := a.Eval(idx, node, root)
+	if resolved.literal == nil {
+		return nil
+	}
+	lit := *resolved.literal
+	var value *yaml.Node
+	switch {
+	case lit.node != nil:
+		value = lit.node
+	case lit.integer != nil:
+		value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(*lit.integer)}
+	case lit.float64 != nil:
+		value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: strconv.FormatFloat(*lit.float64, 'g', -1, 64)}
+	case lit.string != nil:
+		value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: *lit.string}
+	case lit.time != nil:
+		value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!timestamp", Value: lit.time.Format(time.RFC3339Nano)}
+	case lit.version != nil:
+		value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: lit.version.text}
+	case lit.bool != nil:
+		value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(*lit.bool)}
+	case lit.null != nil:
+		value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	default:
+		return nil
+	}
+	return []*yaml.Node{value}
+}
@@ -0,0 +1,194 @@
+package jsonpath
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const functionsDoc = `
+components:
+  schemas:
+    Pet:
+      example: {name: Rex}
+    Owner:
+      properties:
+        pet: {$ref: '#/components/schemas/Pet'}
+        vet: {$ref: '#/components/schemas/Vet'}
+    Order:
+      properties:
+        total: {type: number}
+        discount: {type: number}
+`
+
+// hasExample reports whether its argument is a mapping with an example
+var hasExample = config.Function{Args: 1, Call: func(call config.FunctionCall) any {
+	for _, node := range call.Args[0] {
+		for i := 0; node.Kind == yaml.MappingNode && i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == "example" {
+				return true
+			}
+		}
+	}
+	return false
+}}
+
+// validRef reports whether a local reference points to a node in the document
+var validRef = config.Function{Args: 1, Call: func(call config.FunctionCall) any {
+	if len(call.Args[0]) != 1 {
+		return nil
+	}
+	ref := call.Args[0][0].Value
+	if !strings.HasPrefix(ref, "#/") {
+		return false
+	}
+	p, err := NewPath("$" + strings.ReplaceAll(strings.TrimPrefix(ref, "#"), "/", "."))
+	return err == nil && len(p.Query(call.Root)) == 1
+}}
+
+// concat concatenates the values of two arguments
+var concat = config.Function{Args: 2, Call: func(call config.FunctionCall) any {
+	var b strings.Builder
+	for _, arg := range call.Args {
+		for _, node := range arg {
+			b.WriteString(node.Value)
+		}
+	}
+	return b.String()
+}}
+
+func TestCustomFunctions(t *testing.T) {
+	requirePlus(t)
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(functionsDoc), &node))
+	opts := []config.Option{
+		config.WithFunction("hasExample", hasExample),
+		config.WithFunction("validRef", validRef),
+		config.WithFunction("concat", concat),
+		config.WithPropertyNameExtension(),
+	}
+
+	tests := []struct {
+		input    string
+		expected []string
+		str      string
+	}{
+		{input: `$.components.schemas[?hasExample(@)]~`, expected: []string{"Pet"}},
+		{input: `$.components.schemas[?!hasExample(@)]~`, expected: []string{"Owner", "Order"}, str: `$.components.schemas[?!(hasExample(@))]~`},
+		{input: `$..properties[?validRef(@['$ref'])]~`, expected: []string{"pet"}},
+		{input: `$..properties[?!validRef(@['$ref'])]~`, expected: []string{"vet", "total", "discount"}, str: `$..properties[?!(validRef(@['$ref']))]~`},
+		{input: `$..properties[?concat(@property, '-ok') == 'vet-ok']~`, expected: []string{"vet"}},
+		{input: `$..properties[?concat(@.type, length(@.type)) == 'number6']~`, expected: []string{"total", "discount"}},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			path, err := NewPath(test.input, opts...)
+			require.NoError(t, err)
+			if test.str == "" {
+				test.str = test.input
+			}
+			assert.Equal(t, test.str, path.String())
+
+			var values []string
+			for _, result := range path.Query(&node) {
+				values = append(values, result.Value)
+			}
+			assert.Equal(t, test.expected, values)
+		})
+	}
+
+	// custom functions are extensions RFC 9535 allows, so strict mode accepts them
+	_, err := NewPath(`$[?hasExample(@)]`, config.WithStrictRFC9535(), config.WithFunction("hasExample", hasExample))
+	assert.NoError(t, err)
+}
+
+func TestCustomFunctionErrors(t *testing.T) {
+	opts := []config.Option{config.WithFunction("hasExample", hasExample), config.WithFunction("concat", concat)}
+	for input, msg := range map[string]string{
+		`$[?hasExample()]`:          "hasExample function takes 1 argument(s), got 0",
+		`$[?hasExample(@, @)]`:      "hasExample function takes 1 argument(s), got 2",
+		`$[?concat(@)]`:               "concat function takes 2 argument(s), got 1",
+		`$[?concat(@ 'a')]`:           "expected ','",
+		`$[?hasOtherExample(@)]`:    "",
+		`$[?hasExample (@) == 1]`:   "",
+		`$[?hasExample(@) == true]`: "-",
+	} {
+		_, err := NewPath(input, opts...)
+		switch msg {
+		case "-":
+			assert.NoError(t, err, input)
+		case "":
+			assert.Error(t, err, input)
+		default:
+			assert.ErrorContains(t, err, msg, input)
+		}
+	}
+
+	noop := config.Function{Call: func(config.FunctionCall) any { return nil }}
+	for name, msg := range map[string]string{
+		"length":    `function "length" is built in and cannot be replaced`,
+		"isString":  `function "isString" is built in and cannot be replaced`,
+		"Upper":     `invalid function name "Upper"`,
+		"has-value": `invalid function name "has-value"`,
+		"true":      `invalid function name "true"`,
+	} {
+		_, err := NewPath(`$`, config.WithFunction(name, noop))
+		assert.EqualError(t, err, msg, name)
+	}
+	_, err := NewPath(`$`, config.WithFunction("broken", config.Function{Args: 1}))
+	assert.EqualError(t, err, `function "broken" has no Call`)
+}
+
+func TestFunctionsStrictMode(t *testing.T) {
+	standard := []string{
+		`$[?length(@.a) > 1]`,
+		`$[?count(@.*) == 1]`,
+		`$[?match(@.a, 'x')]`,
+		`$[?search(@.a, 'x')]`,
+		`$[?value(@.a) == 1]`,
+	}
+	for _, expr := range standard {
+		_, err := NewPath(expr, config.WithStrictRFC9535())
+		assert.NoError(t, err, expr)
+	}
+
+	extensions := []string{
+		`$[?contains(@.a, 'x')]`,
+		`$[?starts_with(@.a, 'x')]`,
+		`$[?ends_with(@.a, 'x')]`,
+		`$[?min(@.a) > 1]`,
+		`$[?max(@.a) > 1]`,
+		`$[?sum(@.a) > 1]`,
+		`$[?avg(@.a) > 1]`,
+		`$[?decodeBase64(@.a) == 'x']`,
+		`$[?byteLength(@.a) > 1]`,
+		`$[?lowercase(@.a) == 'x']`,
+		`$[?uppercase(@.a) == 'x']`,
+		`$[?trim(@.a) == 'x']`,
+		`$[?split(@.a, ',') == 'x']`,
+		`$[?join(@.a, ',') == 'x']`,
+		`$[?date(@.a) > date('2024-01-01')]`,
+		`$[?datetime(@.a) > 1]`,
+		`$[?semver(@.a) > semver('1.0.0')]`,
+		`$[?isString(@.a)]`,
+	}
+	for _, expr := range extensions {
+		_, err := NewPath(expr, config.WithStrictRFC9535())
+		assert.Error(t, err, expr)
+		if config.PlusAvailable {
+			_, err = NewPath(expr)
+			assert.NoError(t, err, expr)
+		}
+	}
+
+	// only the standard functions are suggested in strict mode
+	var labels []string
+	for _, s := range Suggest(`$[?c`, nil, config.WithStrictRFC9535()) {
+		labels = append(labels, s.Label)
+	}
+	assert.Equal(t, []string{"count"}, labels)
+}
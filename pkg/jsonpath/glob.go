@@ -0,0 +1,54 @@
+package jsonpath
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// isGlob returns true if name holds a * or ?, escaped or not, making it a glob pattern when
+// config.WithGlobNames is set
+func isGlob(name string) bool {
+	return strings.ContainsAny(name, "*?")
+}
+
+// matchGlob reports whether name matches pattern, where * matches any run of characters, ? matches a
+// single character and a backslash makes the character after it literal. Unlike path.Match, * also
+// matches /, as member names such as /users/{id} are not file paths.
+func matchGlob(pattern, name string) bool {
+	// on a mismatch, retry the last * with one more character of name
+	star, retry := -1, 0
+	p, n := 0, 0
+	for n < len(name) {
+		if p < len(pattern) {
+			switch c := pattern[p]; c {
+			case '*':
+				star, retry = p, n
+				p++
+				continue
+			case '?':
+				_, size := utf8.DecodeRuneInString(name[n:])
+				p, n = p+1, n+size
+				continue
+			default:
+				if c == '\\' && p+1 < len(pattern) {
+					p++
+				}
+				_, size := utf8.DecodeRuneInString(pattern[p:])
+				if strings.HasPrefix(name[n:], pattern[p:p+size]) {
+					p, n = p+size, n+size
+					continue
+				}
+			}
+		}
+		if star < 0 {
+			return false
+		}
+		_, size := utf8.DecodeRuneInString(name[retry:])
+		retry += size
+		p, n = star+1, retry
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
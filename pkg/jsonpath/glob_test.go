@@ -0,0 +1,107 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{"/users*", "/users", true},
+		{"/users*", "/users/{id}/pets", true},
+		{"/users*", "/pets", false},
+		{"*Error", "NotFoundError", true},
+		{"*Error", "ErrorCode", false},
+		{"*", "", true},
+		{"a*b*c", "aXbYbZc", true},
+		{"a*b*c", "aXbYbZ", false},
+		{"?et", "pet", true},
+		{"?t", "ét", true},
+		{"?et", "et", false},
+		{`a\*`, "a*", true},
+		{`a\*`, "ab", false},
+		{`\?`, "?", true},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, matchGlob(test.pattern, test.name))
+		})
+	}
+}
+
+func TestGlobNames(t *testing.T) {
+	requirePlus(t)
+	yamlData := `
+paths:
+  /users: {get: {}}
+  /users/{id}: {get: {}, delete: {}}
+  /pets: {get: {}}
+components:
+  schemas:
+    NotFoundError: {type: object}
+    User: {type: object}
+    ServerError: {type: string}
+    'a*': {type: number}
+`
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(yamlData), &root))
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{`$.paths['/users*']`, []string{`$['paths']['/users']`, `$['paths']['/users/{id}']`}},
+		{`$.paths['/users*'].*`, []string{`$['paths']['/users']['get']`, `$['paths']['/users/{id}']['get']`, `$['paths']['/users/{id}']['delete']`}},
+		{`$.components.schemas[*Error]`, []string{`$['components']['schemas']['NotFoundError']`, `$['components']['schemas']['ServerError']`}},
+		{`$.components.schemas[Serv*]`, []string{`$['components']['schemas']['ServerError']`}},
+		{`$.components.schemas[Se*Error]`, []string{`$['components']['schemas']['ServerError']`}},
+		{`$.components.schemas[*F*E*]`, []string{`$['components']['schemas']['NotFoundError']`}},
+		{`$.components.schemas[Us*, *Error]`, []string{`$['components']['schemas']['User']`, `$['components']['schemas']['NotFoundError']`, `$['components']['schemas']['ServerError']`}},
+		{`$.components.schemas[User,*Error]`, []string{`$['components']['schemas']['User']`, `$['components']['schemas']['NotFoundError']`, `$['components']['schemas']['ServerError']`}},
+		{`$.components.schemas['?ser']`, []string{`$['components']['schemas']['User']`}},
+		{`$.components.schemas['a\\*']`, []string{`$['components']['schemas']['a*']`}},
+		{`$..[*Error][?@ == 'string']`, []string{`$['components']['schemas']['ServerError']['type']`}},
+		{`$.paths[?@path == "$['paths']['/pets']"]['g*']`, []string{`$['paths']['/pets']['get']`}},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			path, err := NewPath(test.query, config.WithGlobNames())
+			require.NoError(t, err)
+			var paths []string
+			for _, result := range path.QueryResults(&root) {
+				paths = append(paths, result.Path)
+			}
+			assert.Equal(t, test.expected, paths)
+		})
+	}
+
+	// without the option, * is part of the name
+	path, err := NewPath(`$.components.schemas['a*']`)
+	require.NoError(t, err)
+	assert.Len(t, path.Query(&root), 1)
+
+	// unquoted patterns need JSONPath Plus mode, and singular queries cannot hold patterns
+	_, err = NewPath(`$.components.schemas[*Error]`, config.WithGlobNames(), config.WithStrictRFC9535())
+	assert.Error(t, err)
+	_, err = NewPath(`$.paths[?@ == $.paths['/pets*']]`, config.WithGlobNames())
+	assert.Error(t, err)
+
+	path, err = NewPath(`$.components.schemas[*Error]`, config.WithGlobNames())
+	require.NoError(t, err)
+	assert.Equal(t, `$.components.schemas['*Error']`, path.String())
+	path, err = NewPath(`$.components.schemas[Se*Er*]`, config.WithGlobNames())
+	require.NoError(t, err)
+	assert.Equal(t, `$.components.schemas['Se*Er*']`, path.String())
+
+	// unquoted names holding * are not names without the option
+	_, err = NewPath(`$.components.schemas[Serv*]`)
+	assert.Error(t, err)
+}
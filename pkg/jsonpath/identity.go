@@ -0,0 +1,101 @@
+package jsonpath
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// NodeID identifies a node by its source position, kind and a hash of its content. Unlike the node
+// pointer it survives deep copies, so results computed against one copy of a document can be found
+// again in another, e.g. after the document has been transformed. NodeID is comparable and can be
+// used as a map key.
+type NodeID struct {
+	Line   int
+	Column int
+	Kind   yaml.Kind
+	// Hash covers the tag and value of the node and, recursively, its children.
+	Hash uint64
+}
+
+// String returns the identity as "line:column:kind:hash".
+func (id NodeID) String() string {
+	return fmt.Sprintf("%d:%d:%d:%016x", id.Line, id.Column, id.Kind, id.Hash)
+}
+
+// Identify returns the identity of node.
+func Identify(node *yaml.Node) NodeID {
+	h := fnv.New64a()
+	hashNode(h, node)
+	return NodeID{Line: node.Line, Column: node.Column, Kind: node.Kind, Hash: h.Sum64()}
+}
+
+func hashNode(w io.Writer, node *yaml.Node) {
+	if node == nil {
+		_, _ = w.Write([]byte{0})
+		return
+	}
+	_, _ = io.WriteString(w, strconv.Itoa(int(node.Kind)))
+	_, _ = io.WriteString(w, node.Tag)
+	_, _ = w.Write([]byte{0})
+	if node.Kind == yaml.AliasNode {
+		// aliases are identified by the anchor they refer to, which also keeps cycles finite
+		_, _ = io.WriteString(w, "*"+node.Value)
+		_, _ = w.Write([]byte{0})
+		return
+	}
+	_, _ = io.WriteString(w, node.Value)
+	_, _ = w.Write([]byte{0})
+	_, _ = io.WriteString(w, strconv.Itoa(len(node.Content)))
+	for _, child := range node.Content {
+		hashNode(w, child)
+	}
+}
+
+// Reassociate finds the counterparts of nodes in the document rooted at target. A node whose identity
+// appears in target maps to the node with that identity; failing that, a node maps to the node of the
+// same kind at the same source position, which is how a node modified in a copy is found. Nodes
+// without a counterpart map to nil.
+func Reassociate(nodes []*yaml.Node, target *yaml.Node) []*yaml.Node {
+	byID := make(map[NodeID]*yaml.Node)
+	byPosition := make(map[NodeID]*yaml.Node)
+	seen := make(map[*yaml.Node]bool)
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node == nil || seen[node] {
+			return
+		}
+		seen[node] = true
+		id := Identify(node)
+		if _, ok := byID[id]; !ok {
+			byID[id] = node
+		}
+		// nodes without a position (e.g. inserted by a transform) cannot be matched by it
+		if node.Line > 0 {
+			position := NodeID{Line: node.Line, Column: node.Column, Kind: node.Kind}
+			if _, ok := byPosition[position]; !ok {
+				byPosition[position] = node
+			}
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(target)
+
+	result := make([]*yaml.Node, len(nodes))
+	for i, node := range nodes {
+		id := Identify(node)
+		if match, ok := byID[id]; ok {
+			result[i] = match
+			continue
+		}
+		if node.Line > 0 {
+			result[i] = byPosition[NodeID{Line: id.Line, Column: id.Column, Kind: id.Kind}]
+		}
+	}
+	return result
+}
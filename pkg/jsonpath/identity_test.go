@@ -0,0 +1,63 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func deepCopy(node *yaml.Node) *yaml.Node {
+	c := *node
+	c.Content = nil
+	for _, child := range node.Content {
+		c.Content = append(c.Content, deepCopy(child))
+	}
+	return &c
+}
+
+func TestIdentify(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("a: {b: 1}\nc: {b: 1}\n"), &node))
+	a := node.Content[0].Content[1]
+	c := node.Content[0].Content[3]
+
+	assert.Equal(t, Identify(a), Identify(deepCopy(a)))
+	assert.Equal(t, Identify(a).Hash, Identify(c).Hash, "equal content hashes equally")
+	assert.NotEqual(t, Identify(a), Identify(c), "but the position differs")
+	assert.Regexp(t, `^1:4:4:[0-9a-f]{16}$`, Identify(a).String())
+
+	modified := deepCopy(a)
+	modified.Content[1].Value = "2"
+	assert.NotEqual(t, Identify(a).Hash, Identify(modified).Hash)
+}
+
+func TestReassociate(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+paths:
+  /users:
+    get: {summary: List}
+    post: {summary: Create}
+`), &node))
+	path, err := NewPath(`$.paths['/users'].*`)
+	require.NoError(t, err)
+	results := path.Query(&node)
+	require.Len(t, results, 2)
+
+	// transform a copy: modify the post operation and insert a new node
+	copied := deepCopy(&node)
+	users := copied.Content[0].Content[1].Content[1]
+	users.Content[3].Content[1].Value = "Create a user"
+	users.Content = append(users.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: "put"},
+		&yaml.Node{Kind: yaml.MappingNode})
+
+	matched := Reassociate(results, copied)
+	assert.Same(t, users.Content[1], matched[0], "unchanged node found by identity")
+	assert.Same(t, users.Content[3], matched[1], "modified node found by position")
+
+	missing := Reassociate([]*yaml.Node{{Kind: yaml.ScalarNode, Value: "x", Line: 99}}, copied)
+	assert.Nil(t, missing[0])
+}
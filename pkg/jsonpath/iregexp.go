@@ -0,0 +1,312 @@
+package jsonpath
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// maxCachedPatterns bounds the compiled match() and search() patterns kept, as patterns may come from
+// the document
+const maxCachedPatterns = 512
+
+type patternKey struct {
+	pattern  string
+	anchored bool
+	strict   bool
+}
+
+var (
+	patternCacheMu sync.RWMutex
+	patternCache   = make(map[patternKey]*regexp.Regexp)
+)
+
+// compilePattern compiles the pattern of a match() or search() call, returning nil if it is invalid.
+// anchored patterns must match the whole value, as in match().
+//
+// In strict mode patterns are I-Regexps (RFC 9485), and any other pattern is invalid. Otherwise
+// I-Regexps keep their meaning, while other patterns, including those using the ^ and $ anchors,
+// are read as RE2 expressions.
+func compilePattern(pattern string, anchored bool, strict bool) *regexp.Regexp {
+	key := patternKey{pattern: pattern, anchored: anchored, strict: strict}
+	patternCacheMu.RLock()
+	re, ok := patternCache[key]
+	patternCacheMu.RUnlock()
+	if ok {
+		return re
+	}
+
+	expr, literalAnchors, err := translateIRegexp(pattern)
+	if !strict && (err != nil || literalAnchors) {
+		expr, err = pattern, nil
+	}
+	if err == nil {
+		if anchored {
+			expr = "^(?:" + expr + ")$"
+		}
+		re, err = regexp.Compile(expr)
+	}
+	if err != nil {
+		re = nil
+	}
+
+	patternCacheMu.Lock()
+	if len(patternCache) < maxCachedPatterns {
+		patternCache[key] = re
+	}
+	patternCacheMu.Unlock()
+	return re
+}
+
+// translateIRegexp checks that pattern is an I-Regexp (RFC 9485) and rewrites it as an RE2
+// expression with the same meaning. literalAnchors reports whether the pattern contains ^ or $
+// outside of a character class, which are ordinary characters in an I-Regexp.
+func translateIRegexp(pattern string) (expr string, literalAnchors bool, err error) {
+	t := &iregexpTranslator{input: []rune(pattern)}
+	t.regexp()
+	if t.err == nil && t.pos < len(t.input) {
+		t.fail("unexpected %q", t.input[t.pos])
+	}
+	return t.out.String(), t.literalAnchors, t.err
+}
+
+type iregexpTranslator struct {
+	input          []rune
+	pos            int
+	out            strings.Builder
+	literalAnchors bool
+	err            error
+}
+
+func (t *iregexpTranslator) fail(format string, args ...any) {
+	if t.err == nil {
+		t.err = fmt.Errorf("invalid I-Regexp at offset %d: %s", t.pos, fmt.Sprintf(format, args...))
+	}
+}
+
+func (t *iregexpTranslator) peek() rune {
+	if t.pos < len(t.input) {
+		return t.input[t.pos]
+	}
+	return -1
+}
+
+// regexp translates i-regexp = branch *( "|" branch )
+func (t *iregexpTranslator) regexp() {
+	t.branch()
+	for t.err == nil && t.peek() == '|' {
+		t.pos++
+		t.out.WriteByte('|')
+		t.branch()
+	}
+}
+
+// branch translates branch = *piece, where piece = atom [ quantifier ]
+func (t *iregexpTranslator) branch() {
+	for t.err == nil {
+		switch t.peek() {
+		case -1, '|', ')':
+			return
+		}
+		t.atom()
+		t.quantifier()
+	}
+}
+
+func (t *iregexpTranslator) atom() {
+	switch ch := t.peek(); ch {
+	case '(':
+		t.pos++
+		t.out.WriteString("(?:")
+		t.regexp()
+		if t.peek() != ')' {
+			t.fail("missing )")
+			return
+		}
+		t.pos++
+		t.out.WriteByte(')')
+	case '.':
+		// unlike in RE2, . does not match a carriage return
+		t.pos++
+		t.out.WriteString(`[^\n\r]`)
+	case '[':
+		t.charClassExpr()
+	case '\\':
+		t.escape()
+	case '*', '+', '?', '{', '}', ']', ')':
+		t.fail("unexpected %q", ch)
+	default:
+		if ch == '^' || ch == '$' {
+			t.literalAnchors = true
+		}
+		t.pos++
+		t.out.WriteString(regexp.QuoteMeta(string(ch)))
+	}
+}
+
+// quantifier translates ( "*" / "+" / "?" ) / "{" QuantExact [ "," [ QuantExact ] ] "}"
+func (t *iregexpTranslator) quantifier() {
+	switch t.peek() {
+	case '*', '+', '?':
+		t.out.WriteRune(t.input[t.pos])
+		t.pos++
+	case '{':
+		t.pos++
+		t.out.WriteByte('{')
+		if !t.digits() {
+			t.fail("expected a number in a quantifier")
+			return
+		}
+		if t.peek() == ',' {
+			t.pos++
+			t.out.WriteByte(',')
+			t.digits()
+		}
+		if t.peek() != '}' {
+			t.fail("missing } in a quantifier")
+			return
+		}
+		t.pos++
+		t.out.WriteByte('}')
+	}
+}
+
+func (t *iregexpTranslator) digits() bool {
+	start := t.pos
+	for ch := t.peek(); ch >= '0' && ch <= '9'; ch = t.peek() {
+		t.out.WriteRune(ch)
+		t.pos++
+	}
+	return t.pos > start
+}
+
+// charClassExpr translates "[" [ "^" ] ( "-" / CCE1 ) *CCE1 [ "-" ] "]"
+func (t *iregexpTranslator) charClassExpr() {
+	t.pos++
+	t.out.WriteByte('[')
+	if t.peek() == '^' {
+		t.pos++
+		t.out.WriteByte('^')
+	}
+	if t.peek() == '-' {
+		t.pos++
+		t.out.WriteString(`\-`)
+	}
+	items := 0
+	for t.err == nil {
+		switch ch := t.peek(); ch {
+		case -1:
+			t.fail("missing ]")
+			return
+		case ']':
+			if items == 0 && !strings.HasSuffix(t.out.String(), `\-`) {
+				t.fail("empty character class")
+				return
+			}
+			t.pos++
+			t.out.WriteByte(']')
+			return
+		case '-':
+			// only allowed as the last character of the class
+			t.pos++
+			if t.peek() != ']' {
+				t.fail("unexpected - in a character class")
+				return
+			}
+			t.out.WriteString(`\-`)
+		default:
+			items++
+			if t.ccChar() && t.peek() == '-' && t.pos+1 < len(t.input) && t.input[t.pos+1] != ']' {
+				t.pos++
+				t.out.WriteByte('-')
+				if !t.ccChar() {
+					t.fail("invalid character class range")
+				}
+			}
+		}
+	}
+}
+
+// ccChar translates a character of a class, or a category escape, returning whether it was a single
+// character that may start a range
+func (t *iregexpTranslator) ccChar() bool {
+	switch ch := t.peek(); ch {
+	case '\\':
+		return t.escape()
+	case '[', ']', '-':
+		t.fail("unexpected %q in a character class", ch)
+		return false
+	default:
+		t.pos++
+		t.out.WriteString(regexp.QuoteMeta(string(ch)))
+		return true
+	}
+}
+
+// escape translates SingleCharEsc, catEsc and complEsc, returning whether it was a single character
+func (t *iregexpTranslator) escape() bool {
+	t.pos++
+	switch ch := t.peek(); ch {
+	case '(', ')', '*', '+', '-', '.', '?', '[', '\\', ']', '^', '{', '|', '}':
+		t.pos++
+		t.out.WriteByte('\\')
+		t.out.WriteRune(ch)
+		return true
+	case 'n', 'r', 't':
+		t.pos++
+		t.out.WriteByte('\\')
+		t.out.WriteRune(ch)
+		return true
+	case 'p', 'P':
+		t.pos++
+		t.category(ch)
+		return false
+	default:
+		t.fail("unsupported escape \\%c", ch)
+		return false
+	}
+}
+
+// iregexpCategories maps each Unicode category an I-Regexp may name to the subcategories allowed
+var iregexpCategories = map[rune]string{
+	'L': "lmotu",
+	'M': "cen",
+	'N': "dlo",
+	'P': "cdefios",
+	'Z': "lps",
+	'S': "ckmo",
+	'C': "cfno",
+}
+
+// category translates "\p{" charProp "}" and "\P{" charProp "}", after the p or P
+func (t *iregexpTranslator) category(escape rune) {
+	if t.peek() != '{' {
+		t.fail("expected { after \\%c", escape)
+		return
+	}
+	end := t.pos + 1
+	for end < len(t.input) && t.input[end] != '}' {
+		end++
+	}
+	if end >= len(t.input) {
+		t.fail("missing } after \\%c", escape)
+		return
+	}
+	name := t.input[t.pos+1 : end]
+	var subcategories string
+	ok := len(name) == 1 || len(name) == 2
+	if ok {
+		subcategories, ok = iregexpCategories[name[0]]
+	}
+	if !ok || len(name) == 2 && !strings.ContainsRune(subcategories, name[1]) {
+		t.fail("unknown category %q", string(name))
+		return
+	}
+	t.pos = end + 1
+	t.out.WriteByte('\\')
+	t.out.WriteRune(escape)
+	t.out.WriteByte('{')
+	t.out.WriteString(string(name))
+	t.out.WriteByte('}')
+}
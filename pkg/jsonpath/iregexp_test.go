@@ -0,0 +1,125 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestTranslateIRegexp(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected string
+		anchors  bool
+	}{
+		{pattern: `a.*`, expected: `a[^\n\r]*`},
+		{pattern: `(ab|c)+d?`, expected: `(?:ab|c)+d?`},
+		{pattern: `x{2}y{1,}z{0,3}`, expected: `x{2}y{1,}z{0,3}`},
+		{pattern: `[a-z0-9_.-]`, expected: `[a-z0-9_\.\-]`},
+		{pattern: `[^-a]`, expected: `[^\-a]`},
+		{pattern: `\p{Lu}\P{N}[\p{L}\-]`, expected: `\p{Lu}\P{N}[\p{L}\-]`},
+		{pattern: `\.\n\t\{`, expected: `\.\n\t\{`},
+		{pattern: `^a$`, expected: `\^a\$`, anchors: true},
+		{pattern: `[$^]`, expected: `[\$\^]`},
+		{pattern: ``, expected: ``},
+	}
+	for _, test := range tests {
+		expr, anchors, err := translateIRegexp(test.pattern)
+		require.NoError(t, err, test.pattern)
+		assert.Equal(t, test.expected, expr, test.pattern)
+		assert.Equal(t, test.anchors, anchors, test.pattern)
+	}
+
+	for _, pattern := range []string{
+		`a**`, `a*?`, `(a`, `a)`, `[a`, `[]`, `[a-]b-c]`, `\d`, `\w+`, `(?i)a`, `\p{Xx}`, `\p{L`, `a{,2}`, `{1}`, `[[]`,
+	} {
+		_, _, err := translateIRegexp(pattern)
+		assert.Error(t, err, pattern)
+	}
+}
+
+func TestQueryMatchAndSearch(t *testing.T) {
+	requirePlus(t)
+	doc := `
+- {name: abc}
+- {name: xa}
+- {name: "a\rb"}
+- {name: "a\u2028b"}
+- {name: "a.b"}
+- {name: "^ab"}
+`
+	tests := []struct {
+		name     string
+		input    string
+		strict   []string
+		standard []string
+	}{
+		{
+			name:     "Match is anchored",
+			input:    `$[?match(@.name, "a.*")].name`,
+			strict:   []string{"abc", "a\u2028b", "a.b"},
+			standard: []string{"abc", "a\u2028b", "a.b"},
+		},
+		{
+			name:     "Search is not anchored",
+			input:    `$[?search(@.name, "b")].name`,
+			strict:   []string{"abc", "a\rb", "a\u2028b", "a.b", "^ab"},
+			standard: []string{"abc", "a\rb", "a\u2028b", "a.b", "^ab"},
+		},
+		{
+			name:     "Dot matches anything but line feeds and carriage returns",
+			input:    `$[?match(@.name, "a.b")].name`,
+			strict:   []string{"a\u2028b", "a.b"},
+			standard: []string{"a\u2028b", "a.b"},
+		},
+		{
+			name:     "Escaped dot",
+			input:    `$[?search(@.name, "a\\.b")].name`,
+			strict:   []string{"a.b"},
+			standard: []string{"a.b"},
+		},
+		{
+			name:     "Caret is an ordinary character in I-Regexp, an anchor in RE2",
+			input:    `$[?search(@.name, "^a")].name`,
+			strict:   []string{"^ab"},
+			standard: []string{"abc", "a\rb", "a\u2028b", "a.b"},
+		},
+		{
+			name:     "RE2 classes are not I-Regexp",
+			input:    `$[?search(@.name, "\\w\\W")].name`,
+			strict:   nil,
+			standard: []string{"a\rb", "a\u2028b", "a.b"},
+		},
+		{
+			name:     "Invalid patterns match nothing",
+			input:    `$[?search(@.name, "a(")].name`,
+			strict:   nil,
+			standard: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var root yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(doc), &root))
+			for _, mode := range []struct {
+				opts     []config.Option
+				expected []string
+			}{
+				{opts: []config.Option{config.WithStrictRFC9535()}, expected: test.strict},
+				{expected: test.standard},
+			} {
+				path, err := NewPath(test.input, mode.opts...)
+				require.NoError(t, err)
+				var actual []string
+				for _, node := range path.Query(&root) {
+					actual = append(actual, node.Value)
+				}
+				assert.Equal(t, mode.expected, actual, "strict: %v", len(mode.opts) > 0)
+			}
+		})
+	}
+}
@@ -0,0 +1,678 @@
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+)
+
+// TranslationError reports the constructs of an expression that have no equivalent in the
+// language it is being translated to.
+type TranslationError struct {
+	// Constructs describes each untranslatable construct, in the order they appear
+	Constructs []string
+}
+
+func (e *TranslationError) Error() string {
+	return "cannot translate " + strings.Join(e.Constructs, ", ")
+}
+
+// ToJMESPath translates a JSONPath expression into the JMESPath expression selecting the same
+// values, for the part of JSONPath the two languages share: member names, indices, slices,
+// wildcards and filters comparing relative queries, literals and the length, contains,
+// starts_with and ends_with functions. A JMESPath projection, such as paths.*.get, lists the
+// values of the nodes the JSONPath query returns.
+//
+// The translation is best effort where the languages differ in detail: .* becomes an object
+// projection and [*] a list projection, while JSONPath wildcards select the members of both, and
+// JMESPath filters only select array elements. Constructs without an equivalent, such as
+// descendant segments, unions, root queries in filters and existence tests, which JMESPath would
+// read as truthiness tests, are all reported in a *TranslationError.
+func ToJMESPath(expr string, opts ...config.Option) (string, error) {
+	p, err := NewPath(expr, opts...)
+	if err != nil {
+		return "", err
+	}
+	t := &jmesTranslator{}
+	result := t.query(p.ast.segments)
+	if len(p.ast.union) > 0 {
+		t.unsupported("union of queries |")
+	}
+	if len(t.constructs) > 0 {
+		return "", &TranslationError{Constructs: t.constructs}
+	}
+	if result == "" {
+		return "@", nil
+	}
+	return result, nil
+}
+
+// jmesTranslator writes a JSONPath AST as JMESPath, collecting the constructs it cannot translate
+type jmesTranslator struct {
+	constructs []string
+}
+
+func (t *jmesTranslator) unsupported(construct string) {
+	t.constructs = append(t.constructs, construct)
+}
+
+// query translates the segments of a query, which are relative to the current node in JMESPath
+// either way
+func (t *jmesTranslator) query(segments []*segment) string {
+	var b strings.Builder
+	member := func(name string) {
+		if b.Len() > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(jmesIdentifier(name))
+	}
+	for _, seg := range segments {
+		switch {
+		case seg.kind == segmentKindDescendant:
+			t.unsupported("descendant segment " + seg.ToString())
+		case seg.kind != segmentKindChild:
+			t.unsupported("segment " + seg.ToString())
+		case seg.child.kind == segmentDotMemberName:
+			member(seg.child.dotName)
+		case seg.child.kind == segmentDotWildcard:
+			if b.Len() > 0 {
+				b.WriteString(".")
+			}
+			b.WriteString("*")
+		case len(seg.child.selectors) != 1:
+			t.unsupported("union " + seg.ToString())
+		default:
+			sel := seg.child.selectors[0]
+			switch sel.kind {
+			case selectorSubKindName:
+				member(sel.name)
+			case selectorSubKindArrayIndex, selectorSubKindArraySlice:
+				b.WriteString("[" + sel.ToString() + "]")
+			case selectorSubKindWildcard:
+				b.WriteString("[*]")
+			case selectorSubKindFilter:
+				b.WriteString("[?" + t.logical(sel.filter.expression) + "]")
+			default:
+				t.unsupported("selector " + seg.ToString())
+			}
+		}
+	}
+	return b.String()
+}
+
+func (t *jmesTranslator) logical(expr *logicalOrExpr) string {
+	if expr.conditional != nil {
+		// JMESPath has no conditional expressions
+		t.unsupported("conditional " + expr.ToString())
+		return ""
+	}
+	ors := make([]string, len(expr.expressions))
+	for i, and := range expr.expressions {
+		ands := make([]string, len(and.expressions))
+		for j, basic := range and.expressions {
+			ands[j] = t.basic(basic)
+		}
+		ors[i] = strings.Join(ands, " && ")
+	}
+	return strings.Join(ors, " || ")
+}
+
+func (t *jmesTranslator) basic(expr *basicExpr) string {
+	switch {
+	case expr.parenExpr != nil:
+		s := "(" + t.logical(expr.parenExpr.expr) + ")"
+		if expr.parenExpr.not {
+			return "!" + s
+		}
+		return s
+	case expr.comparisonExpr != nil:
+		e := expr.comparisonExpr
+		if e.op > greaterThanEqualTo {
+			t.unsupported("operator " + e.op.ToString())
+			return ""
+		}
+		if e.op != equalTo && e.op != notEqualTo && (isStringOperand(e.left) || isStringOperand(e.right)) {
+			// JMESPath only orders numbers
+			t.unsupported("string ordering " + e.ToString())
+			return ""
+		}
+		return t.comparable(e.left) + " " + e.op.ToString() + " " + t.comparable(e.right)
+	case expr.testExpr != nil:
+		e := expr.testExpr
+		if e.functionExpr == nil || !jmesFunctions[e.functionExpr.funcType] || e.functionExpr.funcType == functionTypeLength {
+			t.unsupported("test " + e.ToString())
+			return ""
+		}
+		s := t.function(e.functionExpr)
+		if e.not {
+			return "!" + s
+		}
+		return s
+	}
+	return ""
+}
+
+// jmesFunctions are the functions that JMESPath has with the same name and meaning
+var jmesFunctions = map[functionType]bool{
+	functionTypeLength:     true,
+	functionTypeContains:   true,
+	functionTypeStartsWith: true,
+	functionTypeEndsWith:   true,
+}
+
+func (t *jmesTranslator) function(e *functionExpr) string {
+	args := make([]string, len(e.args))
+	for i, arg := range e.args {
+		switch {
+		case arg.literal != nil:
+			args[i] = t.literal(arg.literal)
+		case arg.filterQuery != nil && arg.filterQuery.relQuery != nil:
+			args[i] = t.relative(arg.filterQuery.relQuery)
+		default:
+			t.unsupported("argument " + arg.ToString())
+		}
+	}
+	return e.funcType.String() + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (t *jmesTranslator) comparable(c *comparable) string {
+	switch {
+	case c.literal != nil:
+		return t.literal(c.literal)
+	case c.singularQuery != nil && c.singularQuery.relQuery != nil:
+		return t.relative(c.singularQuery.relQuery)
+	case c.functionExpr != nil && c.functionExpr.funcType == functionTypeLength:
+		return t.function(c.functionExpr)
+	}
+	t.unsupported("operand " + c.ToString())
+	return ""
+}
+
+// relative translates a query relative to the node a filter tests
+func (t *jmesTranslator) relative(q *relQuery) string {
+	if s := t.query(q.segments); s != "" {
+		return s
+	}
+	return "@"
+}
+
+func (t *jmesTranslator) literal(l *literal) string {
+	switch {
+	case l.string != nil:
+		if !strings.ContainsAny(*l.string, `'\`) {
+			return "'" + *l.string + "'"
+		}
+		return "`" + strings.ReplaceAll(jsonString(*l.string), "`", "\\`") + "`"
+	case l.node != nil:
+		t.unsupported("literal " + l.ToString())
+		return ""
+	}
+	return "`" + l.ToString() + "`"
+}
+
+// isStringOperand reports whether c is a string literal
+func isStringOperand(c *comparable) bool {
+	return c.literal != nil && c.literal.string != nil
+}
+
+// jmesIdentifier writes name as an unquoted identifier if it can be one, and as a quoted identifier
+// otherwise
+func jmesIdentifier(name string) string {
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !(c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9') {
+			return jsonString(name)
+		}
+	}
+	if name == "" {
+		return `""`
+	}
+	return name
+}
+
+// jsonString quotes s as a JSON string, without escaping HTML characters
+func jsonString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// FromJMESPath translates a JMESPath expression into the JSONPath expression returning the nodes
+// whose values the JMESPath expression selects, for the part of JMESPath the two languages share:
+// identifiers, indices, slices, projections and filters comparing fields, literals and the length,
+// contains, starts_with and ends_with functions.
+//
+// Constructs without an equivalent, such as pipes, flattening, multiselect lists and hashes, most
+// functions and truthiness tests, which JSONPath would read as existence tests, are all reported in
+// a *TranslationError. A syntax error in expr is reported as a plain error.
+func FromJMESPath(expr string) (string, error) {
+	tokens, err := lexJMESPath(expr)
+	if err != nil {
+		return "", err
+	}
+	p := &jmesParser{expr: expr, tokens: tokens}
+	path := p.path()
+	if p.err == nil && !p.peek().is(jmesEOF) {
+		p.fail("unexpected " + p.peek().text)
+	}
+	if p.err != nil {
+		return "", p.err
+	}
+	if len(p.constructs) > 0 {
+		return "", &TranslationError{Constructs: p.constructs}
+	}
+	return "$" + path, nil
+}
+
+const (
+	jmesEOF = iota
+	jmesIdent
+	jmesQuotedIdent
+	jmesRawString
+	jmesLiteral
+	jmesNumber
+	jmesPunct
+)
+
+type jmesToken struct {
+	kind   int
+	text   string // the punctuation or the source of the token
+	value  any    // the name of an identifier, or the value of a string, literal or number
+	offset int
+}
+
+func (t jmesToken) is(kind int) bool {
+	return t.kind == kind
+}
+
+func (t jmesToken) punct(text string) bool {
+	return t.kind == jmesPunct && t.text == text
+}
+
+// jmesPunctuation is tried in order, so longer punctuation comes first
+var jmesPunctuation = []string{"[?", "[]", "==", "!=", "<=", ">=", "&&", "||", "[", "]", ".", "*", "@", "(", ")", ",", ":", "{", "}", "&", "|", "!", "<", ">"}
+
+func lexJMESPath(expr string) ([]jmesToken, error) {
+	var tokens []jmesToken
+	fail := func(offset int, msg string) error {
+		return fmt.Errorf("invalid JMESPath expression at offset %d: %s", offset, msg)
+	}
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+			start := i
+			for i < len(expr) && (isNameByte(expr[i]) && expr[i] < 0x80) {
+				i++
+			}
+			tokens = append(tokens, jmesToken{kind: jmesIdent, text: expr[start:i], value: expr[start:i], offset: start})
+		case c == '-' || '0' <= c && c <= '9':
+			start := i
+			i++
+			for i < len(expr) && '0' <= expr[i] && expr[i] <= '9' {
+				i++
+			}
+			n, err := strconv.Atoi(expr[start:i])
+			if err != nil {
+				return nil, fail(start, "invalid number "+expr[start:i])
+			}
+			tokens = append(tokens, jmesToken{kind: jmesNumber, text: expr[start:i], value: n, offset: start})
+		case c == '"' || c == '\'' || c == '`':
+			start := i
+			var b strings.Builder
+			for i++; i < len(expr) && expr[i] != c; i++ {
+				if expr[i] == '\\' && i+1 < len(expr) {
+					if c == '"' || expr[i+1] == c {
+						// quoted identifiers keep every escape for the JSON decoder
+						if c == '"' {
+							b.WriteByte('\\')
+						}
+						i++
+					}
+				}
+				b.WriteByte(expr[i])
+			}
+			if i == len(expr) {
+				return nil, fail(start, "unterminated "+string(c))
+			}
+			i++
+			token := jmesToken{text: expr[start:i], offset: start}
+			switch c {
+			case '"':
+				var name string
+				if err := json.Unmarshal([]byte(`"`+b.String()+`"`), &name); err != nil {
+					return nil, fail(start, "invalid quoted identifier "+token.text)
+				}
+				token.kind, token.value = jmesQuotedIdent, name
+			case '\'':
+				token.kind, token.value = jmesRawString, b.String()
+			default:
+				var value any
+				decoder := json.NewDecoder(strings.NewReader(b.String()))
+				decoder.UseNumber()
+				if err := decoder.Decode(&value); err != nil {
+					return nil, fail(start, "invalid JSON literal "+token.text)
+				}
+				token.kind, token.value = jmesLiteral, value
+			}
+			tokens = append(tokens, token)
+		default:
+			matched := false
+			for _, punct := range jmesPunctuation {
+				if strings.HasPrefix(expr[i:], punct) {
+					tokens = append(tokens, jmesToken{kind: jmesPunct, text: punct, offset: i})
+					i += len(punct)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fail(i, "unexpected "+string(c))
+			}
+		}
+	}
+	return append(tokens, jmesToken{kind: jmesEOF, text: "end of expression", offset: len(expr)}), nil
+}
+
+// jmesParser translates JMESPath tokens to JSONPath as it parses them, collecting the constructs
+// it cannot translate
+type jmesParser struct {
+	expr       string
+	tokens     []jmesToken
+	pos        int
+	constructs []string
+	err        error
+}
+
+// jmesTerm is a translated filter operand, which is logical if JSONPath can use it as a test. An
+// atom can be negated without parentheses, and str is set for string literals.
+type jmesTerm struct {
+	text    string
+	logical bool
+	atom    bool
+	str     bool
+}
+
+func (p *jmesParser) peek() jmesToken {
+	return p.tokens[p.pos]
+}
+
+func (p *jmesParser) next() jmesToken {
+	token := p.tokens[p.pos]
+	if !token.is(jmesEOF) {
+		p.pos++
+	}
+	return token
+}
+
+func (p *jmesParser) accept(punct string) bool {
+	if p.peek().punct(punct) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *jmesParser) expect(punct string) {
+	if !p.accept(punct) {
+		p.fail("expected " + punct + ", found " + p.peek().text)
+	}
+}
+
+func (p *jmesParser) fail(msg string) {
+	if p.err == nil {
+		p.err = fmt.Errorf("invalid JMESPath expression at offset %d: %s", p.peek().offset, msg)
+	}
+	// stop parsing
+	p.pos = len(p.tokens) - 1
+}
+
+func (p *jmesParser) unsupported(construct string) {
+	p.constructs = append(p.constructs, construct)
+}
+
+// skip consumes a construct that cannot be translated, up to the bracket closing the one opening
+// it, and returns its source
+func (p *jmesParser) skip() string {
+	start := p.peek()
+	depth := 0
+	for {
+		token := p.next()
+		switch {
+		case token.is(jmesEOF):
+			p.fail("unbalanced " + start.text)
+			return start.text
+		case token.punct("[") || token.punct("[?") || token.punct("(") || token.punct("{"):
+			depth++
+		case token.punct("]") || token.punct(")") || token.punct("}"):
+			depth--
+		}
+		if depth == 0 {
+			end := p.tokens[p.pos-1]
+			return p.expr[start.offset : end.offset+len(end.text)]
+		}
+	}
+}
+
+// path translates a chain of sub-expressions, index expressions and projections to the segments
+// of a JSONPath query
+func (p *jmesParser) path() string {
+	var b strings.Builder
+	p.step(&b, true)
+	for p.err == nil {
+		switch token := p.peek(); {
+		case token.punct("."):
+			p.pos++
+			p.step(&b, false)
+		case token.punct("[") || token.punct("[?") || token.punct("[]"):
+			p.bracket(&b)
+		case token.punct("|"):
+			p.pos++
+			p.unsupported("pipe |")
+			p.step(&b, true)
+		default:
+			return b.String()
+		}
+	}
+	return b.String()
+}
+
+// step translates the identifier, wildcard or bracket starting an expression or following a dot
+func (p *jmesParser) step(b *strings.Builder, first bool) {
+	token := p.peek()
+	switch {
+	case token.is(jmesIdent) && p.tokens[p.pos+1].punct("("):
+		p.pos++
+		p.unsupported("function " + token.text + p.skip())
+	case token.is(jmesIdent) || token.is(jmesQuotedIdent):
+		p.pos++
+		name := token.value.(string)
+		if isShorthandName(name) {
+			b.WriteString("." + name)
+		} else {
+			b.WriteString(normalizePathSegment(name))
+		}
+	case token.punct("*"):
+		p.pos++
+		b.WriteString(".*")
+	case token.punct("@") && first:
+		p.pos++
+	case token.punct("{"):
+		p.unsupported("multiselect hash " + p.skip())
+	case token.punct("[") && !first:
+		p.unsupported("multiselect list " + p.skip())
+	case (token.punct("[") || token.punct("[?") || token.punct("[]")) && first:
+		p.bracket(b)
+	case token.punct("&"):
+		p.pos++
+		p.unsupported("expression reference &")
+		p.step(b, true)
+	case token.punct("(") && first:
+		p.unsupported("parenthesised expression " + p.skip())
+	case (token.is(jmesLiteral) || token.is(jmesRawString)) && first:
+		p.pos++
+		p.unsupported("literal " + token.text)
+	default:
+		p.fail("unexpected " + token.text)
+	}
+}
+
+// bracket translates an index, slice, projection, filter or flatten
+func (p *jmesParser) bracket(b *strings.Builder) {
+	switch token := p.peek(); {
+	case token.punct("[]"):
+		p.pos++
+		p.unsupported("flatten []")
+	case token.punct("[?"):
+		p.pos++
+		filter := p.or()
+		if !filter.logical {
+			p.unsupported("truthiness test " + filter.text)
+		}
+		p.expect("]")
+		b.WriteString("[?" + filter.text + "]")
+	case p.tokens[p.pos+1].punct("*") && p.tokens[p.pos+2].punct("]"):
+		p.pos += 3
+		b.WriteString("[*]")
+	case p.tokens[p.pos+1].is(jmesNumber) || p.tokens[p.pos+1].punct(":"):
+		p.pos++
+		var parts []string
+		for {
+			part := ""
+			if p.peek().is(jmesNumber) {
+				part = p.next().text
+			}
+			parts = append(parts, part)
+			if !p.accept(":") || len(parts) == 3 {
+				break
+			}
+		}
+		p.expect("]")
+		b.WriteString("[" + strings.Join(parts, ":") + "]")
+	default:
+		p.unsupported("multiselect list " + p.skip())
+	}
+}
+
+func (p *jmesParser) or() jmesTerm {
+	term := p.and()
+	for p.err == nil && p.accept("||") {
+		right := p.and()
+		term = jmesTerm{text: p.test(term) + " || " + p.test(right), logical: true}
+	}
+	return term
+}
+
+func (p *jmesParser) and() jmesTerm {
+	term := p.not()
+	for p.err == nil && p.accept("&&") {
+		right := p.not()
+		term = jmesTerm{text: p.test(term) + " && " + p.test(right), logical: true}
+	}
+	return term
+}
+
+func (p *jmesParser) not() jmesTerm {
+	if !p.accept("!") {
+		return p.comparison()
+	}
+	term := p.not()
+	text := p.test(term)
+	if !term.atom {
+		// JSONPath negates parenthesised expressions and tests
+		text = "(" + text + ")"
+	}
+	return jmesTerm{text: "!" + text, logical: true, atom: true}
+}
+
+// test returns the text of a term used as a logical operand, reporting a term that JMESPath would
+// test for truthiness
+func (p *jmesParser) test(term jmesTerm) string {
+	if !term.logical {
+		p.unsupported("truthiness test " + term.text)
+	}
+	return term.text
+}
+
+var jmesComparisons = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func (p *jmesParser) comparison() jmesTerm {
+	left := p.operand()
+	for _, op := range jmesComparisons {
+		if p.accept(op) {
+			right := p.operand()
+			text := left.text + " " + op + " " + right.text
+			if op != "==" && op != "!=" && (left.str || right.str) {
+				// JMESPath only orders numbers
+				p.unsupported("string ordering " + text)
+			}
+			return jmesTerm{text: text, logical: true}
+		}
+	}
+	return left
+}
+
+func (p *jmesParser) operand() jmesTerm {
+	token := p.peek()
+	switch {
+	case token.punct("("):
+		p.pos++
+		term := p.or()
+		p.expect(")")
+		return jmesTerm{text: "(" + term.text + ")", logical: term.logical, atom: true}
+	case token.is(jmesRawString):
+		p.pos++
+		return jmesTerm{text: "'" + escapePathSegment(token.value.(string)) + "'", str: true}
+	case token.is(jmesNumber):
+		p.pos++
+		return jmesTerm{text: token.text}
+	case token.is(jmesLiteral):
+		p.pos++
+		switch value := token.value.(type) {
+		case string:
+			return jmesTerm{text: "'" + escapePathSegment(value) + "'", str: true}
+		case json.Number:
+			return jmesTerm{text: value.String()}
+		case bool:
+			return jmesTerm{text: strconv.FormatBool(value)}
+		case nil:
+			return jmesTerm{text: "null"}
+		}
+		p.unsupported("literal " + token.text)
+		return jmesTerm{text: token.text}
+	case token.is(jmesIdent) && p.tokens[p.pos+1].punct("("):
+		return p.function()
+	}
+	return jmesTerm{text: "@" + p.path()}
+}
+
+// jmesLogicalFunctions are the JMESPath functions JSONPath has with the same name and meaning,
+// mapped to whether they return a boolean
+var jmesLogicalFunctions = map[string]bool{"length": false, "contains": true, "starts_with": true, "ends_with": true}
+
+func (p *jmesParser) function() jmesTerm {
+	name := p.next()
+	logical, ok := jmesLogicalFunctions[name.text]
+	if !ok {
+		construct := "function " + name.text + p.skip()
+		p.unsupported(construct)
+		return jmesTerm{text: construct}
+	}
+	p.expect("(")
+	var args []string
+	for p.err == nil && !p.accept(")") {
+		if len(args) > 0 {
+			p.expect(",")
+		}
+		args = append(args, p.operand().text)
+	}
+	return jmesTerm{text: name.text + "(" + strings.Join(args, ", ") + ")", logical: logical, atom: true}
+}
@@ -0,0 +1,125 @@
+package jsonpath
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToJMESPath(t *testing.T) {
+	requirePlus(t)
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{`$`, `@`},
+		{`$.store.book[*].author`, `store.book[*].author`},
+		{`$.paths.*.get`, `paths.*.get`},
+		{`$['x-logo'].url`, `"x-logo".url`},
+		{`$.a[0][-1][1:3][::2]`, `a[0][-1][1:3][::2]`},
+		{`$.books[?@.price > 10 && @.title == 'x' || !(@.a == 1)]`, "books[?price > `10` && title == 'x' || !(a == `1`)]"},
+		{`$.books[?starts_with(@.title, 'S')].title`, `books[?starts_with(title, 'S')].title`},
+		{`$.books[?length(@.tags) >= 2]`, "books[?length(tags) >= `2`]"},
+		{`$.tags[?@ == "it's"]`, "tags[?@ == `\"it's\"`]"},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			actual, err := ToJMESPath(test.path)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestToJMESPathUntranslatable(t *testing.T) {
+	requirePlus(t)
+	tests := []struct {
+		path       string
+		constructs []string
+	}{
+		{`$..book`, []string{"descendant segment ..book"}},
+		{`$.a['b','c']`, []string{"union ['b', 'c']"}},
+		{`$.a[?@.x]`, []string{"test @.x"}},
+		{`$.a[?@.x > 'm']`, []string{"string ordering @.x > 'm'"}},
+		{`$.a[?@.x == $.y]`, []string{"operand $.y"}},
+		{`$.a | $.b`, []string{"union of queries |"}},
+		{`$..a[?@.x =~ /y/]^`, []string{"descendant segment ..a", "operator =~", "segment ^"}},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			_, err := ToJMESPath(test.path)
+			var translationErr *TranslationError
+			require.True(t, errors.As(err, &translationErr), "%v", err)
+			assert.Equal(t, test.constructs, translationErr.Constructs)
+		})
+	}
+
+	_, err := ToJMESPath(`$[`)
+	var translationErr *TranslationError
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &translationErr))
+}
+
+func TestFromJMESPath(t *testing.T) {
+	requirePlus(t)
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{`@`, `$`},
+		{`store.book[*].author`, `$.store.book[*].author`},
+		{`paths.*.get`, `$.paths.*.get`},
+		{`"x-logo".url`, `$['x-logo'].url`},
+		{`[0].b`, `$[0].b`},
+		{`a[0][-1][1:3][::2]`, `$.a[0][-1][1:3][::2]`},
+		{"books[?price > `10` && title == 'x' || !(a == `1`)]", `$.books[?@.price > 10 && @.title == 'x' || !(@.a == 1)]`},
+		{"books[?!(price > `10`)]", `$.books[?!(@.price > 10)]`},
+		{"books[?price == `10` && !contains(tags, 'x')]", `$.books[?@.price == 10 && !contains(@.tags, 'x')]`},
+		{"b[?length(tags) >= `2`]", `$.b[?length(@.tags) >= 2]`},
+		{"a[?x == `\"it's\"`]", `$.a[?@.x == 'it\'s']`},
+		{`a[?@ == 'x']`, `$.a[?@ == 'x']`},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			actual, err := FromJMESPath(test.expr)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+			_, err = NewPath(actual)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFromJMESPathUntranslatable(t *testing.T) {
+	tests := []struct {
+		expr       string
+		constructs []string
+	}{
+		{`a[].b`, []string{"flatten []"}},
+		{`a | b`, []string{"pipe |"}},
+		{`{x: a}`, []string{"multiselect hash {x: a}"}},
+		{`a.[b, c]`, []string{"multiselect list [b, c]"}},
+		{`sort_by(a, &b)`, []string{"function sort_by(a, &b)"}},
+		{`a[?x]`, []string{"truthiness test @.x"}},
+		{`a[?x > 'm']`, []string{"string ordering @.x > 'm'"}},
+		{"a[?x == `[1]`]", []string{"literal `[1]`"}},
+		{`a[].b | c[?d]`, []string{"flatten []", "pipe |", "truthiness test @.d"}},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			_, err := FromJMESPath(test.expr)
+			var translationErr *TranslationError
+			require.True(t, errors.As(err, &translationErr), "%v", err)
+			assert.Equal(t, test.constructs, translationErr.Constructs)
+		})
+	}
+
+	for _, invalid := range []string{`a.`, `a[?x == 'y'`, "a[?x == `{`]", `a ~ b`} {
+		_, err := FromJMESPath(invalid)
+		var translationErr *TranslationError
+		assert.Error(t, err, invalid)
+		assert.False(t, errors.As(err, &translationErr), invalid)
+	}
+}
@@ -1,14 +1,30 @@
 package jsonpath
 
 import (
+    "errors"
     "fmt"
     "github.com/pb33f/jsonpath/pkg/jsonpath/config"
     "github.com/pb33f/jsonpath/pkg/jsonpath/token"
     "go.yaml.in/yaml/v4"
+    "strings"
 )
 
+const byteOrderMark = "\ufeff"
+
 func NewPath(input string, opts ...config.Option) (*JSONPath, error) {
+    cfg := config.New(opts...)
+    if err := validateFunctions(cfg.Functions()); err != nil {
+        return nil, err
+    }
+    input, warnings, err := checkSurroundings(input, cfg)
+    if err != nil {
+        return nil, err
+    }
     tokenizer := token.NewTokenizer(input, opts...)
+    syntaxes := registeredSelectorSyntaxes()
+    if syntaxes != nil {
+        tokenizer.SetSelectorSyntaxes(selectorSyntaxDelimiters(syntaxes))
+    }
     tokens := tokenizer.Tokenize()
     for i := 0; i < len(tokens); i++ {
         if tokens[i].Token == token.ILLEGAL {
@@ -16,15 +32,70 @@ func NewPath(input string, opts ...config.Option) (*JSONPath, error) {
         }
     }
     parser := newParserPrivate(tokenizer, tokens, opts...)
-    err := parser.parse()
+    parser.warnings = warnings
+    parser.selectorSyntaxes = syntaxes
+    err = parser.parse()
     if err != nil {
         return nil, err
     }
     return parser, nil
 }
 
+// MustNewPath is like NewPath but panics if the expression cannot be parsed. It is intended for
+// package-level variables holding static expressions.
+func MustNewPath(input string, opts ...config.Option) *JSONPath {
+    p, err := NewPath(input, opts...)
+    if err != nil {
+        panic(fmt.Sprintf("jsonpath: NewPath(%q): %v", input, err))
+    }
+    return p
+}
+
+// Warnings returns the deviations from RFC 9535 that were accepted while parsing in lenient mode.
+func (p *JSONPath) Warnings() []string {
+    return p.warnings
+}
+
+// checkSurroundings looks for a byte order mark and blank space around the expression. Strict parsing
+// rejects them with the offset of the offending character; lenient parsing trims them and warns.
+func checkSurroundings(input string, cfg config.Config) (string, []string, error) {
+    var warnings []string
+    if strings.HasPrefix(input, byteOrderMark) {
+        if !cfg.LenientParsingEnabled() {
+            return "", nil, surroundingError(input, 0, len(byteOrderMark), "unexpected byte order mark")
+        }
+        warnings = append(warnings, "removed byte order mark")
+        input = input[len(byteOrderMark):]
+    }
+    trimmed := strings.TrimLeft(input, " \t\r\n")
+    if leading := len(input) - len(trimmed); leading > 0 {
+        if !cfg.LenientParsingEnabled() {
+            return "", nil, surroundingError(input, 0, leading, "unexpected leading whitespace")
+        }
+        warnings = append(warnings, fmt.Sprintf("removed %d leading whitespace character(s)", leading))
+        input = trimmed
+    }
+    trimmed = strings.TrimRight(input, " \t\r\n")
+    if trailing := len(input) - len(trimmed); trailing > 0 {
+        if !cfg.LenientParsingEnabled() {
+            return "", nil, surroundingError(input, len(trimmed), trailing, "unexpected trailing whitespace")
+        }
+        warnings = append(warnings, fmt.Sprintf("removed %d trailing whitespace character(s)", trailing))
+        input = trimmed
+    }
+    return input, warnings, nil
+}
+
+// surroundingError reports an error at the given byte offset of the input.
+func surroundingError(input string, offset int, length int, msg string) error {
+    line := 1 + strings.Count(input[:offset], "\n")
+    column := offset - (strings.LastIndexByte(input[:offset], '\n') + 1)
+    tokenizer := token.NewTokenizer(input)
+    return errors.New(tokenizer.ErrorString(&token.TokenInfo{Line: line, Column: column, Len: length}, msg))
+}
+
 func (p *JSONPath) Query(root *yaml.Node) []*yaml.Node {
-    return p.ast.Query(root, root)
+    return copyResults(p.config, p.ast.query(root, root, p.config))
 }
 
 func (p *JSONPath) String() string {
@@ -33,3 +104,30 @@ func (p *JSONPath) String() string {
     }
     return p.ast.ToString()
 }
+
+// IsSingular reports whether the query is a singular query as defined by RFC 9535: a sequence of
+// child segments that each select a single member name or array index. A singular query matches at
+// most one node. A union of complete queries is never singular.
+func (p *JSONPath) IsSingular() bool {
+    if len(p.ast.union) > 0 {
+        return false
+    }
+    for _, seg := range p.ast.segments {
+        if seg.kind != segmentKindChild {
+            return false
+        }
+        switch seg.child.kind {
+        case segmentDotMemberName:
+            continue
+        case segmentDotWildcard:
+            return false
+        }
+        if len(seg.child.selectors) != 1 {
+            return false
+        }
+        if kind := seg.child.selectors[0].kind; kind != selectorSubKindName && kind != selectorSubKindArrayIndex {
+            return false
+        }
+    }
+    return true
+}
@@ -8,8 +8,18 @@ import (
 	"go.yaml.in/yaml/v4"
 )
 
+// requirePlus skips a test of the JSONPath Plus extensions in builds without them, made with the
+// jsonpath_core build tag
+func requirePlus(t *testing.T) {
+	t.Helper()
+	if !config.PlusAvailable {
+		t.Skip("JSONPath Plus extensions are not built in")
+	}
+}
+
 // TestPropertyContextVariable tests @property filter context variable
 func TestPropertyContextVariable(t *testing.T) {
+	requirePlus(t)
 	tests := []struct {
 		name     string
 		yaml     string
@@ -182,6 +192,7 @@ paths:
 
 // TestPropertyVariableWithComplexFilters tests @property combined with other filter expressions
 func TestPropertyVariableWithComplexFilters(t *testing.T) {
+	requirePlus(t)
 	tests := []struct {
 		name     string
 		yaml     string
@@ -235,6 +246,7 @@ methods:
 
 // TestIndexContextVariable tests @index in array context
 func TestIndexContextVariable(t *testing.T) {
+	requirePlus(t)
 	tests := []struct {
 		name     string
 		yaml     string
@@ -282,6 +294,7 @@ items:
 
 // TestTokenizerContextVariables tests that the tokenizer correctly recognizes context variables
 func TestTokenizerContextVariables(t *testing.T) {
+	requirePlus(t)
 	tests := []struct {
 		name  string
 		input string
@@ -310,6 +323,7 @@ func TestTokenizerContextVariables(t *testing.T) {
 
 // TestRootContextVariable tests @root access in filter expressions
 func TestRootContextVariable(t *testing.T) {
+	requirePlus(t)
 	tests := []struct {
 		name     string
 		yaml     string
@@ -353,10 +367,22 @@ entries:
   - id: "admin_456"
   - id: "user_789"
 `,
-			// This tests @root access, though string starts-with would need a function
+			// This tests @root access in an exact comparison; prefixes need starts_with(), below
 			path:     `$.entries[?(@.id == @root.prefix)]`,
 			expected: 0, // No exact matches
 		},
+		{
+			name: "root string prefix",
+			yaml: `
+prefix: "user_"
+entries:
+  - id: "user_123"
+  - id: "admin_456"
+  - id: "user_789"
+`,
+			path:     `$.entries[?(starts_with(@.id, @root.prefix))]`,
+			expected: 2, // user_123 and user_789
+		},
 		{
 			name: "root with object type",
 			yaml: `
@@ -447,6 +473,7 @@ items:
 
 // TestRootCombinedWithProperty tests @root and @property together
 func TestRootCombinedWithProperty(t *testing.T) {
+	requirePlus(t)
 	yamlData := `
 allowedMethods:
   - get
@@ -475,6 +502,7 @@ paths:
 
 // TestTypeSelectorFunctions tests the type selector functions (isNull, isString, etc.)
 func TestTypeSelectorFunctions(t *testing.T) {
+	requirePlus(t)
 	yamlData := `
 items:
   - name: "Alice"
@@ -548,6 +576,7 @@ items:
 
 // TestTypeSelectorFunctionsWithLiterals tests type selectors with literal arguments
 func TestTypeSelectorFunctionsWithLiterals(t *testing.T) {
+	requirePlus(t)
 	yamlData := `
 items:
   - value: 1
@@ -582,6 +611,7 @@ items:
 
 // TestTypeSelectorCombinations tests combining type selectors with other filters
 func TestTypeSelectorCombinations(t *testing.T) {
+	requirePlus(t)
 	yamlData := `
 users:
   - name: "Alice"
@@ -612,6 +642,7 @@ users:
 
 // TestParentContextVariable tests @parent access in filter expressions
 func TestParentContextVariable(t *testing.T) {
+	requirePlus(t)
 	yamlData := `
 users:
   - name: "Alice"
@@ -649,9 +680,6 @@ users:
 	err := yaml.Unmarshal([]byte(yamlData), &node)
 	assert.NoError(t, err)
 
-	// When @parent is properly implemented, this pattern will work:
-	// Find users whose count is less than a sibling config value
-	// For now, test that parsing works
 	path, err := NewPath(`$.users[?(@.count < 100)]`)
 	assert.NoError(t, err)
 
@@ -659,8 +687,96 @@ users:
 	assert.Len(t, results, 1, "should find 1 user with count < 100")
 }
 
+// TestParentContextVariableNavigation tests segments following @parent, which reach into the parent
+func TestParentContextVariableNavigation(t *testing.T) {
+	requirePlus(t)
+	yamlData := `
+users:
+  - name: alice
+    primaryRole: admin
+    roles: {main: admin, extra: user}
+  - name: bob
+    primaryRole: user
+    roles: {main: admin}
+  - name: carol
+    groups: [{name: ops, size: 3}, {name: dev, size: 5}]
+`
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte(yamlData), &node)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "member of the parent",
+			query:    `$.users[*][?(@parent.primaryRole == 'admin')]`,
+			expected: []string{"$['users'][0]['name']", "$['users'][0]['primaryRole']", "$['users'][0]['roles']"},
+		},
+		{
+			name:     "member of the grandparent",
+			query:    `$.users[*].roles[?(@ == @parent^.primaryRole)]`,
+			expected: []string{"$['users'][0]['roles']['main']"},
+		},
+		{
+			name:     "members of the grandparent that differ",
+			query:    `$.users[*].roles[?(@ != @parent^.primaryRole)]`,
+			expected: []string{"$['users'][0]['roles']['extra']", "$['users'][1]['roles']['main']"},
+		},
+		{
+			name:     "the parent of a role is the roles mapping",
+			query:    `$.users[*].roles[?(@ == @parent.primaryRole)]`,
+			expected: nil,
+		},
+		{
+			name:     "index into the parent",
+			query:    `$.users[?(@parent[0].name == @.name)]`,
+			expected: []string{"$['users'][0]"},
+		},
+		{
+			name:     "existence",
+			query:    `$.users[*][?(!@parent.primaryRole)]`,
+			expected: []string{"$['users'][2]['name']", "$['users'][2]['groups']"},
+		},
+		{
+			name:     "wildcard in a function taking nodes",
+			query:    `$.users[*].roles[?(count(@parent.*) == 2)]`,
+			expected: []string{"$['users'][0]['roles']['main']", "$['users'][0]['roles']['extra']"},
+		},
+		{
+			name:     "descendants",
+			query:    `$.users[*].groups[?(max(@parent..size) == @.size)]`,
+			expected: []string{"$['users'][2]['groups'][1]"},
+		},
+		{
+			name:     "filter below the parent",
+			query:    `$.users[*].groups[?(@parent[?@.size > 4])].name`,
+			expected: []string{"$['users'][2]['groups'][0]['name']", "$['users'][2]['groups'][1]['name']"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path, err := NewPath(test.query)
+			assert.NoError(t, err)
+			var paths []string
+			for _, result := range path.QueryResults(&node) {
+				paths = append(paths, result.Path)
+			}
+			assert.Equal(t, test.expected, paths)
+		})
+	}
+
+	// comparisons need a single value
+	_, err = NewPath(`$.users[*][?(@parent.* == 'admin')]`)
+	assert.Error(t, err)
+}
+
 // TestParentSelector tests the ^ parent selector
 func TestParentSelector(t *testing.T) {
+	requirePlus(t)
 	yamlData := `
 store:
   book:
@@ -702,8 +818,71 @@ store:
 	}
 }
 
+// TestParentSelectorAscent tests ^ with a level count and keyed ascent, and that brackets after
+// the caret still select children of the parent
+func TestParentSelectorAscent(t *testing.T) {
+	requirePlus(t)
+	yamlData := `
+paths:
+  /users:
+    get:
+      parameters:
+        - name: limit
+        - name: offset
+    post:
+      parameters:
+        - name: dryRun
+  /health:
+    get:
+      responses: {}
+`
+	var node yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte(yamlData), &node))
+
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+	}{
+		{"level count", `$.paths['/users'].get.parameters[0]^2`, []string{"$['paths']['/users']['get']"}},
+		{"level count matches repeated carets", `$.paths['/users'].get.parameters[0]^^^`, []string{"$['paths']['/users']"}},
+		{"level count past the root", `$.paths['/users'].get.parameters[0]^9`, nil},
+		{"level count after descent", `$..parameters[?@.name == 'dryRun']^3`, []string{"$['paths']['/users']"}},
+		{"operation of a parameter", `$..parameters[?@.name == 'offset']^('get', 'post', 'put')`, []string{"$['paths']['/users']['get']"}},
+		{"nearest key wins", `$.paths.*.*.parameters[*]^('paths', '/users')`, []string{"$['paths']['/users']", "$['paths']['/users']", "$['paths']['/users']"}},
+		{"keys the nodes are under", `$..[?@.name]^('paths')`, []string{"$['paths']", "$['paths']", "$['paths']"}},
+		{"no matching ancestor", `$.paths.*.get.responses^('post')`, nil},
+		{"the node itself is not an ancestor", `$.paths['/users'].get^('get')`, nil},
+		{"brackets after the caret select children of the parent", `$.paths['/users'].get^['get']`, []string{"$['paths']['/users']['get']"}},
+		{"index after the caret", `$.paths['/users'].get.parameters[1]^[0].name`, []string{"$['paths']['/users']['get']['parameters'][0]['name']"}},
+		{"union after the caret", `$.paths['/health'].get^['get', *]`, []string{"$['paths']['/health']['get']", "$['paths']['/health']['get']"}},
+		{"filter after the caret", `$.paths['/users'].get^[?@.parameters]`, []string{"$['paths']['/users']['get']", "$['paths']['/users']['post']"}},
+		{"sibling after level count", `$.paths['/users'].get.parameters^2.post.parameters[0].name`, []string{"$['paths']['/users']['post']['parameters'][0]['name']"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := NewPath(tt.path)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.path, path.String())
+			var actual []string
+			for _, result := range path.QueryResults(&node) {
+				actual = append(actual, result.Path)
+			}
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+
+	for _, input := range []string{`$.a^0`, `$.a^01`, `$.a^()`, `$.a^(0)`, `$.a^(b)`, `$.a^('b' 'c')`, `$.a^('b',)`, `$.a^('b'`} {
+		_, err := NewPath(input)
+		assert.Error(t, err, input)
+	}
+}
+
 // TestParentSelectorWithFilter tests ^ combined with filter expressions
 func TestParentSelectorWithFilter(t *testing.T) {
+	requirePlus(t)
 	yamlData := `
 departments:
   engineering:
@@ -734,6 +913,7 @@ departments:
 
 // TestParentSelectorAfterWildcard tests ^ after wildcard selector
 func TestParentSelectorAfterWildcard(t *testing.T) {
+	requirePlus(t)
 	yamlData := `
 items:
   - id: 1
@@ -796,6 +976,7 @@ items:
 
 // TestSpectralStyleQuery tests the original Spectral-style query that started this issue
 func TestSpectralStyleQuery(t *testing.T) {
+	requirePlus(t)
 	yamlData := `
 paths:
   /users:
@@ -826,6 +1007,7 @@ paths:
 
 // TestContextVariableEvaluationEdgeCases tests edge cases in context variable evaluation
 func TestContextVariableEvaluationEdgeCases(t *testing.T) {
+	requirePlus(t)
 	yamlData := `
 items:
   - value: 1
@@ -863,6 +1045,7 @@ items:
 
 // TestTypeSelectorEdgeCases tests edge cases for type selectors
 func TestTypeSelectorEdgeCases(t *testing.T) {
+	requirePlus(t)
 	yamlData := `
 data:
   - empty: null
@@ -905,6 +1088,7 @@ data:
 
 // TestTypeSelectorWithNodesArgument tests type selectors with node results
 func TestTypeSelectorWithNodesArgument(t *testing.T) {
+	requirePlus(t)
 	yamlData := `
 items:
   - names:
@@ -927,6 +1111,7 @@ items:
 
 // TestPathContextVariable tests the @path context variable
 func TestPathContextVariable(t *testing.T) {
+	requirePlus(t)
 	tests := []struct {
 		name     string
 		yaml     string
@@ -1144,6 +1329,7 @@ store:
 
 // TestParentPropertyContextVariable tests the @parentProperty context variable
 func TestParentPropertyContextVariable(t *testing.T) {
+	requirePlus(t)
 	tests := []struct {
 		name     string
 		yaml     string
@@ -1263,6 +1449,52 @@ store:
 	}
 }
 
+// TestRootFilters tests filters applied to the root node, which test the members or elements of
+// the document itself
+func TestRootFilters(t *testing.T) {
+	requirePlus(t)
+	const document = `
+openapi: 3.1.0
+info: {title: Pets}
+paths: {/pets: {}, /owners: {}, /stores: {}, /orders: {}}
+tags: [pets, owners]
+`
+	tests := []struct {
+		name     string
+		yaml     string
+		path     string
+		expected []string
+	}{
+		{name: "property", yaml: document, path: `$[?(@property == 'info')]`, expected: []string{"$['info']"}},
+		{name: "property without parentheses", yaml: document, path: `$[?@property != 'info' && @property != 'openapi']`, expected: []string{"$['paths']", "$['tags']"}},
+		{name: "length", yaml: document, path: `$[?length(@) > 3]`, expected: []string{"$['openapi']", "$['paths']"}},
+		{name: "member", yaml: document, path: `$[?(@.title)]`, expected: []string{"$['info']"}},
+		{name: "path", yaml: document, path: `$[?(@path == "$['tags']")]`, expected: []string{"$['tags']"}},
+		{name: "parent is the root", yaml: document, path: `$[?(@parent == $ && @.length == 2)]`, expected: []string{"$['tags']"}},
+		{name: "no parent property", yaml: document, path: `$[?(@parentProperty)]`},
+		{name: "parent property is not null", yaml: document, path: `$[?(@parentProperty == null)]`},
+		{name: "parent property below the root", yaml: document, path: `$.paths[?(@parentProperty == 'paths')]`, expected: []string{"$['paths']['/pets']", "$['paths']['/owners']", "$['paths']['/stores']", "$['paths']['/orders']"}},
+		{name: "sequence", yaml: `[{id: 1}, {id: 2}, [1, 2, 3, 4]]`, path: `$[?(@.id > 1 || length(@) > 3)]`, expected: []string{"$[1]", "$[2]"}},
+		{name: "sequence index", yaml: `[a, b, c]`, path: `$[?(@property == '1' || @index == 2)]`, expected: []string{"$[1]", "$[2]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node yaml.Node
+			assert.NoError(t, yaml.Unmarshal([]byte(tt.yaml), &node))
+
+			path, err := NewPath(tt.path)
+			assert.NoError(t, err)
+
+			var paths []string
+			for _, result := range path.QueryResults(&node) {
+				paths = append(paths, result.Path)
+			}
+			assert.Equal(t, tt.expected, paths)
+		})
+	}
+}
+
 // Helper function to check if a YAML string contains expected content
 func containsYAML(haystack, needle string) bool {
 	// Simple substring check - good enough for test assertions
@@ -1281,3 +1513,876 @@ func contains(s, substr string) bool {
 	}
 	return false
 }
+
+// TestPropertyPathContextVariable tests the @propertyPath context variable
+func TestPropertyPathContextVariable(t *testing.T) {
+	requirePlus(t)
+	yamlData := `
+components:
+  schemas:
+    User:
+      properties:
+        id: { type: integer }
+    Tags:
+      items:
+        - name: a
+        - name: b
+paths:
+  /users:
+    get:
+      properties:
+        id: { type: string }
+"it's\nhere":
+  - z
+`
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+	}{
+		{
+			name:     "first key of the ancestor chain",
+			path:     `$.components.schemas.User.properties[?(@propertyPath[0] == 'components')]`,
+			expected: []string{"$['components']['schemas']['User']['properties']['id']"},
+		},
+		{
+			name:     "negative index from the end",
+			path:     `$.paths.*.get.properties[?(@propertyPath[-4] == '/users')]`,
+			expected: []string{"$['paths']['/users']['get']['properties']['id']"},
+		},
+		{
+			name:     "array indices are integers",
+			path:     `$.components.schemas.Tags.items[?(@propertyPath[-1] == 1)]`,
+			expected: []string{"$['components']['schemas']['Tags']['items'][1]"},
+		},
+		{
+			name:     "length of the chain",
+			path:     `$.components.schemas[?(length(@propertyPath) == 3)]`,
+			expected: []string{"$['components']['schemas']['User']", "$['components']['schemas']['Tags']"},
+		},
+		{
+			name:     "out of range index is nothing",
+			path:     `$.components.schemas[?(@propertyPath[10] == 'x')]`,
+			expected: nil,
+		},
+		{
+			name:     "any ancestor key",
+			path:     `$..properties[?(contains(@propertyPath, 'components'))]`,
+			expected: []string{"$['components']['schemas']['User']['properties']['id']"},
+		},
+		{
+			name:     "any ancestor key below a descendant segment",
+			path:     `$..[?(contains(@propertyPath, 'paths') && @.type)]`,
+			expected: []string{"$['paths']['/users']['get']['properties']['id']"},
+		},
+		{
+			name:     "parent property below a descendant segment",
+			path:     `$..[?(@parentProperty == 'items' && @.name == 'b')]`,
+			expected: []string{"$['components']['schemas']['Tags']['items'][1]"},
+		},
+		{
+			name:     "path below a descendant segment",
+			path:     `$..[?(@path == '$[\'paths\'][\'/users\'][\'get\']')]`,
+			expected: []string{"$['paths']['/users']['get']"},
+		},
+		{
+			name:     "elements of the path",
+			path:     `$..[?(@path[-2] == 'paths' && @path[0] == 'paths')]`,
+			expected: []string{"$['paths']['/users']"},
+		},
+		{
+			name:     "number of elements of the path",
+			path:     `$..[?(@path.length == 3 && @path[0] == 'paths')]`,
+			expected: []string{"$['paths']['/users']['get']"},
+		},
+		{
+			name:     "number of elements of the property path",
+			path:     `$.components.schemas.*.*[?(@propertyPath.length > 4)]`,
+			expected: []string{"$['components']['schemas']['User']['properties']['id']", "$['components']['schemas']['Tags']['items'][0]", "$['components']['schemas']['Tags']['items'][1]"},
+		},
+		{
+			name:     "length of a string element is nothing",
+			path:     `$.paths[?(@path[-1].length == 6)]`,
+			expected: nil,
+		},
+		{
+			name:     "escaped member names are decoded",
+			path:     `$['it\'s\nhere'][?(@propertyPath[0] == 'it\'s\nhere')]`,
+			expected: []string{`$['it\'s\nhere'][0]`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node yaml.Node
+			assert.NoError(t, yaml.Unmarshal([]byte(yamlData), &node))
+
+			path, err := NewPath(tt.path)
+			assert.NoError(t, err, "failed to parse path: %s", tt.path)
+			assert.Equal(t, tt.path, path.String())
+
+			var paths []string
+			for _, result := range path.QueryResults(&node) {
+				paths = append(paths, result.Path)
+			}
+			assert.Equal(t, tt.expected, paths)
+		})
+	}
+
+	_, err := NewPath(`$[?(@propertyPath[0] == 'a')]`, config.WithStrictRFC9535())
+	assert.Error(t, err)
+}
+
+// TestPathKeysContextVariable tests @pathKeys, which tests whole keys of the ancestor chain where
+// contains(@path, ...) matches any part of the path string
+func TestPathKeysContextVariable(t *testing.T) {
+	requirePlus(t)
+	yamlData := `
+components:
+  schemas:
+    User: {type: object}
+x-components-old:
+  schemas:
+    Legacy: {type: object}
+paths:
+  /users:
+    get: {type: object}
+`
+	var node yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte(yamlData), &node))
+
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+	}{
+		{
+			name:     "any ancestor key",
+			path:     `$..[?(@.type && contains(@pathKeys, 'components'))]`,
+			expected: []string{"$['components']['schemas']['User']"},
+		},
+		{
+			name:     "the path string also matches parts of keys",
+			path:     `$..[?(@.type && contains(@path, 'components'))]`,
+			expected: []string{"$['components']['schemas']['User']", "$['x-components-old']['schemas']['Legacy']"},
+		},
+		{
+			name:     "no ancestor key",
+			path:     `$..[?(@.type && !(contains(@pathKeys, 'components')))]`,
+			expected: []string{"$['x-components-old']['schemas']['Legacy']", "$['paths']['/users']['get']"},
+		},
+		{
+			name:     "elements and length",
+			path:     `$..[?(@pathKeys[0] == 'paths' && @pathKeys.length == 3)]`,
+			expected: []string{"$['paths']['/users']['get']"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := NewPath(tt.path)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.path, path.String())
+			var paths []string
+			for _, result := range path.QueryResults(&node) {
+				paths = append(paths, result.Path)
+			}
+			assert.Equal(t, tt.expected, paths)
+		})
+	}
+
+	_, err := NewPath(`$[?(contains(@pathKeys, 'a'))]`, config.WithStrictRFC9535())
+	assert.Error(t, err)
+}
+
+// TestSiblingContextVariable tests the @sibling context variable
+func TestSiblingContextVariable(t *testing.T) {
+	requirePlus(t)
+	yamlData := `
+paths:
+  /users:
+    get:
+      deprecated: true
+      summary: List users
+      tags: [users]
+    post:
+      summary: Create user
+  /orders:
+    get:
+      deprecated: false
+      summary: List orders
+matrix:
+  - [1, 2, 3]
+  - [4, 5, 6]
+`
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+	}{
+		{
+			name: "children of deprecated operations",
+			path: `$.paths.*.*[?(@sibling('deprecated') == true)]`,
+			expected: []string{
+				"$['paths']['/users']['get']['summary']",
+				"$['paths']['/users']['get']['tags']",
+			},
+		},
+		{
+			name:     "sibling followed by segments",
+			path:     `$.paths.*[?(@sibling('get').summary == 'List users')]`,
+			expected: []string{"$['paths']['/users']['post']"},
+		},
+		{
+			name:     "sibling by index in a sequence",
+			path:     `$.matrix[*][?(@sibling(-1) == 6)]`,
+			expected: []string{"$['matrix'][1][0]", "$['matrix'][1][1]"},
+		},
+		{
+			name:     "missing sibling is nothing",
+			path:     `$.paths.*.*[?(@sibling('operationId') == 'x')]`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node yaml.Node
+			assert.NoError(t, yaml.Unmarshal([]byte(yamlData), &node))
+
+			path, err := NewPath(tt.path)
+			assert.NoError(t, err, "failed to parse path: %s", tt.path)
+			assert.Equal(t, tt.path, path.String())
+
+			var paths []string
+			for _, result := range path.QueryResults(&node) {
+				paths = append(paths, result.Path)
+			}
+			assert.Equal(t, tt.expected, paths)
+		})
+	}
+
+	for _, invalid := range []string{`$[?(@sibling == 1)]`, `$[?(@sibling(true) == 1)]`, `$[?(@sibling('a' == 1)]`} {
+		_, err := NewPath(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+// TestNeighborContextVariables tests the @prev and @next context variables
+func TestNeighborContextVariables(t *testing.T) {
+	requirePlus(t)
+	yamlData := `
+events:
+  - {name: a, timestamp: 1}
+  - {name: b, timestamp: 5}
+  - {name: c, timestamp: 3}
+  - {name: d, timestamp: 3}
+versions: [1, 2, 2, 4]
+info:
+  title: x
+`
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+	}{
+		{
+			name:     "out of order events",
+			path:     `$.events[?(@.timestamp < @prev.timestamp)].name`,
+			expected: []string{"c"},
+		},
+		{
+			name:     "events followed by an equal timestamp",
+			path:     `$.events[?(@.timestamp == @next.timestamp)].name`,
+			expected: []string{"c"},
+		},
+		{
+			name:     "consecutive duplicates of scalars",
+			path:     `$.versions[?(@ == @prev)]`,
+			expected: []string{"2"},
+		},
+		{
+			name:     "element before a given one",
+			path:     `$.events[?(@next.name == 'b')].name`,
+			expected: []string{"a"},
+		},
+		{
+			name:     "no neighbors in mappings",
+			path:     `$.info[?(@next == 'x' || @prev == 'x')]`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node yaml.Node
+			assert.NoError(t, yaml.Unmarshal([]byte(yamlData), &node))
+
+			path, err := NewPath(tt.path)
+			assert.NoError(t, err, "failed to parse path: %s", tt.path)
+			assert.Equal(t, tt.path, path.String())
+
+			var values []string
+			for _, result := range path.Query(&node) {
+				values = append(values, result.Value)
+			}
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+}
+
+// TestPositionContextVariables tests the @line and @column context variables
+func TestPositionContextVariables(t *testing.T) {
+	requirePlus(t)
+	yamlData := `
+paths:
+  /users:
+    get: {operationId: listUsers}
+  /orders:
+    get: {operationId: listOrders}
+    post: {operationId: createOrder}
+tags: [a, b]
+`
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+	}{
+		{
+			name:     "nodes in a range of lines",
+			path:     `$..[?(@line > 4 && @line < 8)]~`,
+			expected: []string{"/orders", "get", "post", "operationId", "operationId"},
+		},
+		{
+			name:     "column of flow sequence elements",
+			path:     `$.tags[?(@column == 11)]`,
+			expected: []string{"b"},
+		},
+		{
+			name:     "operations below a line",
+			path:     `$.paths.*[?(@line >= 6)].operationId`,
+			expected: []string{"listOrders", "createOrder"},
+		},
+		{
+			name:     "arithmetic on lines",
+			path:     `$.paths.*[?(@line + 1 == 7)]~`,
+			expected: []string{"get"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node yaml.Node
+			assert.NoError(t, yaml.Unmarshal([]byte(yamlData), &node))
+
+			path, err := NewPath(tt.path)
+			assert.NoError(t, err, "failed to parse path: %s", tt.path)
+			assert.Equal(t, tt.path, path.String())
+
+			var values []string
+			for _, result := range path.Query(&node) {
+				values = append(values, result.Value)
+			}
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+
+	// nodes built rather than parsed have no position
+	built := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: "x"}}}
+	assert.Len(t, MustNewPath(`$[?(@line == 0 && @column == 0)]`).Query(built), 1)
+
+	_, err := NewPath(`$[?(@line > 1)]`, config.WithStrictRFC9535())
+	assert.Error(t, err)
+}
+
+// TestLengthProperty tests the JavaScript style .length of arrays and strings in filters
+func TestLengthProperty(t *testing.T) {
+	requirePlus(t)
+	yamlData := `
+items:
+  - {name: a, tags: [x, y, z]}
+  - {name: bb, tags: [x]}
+  - {name: ccc, tags: {length: 5}}
+  - {name: dddd, tags: {x: 1, y: 2, z: 3}}
+limits: [1, 2]
+`
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+	}{
+		{
+			name:     "length of an array",
+			path:     `$.items[?(@.tags.length > 2)].name`,
+			expected: []string{"a", "ccc"},
+		},
+		{
+			name:     "length of a string",
+			path:     `$.items[?(@.name.length == 2)].name`,
+			expected: []string{"bb"},
+		},
+		{
+			name:     "a member named length is preferred",
+			path:     `$.items[?(@.tags['length'] == 5)].name`,
+			expected: []string{"ccc"},
+		},
+		{
+			name:     "mappings have no length",
+			path:     `$.items[?(@.tags.length == 3)].name`,
+			expected: []string{"a"},
+		},
+		{
+			name:     "absolute queries",
+			path:     `$.items[?(@.name.length == $.limits.length)].name`,
+			expected: []string{"bb"},
+		},
+		{
+			name:     "arithmetic",
+			path:     `$.items[?(@.name.length * 3 == @.tags.length)].name`,
+			expected: []string{"a"},
+		},
+		{
+			name:     "the elements of an array",
+			path:     `$.items[?(@.tags.length == length(@.tags))].name`,
+			expected: []string{"a", "bb"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node yaml.Node
+			assert.NoError(t, yaml.Unmarshal([]byte(yamlData), &node))
+
+			path, err := NewPath(tt.path)
+			assert.NoError(t, err, "failed to parse path: %s", tt.path)
+
+			var values []string
+			for _, result := range path.Query(&node) {
+				values = append(values, result.Value)
+			}
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+
+	// RFC 9535 queries select the member named length only
+	var node yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte(yamlData), &node))
+	path, err := NewPath(`$.items[?(@.tags.length > 2)].name`, config.WithStrictRFC9535())
+	assert.NoError(t, err)
+	var values []string
+	for _, result := range path.Query(&node) {
+		values = append(values, result.Value)
+	}
+	assert.Equal(t, []string{"ccc"}, values)
+}
+
+// TestRegexMatchOperator tests the =~ operator with regular expression and string patterns
+func TestRegexMatchOperator(t *testing.T) {
+	requirePlus(t)
+	yamlData := `
+paths:
+  /users: {summary: List users}
+  /users/{id}: {summary: Get a user}
+  /orders: {summary: list orders}
+  /admin/users: {summary: Manage users}
+codes: [200, 201, 404, true, null, {status: 500}]
+`
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+	}{
+		{
+			name:     "property names",
+			path:     `$.paths[?(@property =~ /^\/users/)].summary`,
+			expected: []string{"List users", "Get a user"},
+		},
+		{
+			name:     "unanchored string values",
+			path:     `$.paths[?(@.summary =~ /users/)]~`,
+			expected: []string{"/users", "/admin/users"},
+		},
+		{
+			name:     "case insensitive flag",
+			path:     `$.paths[?(@.summary =~ /^list/i)]~`,
+			expected: []string{"/users", "/orders"},
+		},
+		{
+			name:     "slash in a character class",
+			path:     `$.paths[?(@property =~ /^[/]admin/)]~`,
+			expected: []string{"/admin/users"},
+		},
+		{
+			name:     "string pattern",
+			path:     `$.paths[?(@property =~ '\\{id\\}$')]~`,
+			expected: []string{"/users/{id}"},
+		},
+		{
+			name:     "numbers and booleans match their text",
+			path:     `$.codes[?(@ =~ /^(2..|true)$/)]`,
+			expected: []string{"200", "201", "true"},
+		},
+		{
+			name:     "negated match",
+			path:     `$.paths[?(!(@property =~ /users/))]~`,
+			expected: []string{"/orders"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node yaml.Node
+			assert.NoError(t, yaml.Unmarshal([]byte(yamlData), &node))
+
+			path, err := NewPath(tt.path, config.WithPropertyNameExtension())
+			assert.NoError(t, err, "failed to parse path: %s", tt.path)
+			assert.Equal(t, tt.path, path.String())
+
+			var values []string
+			for _, result := range path.Query(&node) {
+				values = append(values, result.Value)
+			}
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+}
+
+// TestRegexMatchOperatorErrors tests invalid uses of the =~ operator
+func TestRegexMatchOperatorErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		opts []config.Option
+	}{
+		{"unterminated", `$[?(@ =~ /abc)]`, nil},
+		{"invalid pattern", `$[?(@ =~ /a(b/)]`, nil},
+		{"unsupported flag", `$[?(@ =~ /abc/y)]`, nil},
+		{"not a pattern", `$[?(@ =~ @.pattern)]`, nil},
+		{"strict mode", `$[?(@ =~ /abc/)]`, []config.Option{config.WithStrictRFC9535()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPath(tt.path, tt.opts...)
+			assert.Error(t, err, "expected invalid path for %s", tt.path)
+		})
+	}
+}
+
+// TestMembershipOperator tests the in operator with literal lists and arrays in the document
+func TestMembershipOperator(t *testing.T) {
+	requirePlus(t)
+	yamlData := `
+validTypes: [string, integer]
+items:
+  - {name: a, type: string, code: 200}
+  - {name: b, type: object, code: 404}
+  - {name: c, type: integer, code: 201.0}
+  - {name: d, code: 500}
+  - {name: in, type: array, code: null}
+`
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+		str      string
+	}{
+		{
+			name:     "list of strings",
+			path:     `$.items[?(@.type in ['string', 'object'])].name`,
+			expected: []string{"a", "b"},
+		},
+		{
+			name:     "list of numbers",
+			path:     `$.items[?(@.code in [200, 201])].name`,
+			expected: []string{"a", "c"},
+		},
+		{
+			name:     "list of mixed literals",
+			path:     `$.items[?(@.code in [null, true, 'x', 404])].name`,
+			expected: []string{"b", "in"},
+		},
+		{
+			name:     "array in the document",
+			path:     `$.items[?(@.type in @root.validTypes)].name`,
+			expected: []string{"a", "c"},
+			str:      `$.items[?(@.type in $.validTypes)].name`,
+		},
+		{
+			name:     "negated membership",
+			path:     `$.items[?(!(@.type in $.validTypes))].name`,
+			expected: []string{"b", "d", "in"},
+		},
+		{
+			name:     "member named in",
+			path:     `$.items[?(@.name in ['in'])].name`,
+			expected: []string{"in"},
+		},
+		{
+			name:     "empty list",
+			path:     `$.items[?(@.type in [])].name`,
+			expected: nil,
+		},
+		{
+			name:     "not an array",
+			path:     `$.items[?(@.type in $.items[0].type)].name`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node yaml.Node
+			assert.NoError(t, yaml.Unmarshal([]byte(yamlData), &node))
+
+			path, err := NewPath(tt.path)
+			assert.NoError(t, err, "failed to parse path: %s", tt.path)
+			if tt.str == "" {
+				tt.str = tt.path
+			}
+			assert.Equal(t, tt.str, path.String())
+
+			var values []string
+			for _, result := range path.Query(&node) {
+				values = append(values, result.Value)
+			}
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+}
+
+// TestMembershipOperatorErrors tests invalid uses of the in operator
+func TestMembershipOperatorErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		opts []config.Option
+	}{
+		{"unterminated list", `$[?(@ in ['a', 'b')]`, nil},
+		{"missing comma", `$[?(@ in ['a' 'b'])]`, nil},
+		{"query in the list", `$[?(@ in [@.a])]`, nil},
+		{"missing right hand side", `$[?(@ in)]`, nil},
+		{"strict mode", `$[?(@ in ['a'])]`, []config.Option{config.WithStrictRFC9535()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPath(tt.path, tt.opts...)
+			assert.Error(t, err, "expected invalid path for %s", tt.path)
+		})
+	}
+
+	// member names are not operators
+	for _, valid := range []string{`$.in`, `$..in`, `$[?@.in == 1]`, `$['in']`} {
+		_, err := NewPath(valid)
+		assert.NoError(t, err, valid)
+	}
+}
+
+// TestArithmeticOperators tests + - * / % in filter expressions
+func TestArithmeticOperators(t *testing.T) {
+	requirePlus(t)
+	yamlData := `
+discount: 0.5
+items:
+  - {name: a, price: 10, qty: 20}
+  - {name: b, price: 2.5, qty: 4}
+  - {name: c, price: 7, qty: 2}
+  - {name: d, price: '50', qty: 3}
+  - {name: e, price: 9223372036854775807, qty: 2}
+`
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+		str      string
+	}{
+		{
+			name:     "product of members",
+			path:     `$.items[?(@.price * @.qty > 100)].name`,
+			expected: []string{"a", "e"},
+		},
+		{
+			name:     "float and integer",
+			path:     `$.items[?(@.price * @.qty == 10)].name`,
+			expected: []string{"b"},
+		},
+		{
+			name:     "index modulo",
+			path:     `$.items[?(@index % 2 == 0)].name`,
+			expected: []string{"a", "c", "e"},
+		},
+		{
+			name:     "precedence",
+			path:     `$.items[?(@.price + @.qty * 2 == 11)].name`,
+			expected: []string{"c"},
+		},
+		{
+			name:     "grouping",
+			path:     `$.items[?((@.price + @.qty) * 2 == 18)].name`,
+			expected: []string{"c"},
+		},
+		{
+			name:     "left associative",
+			path:     `$.items[?(@.qty - 1 - 1 == 0)].name`,
+			expected: []string{"c", "e"},
+		},
+		{
+			name:     "division with a remainder",
+			path:     `$.items[?(@.price / 2 == 3.5)].name`,
+			expected: []string{"c"},
+		},
+		{
+			name:     "negative literal",
+			path:     `$.items[?(@.qty * -1 < -10)].name`,
+			expected: []string{"a"},
+		},
+		{
+			name:     "root value",
+			path:     `$.items[?(@.price * @root.discount == 5)].name`,
+			expected: []string{"a"},
+			str:      `$.items[?(@.price * $.discount == 5)].name`,
+		},
+		{
+			name:     "function result",
+			path:     `$.items[?(length(@.name) + 1 == 2)].name`,
+			expected: []string{"a", "b", "c", "d", "e"},
+		},
+		{
+			name:     "division by zero is Nothing",
+			path:     `$.items[?(@.price / 0 == @.missing)].name`,
+			expected: []string{"a", "b", "c", "d", "e"},
+		},
+		{
+			name:     "strings are not numbers",
+			path:     `$.items[?(@.price + 0 >= 0)].name`,
+			expected: []string{"a", "b", "c", "e"},
+		},
+		{
+			name:     "parenthesised comparison",
+			path:     `$.items[?(@.price == 7)].name`,
+			expected: []string{"c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node yaml.Node
+			assert.NoError(t, yaml.Unmarshal([]byte(yamlData), &node))
+
+			path, err := NewPath(tt.path)
+			assert.NoError(t, err, "failed to parse path: %s", tt.path)
+			if tt.str == "" {
+				tt.str = tt.path
+			}
+			assert.Equal(t, tt.str, path.String())
+
+			var values []string
+			for _, result := range path.Query(&node) {
+				values = append(values, result.Value)
+			}
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+}
+
+// TestArithmeticOperatorErrors tests invalid uses of arithmetic operators
+func TestArithmeticOperatorErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		opts []config.Option
+	}{
+		{"missing operand", `$[?(@.a * == 1)]`, nil},
+		{"unterminated group", `$[?((@.a + 1 == 1)]`, nil},
+		{"without a comparison", `$[?(@.a + 1)]`, nil},
+		{"on a wildcard", `$[?(@.* + 1 == 1)]`, nil},
+		{"strict mode", `$[?(@.a + 1 == 1)]`, []config.Option{config.WithStrictRFC9535()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPath(tt.path, tt.opts...)
+			assert.Error(t, err, "expected invalid path for %s", tt.path)
+		})
+	}
+
+	// signs and wildcards outside arithmetic are unchanged
+	for _, valid := range []string{`$[-1]`, `$[1:-1]`, `$[?@.a == -1]`, `$.*`, `$[*]`, `$[?@.*]`, `$[?@..*]`} {
+		_, err := NewPath(valid)
+		assert.NoError(t, err, valid)
+	}
+}
+
+// TestNestedFilters tests filters whose operands are themselves filtered queries, and the context
+// variables around them
+func TestNestedFilters(t *testing.T) {
+	requirePlus(t)
+	yamlData := `
+paths:
+  /pets:
+    get: {parameters: [{name: limit, in: query}, {name: trace, in: header}]}
+    post: {parameters: [{name: body, in: body}]}
+items:
+  - {name: a, tags: [x]}
+  - {name: b, tags: [y, x]}
+`
+	tests := []struct {
+		name     string
+		path     string
+		expected []string
+	}{
+		{
+			name:     "existence of the inner results",
+			path:     `$.paths[*][?(@.parameters[?(@.in == 'header')])].parameters[0].name`,
+			expected: []string{"limit"},
+		},
+		{
+			name:     "negated",
+			path:     `$.items[?(!@.tags[?(@ == 'y')])].name`,
+			expected: []string{"a"},
+		},
+		{
+			name:     "with another operand",
+			path:     `$.items[?(@.tags[?(@ == 'y')] || @.name == 'a')].name`,
+			expected: []string{"a", "b"},
+		},
+		{
+			name:     "@index after the inner filter",
+			path:     `$.items[?(@.tags[?(@ == 'x')] && @index == 1)].name`,
+			expected: []string{"b"},
+		},
+		{
+			name:     "@path after the inner filter",
+			path:     `$.items[?(@.tags[?(@ == 'x')] && @path == "$['items'][1]")].name`,
+			expected: []string{"b"},
+		},
+		{
+			name:     "@property after a relative query",
+			path:     `$.items[?(@.name == 'b' && @property == '1')].name`,
+			expected: []string{"b"},
+		},
+		{
+			name:     "@path in the inner filter",
+			path:     `$.items[?(@.tags[?(@path == "$['items'][1]['tags'][1]")])].name`,
+			expected: []string{"b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node yaml.Node
+			assert.NoError(t, yaml.Unmarshal([]byte(yamlData), &node))
+
+			path, err := NewPath(tt.path)
+			assert.NoError(t, err, "failed to parse path: %s", tt.path)
+
+			var values []string
+			for _, result := range path.Query(&node) {
+				values = append(values, result.Value)
+			}
+			assert.Equal(t, tt.expected, values)
+		})
+	}
+}
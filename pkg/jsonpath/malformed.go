@@ -0,0 +1,130 @@
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// ErrMalformedDocument is matched by the errors returned for documents that break the invariants of
+// yaml.Node trees, such as those built by hand or by third-party tools.
+var ErrMalformedDocument = errors.New("jsonpath: malformed document")
+
+// MalformedDocumentError describes where a document breaks the invariants of yaml.Node trees.
+type MalformedDocumentError struct {
+	// Path is the normalized path of the offending node.
+	Path string
+	// Reason describes what is wrong with the node.
+	Reason string
+}
+
+func (e *MalformedDocumentError) Error() string {
+	return fmt.Sprintf("jsonpath: malformed document at %s: %s", e.Path, e.Reason)
+}
+
+func (e *MalformedDocumentError) Unwrap() error {
+	return ErrMalformedDocument
+}
+
+// ValidateDocument checks that the tree rooted at root can be queried as parsed YAML: that it has
+// no nil nodes and that every mapping has a value for each key. It returns a *MalformedDocumentError
+// for the first problem found in document order.
+//
+// Queries never panic on malformed documents: nil nodes and keys without a value are skipped, so the
+// rest of the document can still be queried. Use ValidateDocument, or TryQuery, to find out whether
+// anything was skipped.
+func ValidateDocument(root *yaml.Node) error {
+	return validateNode(root, "$", make(map[*yaml.Node]bool))
+}
+
+func validateNode(node *yaml.Node, path string, seen map[*yaml.Node]bool) error {
+	if node == nil {
+		return &MalformedDocumentError{Path: path, Reason: "nil node"}
+	}
+	if seen[node] {
+		return nil
+	}
+	seen[node] = true
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := validateNode(child, path, seen); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		if len(node.Content)%2 != 0 {
+			return &MalformedDocumentError{Path: path, Reason: fmt.Sprintf("mapping has %d keys and values", len(node.Content))}
+		}
+		for i := 0; i < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key == nil {
+				return &MalformedDocumentError{Path: path, Reason: fmt.Sprintf("nil key at position %d", i/2)}
+			}
+			if err := validateNode(node.Content[i+1], path+normalizePathSegment(key.Value), seen); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			if err := validateNode(child, path+normalizeIndexSegment(i), seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TryQuery is Query for documents that may be malformed. It returns the results of Query if the
+// document is well formed, and otherwise a *MalformedDocumentError describing the first problem.
+// It also returns the *CyclicDocumentError a query fails with under config.CycleError, and recovers
+// from any panic during evaluation, returning it as an error.
+func (p *JSONPath) TryQuery(root *yaml.Node) (result []*yaml.Node, err error) {
+	if err := ValidateDocument(root); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("jsonpath: query %q failed: %v", p.String(), r)
+		}
+	}()
+	result, err = p.ast.tryQuery(root, root, p.config)
+	return copyResults(p.config, result), err
+}
+
+// wellFormed returns value, or if value is a collection holding nil nodes or a key without a value, a
+// copy of it without them. The copy holds the document's own nodes.
+func wellFormed(value *yaml.Node) *yaml.Node {
+	if !malformedCollection(value) {
+		return value
+	}
+	repaired := *value
+	repaired.Content = make([]*yaml.Node, 0, len(value.Content))
+	if value.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			if value.Content[i] != nil && value.Content[i+1] != nil {
+				repaired.Content = append(repaired.Content, value.Content[i], value.Content[i+1])
+			}
+		}
+		return &repaired
+	}
+	for _, child := range value.Content {
+		if child != nil {
+			repaired.Content = append(repaired.Content, child)
+		}
+	}
+	return &repaired
+}
+
+func malformedCollection(value *yaml.Node) bool {
+	if value.Kind == yaml.MappingNode && len(value.Content)%2 != 0 {
+		return true
+	}
+	for _, child := range value.Content {
+		if child == nil {
+			return true
+		}
+	}
+	return false
+}
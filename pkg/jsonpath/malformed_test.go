@@ -0,0 +1,153 @@
+package jsonpath
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func mapping(content ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: content}
+}
+
+func sequence(content ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: content}
+}
+
+func TestMalformedDocuments(t *testing.T) {
+	requirePlus(t)
+	tests := []struct {
+		name   string
+		doc    func() *yaml.Node
+		query  string
+		values []string
+		path   string
+	}{
+		{
+			name:   "nil root",
+			doc:    func() *yaml.Node { return nil },
+			query:  `$..*`,
+			values: nil,
+			path:   "$",
+		},
+		{
+			name:   "document without content",
+			doc:    func() *yaml.Node { return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{nil}} },
+			query:  `$.a`,
+			values: nil,
+			path:   "$",
+		},
+		{
+			name:   "key without a value",
+			doc:    func() *yaml.Node { return mapping(scalar("a"), scalar("1"), scalar("b")) },
+			query:  `$.*`,
+			values: []string{"1"},
+			path:   "$",
+		},
+		{
+			name:   "nil value",
+			doc:    func() *yaml.Node { return mapping(scalar("a"), nil, scalar("b"), scalar("2")) },
+			query:  `$[?@ == '2']`,
+			values: []string{"2"},
+			path:   "$['a']",
+		},
+		{
+			name:   "nil key",
+			doc:    func() *yaml.Node { return mapping(nil, scalar("1"), scalar("b"), scalar("2")) },
+			query:  `$..*`,
+			values: []string{"2"},
+			path:   "$",
+		},
+		{
+			name: "nil sequence element",
+			doc: func() *yaml.Node {
+				return mapping(scalar("items"), sequence(nil, mapping(scalar("name"), scalar("x")), mapping(scalar("name"))))
+			},
+			query:  `$.items[?@.name].name`,
+			values: []string{"x"},
+			path:   "$['items'][0]",
+		},
+		{
+			name:   "union",
+			doc:    func() *yaml.Node { return mapping(scalar("a"), nil, scalar("b"), scalar("c")) },
+			query:  `$.a | $.b`,
+			values: []string{"c"},
+			path:   "$['a']",
+		},
+		{
+			name: "malformed set and ordered map",
+			doc: func() *yaml.Node {
+				set := mapping(scalar("x"))
+				set.Tag = "!!set"
+				omap := sequence(nil, mapping(scalar("k")), mapping(scalar("k"), scalar("v")))
+				omap.Tag = "!!omap"
+				return mapping(scalar("set"), set, scalar("omap"), omap)
+			},
+			query:  `$.omap[*].k`,
+			values: []string{"v"},
+			path:   "$['set']",
+		},
+	}
+
+	queries := []string{
+		`$..*`, `$..[?@]`, `$.*[0]`, `$..[-1]`, `$..[::-1]`, `$..[?length(@) > 0]`, `$..[?count(@.*) > 0]`,
+		`$..[?@.name == 'x']`, `$..[?match(@property, 'a')]`, `$..[?@property == 'a']`, `$..[?@path != '']`, `$..*^`,
+		`$..[?@parent.name == 'x']`, `$..[?@sibling('name') == 'x']`, `$..[?@sibling(-1) == 'x']`, `$..[?value(@..name) == 'x']`,
+		`$.a | $.b`, `$..* | $..[0]`,
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := MustNewPath(test.query)
+			var values []string
+			for _, node := range path.Query(test.doc()) {
+				values = append(values, node.Value)
+			}
+			assert.Equal(t, test.values, values)
+
+			for _, query := range queries {
+				p := MustNewPath(query)
+				assert.NotPanics(t, func() { p.Query(test.doc()) }, query)
+				assert.NotPanics(t, func() { p.QueryResults(test.doc()) }, query)
+				assert.NotPanics(t, func() { p.QueryMatches(test.doc()) }, query)
+				assert.NotPanics(t, func() { p.QueryWithDiagnostics(test.doc()) }, query)
+				assert.NotPanics(t, func() { p.QueryDependencies(test.doc()) }, query)
+				assert.NotPanics(t, func() { NewQueryCache(test.doc()).Query(p) }, query)
+			}
+
+			err := ValidateDocument(test.doc())
+			require.ErrorIs(t, err, ErrMalformedDocument)
+			var malformed *MalformedDocumentError
+			require.True(t, errors.As(err, &malformed))
+			assert.Equal(t, test.path, malformed.Path)
+
+			_, err = path.TryQuery(test.doc())
+			assert.ErrorIs(t, err, ErrMalformedDocument)
+
+			_, err = NewEngine().Query(context.Background(), test.query, test.doc())
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestTryQuery(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("a: [1, 2]\nb: {c: 3}\n"), &root))
+	require.NoError(t, ValidateDocument(&root))
+
+	result, err := MustNewPath(`$..*`).TryQuery(&root)
+	require.NoError(t, err)
+	assert.Len(t, result, 5)
+
+	root.Content[0].Content[3].Content = append(root.Content[0].Content[3].Content, scalar("d"))
+	_, err = MustNewPath(`$.a`).TryQuery(&root)
+	assert.EqualError(t, err, "jsonpath: malformed document at $['b']: mapping has 3 keys and values")
+}
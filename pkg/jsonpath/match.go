@@ -0,0 +1,147 @@
+package jsonpath
+
+import (
+	"sort"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// Match is a query result with details of how the query reached it, for analysing which parts of
+// an expression do the work when unions or filters overlap.
+type Match struct {
+	Result
+	// Depth is the number of mappings and sequences enclosing the node, so the document root has
+	// depth 0 and its members depth 1. A mapping key has the same depth as its value.
+	Depth int
+	// Selectors holds, for each segment of the query, the indices of the selectors in that segment
+	// that selected the node or the ancestor it was reached through. A node reached in more than one
+	// way lists every selector involved. Segments without selectors, such as .name, .* and ^,
+	// report index 0.
+	Selectors [][]int
+}
+
+// QueryMatches runs the query against root and returns the same nodes as Query, in the same order,
+// with their normalized path, position, depth and the selectors that produced them.
+func (p *JSONPath) QueryMatches(root *yaml.Node) []Match {
+	results := p.queryResults(root)
+
+	doc := root
+	if doc != nil && doc.Kind == yaml.DocumentNode && len(doc.Content) == 1 {
+		doc = doc.Content[0]
+	}
+	if doc == nil {
+		return nil
+	}
+	depths := make(map[*yaml.Node]int)
+	indexDepths(depths, doc, 0)
+
+	selectors := p.traceSelectors(doc)
+	copier := newResultCopier(p.config)
+	matches := make([]Match, len(results))
+	for i, result := range results {
+		matches[i] = Match{Result: result, Depth: depths[result.Node], Selectors: selectors[result.Node]}
+		matches[i].Node = copier.node(result.Node)
+	}
+	return matches
+}
+
+// traceSelectors evaluates the query one selector at a time, recording for every node it reaches
+// the selectors of each segment that led to it. A node reached by several queries of a union has
+// the selectors of the first of them.
+func (p *JSONPath) traceSelectors(root *yaml.Node) map[*yaml.Node][][]int {
+	traces := make(map[*yaml.Node][][]int)
+	for _, query := range p.ast.queries() {
+		for node, trace := range p.traceQuery(query, root) {
+			if _, seen := traces[node]; !seen {
+				traces[node] = trace
+			}
+		}
+	}
+	return traces
+}
+
+// traceQuery is traceSelectors for one query of a union
+func (p *JSONPath) traceQuery(query jsonPathAST, root *yaml.Node) map[*yaml.Node][][]int {
+	ctx := newFilterContext(root, p.config)
+	if query.hasParentReferences() {
+		ctx.EnableParentTracking()
+	}
+
+	traces := map[*yaml.Node][][]int{root: {}}
+	current := []*yaml.Node{root}
+	for depth, seg := range query.segments {
+		next := make(map[*yaml.Node][][]int)
+		var order []*yaml.Node
+		for _, value := range current {
+			for i, single := range seg.perSelector() {
+				for _, node := range single.Query(ctx, value, root) {
+					trace, seen := next[node]
+					if !seen {
+						order = append(order, node)
+						trace = make([][]int, depth+1)
+						for d := range traces[value] {
+							trace[d] = append([]int(nil), traces[value][d]...)
+						}
+					} else {
+						for d := range traces[value] {
+							trace[d] = mergeIndices(trace[d], traces[value][d]...)
+						}
+					}
+					trace[depth] = mergeIndices(trace[depth], i)
+					next[node] = trace
+				}
+			}
+		}
+		traces, current = next, order
+	}
+	return traces
+}
+
+// perSelector splits a segment into segments of one selector each, in selector order
+func (s *segment) perSelector() []*segment {
+	inner := s.child
+	if s.kind == segmentKindDescendant {
+		inner = s.descendant
+	}
+	if inner == nil || inner.kind != segmentLongHand || len(inner.selectors) < 2 {
+		return []*segment{s}
+	}
+	split := make([]*segment, len(inner.selectors))
+	for i, sel := range inner.selectors {
+		single := &innerSegment{kind: segmentLongHand, selectors: []*selector{sel}}
+		split[i] = &segment{kind: s.kind}
+		if s.kind == segmentKindDescendant {
+			split[i].descendant = single
+		} else {
+			split[i].child = single
+		}
+	}
+	return split
+}
+
+// mergeIndices adds indices to the sorted set a
+func mergeIndices(a []int, indices ...int) []int {
+	for _, i := range indices {
+		at := sort.SearchInts(a, i)
+		if at < len(a) && a[at] == i {
+			continue
+		}
+		a = append(a, 0)
+		copy(a[at+1:], a[at:])
+		a[at] = i
+	}
+	return a
+}
+
+func indexDepths(depths map[*yaml.Node]int, node *yaml.Node, depth int) {
+	if node == nil {
+		return
+	}
+	if _, seen := depths[node]; seen {
+		return
+	}
+	depths[node] = depth
+	for _, child := range node.Content {
+		indexDepths(depths, child, depth+1)
+	}
+}
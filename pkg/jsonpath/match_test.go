@@ -0,0 +1,82 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const matchDoc = `
+items:
+  - {name: a, price: 5, tags: [sale]}
+  - {name: b, price: 15}
+  - {name: c, price: 25, tags: [sale]}
+`
+
+func TestQueryMatches(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(matchDoc), &node))
+
+	tests := []struct {
+		name      string
+		input     string
+		opts      []config.Option
+		paths     []string
+		depths    []int
+		selectors [][][]int
+	}{
+		{
+			name:      "Overlapping union",
+			input:     `$.items[0, ?@.tags, 1]`,
+			paths:     []string{"$['items'][0]", "$['items'][0]", "$['items'][2]", "$['items'][1]"},
+			depths:    []int{2, 2, 2, 2},
+			selectors: [][][]int{{{0}, {0, 1}}, {{0}, {0, 1}}, {{0}, {1}}, {{0}, {2}}},
+		},
+		{
+			name:      "Deduplicated union",
+			input:     `$.items[0, ?@.tags, 1]`,
+			opts:      []config.Option{config.WithDeduplicatedResults()},
+			paths:     []string{"$['items'][0]", "$['items'][2]", "$['items'][1]"},
+			depths:    []int{2, 2, 2},
+			selectors: [][][]int{{{0}, {0, 1}}, {{0}, {1}}, {{0}, {2}}},
+		},
+		{
+			name:      "Selectors of ancestors",
+			input:     `$.items[?@.price < 10, ?@.price > 20]['name', 'price']`,
+			paths:     []string{"$['items'][0]['name']", "$['items'][0]['price']", "$['items'][2]['name']", "$['items'][2]['price']"},
+			depths:    []int{3, 3, 3, 3},
+			selectors: [][][]int{{{0}, {0}, {0}}, {{0}, {0}, {1}}, {{0}, {1}, {0}}, {{0}, {1}, {1}}},
+		},
+		{
+			name:      "Descendants",
+			input:     `$..['tags', 'name'][0]`,
+			paths:     []string{"$['items'][0]['tags'][0]", "$['items'][2]['tags'][0]"},
+			depths:    []int{4, 4},
+			selectors: [][][]int{{{0}, {0}}, {{0}, {0}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path, err := NewPath(test.input, test.opts...)
+			require.NoError(t, err)
+			matches := path.QueryMatches(&node)
+			require.Len(t, matches, len(path.Query(&node)))
+
+			var paths []string
+			var depths []int
+			var selectors [][][]int
+			for _, m := range matches {
+				paths = append(paths, m.Path)
+				depths = append(depths, m.Depth)
+				selectors = append(selectors, m.Selectors)
+			}
+			assert.Equal(t, test.paths, paths)
+			assert.Equal(t, test.depths, depths)
+			assert.Equal(t, test.selectors, selectors)
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package jsonpath
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// DefaultLatencyBuckets are the histogram bucket upper bounds used by NewMetrics when none are given.
+var DefaultLatencyBuckets = []time.Duration{
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// Metrics collects per-expression latency and result-count statistics across many queries,
+// typically a batch run of a rule set over a corpus of documents. It is safe for concurrent use.
+type Metrics struct {
+	mu      sync.Mutex
+	buckets []time.Duration
+	stats   map[string]*ExpressionStats
+}
+
+// HistogramBucket counts the observations whose latency was at most UpperBound.
+// The final bucket of a histogram has an UpperBound of zero and counts everything slower
+// than the largest configured bound.
+type HistogramBucket struct {
+	UpperBound time.Duration
+	Count      int
+}
+
+// ExpressionStats is the aggregated timing information for a single expression.
+type ExpressionStats struct {
+	Expression string
+	Count      int
+	Results    int
+	Total      time.Duration
+	Min        time.Duration
+	Max        time.Duration
+	Histogram  []HistogramBucket
+	// Segments is the cost of each segment of the expression, summed over the evaluations made
+	// with Trace, or nil if there were none. The segments of a union follow each other in order.
+	Segments []SegmentStats
+}
+
+// SegmentStats is the cost of one segment of an expression.
+type SegmentStats struct {
+	// Segment is the text of the segment, such as .paths or ..*.
+	Segment string
+	Total   time.Duration
+	// Inputs is the number of nodes the segment was applied to, and Visits the number of nodes it
+	// examined: its inputs, and for a descendant segment every node below them too, as well as the
+	// nodes descendant segments in its filters examined.
+	Inputs  int
+	Visits  int
+	Results int
+}
+
+// Share returns the fraction, from 0 to 1, of the time spent in the segments of the expression
+// that segment i took.
+func (s ExpressionStats) Share(i int) float64 {
+	var total time.Duration
+	for _, segment := range s.Segments {
+		total += segment.Total
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Segments[i].Total) / float64(total)
+}
+
+// Mean returns the mean latency of the expression.
+func (s ExpressionStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// NewMetrics creates a collector with the given histogram bucket upper bounds,
+// or DefaultLatencyBuckets if none are supplied.
+func NewMetrics(buckets ...time.Duration) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	sorted := make([]time.Duration, len(buckets))
+	copy(sorted, buckets)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return &Metrics{
+		buckets: sorted,
+		stats:   make(map[string]*ExpressionStats),
+	}
+}
+
+// Query runs the path against root, recording its latency and the number of results.
+func (m *Metrics) Query(p *JSONPath, root *yaml.Node) []*yaml.Node {
+	start := time.Now()
+	result := p.Query(root)
+	m.Observe(p.String(), time.Since(start), len(result))
+	return result
+}
+
+// Trace runs the path against root like Query, additionally timing each of its segments, so the
+// report shows which segments of the expression its evaluation time is spent in. Timing has a cost,
+// so Query does not do it.
+func (m *Metrics) Trace(p *JSONPath, root *yaml.Node) []*yaml.Node {
+	start := time.Now()
+	result, segments := p.QueryProfile(root)
+	elapsed := time.Since(start)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.observe(p.String(), elapsed, len(result))
+	if stats.Segments == nil {
+		stats.Segments = segments
+		return result
+	}
+	for i := range segments {
+		if i < len(stats.Segments) {
+			stats.Segments[i].Total += segments[i].Total
+			stats.Segments[i].Inputs += segments[i].Inputs
+			stats.Segments[i].Visits += segments[i].Visits
+			stats.Segments[i].Results += segments[i].Results
+		}
+	}
+	return result
+}
+
+// Observe records a single evaluation of expr.
+func (m *Metrics) Observe(expr string, elapsed time.Duration, results int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observe(expr, elapsed, results)
+}
+
+// observe records a single evaluation of expr with m locked, and returns its statistics
+func (m *Metrics) observe(expr string, elapsed time.Duration, results int) *ExpressionStats {
+	stats, ok := m.stats[expr]
+	if !ok {
+		stats = &ExpressionStats{
+			Expression: expr,
+			Min:        elapsed,
+			Histogram:  make([]HistogramBucket, len(m.buckets)+1),
+		}
+		for i, bound := range m.buckets {
+			stats.Histogram[i].UpperBound = bound
+		}
+		m.stats[expr] = stats
+	}
+	stats.Count++
+	stats.Results += results
+	stats.Total += elapsed
+	stats.Min = min(stats.Min, elapsed)
+	stats.Max = max(stats.Max, elapsed)
+
+	bucket := len(m.buckets)
+	for i, bound := range m.buckets {
+		if elapsed <= bound {
+			bucket = i
+			break
+		}
+	}
+	stats.Histogram[bucket].Count++
+	return stats
+}
+
+// Report returns a snapshot of the collected statistics, slowest (by total time) first.
+func (m *Metrics) Report() []ExpressionStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report := make([]ExpressionStats, 0, len(m.stats))
+	for _, stats := range m.stats {
+		snapshot := *stats
+		snapshot.Histogram = append([]HistogramBucket(nil), stats.Histogram...)
+		snapshot.Segments = append([]SegmentStats(nil), stats.Segments...)
+		report = append(report, snapshot)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Total != report[j].Total {
+			return report[i].Total > report[j].Total
+		}
+		return report[i].Expression < report[j].Expression
+	})
+	return report
+}
+
+// Reset discards all collected statistics.
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = make(map[string]*ExpressionStats)
+}
+
+// WriteReport writes the report as an aligned text table. The segments of traced expressions are
+// listed below them, indented, with the results they produced and their share of the time.
+func (m *Metrics) WriteReport(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "EXPRESSION\tCOUNT\tRESULTS\tTOTAL\tMEAN\tMIN\tMAX")
+	for _, stats := range m.Report() {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\n",
+			stats.Expression, stats.Count, stats.Results, stats.Total, stats.Mean(), stats.Min, stats.Max)
+		for i, segment := range stats.Segments {
+			fmt.Fprintf(tw, "  %s\t\t%d\t%s\t%.1f%%\t\t\n", segment.Segment, segment.Results, segment.Total, 100*stats.Share(i))
+		}
+	}
+	return tw.Flush()
+}
+
+// QueryProfile runs the query against root like Query, additionally returning the cost of each of
+// its segments for this evaluation.
+func (p *JSONPath) QueryProfile(root *yaml.Node) ([]*yaml.Node, []SegmentStats) {
+	node := root
+	if node != nil && node.Kind == yaml.DocumentNode && len(node.Content) == 1 {
+		node = node.Content[0]
+	}
+	if node == nil {
+		return nil, nil
+	}
+	ctx := newFilterContext(node, p.config)
+	ctx.profile = &queryProfile{}
+	result := p.ast.evaluate(ctx, node)
+	return copyResults(p.config, result), ctx.profile.segments
+}
+
+// queryProfile records the cost of the segments of a query as it is evaluated
+type queryProfile struct {
+	segments []SegmentStats
+}
+
+// begin starts timing a segment applied to inputs nodes
+func (q *queryProfile) begin(seg *segment, inputs int) time.Time {
+	q.segments = append(q.segments, SegmentStats{Segment: seg.ToString(), Inputs: inputs, Visits: inputs})
+	return time.Now()
+}
+
+// visit records that the segment being timed examined n more nodes
+func (q *queryProfile) visit(n int) {
+	if len(q.segments) > 0 {
+		q.segments[len(q.segments)-1].Visits += n
+	}
+}
+
+// end stops timing the segment, which produced results nodes
+func (q *queryProfile) end(start time.Time, results int) {
+	current := &q.segments[len(q.segments)-1]
+	current.Total = time.Since(start)
+	current.Results = results
+}
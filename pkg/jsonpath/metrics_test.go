@@ -0,0 +1,107 @@
+package jsonpath
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics(time.Millisecond, time.Microsecond)
+
+	metrics.Observe("$.a", 500*time.Nanosecond, 1)
+	metrics.Observe("$.a", 2*time.Millisecond, 3)
+	metrics.Observe("$.b", 100*time.Microsecond, 0)
+
+	report := metrics.Report()
+	require.Len(t, report, 2)
+
+	a := report[0]
+	assert.Equal(t, "$.a", a.Expression)
+	assert.Equal(t, 2, a.Count)
+	assert.Equal(t, 4, a.Results)
+	assert.Equal(t, 500*time.Nanosecond, a.Min)
+	assert.Equal(t, 2*time.Millisecond, a.Max)
+	assert.Equal(t, (2*time.Millisecond+500*time.Nanosecond)/2, a.Mean())
+	assert.Equal(t, []HistogramBucket{
+		{UpperBound: time.Microsecond, Count: 1},
+		{UpperBound: time.Millisecond, Count: 0},
+		{UpperBound: 0, Count: 1},
+	}, a.Histogram)
+
+	b := report[1]
+	assert.Equal(t, "$.b", b.Expression)
+	assert.Equal(t, 1, b.Histogram[1].Count)
+
+	var buf bytes.Buffer
+	require.NoError(t, metrics.WriteReport(&buf))
+	assert.Contains(t, buf.String(), "EXPRESSION")
+	assert.Contains(t, buf.String(), "$.a")
+
+	metrics.Reset()
+	assert.Empty(t, metrics.Report())
+}
+
+func TestMetricsQuery(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`{"a": [1, 2, 3]}`), &node))
+	path, err := NewPath("$.a[*]")
+	require.NoError(t, err)
+
+	metrics := NewMetrics()
+	for i := 0; i < 3; i++ {
+		assert.Len(t, metrics.Query(path, &node), 3)
+	}
+	report := metrics.Report()
+	require.Len(t, report, 1)
+	assert.Equal(t, "$.a[*]", report[0].Expression)
+	assert.Equal(t, 3, report[0].Count)
+	assert.Equal(t, 9, report[0].Results)
+}
+
+func TestMetricsTrace(t *testing.T) {
+	requirePlus(t)
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`{"paths": {"/a": {"get": {"x": 1}}, "/b": {"get": {}}}, "info": {"x": 2}}`), &node))
+	path, err := NewPath("$.paths..[?@.x].x")
+	require.NoError(t, err)
+
+	nodes, segments := path.QueryProfile(&node)
+	assert.Len(t, nodes, 1)
+	require.Len(t, segments, 3)
+	assert.Equal(t, []string{".paths", "..[?@.x]", ".x"}, []string{segments[0].Segment, segments[1].Segment, segments[2].Segment})
+	assert.Equal(t, SegmentStats{Segment: ".paths", Total: segments[0].Total, Inputs: 1, Visits: 1, Results: 1}, segments[0])
+	// the descendant segment examines paths and the ten keys and values below it
+	assert.Equal(t, 1, segments[1].Inputs)
+	assert.Equal(t, 11, segments[1].Visits)
+	assert.Equal(t, 1, segments[1].Results)
+
+	metrics := NewMetrics()
+	for i := 0; i < 2; i++ {
+		assert.Len(t, metrics.Trace(path, &node), 1)
+	}
+	metrics.Query(path, &node)
+	report := metrics.Report()
+	require.Len(t, report, 1)
+	assert.Equal(t, 3, report[0].Count)
+	require.Len(t, report[0].Segments, 3)
+	assert.Equal(t, 22, report[0].Segments[1].Visits)
+	var share float64
+	for i := range report[0].Segments {
+		share += report[0].Share(i)
+	}
+	assert.InDelta(t, 1, share, 1e-9)
+
+	var buf bytes.Buffer
+	require.NoError(t, metrics.WriteReport(&buf))
+	assert.Contains(t, buf.String(), "  ..[?@.x]")
+
+	// the segments of each query of a union follow each other
+	_, segments = MustNewPath("$.info | $.paths.*").QueryProfile(&node)
+	require.Len(t, segments, 3)
+	assert.Equal(t, 2, segments[2].Results)
+}
@@ -0,0 +1,84 @@
+package jsonpath
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"go.yaml.in/yaml/v4"
+)
+
+// parallelizes reports whether filter is tested against the elements of seq in parallel: the config
+// asks for it, seq is long enough to be worth it, and the evaluation needs none of the state that
+// filter contexts share as they go, which @parent and dependency recording do
+func (fc *filterContext) parallelizes(filter *filterSelector, seq *yaml.Node) bool {
+	return fc.config.Parallelism() > 1 && len(seq.Content) >= config.MinParallelElements &&
+		fc.dependencies == nil && !filter.hasParentReferences()
+}
+
+// filterParallel tests filter against the elements of seq in chunks, one goroutine to a chunk, and
+// returns the elements that matched in the order of seq. Each goroutine evaluates with a worker
+// context of its own, and the first failure of a worker, in the order of their chunks, fails the query.
+// Diagnostics of type errors are buffered by each worker, and collected in the order of the chunks.
+func (fc *filterContext) filterParallel(filter *filterSelector, seq *yaml.Node, root *yaml.Node, parentPropName string) []*yaml.Node {
+	if fc.parentTrackingActive {
+		for _, child := range seq.Content {
+			fc.setParentNode(child, seq)
+		}
+	}
+
+	matched := make([]bool, len(seq.Content))
+	size := (len(seq.Content) + fc.config.Parallelism() - 1) / fc.config.Parallelism()
+	chunks := (len(seq.Content) + size - 1) / size
+	workers := make([]*filterContext, 0, chunks)
+	diagnostics := make([][]Diagnostic, chunks)
+	var wg sync.WaitGroup
+	for start := 0; start < len(seq.Content); start += size {
+		end := min(start+size, len(seq.Content))
+		worker := fc.worker()
+		worker.config = bufferDiagnostics(fc.config, &diagnostics[len(workers)])
+		workers = append(workers, worker)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := start; i < end && !worker.interrupted(); i++ {
+				worker.SetParentPropertyName(parentPropName)
+				worker.SetPropertyName(strconv.Itoa(i))
+				worker.SetParent(seq)
+				worker.SetIndex(i)
+				worker.PushPathSegment(normalizeIndexSegment(i))
+				matched[i] = filter.Matches(worker, seq.Content[i], root)
+				worker.PopPathSegment()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, buffer := range diagnostics {
+		flushDiagnostics(fc.config, buffer)
+	}
+	for _, worker := range workers {
+		if err := worker.err(); err != nil {
+			fc.fail(err)
+			return nil
+		}
+	}
+	var result []*yaml.Node
+	for i, child := range seq.Content {
+		if matched[i] {
+			result = append(result, child)
+		}
+	}
+	return result
+}
+
+// worker returns a context for evaluating a filter on another goroutine, describing the same place
+// in the document as fc but sharing none of the state evaluation updates
+func (fc *filterContext) worker() *filterContext {
+	worker := newFilterContext(fc.root, fc.config)
+	worker.pathSegments = append(worker.pathSegments, fc.pathSegments...)
+	worker.parentTrackingActive = fc.parentTrackingActive
+	worker.pathTrackingActive = fc.pathTrackingActive
+	worker.done = fc.done
+	return worker
+}
@@ -0,0 +1,88 @@
+package jsonpath
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestParallelFilters(t *testing.T) {
+	requirePlus(t)
+	var b strings.Builder
+	b.WriteString("limit: 3\nitems:\n")
+	for i := 0; i < config.MinParallelElements*2+5; i++ {
+		fmt.Fprintf(&b, "  - {id: %d, tags: [t%d, t%d]}\n", i, i%5, i%3)
+	}
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(b.String()), &root))
+
+	queries := []string{
+		`$.items[?@.id % 1000 == 7].id`,
+		`$.items[?@.tags[?@ == 't4'] && @.id > 8000]`,
+		`$.items[?@index % 999 == 0 && @path != "$['items'][0]"]`,
+		`$.items[?@parentProperty == 'items' && @.id < $.limit]^`,
+		`$.items[?@.id < 2 || @parent.limit == 9]`,
+		`$..items[?@.id > 8190]~`,
+		`$.items[?@.missing]`,
+	}
+	for _, query := range queries {
+		t.Run(query, func(t *testing.T) {
+			sequential, err := NewPath(query)
+			require.NoError(t, err)
+			parallel, err := NewPath(query, config.WithParallelism(4))
+			require.NoError(t, err)
+
+			expected := sequential.QueryResults(&root)
+			actual := parallel.QueryResults(&root)
+			require.Equal(t, len(expected), len(actual))
+			for i := range expected {
+				assert.Same(t, expected[i].Node, actual[i].Node)
+			}
+		})
+	}
+
+	// the failure of a worker fails the query
+	var cyclic yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("a: &a {b: *a}"), &cyclic))
+	items := &yaml.Node{Kind: yaml.SequenceNode}
+	for i := 0; i < config.MinParallelElements; i++ {
+		items.Content = append(items.Content, cyclic.Content[0])
+	}
+	path, err := NewPath(`$[?@..b]`, config.WithParallelism(4), config.WithAliasExpansion(), config.WithCycleHandling(config.CycleError))
+	require.NoError(t, err)
+	_, err = path.TryQuery(items)
+	assert.ErrorIs(t, err, ErrCyclicDocument)
+}
+
+func TestParallelDiagnostics(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < config.MinParallelElements+10; i++ {
+		if i%2 == 0 {
+			fmt.Fprintf(&b, "- {a: {n: %d}}\n", i)
+		} else {
+			fmt.Fprintf(&b, "- {a: %d}\n", i)
+		}
+	}
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(b.String()), &root))
+
+	sequential, err := NewPath(`$[?@.a > 1]`)
+	require.NoError(t, err)
+	parallel, err := NewPath(`$[?@.a > 1]`, config.WithParallelism(8))
+	require.NoError(t, err)
+
+	expected, expectedDiagnostics := sequential.QueryWithDiagnostics(&root)
+	actual, actualDiagnostics := parallel.QueryWithDiagnostics(&root)
+	assert.Equal(t, expected, actual)
+	require.Len(t, actualDiagnostics, (config.MinParallelElements+10)/2)
+	// diagnostics are in evaluation order, as they are without parallelism
+	for i := range expectedDiagnostics {
+		assert.Equal(t, expectedDiagnostics[i].Path, actualDiagnostics[i].Path)
+	}
+	assert.Equal(t, "$[0]", actualDiagnostics[0].Path)
+}
@@ -26,6 +26,13 @@ var contextVarTokenMap = map[token.Token]contextVarKind{
     token.CONTEXT_PARENT_PROPERTY: contextVarParentProperty,
     token.CONTEXT_PATH:            contextVarPath,
     token.CONTEXT_INDEX:           contextVarIndex,
+    token.CONTEXT_PROPERTY_PATH:   contextVarPropertyPath,
+    token.CONTEXT_PATH_KEYS:       contextVarPathKeys,
+    token.CONTEXT_SIBLING:         contextVarSibling,
+    token.CONTEXT_PREV:            contextVarPrev,
+    token.CONTEXT_NEXT:            contextVarNext,
+    token.CONTEXT_LINE:            contextVarLine,
+    token.CONTEXT_COLUMN:          contextVarColumn,
 }
 
 // JSONPath represents a JSONPath parser.
@@ -36,11 +43,21 @@ type JSONPath struct {
     current   int
     mode      []mode
     config    config.Config
+    warnings  []string
+    // selectorSyntaxes are the custom selector syntaxes registered when the path was tokenized
+    selectorSyntaxes map[string]selectorSyntax
 }
 
 // newParserPrivate creates a new JSONPath with the given tokens.
 func newParserPrivate(tokenizer *token.Tokenizer, tokens []token.TokenInfo, opts ...config.Option) *JSONPath {
-    return &JSONPath{tokenizer, tokens, jsonPathAST{}, 0, []mode{modeNormal}, config.New(opts...)}
+    return &JSONPath{
+        tokenizer: tokenizer,
+        tokens:    tokens,
+        ast:       jsonPathAST{},
+        current:   0,
+        mode:      []mode{modeNormal},
+        config:    config.New(opts...),
+    }
 }
 
 // parse parses the JSONPath tokens and returns the root node of the AST.
@@ -56,16 +73,46 @@ func (p *JSONPath) parse() error {
     }
     p.current++
 
+    query := &p.ast
     for p.current < len(p.tokens) {
+        if p.tokens[p.current].Token == token.PIPE {
+            // JSONPath Plus: a union of complete queries, as in $.info.title | $.paths..operationId
+            p.current++
+            if p.current == len(p.tokens) || p.tokens[p.current].Token != token.ROOT {
+                return p.parseFailure(&p.tokens[p.current-1], "expected '$' after '|'")
+            }
+            p.current++
+            p.ast.union = append(p.ast.union, jsonPathAST{})
+            query = &p.ast.union[len(p.ast.union)-1]
+            continue
+        }
         segment, err := p.parseSegment()
         if err != nil {
+            if p.current < len(p.tokens) && !p.isSegmentStart(p.tokens[p.current].Token) {
+                return p.parseFailure(&p.tokens[p.current], "unexpected trailing characters after valid path")
+            }
             return err
         }
-        p.ast.segments = append(p.ast.segments, segment)
+        query.segments = append(query.segments, segment)
     }
     return nil
 }
 
+// isSegmentStart returns true if the given token can begin a segment.
+func (p *JSONPath) isSegmentStart(tok token.Token) bool {
+    switch tok {
+    case token.CHILD, token.RECURSIVE, token.BRACKET_LEFT:
+        return true
+    case token.PIPE:
+        return plusEnabled(p.config)
+    case token.PROPERTY_NAME:
+        return p.config.PropertyNameEnabled()
+    case token.PARENT_SELECTOR, token.TYPE_SELECTOR:
+        return plusEnabled(p.config)
+    }
+    return false
+}
+
 func (p *JSONPath) parseFailure(target *token.TokenInfo, msg string) error {
     return errors.New(p.tokenizer.ErrorString(target, msg))
 }
@@ -80,6 +127,11 @@ func (p *JSONPath) next(token token.Token) bool {
     return p.current < len(p.tokens) && p.tokens[p.current].Token == token
 }
 
+// peekAt returns true if the token offset places after the current one matches the given token type.
+func (p *JSONPath) peekAt(offset int, token token.Token) bool {
+    return p.current+offset < len(p.tokens) && p.tokens[p.current+offset].Token == token
+}
+
 // expect consumes the current token if it matches the given token type.
 func (p *JSONPath) expect(token token.Token) bool {
     if p.peek(token) {
@@ -91,7 +143,7 @@ func (p *JSONPath) expect(token token.Token) bool {
 
 // isComparisonOperator returns true if the given token is a comparison operator.
 func (p *JSONPath) isComparisonOperator(tok token.Token) bool {
-    return tok == token.EQ || tok == token.NE || tok == token.GT || tok == token.GE || tok == token.LT || tok == token.LE
+    return tok == token.EQ || tok == token.NE || tok == token.GT || tok == token.GE || tok == token.LT || tok == token.LE || tok == token.MATCHES || tok == token.IN
 }
 
 func (p *JSONPath) parseSegment() (*segment, error) {
@@ -101,6 +153,10 @@ func (p *JSONPath) parseSegment() (*segment, error) {
             return nil, p.parseFailure(&p.tokens[p.current], "unexpected recursive descent in singular query")
         }
         p.current++
+        if p.current == len(p.tokens) && plusEnabled(p.config) {
+            // JSONPath Plus: a final .. selects the node and the mappings and sequences below it
+            return &segment{kind: segmentKindDescendant, descendant: &innerSegment{kind: segmentContainers}}, nil
+        }
         child, err := p.parseInnerSegment()
         if err != nil {
             return nil, err
@@ -118,14 +174,54 @@ func (p *JSONPath) parseSegment() (*segment, error) {
     } else if p.config.PropertyNameEnabled() && currentToken.Token == token.PROPERTY_NAME {
         p.current++
         return &segment{kind: segmentKindProperyName}, nil
-    } else if p.config.JSONPathPlusEnabled() && currentToken.Token == token.PARENT_SELECTOR {
+    } else if plusEnabled(p.config) && currentToken.Token == token.PARENT_SELECTOR {
         // JSONPath Plus parent selector: ^ returns parent of current node
+        return p.parseParentSegment()
+    } else if plusEnabled(p.config) && currentToken.Token == token.TYPE_SELECTOR {
+        // JSONPath Plus type selector: @number() keeps the nodes that are numbers
         p.current++
-        return &segment{kind: segmentKindParent}, nil
+        return &segment{kind: segmentKindTypeSelector, typeSelector: currentToken.Literal}, nil
     }
     return nil, p.parseFailure(&currentToken, "unexpected token when parsing segment")
 }
 
+// parseParentSegment parses a parent selector: ^ for the parent, ^2 for the grandparent, and
+// ^('get', 'post') for the nearest ancestor that is the value of a member with one of the names.
+// A bracket after the caret is a child segment of the parent, as in ^['get'].
+func (p *JSONPath) parseParentSegment() (*segment, error) {
+    caret := p.tokens[p.current]
+    p.current++
+    seg := &segment{kind: segmentKindParent}
+    if caret.Literal != "" {
+        levels, err := strconv.Atoi(caret.Literal)
+        if err != nil || levels < 1 || caret.Literal[0] == '0' {
+            return nil, p.parseFailure(&caret, "parent selector levels must be a positive integer")
+        }
+        seg.parentLevels = levels
+        return seg, nil
+    }
+    if !p.next(token.PAREN_LEFT) {
+        return seg, nil
+    }
+    p.current++
+    for {
+        if !p.next(token.STRING_LITERAL) {
+            return nil, p.parseFailure(&caret, "keyed ascent ^(...) only accepts quoted member names")
+        }
+        seg.parentKeys = append(seg.parentKeys, p.tokens[p.current].Literal)
+        p.current++
+        switch {
+        case p.next(token.PAREN_RIGHT):
+            p.current++
+            return seg, nil
+        case p.next(token.COMMA):
+            p.current++
+        default:
+            return nil, p.parseFailure(&caret, "expected ',' or ')' in keyed ascent")
+        }
+    }
+}
+
 func (p *JSONPath) parseInnerSegment() (retValue *innerSegment, err error) {
     defer func() {
         if p.mode[len(p.mode)-1] == modeSingular && retValue != nil {
@@ -154,6 +250,13 @@ func (p *JSONPath) parseInnerSegment() (retValue *innerSegment, err error) {
         dotName := p.tokens[p.current].Literal
         p.current += 1
         return &innerSegment{segmentDotMemberName, dotName, nil}, nil
+    } else if firstToken.Token == token.STRING_LITERAL && p.config.LenientParsingEnabled() && p.current > 0 &&
+        (p.tokens[p.current-1].Token == token.CHILD || p.tokens[p.current-1].Token == token.RECURSIVE) {
+        // JSONPath-Plus quoted member shorthand: $.paths.'/users' is normalized to $.paths['/users']
+        name := firstToken.Literal
+        p.current += 1
+        p.warnings = append(p.warnings, fmt.Sprintf("quoted member name after '.' at column %d normalized to bracket notation", firstToken.Column))
+        return &innerSegment{kind: segmentLongHand, selectors: []*selector{{kind: selectorSubKindName, name: name}}}, nil
     } else if firstToken.Token == token.BRACKET_LEFT {
         prior := p.current
         p.current += 1
@@ -172,6 +275,10 @@ func (p *JSONPath) parseInnerSegment() (retValue *innerSegment, err error) {
                 break
             } else if p.tokens[p.current].Token == token.COMMA {
                 p.current++
+            } else {
+                err := p.parseFailure(&p.tokens[p.current], "expected ',' or ']'")
+                p.current = prior
+                return nil, err
             }
         }
         if p.tokens[p.current].Token != token.BRACKET_RIGHT {
@@ -199,12 +306,31 @@ func (p *JSONPath) parseSelector() (retSelector *selector, err error) {
             } else if retSelector.kind == selectorSubKindArraySlice {
                 err = p.parseFailure(&p.tokens[initial], "unexpected slice in singular query")
                 retSelector = nil
+            } else if retSelector.kind == selectorSubKindCustom {
+                err = p.parseFailure(&p.tokens[initial], "unexpected custom selector in singular query")
+                retSelector = nil
+            } else if retSelector.kind == selectorSubKindGlob || retSelector.kind == selectorSubKindKeyPattern {
+                err = p.parseFailure(&p.tokens[initial], "unexpected pattern in singular query")
+                retSelector = nil
             }
         }
     }()
 
     //    name-selector       = string-literal
     if p.tokens[p.current].Token == token.STRING_LITERAL {
+        name := p.tokens[p.current].Literal
+        p.current++
+        if p.config.GlobNamesEnabled() && isGlob(name) {
+            return &selector{kind: selectorSubKindGlob, name: name}, nil
+        }
+        if _, ok := quotedIndex(name); ok && p.config.LenientParsingEnabled() {
+            p.warnings = append(p.warnings, fmt.Sprintf("quoted index '%s' at column %d also selects the array element at that index", name, p.tokens[p.current-1].Column))
+        }
+        return &selector{kind: selectorSubKindName, name: name}, nil
+    } else if glob := p.parseUnquotedGlob(); glob != nil {
+        return glob, nil
+    } else if p.tokens[p.current].Token == token.STRING && plusEnabled(p.config) && (p.peek(token.BRACKET_RIGHT) || p.peek(token.COMMA)) {
+        // JSONPath Plus: an unquoted member name, as in $..book[0][category,author]
         name := p.tokens[p.current].Literal
         p.current++
         return &selector{kind: selectorSubKindName, name: name}, nil
@@ -252,11 +378,118 @@ func (p *JSONPath) parseSelector() (retSelector *selector, err error) {
         return &selector{kind: selectorSubKindArraySlice, slice: slice}, nil
     } else if p.tokens[p.current].Token == token.FILTER {
         return p.parseFilterSelector()
+    } else if p.tokens[p.current].Token == token.CUSTOM_SELECTOR {
+        raw := p.tokens[p.current]
+        custom, err := parseCustomSelector(p.selectorSyntaxes, raw.Literal)
+        if err != nil {
+            return nil, p.parseFailure(&raw, err.Error())
+        }
+        p.current++
+        p.warnings = append(p.warnings, fmt.Sprintf("custom selector %s at column %d parsed with a registered syntax", raw.Literal, raw.Column))
+        return custom, nil
+    } else if p.tokens[p.current].Token == token.PAREN_LEFT && plusEnabled(p.config) {
+        return p.parseScriptSelector()
+    } else if p.tokens[p.current].Token == token.REGEX {
+        // JSONPath Plus: the members whose names match a regular expression, as in $.paths[/^\/v1\//]
+        pattern, err := compileRegexLiteral(p.tokens[p.current].Literal)
+        if err != nil {
+            return nil, p.parseFailure(&p.tokens[p.current], err.Error())
+        }
+        p.current++
+        return &selector{kind: selectorSubKindKeyPattern, pattern: pattern}, nil
     }
 
     return nil, p.parseFailure(&p.tokens[p.current], "unexpected token when parsing selector")
 }
 
+// parseUnquotedGlob parses a glob pattern written without quotes, as in [*Error] or [User*], which
+// the tokenizer splits into adjacent names and wildcards. It returns nil, consuming nothing, unless
+// globs are enabled in JSONPath Plus mode and the tokens up to the next ']' or ',' form a pattern.
+func (p *JSONPath) parseUnquotedGlob() *selector {
+    if !p.config.GlobNamesEnabled() || !plusEnabled(p.config) {
+        return nil
+    }
+    var pattern strings.Builder
+    end := p.current
+    for ; end < len(p.tokens); end++ {
+        tok := p.tokens[end]
+        // a * after a name is tokenized as the multiplication operator
+        if tok.Token != token.STRING && tok.Token != token.WILDCARD && tok.Token != token.MULTIPLY {
+            break
+        }
+        if end > p.current && tok.Column != p.tokens[end-1].Column+p.tokens[end-1].Len {
+            return nil
+        }
+        if tok.Token != token.STRING {
+            pattern.WriteString("*")
+        } else {
+            pattern.WriteString(tok.Literal)
+        }
+    }
+    // a lone name or wildcard is parsed as such
+    if end-p.current < 2 || end == len(p.tokens) {
+        return nil
+    }
+    if next := p.tokens[end].Token; next != token.BRACKET_RIGHT && next != token.COMMA {
+        return nil
+    }
+    p.current = end
+    return &selector{kind: selectorSubKindGlob, name: pattern.String()}
+}
+
+// parseScriptSelector translates the Goessner script subscripts that legacy paths rely on into the
+// selectors they stand for, without evaluating any script as JavaScript:
+//
+//	(@.length-n)  the nth element from the end, as [-n]
+//	(n)           the element at index n, as [n]
+//	('name')      the member name, as ['name']
+//
+// Other arithmetic on integers and @.length, such as (@.length/2), is kept as a script selector that
+// computes the index from the length of each array it is applied to.
+func (p *JSONPath) parseScriptSelector() (*selector, error) {
+    open := p.tokens[p.current]
+    unsupported := func() error {
+        return p.parseFailure(&open, "unsupported script subscript: only ('name') and arithmetic on integers and @.length are supported, as scripts are never evaluated as JavaScript")
+    }
+    p.current++
+    var translated *selector
+    if p.peekAt(0, token.STRING_LITERAL) && p.peekAt(1, token.PAREN_RIGHT) {
+        translated = &selector{kind: selectorSubKindName, name: p.tokens[p.current].Literal}
+        p.current++
+    } else {
+        script, err := p.parseScript(unsupported)
+        if err != nil {
+            return nil, err
+        }
+        if !p.peekAt(0, token.PAREN_RIGHT) {
+            return nil, unsupported()
+        }
+        switch coefficient, constant, linear := script.linear(); {
+        case linear && (coefficient == 0 || coefficient == 1 && constant < 0):
+            // a constant index, or one counting from the end
+            if constant > MaxSafeFloat || constant < -MaxSafeFloat {
+                return nil, p.parseFailure(&open, "outside bounds for safe integers")
+            }
+            translated = &selector{kind: selectorSubKindArrayIndex, index: constant}
+        case linear && coefficient == 1:
+            return nil, p.parseFailure(&open, "script subscript always selects past the end of the array")
+        default:
+            translated = &selector{kind: selectorSubKindScript, script: script}
+        }
+    }
+    // skip the closing parenthesis
+    p.current++
+    if !p.peekAt(0, token.BRACKET_RIGHT) && !p.peekAt(0, token.COMMA) {
+        return nil, p.parseFailure(&p.tokens[p.current-1], "expected ']' or ','")
+    }
+    if translated.kind == selectorSubKindScript {
+        p.warnings = append(p.warnings, fmt.Sprintf("script subscript at column %d evaluated as arithmetic on the length of the array", open.Column))
+    } else {
+        p.warnings = append(p.warnings, fmt.Sprintf("script subscript at column %d translated to [%s]", open.Column, translated.ToString()))
+    }
+    return translated, nil
+}
+
 func (p *JSONPath) parseSliceSelector() (*slice, error) {
     // slice-selector = [start S] ":" S [end S] [":" [S step]]
     var start, end, step *int64
@@ -365,9 +598,36 @@ func (p *JSONPath) parseLogicalOrExpr() (*logicalOrExpr, error) {
         p.current++
     }
 
+    if plusEnabled(p.config) && p.next(token.FILTER) {
+        conditional, err := p.parseConditionalExpr()
+        if err != nil {
+            return nil, err
+        }
+        expr.conditional = conditional
+    }
+
     return &expr, nil
 }
 
+// parseConditionalExpr parses the branches following the condition of a conditional expression.
+// The branches extend as far as they can, so a ? b : c ? d : e reads as a ? b : (c ? d : e).
+func (p *JSONPath) parseConditionalExpr() (*conditionalExpr, error) {
+    p.current++
+    then, err := p.parseLogicalOrExpr()
+    if err != nil {
+        return nil, err
+    }
+    if !p.next(token.ARRAY_SLICE) {
+        return nil, p.parseFailure(&p.tokens[p.current], "expected ':' in conditional expression")
+    }
+    p.current++
+    otherwise, err := p.parseLogicalOrExpr()
+    if err != nil {
+        return nil, err
+    }
+    return &conditionalExpr{then: then, otherwise: otherwise}, nil
+}
+
 func (p *JSONPath) parseLogicalAndExpr() (*logicalAndExpr, error) {
     var expr logicalAndExpr
 
@@ -394,19 +654,28 @@ func (p *JSONPath) parseBasicExpr() (*basicExpr, error) {
 
     switch p.tokens[p.current].Token {
     case token.NOT:
+        // "!" binds to the basic expression after it, so !a && b negates a alone
         p.current++
-        expr, err := p.parseLogicalOrExpr()
+        expr, err := p.parseBasicExpr()
         if err != nil {
             return nil, err
         }
-        // Inspect if the expr is topped by a parenExpr -- if so we can simplify
-        if len(expr.expressions) == 1 && len(expr.expressions[0].expressions) == 1 && expr.expressions[0].expressions[0].parenExpr != nil {
-            child := expr.expressions[0].expressions[0].parenExpr
-            child.not = !child.not
-            return &basicExpr{parenExpr: child}, nil
+        // a negated group can simply be flipped, which also cancels out a double negation
+        if expr.parenExpr != nil {
+            expr.parenExpr.not = !expr.parenExpr.not
+            return expr, nil
         }
-        return &basicExpr{parenExpr: &parenExpr{not: true, expr: expr}}, nil
+        group := &logicalOrExpr{expressions: []*logicalAndExpr{{expressions: []*basicExpr{expr}}}}
+        return &basicExpr{parenExpr: &parenExpr{not: true, expr: group}}, nil
     case token.PAREN_LEFT:
+        if plusEnabled(p.config) {
+            // the parenthesis may group the arithmetic of a comparison, as in (@.a + @.b) * 2 > 10
+            prevCurrent, prevWarnings := p.current, len(p.warnings)
+            if comparisonExpr, err := p.parseComparisonExpr(); err == nil {
+                return &basicExpr{comparisonExpr: comparisonExpr}, nil
+            }
+            p.current, p.warnings = prevCurrent, p.warnings[:prevWarnings]
+        }
         p.current++
         expr, err := p.parseLogicalOrExpr()
         if err != nil {
@@ -442,6 +711,12 @@ func (p *JSONPath) parseComparisonExpr() (*comparisonExpr, error) {
         return nil, p.parseFailure(&p.tokens[p.current], "expected comparison operator")
     }
     operator := p.tokens[p.current].Token
+    if operator == token.MATCHES {
+        return p.parseRegexMatch(left)
+    }
+    if operator == token.IN {
+        return p.parseMembership(left)
+    }
     var op comparisonOperator
     switch operator {
     case token.EQ:
@@ -469,11 +744,125 @@ func (p *JSONPath) parseComparisonExpr() (*comparisonExpr, error) {
     return &comparisonExpr{left: left, op: op, right: right}, nil
 }
 
+// parseRegexMatch parses the right hand side of left =~ pattern, where pattern is a regular
+// expression literal such as /^\/users/i or a string literal. JSONPath Plus extension.
+func (p *JSONPath) parseRegexMatch(left *comparable) (*comparisonExpr, error) {
+    if !plusEnabled(p.config) {
+        return nil, p.parseFailure(&p.tokens[p.current], "=~ requires JSONPath Plus mode (enabled by default, disabled with StrictRFC9535)")
+    }
+    p.current++
+
+    patternToken := &p.tokens[p.current]
+    var pattern *regexPattern
+    var err error
+    switch patternToken.Token {
+    case token.REGEX:
+        pattern, err = compileRegexLiteral(patternToken.Literal)
+    case token.STRING_LITERAL:
+        pattern, err = compileRegexString(patternToken.Literal)
+    default:
+        return nil, p.parseFailure(patternToken, "expected a regular expression after =~")
+    }
+    if err != nil {
+        return nil, p.parseFailure(patternToken, err.Error())
+    }
+    p.current++
+
+    return &comparisonExpr{left: left, op: matches, pattern: pattern}, nil
+}
+
+// parseMembership parses the right hand side of left in ['A', 'B'], a list of literals, or of
+// left in @root.allowed, a comparable holding an array. JSONPath Plus extension.
+func (p *JSONPath) parseMembership(left *comparable) (*comparisonExpr, error) {
+    if !plusEnabled(p.config) {
+        return nil, p.parseFailure(&p.tokens[p.current], "in requires JSONPath Plus mode (enabled by default, disabled with StrictRFC9535)")
+    }
+    p.current++
+
+    if p.tokens[p.current].Token != token.BRACKET_LEFT {
+        right, err := p.parseComparable()
+        if err != nil {
+            return nil, err
+        }
+        return &comparisonExpr{left: left, op: in, right: right}, nil
+    }
+    p.current++
+    values := []*literal{}
+    for p.current < len(p.tokens) && p.tokens[p.current].Token != token.BRACKET_RIGHT {
+        if len(values) > 0 {
+            if p.tokens[p.current].Token != token.COMMA {
+                return nil, p.parseFailure(&p.tokens[p.current], "expected ',' or ']' in the list after in")
+            }
+            p.current++
+        }
+        value, err := p.parseLiteral()
+        if err != nil {
+            return nil, p.parseFailure(&p.tokens[p.current], "expected a literal in the list after in")
+        }
+        values = append(values, value)
+    }
+    if p.current >= len(p.tokens) {
+        return nil, p.parseFailure(&p.tokens[p.current-1], "expected ']'")
+    }
+    p.current++
+    return &comparisonExpr{left: left, op: in, values: values}, nil
+}
+
+// arithmeticOperators maps the tokens of JSONPath Plus arithmetic to their operators
+var arithmeticOperators = map[token.Token]arithmeticOperator{
+    token.PLUS:     add,
+    token.MINUS:    subtract,
+    token.MULTIPLY: multiply,
+    token.DIVIDE:   divide,
+    token.MODULO:   modulo,
+}
+
 func (p *JSONPath) parseComparable() (*comparable, error) {
+    return p.parseArithmetic(0)
+}
+
+// parseArithmetic parses an operand followed by any arithmetic operators binding at least as tightly
+// as minPrecedence, e.g. @.price * @.qty + 1. JSONPath Plus extension.
+func (p *JSONPath) parseArithmetic(minPrecedence int) (*comparable, error) {
+    left, err := p.parseOperand()
+    if err != nil {
+        return nil, err
+    }
+    for p.current < len(p.tokens) {
+        op, ok := arithmeticOperators[p.tokens[p.current].Token]
+        if !ok || op.precedence() < minPrecedence {
+            break
+        }
+        if !plusEnabled(p.config) {
+            return nil, p.parseFailure(&p.tokens[p.current], "arithmetic requires JSONPath Plus mode (enabled by default, disabled with StrictRFC9535)")
+        }
+        p.current++
+        right, err := p.parseArithmetic(op.precedence() + 1)
+        if err != nil {
+            return nil, err
+        }
+        left = &comparable{arithmetic: &arithmeticExpr{left: left, op: op, right: right}}
+    }
+    return left, nil
+}
+
+func (p *JSONPath) parseOperand() (*comparable, error) {
     //	comparable = literal /
     //	singular-query / ; singular query value
     //	function-expr    ; ValueType
     //	context-variable ; JSONPath Plus extension
+    if p.current < len(p.tokens) && p.tokens[p.current].Token == token.PAREN_LEFT && plusEnabled(p.config) {
+        p.current++
+        grouped, err := p.parseArithmetic(0)
+        if err != nil {
+            return nil, err
+        }
+        if p.current >= len(p.tokens) || p.tokens[p.current].Token != token.PAREN_RIGHT {
+            return nil, p.parseFailure(&p.tokens[p.current-1], "expected ')'")
+        }
+        p.current++
+        return grouped, nil
+    }
     if literal, err := p.parseLiteral(); err == nil {
         return &comparable{literal: literal}, nil
     }
@@ -482,6 +871,8 @@ func (p *JSONPath) parseComparable() (*comparable, error) {
             return nil, p.parseFailure(&p.tokens[p.current], "match result cannot be compared")
         } else if funcExpr.funcType == functionTypeSearch {
             return nil, p.parseFailure(&p.tokens[p.current], "search result cannot be compared")
+        } else if funcExpr.funcType == functionTypeContains || funcExpr.funcType == functionTypeStartsWith || funcExpr.funcType == functionTypeEndsWith {
+            return nil, p.parseFailure(&p.tokens[p.current], funcExpr.funcType.String()+" result cannot be compared")
         }
         return &comparable{functionExpr: funcExpr}, nil
     }
@@ -512,14 +903,61 @@ func (p *JSONPath) parseComparable() (*comparable, error) {
 
     default:
         // Check for JSONPath Plus context variables
-        if varKind, ok := contextVarTokenMap[p.tokens[p.current].Token]; ok {
-            p.current++
-            return &comparable{contextVar: &contextVariable{kind: varKind}}, nil
+        if varKind, ok := contextVarTokenMap[p.tokens[p.current].Token]; ok && plusEnabled(p.config) {
+            contextVar, err := p.parseContextVariable(varKind, true)
+            if err != nil {
+                return nil, err
+            }
+            return &comparable{contextVar: contextVar}, nil
         }
         return nil, p.parseFailure(&p.tokens[p.current], "expected literal or query")
     }
 }
 
+// parseContextVariable parses a JSONPath Plus context variable starting at its token, including the
+// argument of @sibling and the segments that follow it, e.g. @propertyPath[-2]. Unless single is set,
+// the segments may select several nodes, as in @parent.*
+func (p *JSONPath) parseContextVariable(varKind contextVarKind, single bool) (*contextVariable, error) {
+    p.current++
+    contextVar := &contextVariable{kind: varKind}
+    if varKind == contextVarSibling {
+        //  sibling = "@sibling" "(" (string-literal / int) ")"
+        if !p.next(token.PAREN_LEFT) {
+            return nil, p.parseFailure(&p.tokens[p.current], "expected '(' after @sibling")
+        }
+        p.current++
+        if p.current >= len(p.tokens) {
+            return nil, p.parseFailure(&p.tokens[p.current-1], "expected member name or index in @sibling")
+        }
+        switch p.tokens[p.current].Token {
+        case token.STRING_LITERAL, token.INTEGER:
+            lit, err := p.parseLiteral()
+            if err != nil {
+                return nil, err
+            }
+            contextVar.sibling = lit
+        default:
+            return nil, p.parseFailure(&p.tokens[p.current], "expected member name or index in @sibling")
+        }
+        if !p.next(token.PAREN_RIGHT) {
+            return nil, p.parseFailure(&p.tokens[p.current], "expected ')' after @sibling argument")
+        }
+        p.current++
+    }
+    var query *jsonPathAST
+    var err error
+    if single {
+        query, err = p.parseSingleQuery()
+    } else {
+        query, err = p.parseQuery()
+    }
+    if err != nil {
+        return nil, err
+    }
+    contextVar.segments = query.segments
+    return contextVar, nil
+}
+
 func (p *JSONPath) parseQuery() (*jsonPathAST, error) {
     var query jsonPathAST
     p.mode = append(p.mode, modeNormal)
@@ -565,6 +1003,18 @@ func (p *JSONPath) parseTestExpr() (*testExpr, error) {
         }
         return &testExpr{filterQuery: &filterQuery{jsonPathQuery: &jsonPathAST{segments: query.segments}}, not: not}, nil
     default:
+        if varKind, ok := contextVarTokenMap[p.tokens[p.current].Token]; ok && varKind != contextVarRoot && plusEnabled(p.config) {
+            // JSONPath Plus: a context variable tests whether it has a value, as in @parent.bicycle,
+            // and one followed by segments whether they select anything, as in @parent..name
+            contextVar, err := p.parseContextVariable(varKind, false)
+            if err != nil {
+                return nil, err
+            }
+            if len(contextVar.segments) > 0 {
+                return &testExpr{filterQuery: &filterQuery{contextVar: contextVar}, not: not}, nil
+            }
+            return &testExpr{contextVar: contextVar, not: not}, nil
+        }
         funcExpr, err := p.parseFunctionExpr()
         if err != nil {
             return nil, err
@@ -576,7 +1026,16 @@ func (p *JSONPath) parseTestExpr() (*testExpr, error) {
             return nil, p.parseFailure(&p.tokens[p.current], "length function must be compared")
         }
         if funcExpr.funcType == functionTypeValue {
-            return nil, p.parseFailure(&p.tokens[p.current], "length function must be compared")
+            return nil, p.parseFailure(&p.tokens[p.current], "value function must be compared")
+        }
+        if funcExpr.funcType == functionTypeDecodeBase64 || funcExpr.funcType == functionTypeByteLength ||
+            funcExpr.funcType == functionTypeMin || funcExpr.funcType == functionTypeMax ||
+            funcExpr.funcType == functionTypeSum || funcExpr.funcType == functionTypeAvg ||
+            funcExpr.funcType == functionTypeLowercase || funcExpr.funcType == functionTypeUppercase ||
+            funcExpr.funcType == functionTypeTrim || funcExpr.funcType == functionTypeSplit ||
+            funcExpr.funcType == functionTypeJoin || funcExpr.funcType == functionTypeDate ||
+            funcExpr.funcType == functionTypeDateTime || funcExpr.funcType == functionTypeSemver {
+            return nil, p.parseFailure(&p.tokens[p.current], funcExpr.funcType.String()+" function must be compared")
         }
         return &testExpr{functionExpr: funcExpr, not: not}, nil
     }
@@ -597,6 +1056,13 @@ func (p *JSONPath) parseFunctionExpr() (*functionExpr, error) {
     p.current += 2
     args := []*functionArgument{}
 
+    if fn, ok := p.config.Functions()[functionName]; ok {
+        return p.parseCustomFunction(functionName, fn)
+    }
+    if !plusEnabled(p.config) && !isStandardFunction(functionName) {
+        return nil, p.parseFailure(&p.tokens[p.current-2], functionName+" requires JSONPath Plus mode (enabled by default, disabled with StrictRFC9535)")
+    }
+
     // Check type selector functions first (JSONPath Plus)
     // These take a single argument and return boolean
     if funcType, ok := typeSelectorFunctionMap[functionName]; ok {
@@ -618,6 +1084,9 @@ func (p *JSONPath) parseFunctionExpr() (*functionExpr, error) {
         if err != nil {
             return nil, err
         }
+        if !arg.isValue() {
+            return nil, p.parseFailure(&p.tokens[p.current], "length function requires a value")
+        }
         args = append(args, arg)
     case functionTypeCount:
         arg, err := p.parseFunctionArgument(false)
@@ -627,13 +1096,61 @@ func (p *JSONPath) parseFunctionExpr() (*functionExpr, error) {
         if arg.literal != nil && arg.literal.node == nil {
             return nil, p.parseFailure(&p.tokens[p.current], "count function only supports containers")
         }
+        if arg.filterQuery == nil {
+            return nil, p.parseFailure(&p.tokens[p.current], "count function requires a query")
+        }
+        args = append(args, arg)
+    case functionTypeMin, functionTypeMax, functionTypeSum, functionTypeAvg:
+        arg, err := p.parseFunctionArgument(false)
+        if err != nil {
+            return nil, err
+        }
+        if arg.filterQuery == nil {
+            return nil, p.parseFailure(&p.tokens[p.current], functionName+" function requires a query")
+        }
         args = append(args, arg)
     case functionTypeValue:
         arg, err := p.parseFunctionArgument(false)
         if err != nil {
             return nil, err
         }
+        if arg.filterQuery == nil {
+            return nil, p.parseFailure(&p.tokens[p.current], "value function requires a query")
+        }
+        args = append(args, arg)
+    case functionTypeDecodeBase64, functionTypeByteLength:
+        arg, err := p.parseFunctionArgument(true)
+        if err != nil {
+            return nil, err
+        }
+        args = append(args, arg)
+    case functionTypeLowercase, functionTypeUppercase, functionTypeTrim, functionTypeDate, functionTypeDateTime,
+        functionTypeSemver:
+        arg, err := p.parseFunctionArgument(true)
+        if err != nil {
+            return nil, err
+        }
+        if !arg.isValue() {
+            return nil, p.parseFailure(&p.tokens[p.current], functionName+" function requires a value")
+        }
         args = append(args, arg)
+    case functionTypeContains, functionTypeStartsWith, functionTypeEndsWith, functionTypeSplit, functionTypeJoin:
+        for i := 0; i < 2; i++ {
+            if i > 0 {
+                if p.tokens[p.current].Token != token.COMMA {
+                    return nil, p.parseFailure(&p.tokens[p.current], "expected ','")
+                }
+                p.current++
+            }
+            arg, err := p.parseFunctionArgument(true)
+            if err != nil {
+                return nil, err
+            }
+            if !arg.isValue() {
+                return nil, p.parseFailure(&p.tokens[p.current], functionName+" function requires values")
+            }
+            args = append(args, arg)
+        }
     case functionTypeMatch:
         fallthrough
     case functionTypeSearch:
@@ -705,7 +1222,8 @@ func (p *JSONPath) parseFunctionArgument(single bool) (*functionArgument, error)
             return nil, err
         }
         return &functionArgument{filterQuery: &filterQuery{relQuery: &relQuery{segments: query.segments}}}, nil
-    case token.ROOT:
+    case token.ROOT, token.CONTEXT_ROOT:
+        // @root followed by a path is a query from the root, as in comparisons
         p.current++
         var query *jsonPathAST
         var err error
@@ -721,9 +1239,16 @@ func (p *JSONPath) parseFunctionArgument(single bool) (*functionArgument, error)
     }
 
     // Check for JSONPath Plus context variables as function arguments
-    if varKind, ok := contextVarTokenMap[p.tokens[p.current].Token]; ok {
-        p.current++
-        return &functionArgument{contextVar: &contextVariable{kind: varKind}}, nil
+    if varKind, ok := contextVarTokenMap[p.tokens[p.current].Token]; ok && plusEnabled(p.config) {
+        contextVar, err := p.parseContextVariable(varKind, single)
+        if err != nil {
+            return nil, err
+        }
+        if !single && len(contextVar.segments) > 0 {
+            // a query starting from the variable, which functions taking nodes such as count accept
+            return &functionArgument{filterQuery: &filterQuery{contextVar: contextVar}}, nil
+        }
+        return &functionArgument{contextVar: contextVar}, nil
     }
 
     if expr, err := p.parseLogicalOrExpr(); err == nil {
@@ -777,6 +1302,8 @@ func (p *JSONPath) parseLiteral() (*literal, error) {
 type jsonPathAST struct {
     // "$"
     segments []*segment
+    // union holds the queries after the first in a union of complete queries, as in $.a | $.b
+    union []jsonPathAST
 }
 
 func (q jsonPathAST) ToString() string {
@@ -785,5 +1312,14 @@ func (q jsonPathAST) ToString() string {
     for _, seg := range q.segments {
         b.WriteString(seg.ToString())
     }
+    for _, query := range q.union {
+        b.WriteString(" | ")
+        b.WriteString(query.ToString())
+    }
     return b.String()
 }
+
+// queries returns the queries of a union, or q itself if it is not one
+func (q jsonPathAST) queries() []jsonPathAST {
+    return append([]jsonPathAST{{segments: q.segments}}, q.union...)
+}
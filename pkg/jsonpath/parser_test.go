@@ -4,6 +4,7 @@ import (
     "github.com/pb33f/jsonpath/pkg/jsonpath"
     "github.com/pb33f/jsonpath/pkg/jsonpath/config"
     "github.com/stretchr/testify/require"
+    "go.yaml.in/yaml/v4"
     "testing"
 )
 
@@ -177,6 +178,8 @@ func TestParserPropertyNameExtension(t *testing.T) {
             var opts []config.Option
             if test.enabled {
                 opts = append(opts, config.WithPropertyNameExtension())
+            } else {
+                opts = append(opts, config.WithStrictRFC9535())
             }
 
             path, err := jsonpath.NewPath(test.input, opts...)
@@ -189,3 +192,205 @@ func TestParserPropertyNameExtension(t *testing.T) {
         })
     }
 }
+
+func TestParserSurroundingWhitespace(t *testing.T) {
+    tests := []struct {
+        name        string
+        input       string
+        strictError string
+        warnings    []string
+    }{
+        {
+            name:        "Leading whitespace",
+            input:       "  $.store",
+            strictError: "Error at line 1, column 0: unexpected leading whitespace",
+            warnings:    []string{"removed 2 leading whitespace character(s)"},
+        },
+        {
+            name:        "Trailing newline",
+            input:       "$.store\n",
+            strictError: "Error at line 1, column 7: unexpected trailing whitespace",
+            warnings:    []string{"removed 1 trailing whitespace character(s)"},
+        },
+        {
+            name:        "Byte order mark",
+            input:       "\ufeff$.store",
+            strictError: "Error at line 1, column 0: unexpected byte order mark",
+            warnings:    []string{"removed byte order mark"},
+        },
+        {
+            name:        "Byte order mark and surrounding whitespace",
+            input:       "\ufeff $.store\t",
+            strictError: "unexpected byte order mark",
+            warnings: []string{
+                "removed byte order mark",
+                "removed 1 leading whitespace character(s)",
+                "removed 1 trailing whitespace character(s)",
+            },
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            _, err := jsonpath.NewPath(test.input)
+            require.ErrorContains(t, err, test.strictError)
+
+            path, err := jsonpath.NewPath(test.input, config.WithLenientParsing())
+            require.NoError(t, err)
+            require.Equal(t, "$.store", path.String())
+            require.Equal(t, test.warnings, path.Warnings())
+        })
+    }
+}
+
+func TestParserTrailingGarbage(t *testing.T) {
+    for _, input := range []string{"$.store foo", "$.store 1", "$['store'] ?"} {
+        _, err := jsonpath.NewPath(input)
+        require.ErrorContains(t, err, "unexpected trailing characters after valid path", input)
+
+        _, err = jsonpath.NewPath(input, config.WithLenientParsing())
+        require.Error(t, err, input)
+    }
+
+    path, err := jsonpath.NewPath("$.store")
+    require.NoError(t, err)
+    require.Empty(t, path.Warnings())
+}
+
+func TestParserMissingComma(t *testing.T) {
+    for _, input := range []string{"$..[?@pathNames]", "$['a' b]", "$['a' 'b']", "$[*foo]", "$[?@.x == 1 foo]"} {
+        _, err := jsonpath.NewPath(input)
+        require.ErrorContains(t, err, "expected ',' or ']'", input)
+
+        _, err = jsonpath.NewPath(input, config.WithLenientParsing())
+        require.Error(t, err, input)
+    }
+}
+
+func TestParserQuotedMemberShorthand(t *testing.T) {
+    tests := []struct {
+        input    string
+        expected string
+    }{
+        {input: "$.paths.'/users'.get", expected: "$.paths['/users'].get"},
+        {input: `$.paths."/users".get`, expected: "$.paths['/users'].get"},
+        {input: "$..'x-internal'", expected: "$..['x-internal']"},
+        {input: "$.paths[?(@.'x-internal' == true)]", expected: "$.paths[?(@['x-internal'] == true)]"},
+    }
+
+    for _, test := range tests {
+        t.Run(test.input, func(t *testing.T) {
+            _, err := jsonpath.NewPath(test.input)
+            require.Error(t, err)
+
+            path, err := jsonpath.NewPath(test.input, config.WithLenientParsing())
+            require.NoError(t, err)
+            require.Equal(t, test.expected, path.String())
+            require.Len(t, path.Warnings(), 1)
+            require.Contains(t, path.Warnings()[0], "normalized to bracket notation")
+        })
+    }
+}
+
+func TestParserQuotedIndex(t *testing.T) {
+    var root yaml.Node
+    require.NoError(t, yaml.Unmarshal([]byte("items: [a, b, c]\nmap: {'0': zero, '1': one}\n"), &root))
+
+    strict := jsonpath.MustNewPath("$.items['1']")
+    require.Empty(t, strict.Query(&root))
+
+    path, err := jsonpath.NewPath("$.items['1']", config.WithLenientParsing())
+    require.NoError(t, err)
+    require.Equal(t, "$.items['1']", path.String())
+    require.Len(t, path.Warnings(), 1)
+    require.Contains(t, path.Warnings()[0], "also selects the array element")
+    results := path.QueryResults(&root)
+    require.Len(t, results, 1)
+    require.Equal(t, "b", results[0].Node.Value)
+    require.Equal(t, "$['items'][1]", results[0].Path)
+
+    // mappings are still selected by their keys
+    path, err = jsonpath.NewPath("$.map['0']", config.WithLenientParsing())
+    require.NoError(t, err)
+    nodes := path.Query(&root)
+    require.Len(t, nodes, 1)
+    require.Equal(t, "zero", nodes[0].Value)
+
+    // only canonical non-negative integers are indices
+    for _, input := range []string{"$.items['01']", "$.items['-1']", "$.items['1.0']", "$.items[' 1']"} {
+        path, err := jsonpath.NewPath(input, config.WithLenientParsing())
+        require.NoError(t, err, input)
+        require.Empty(t, path.Warnings(), input)
+        require.Empty(t, path.Query(&root), input)
+    }
+}
+
+func TestMustNewPath(t *testing.T) {
+    path := jsonpath.MustNewPath("$.paths.*")
+    require.Equal(t, "$.paths.*", path.String())
+
+    defer func() {
+        require.Contains(t, recover(), `jsonpath: NewPath("$.paths["): `)
+    }()
+    jsonpath.MustNewPath("$.paths[")
+}
+
+func TestIsSingular(t *testing.T) {
+    if !config.PlusAvailable {
+        t.Skip("JSONPath Plus extensions are not built in")
+    }
+    tests := map[string]bool{
+        "$":                    true,
+        "$.info.title":         true,
+        "$['paths'][0]['a b']": true,
+        "$.tags[-1].name":      true,
+        "$.paths.*":            false,
+        "$..title":             false,
+        "$.tags[0,1]":          false,
+        "$.tags[0:1]":          false,
+        "$.tags[?@.name]":      false,
+        "$.info^":              false,
+    }
+    for input, expected := range tests {
+        path := jsonpath.MustNewPath(input)
+        require.Equal(t, expected, path.IsSingular(), input)
+    }
+}
+
+func TestParserFunctionArgumentTypes(t *testing.T) {
+    tests := []struct {
+        input string
+        valid bool
+    }{
+        {input: "$[?length(@) > 1]", valid: true},
+        {input: "$[?length(@.name) == 3]", valid: true},
+        {input: "$[?length('abc') == 3]", valid: true},
+        {input: "$[?length(length(@)) == 1]", valid: true},
+        {input: "$[?length(match(@, 'a')) == 1]", valid: false},
+        {input: "$[?length(@.a == 1) == 1]", valid: false},
+        {input: "$[?count(@.*) == 2]", valid: true},
+        {input: "$[?count($..name) > 0]", valid: true},
+        {input: "$[?count(1) == 1]", valid: false},
+        {input: "$[?count(length(@)) == 1]", valid: false},
+        {input: "$[?count(@.a == 1) == 1]", valid: false},
+        {input: `$[?value(@..color) == "red"]`, valid: true},
+        {input: "$[?value(@.a) == value($.b)]", valid: true},
+        {input: "$[?length(value(@.a)) == 1]", valid: true},
+        {input: "$[?value(@..color)]", valid: false},
+        {input: "$[?value(1) == 1]", valid: false},
+        {input: "$[?value(length(@)) == 1]", valid: false},
+    }
+
+    for _, test := range tests {
+        t.Run(test.input, func(t *testing.T) {
+            for _, opts := range [][]config.Option{nil, {config.WithStrictRFC9535()}} {
+                _, err := jsonpath.NewPath(test.input, opts...)
+                if test.valid {
+                    require.NoError(t, err)
+                } else {
+                    require.Error(t, err)
+                }
+            }
+        })
+    }
+}
@@ -0,0 +1,111 @@
+package jsonpath
+
+import (
+	"go.yaml.in/yaml/v4"
+)
+
+// PathsOption configures which paths ListPaths returns.
+type PathsOption func(*pathsOptions)
+
+type pathsOptions struct {
+	leavesOnly bool
+	maxDepth   int // negative for no limit
+}
+
+// WithLeafPathsOnly limits ListPaths to the paths of scalars, leaving out mappings, sequences and
+// aliases.
+func WithLeafPathsOnly() PathsOption {
+	return func(o *pathsOptions) {
+		o.leavesOnly = true
+	}
+}
+
+// WithMaxPathDepth limits ListPaths to paths of at most depth segments, so 0 only lists $ and 1 also
+// lists the members of the root.
+func WithMaxPathDepth(depth int) PathsOption {
+	return func(o *pathsOptions) {
+		o.maxDepth = max(depth, 0)
+	}
+}
+
+// ListPaths returns the normalized path of every node in the document rooted at root, in document
+// order, starting with $ for the root itself. Mapping keys have no path of their own, and aliases are
+// listed as the leaves they are rather than expanded. A node shared by several parents, as in trees
+// built by hand, is listed under each of them, but not again under itself.
+// It serves path pickers and autocompletion, e.g. of overlay targets.
+func ListPaths(root *yaml.Node, opts ...PathsOption) []string {
+	o := pathsOptions{maxDepth: -1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if root != nil && root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		root = root.Content[0]
+	}
+	var paths []string
+	listPaths(&paths, root, "$", 0, o, make(map[*yaml.Node]bool))
+	return paths
+}
+
+func listPaths(paths *[]string, node *yaml.Node, path string, depth int, o pathsOptions, inside map[*yaml.Node]bool) {
+	if node == nil || inside[node] {
+		return
+	}
+	if !o.leavesOnly || node.Kind == yaml.ScalarNode {
+		*paths = append(*paths, path)
+	}
+	if o.maxDepth >= 0 && depth >= o.maxDepth {
+		return
+	}
+	inside[node] = true
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i] != nil {
+				listPaths(paths, node.Content[i+1], path+normalizePathSegment(node.Content[i].Value), depth+1, o, inside)
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			listPaths(paths, child, path+normalizeIndexSegment(i), depth+1, o, inside)
+		}
+	}
+	delete(inside, node)
+}
+
+// FindValue returns every scalar in the document rooted at root whose value is value, in document
+// order, with its normalized path and position. Values are compared as written, so 1.0 does not find
+// 1, and mapping keys are not searched. Aliases are not expanded, so a value is found once, at its
+// anchor. It serves "where is this used?" exploration, such as finding every use of an operationId
+// or a server URL.
+func FindValue(root *yaml.Node, value string) []Result {
+	if root != nil && root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		root = root.Content[0]
+	}
+	var results []Result
+	findValue(&results, root, value, "$", make(map[*yaml.Node]bool))
+	return results
+}
+
+func findValue(results *[]Result, node *yaml.Node, value string, path string, inside map[*yaml.Node]bool) {
+	if node == nil || inside[node] {
+		return
+	}
+	inside[node] = true
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Value == value {
+			*results = append(*results, Result{Node: node, Path: path, Line: node.Line, Column: node.Column})
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i] != nil {
+				findValue(results, node.Content[i+1], value, path+normalizePathSegment(node.Content[i].Value), inside)
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			findValue(results, child, value, path+normalizeIndexSegment(i), inside)
+		}
+	}
+	delete(inside, node)
+}
@@ -0,0 +1,112 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const listPathsDoc = `
+info: {title: Pets, "it's": &contact {name: API team}}
+tags: [pets, {}]
+owner: *contact
+`
+
+func TestListPaths(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(listPathsDoc), &root))
+
+	tests := []struct {
+		name     string
+		opts     []PathsOption
+		expected []string
+	}{
+		{
+			name: "all paths",
+			expected: []string{
+				"$", "$['info']", "$['info']['title']", `$['info']['it\'s']`, `$['info']['it\'s']['name']`,
+				"$['tags']", "$['tags'][0]", "$['tags'][1]", "$['owner']",
+			},
+		},
+		{
+			name:     "leaves",
+			opts:     []PathsOption{WithLeafPathsOnly()},
+			expected: []string{"$['info']['title']", `$['info']['it\'s']['name']`, "$['tags'][0]"},
+		},
+		{
+			name:     "max depth",
+			opts:     []PathsOption{WithMaxPathDepth(1)},
+			expected: []string{"$", "$['info']", "$['tags']", "$['owner']"},
+		},
+		{
+			name:     "leaves up to a depth",
+			opts:     []PathsOption{WithLeafPathsOnly(), WithMaxPathDepth(2)},
+			expected: []string{"$['info']['title']", "$['tags'][0]"},
+		},
+		{
+			name:     "root only",
+			opts:     []PathsOption{WithMaxPathDepth(0)},
+			expected: []string{"$"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, ListPaths(&root, test.opts...))
+		})
+	}
+
+	// every listed path selects exactly one node
+	for _, path := range ListPaths(&root) {
+		assert.Len(t, MustNewPath(path).Query(&root), 1, path)
+	}
+	assert.Equal(t, ListPaths(&root)[4], NormalizedPaths(&root)[root.Content[0].Content[1].Content[3].Content[1]])
+
+	assert.Nil(t, ListPaths(nil))
+}
+
+func TestListPathsSharedNodes(t *testing.T) {
+	shared := mapping(scalar("name"), scalar("x"))
+	root := mapping(scalar("a"), shared, scalar("b"), shared)
+	shared.Content = append(shared.Content, scalar("self"), shared)
+
+	assert.Equal(t, []string{"$", "$['a']", "$['a']['name']", "$['b']", "$['b']['name']"}, ListPaths(root))
+}
+
+func TestFindValue(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+servers:
+  - url: https://api.example.com
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      servers: [{url: https://api.example.com}]
+  https://api.example.com: {}
+links:
+  ref: &ref listPets
+  alias: *ref
+  version: 1.0
+`), &root))
+
+	results := FindValue(&root, "https://api.example.com")
+	require.Len(t, results, 2)
+	assert.Equal(t, "$['servers'][0]['url']", results[0].Path)
+	assert.Equal(t, "$['paths']['/pets']['get']['servers'][0]['url']", results[1].Path)
+	assert.Equal(t, 3, results[0].Line)
+	assert.Same(t, root.Content[0].Content[1].Content[0].Content[1], results[0].Node)
+
+	// aliases are not expanded, so the anchored value is found once
+	var paths []string
+	for _, result := range FindValue(&root, "listPets") {
+		paths = append(paths, result.Path)
+	}
+	assert.Equal(t, []string{"$['paths']['/pets']['get']['operationId']", "$['links']['ref']"}, paths)
+
+	assert.Len(t, FindValue(&root, "1.0"), 1)
+	assert.Empty(t, FindValue(&root, "1"))
+	assert.Empty(t, FindValue(nil, "x"))
+}
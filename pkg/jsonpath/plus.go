@@ -0,0 +1,109 @@
+package jsonpath
+
+import "github.com/pb33f/jsonpath/pkg/jsonpath/config"
+
+// PlusSupport tells how far this package supports a JSONPath-Plus feature.
+type PlusSupport int
+
+const (
+	// PlusSupported features behave as in JSONPath-Plus
+	PlusSupported PlusSupport = iota
+	// PlusPartial features are supported with the differences given in their notes
+	PlusPartial
+	// PlusUnsupported features are rejected when parsing
+	PlusUnsupported
+)
+
+func (s PlusSupport) String() string {
+	switch s {
+	case PlusSupported:
+		return "supported"
+	case PlusPartial:
+		return "partial"
+	case PlusUnsupported:
+		return "unsupported"
+	}
+	return "unknown"
+}
+
+// PlusFeature is a feature of JSONPath-Plus, the JavaScript implementation whose extensions JSONPath
+// Plus mode follows, and how this package supports it.
+type PlusFeature struct {
+	// Name names the feature, e.g. "parent selector"
+	Name string
+	// Syntax is the feature as JSONPath-Plus writes it, e.g. ^
+	Syntax string
+	// Example is a query using the feature, which parses unless the feature is unsupported
+	Example string
+	Support PlusSupport
+	// Notes describes the differences from JSONPath-Plus, the options a feature needs, or the
+	// alternative to an unsupported feature
+	Notes string
+}
+
+// plusFeatures follows the order of the JSONPath-Plus documentation
+var plusFeatures = []PlusFeature{
+	{Name: "root", Syntax: "$", Example: `$`, Support: PlusSupported},
+	{Name: "member names", Syntax: ".name ['name']", Example: `$.store.book[*].author`, Support: PlusSupported},
+	{Name: "unquoted member names", Syntax: "[name,name]", Example: `$..book[0][category,author]`, Support: PlusSupported},
+	{Name: "wildcard", Syntax: "*", Example: `$.store.*`, Support: PlusSupported},
+	{Name: "descendants", Syntax: "..", Example: `$.store..price`, Support: PlusSupported},
+	{
+		Name: "containers", Syntax: "$..", Example: `$..`, Support: PlusSupported,
+		Notes: "a final .. selects the node and every mapping and sequence below it",
+	},
+	{Name: "indices, slices and unions", Syntax: "[2] [-1:] [0,1] [:2]", Example: `$..book[-1:].title`, Support: PlusSupported},
+	{
+		Name: "script subscripts", Syntax: "[(expr)]", Example: `$..book[(@.length-1)]`, Support: PlusPartial,
+		Notes: "scripts are never evaluated as JavaScript: (@.length-n), (n) and ('name') are translated to [-n], [n] and ['name'], " +
+			"arithmetic on integers and @.length is evaluated, and other scripts are rejected",
+	},
+	{Name: "filters", Syntax: "[?(expr)]", Example: `$..book[?(@.price<10)].title`, Support: PlusSupported},
+	{
+		Name: "strict equality", Syntax: "=== !==", Example: `$..*[?(@property === 'price' && @ !== 8.95)]`, Support: PlusSupported,
+		Notes: "the same as == and !=",
+	},
+	{
+		Name: "JavaScript in filters", Syntax: "@.match(/re/i)", Example: `$..book.*[?(@property === "category" && @.match(/TION$/i))]`,
+		Support: PlusUnsupported, Notes: "match regular expressions with =~, as in @ =~ /TION$/i",
+	},
+	{
+		Name: "@property", Syntax: "@property", Example: `$..book[0][?(@property !== "category")]`, Support: PlusPartial,
+		Notes: "array indices are strings, so the first element has the @property '0' rather than 0",
+	},
+	{Name: "@parentProperty", Syntax: "@parentProperty", Example: `$.store.*[?(@parentProperty !== "book")]`, Support: PlusSupported},
+	{
+		Name: "@parent", Syntax: "@parent", Example: `$.store.bicycle[?(@parent.color == 'red')]`, Support: PlusPartial,
+		Notes: "refers to the node holding the tested node, where JSONPath-Plus refers to the node above that",
+	},
+	{Name: "@path", Syntax: "@path", Example: `$.store.book[?(@path !== "$['store']['book'][0]")].title`, Support: PlusSupported},
+	{Name: "@root", Syntax: "@root", Example: `$..book[?(@.price < @root.store.bicycle.price)].title`, Support: PlusSupported},
+	{Name: "parent selector", Syntax: "^", Example: `$..[?(@.price>19)]^`, Support: PlusSupported},
+	{
+		Name: "property names", Syntax: "~", Example: `$.store.*~`, Support: PlusPartial,
+		Notes: "array elements have no property name, so ~ selects nothing for them, where JSONPath-Plus gives their index",
+	},
+	{
+		Name: "type selectors", Syntax: "@null() @boolean() @number() @integer() @nonFinite() @string() @scalar() @array() @object()",
+		Example: `$..*@number()`, Support: PlusPartial,
+		Notes: "@integer() only matches YAML integers, so not 1.0, @object() matches arrays too, as in JavaScript, " +
+			"and @undefined(), @function() and @other() are not supported",
+	},
+	{
+		Name: "backtick escapes", Syntax: "`name", Example: "$.`$ref`", Support: PlusUnsupported,
+		Notes: "quote the member name instead, as in $['$ref']",
+	},
+}
+
+// plusEnabled reports whether cfg enables the JSONPath Plus extensions, which builds with the
+// jsonpath_core tag never do.
+func plusEnabled(cfg config.Config) bool {
+	return config.PlusAvailable && cfg.JSONPathPlusEnabled()
+}
+
+// PlusFeatures returns the JSONPath-Plus features and how far this package supports each of them,
+// in the order the JSONPath-Plus documentation introduces them. The features are available in
+// JSONPath Plus mode, which config.WithStrictRFC9535 turns off.
+func PlusFeatures() []PlusFeature {
+	return append([]PlusFeature(nil), plusFeatures...)
+}
@@ -0,0 +1,410 @@
+//go:build !jsonpath_core
+
+package jsonpath
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+// goessnerDoc is the store used throughout the JSONPath-Plus documentation
+const goessnerDoc = `{"store": {
+  "book": [
+    {"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+    {"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+    {"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+    {"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+  ],
+  "bicycle": {"color": "red", "price": 19.95}
+}}`
+
+// describe returns the value of a scalar, the keys of a mapping in braces or the length of a sequence
+// in brackets
+func describe(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		var keys []string
+		for i := 0; i < len(node.Content); i += 2 {
+			keys = append(keys, node.Content[i].Value)
+		}
+		return "{" + strings.Join(keys, ",") + "}"
+	case yaml.SequenceNode:
+		return "[" + strconv.Itoa(len(node.Content)) + "]"
+	}
+	return node.Value
+}
+
+func TestPlusFeatures(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(goessnerDoc), &root))
+
+	book := "{category,author,title,price}"
+	bookWithISBN := "{category,author,title,isbn,price}"
+	expected := map[string][]string{
+		"root":                       {"{store}"},
+		"member names":               {"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"},
+		"unquoted member names":      {"reference", "Nigel Rees"},
+		"wildcard":                   {"[4]", "{color,price}"},
+		"descendants":                {"8.95", "12.99", "8.99", "22.99", "19.95"},
+		"containers":                 {"{store}", "{book,bicycle}", "[4]", book, book, bookWithISBN, bookWithISBN, "{color,price}"},
+		"indices, slices and unions": {"The Lord of the Rings"},
+		"script subscripts":          {bookWithISBN},
+		"filters":                    {"Sayings of the Century", "Moby Dick"},
+		"strict equality":            {"19.95", "12.99", "8.99", "22.99"},
+		"@property":                  {"Nigel Rees", "Sayings of the Century", "8.95"},
+		"@parentProperty":            {"red", "19.95"},
+		"@parent":                    {"red", "19.95"},
+		"@path":                      {"Sword of Honour", "Moby Dick", "The Lord of the Rings"},
+		"@root":                      {"Sayings of the Century", "Sword of Honour", "Moby Dick"},
+		"parent selector":            {"{book,bicycle}", "[4]"},
+		"property names":             {"book", "bicycle"},
+		"type selectors":             {"8.95", "12.99", "8.99", "22.99", "19.95"},
+	}
+
+	features := PlusFeatures()
+	require.NotEmpty(t, features)
+	for _, feature := range features {
+		t.Run(feature.Name, func(t *testing.T) {
+			path, err := NewPath(feature.Example)
+			if feature.Support == PlusUnsupported {
+				assert.Error(t, err)
+				assert.NotEmpty(t, feature.Notes)
+				return
+			}
+			require.NoError(t, err)
+			if feature.Support == PlusPartial {
+				assert.NotEmpty(t, feature.Notes)
+			}
+			var values []string
+			for _, node := range path.Query(&root) {
+				values = append(values, describe(node))
+			}
+			assert.Equal(t, expected[feature.Name], values)
+		})
+	}
+
+	// the matrix is a copy
+	features[0].Support = PlusUnsupported
+	assert.Equal(t, PlusSupported, PlusFeatures()[0].Support)
+	assert.Equal(t, "partial", PlusPartial.String())
+}
+
+func TestTypeSelectors(t *testing.T) {
+	yamlData := `
+values: [1, 1.5, .inf, .nan, text, true, null, [1], {a: 1}, 2024-01-01]
+`
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{`$.values.*@null()`, []string{"null"}},
+		{`$.values.*@boolean()`, []string{"true"}},
+		{`$.values.*@number()`, []string{"1", "1.5"}},
+		{`$.values.*@integer()`, []string{"1"}},
+		{`$.values.*@nonFinite()`, []string{".inf", ".nan"}},
+		{`$.values.*@string()`, []string{"text"}},
+		{`$.values.*@scalar()`, []string{"1", "1.5", ".inf", ".nan", "text", "true", "null", "2024-01-01"}},
+		{`$.values.*@array()`, []string{"[1]"}},
+		{`$.values.*@object()`, []string{"[1]", "{a}"}},
+		{`$.values[?(@ == 1)]@number()`, []string{"1"}},
+	}
+
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(yamlData), &root))
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			path, err := NewPath(test.query)
+			require.NoError(t, err)
+			assert.Equal(t, test.query, path.String())
+			var values []string
+			for _, node := range path.Query(&root) {
+				values = append(values, describe(node))
+			}
+			assert.Equal(t, test.expected, values)
+		})
+	}
+
+	for _, invalid := range []string{`$.values@other()`, `$.values[@number()]`, `$.values[?@number()]`} {
+		_, err := NewPath(invalid)
+		assert.Error(t, err, invalid)
+	}
+	_, err := NewPath(`$.values.*@number()`, config.WithStrictRFC9535())
+	assert.Error(t, err)
+}
+
+func TestPlusContainersAndUnquotedNames(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(goessnerDoc), &root))
+
+	path, err := NewPath(`$.store.bicycle..`)
+	require.NoError(t, err)
+	assert.Equal(t, `$.store.bicycle..`, path.String())
+	assert.Len(t, path.Query(&root), 1)
+
+	path, err = NewPath(`$.store.book[0][title, price]`)
+	require.NoError(t, err)
+	assert.Equal(t, `$.store.book[0]['title', 'price']`, path.String())
+	assert.Len(t, path.Query(&root), 2)
+
+	path, err = NewPath(`$.store.book[?(!@parent[4])].title`)
+	require.NoError(t, err)
+	assert.Len(t, path.Query(&root), 4)
+
+	for _, strict := range []string{`$..`, `$.store[book]`, `$.store.*[?(@parent)]`} {
+		_, err := NewPath(strict, config.WithStrictRFC9535())
+		assert.Error(t, err, strict)
+	}
+}
+
+func TestScriptSubscripts(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(goessnerDoc), &root))
+
+	tests := []struct {
+		query      string
+		translated string
+		expected   []string
+	}{
+		{`$..book[(@.length-1)].title`, `$..book[-1].title`, []string{"The Lord of the Rings"}},
+		{`$..book[(@.length - 2)].title`, `$..book[-2].title`, []string{"Moby Dick"}},
+		{`$..book[(0),(@.length-1)].title`, `$..book[0, -1].title`, []string{"Sayings of the Century", "The Lord of the Rings"}},
+		{`$.store[('bicycle')].color`, `$.store['bicycle'].color`, []string{"red"}},
+		{`$.store.bicycle[(@.length-1)]`, `$.store.bicycle[-1]`, nil},
+		{`$..book[(@.length - 2 - 1)].title`, `$..book[-3].title`, []string{"Sword of Honour"}},
+		{`$..book[(1+1)].title`, `$..book[2].title`, []string{"Moby Dick"}},
+		{`$..book[(@.length/2)].title`, `$..book[(@.length / 2)].title`, []string{"Moby Dick"}},
+		{`$..book[((@.length-1)/3)].title`, `$..book[((@.length - 1) / 3)].title`, []string{"Sword of Honour"}},
+		{`$..book[(@.length/3)].title`, `$..book[(@.length / 3)].title`, nil},
+		{`$..book[(@.length%3),(2*2-4)].title`, `$..book[(@.length % 3), 0].title`, []string{"Sword of Honour", "Sayings of the Century"}},
+		{`$..book[(5-@.length)].title`, `$..book[(5 - @.length)].title`, []string{"Sword of Honour"}},
+		{`$..book[(@.length*2-7)].title`, `$..book[(@.length * 2 - 7)].title`, []string{"Sword of Honour"}},
+		{`$.store.bicycle[(@.length/2)]`, `$.store.bicycle[(@.length / 2)]`, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			path, err := NewPath(test.query)
+			require.NoError(t, err)
+			assert.Equal(t, test.translated, path.String())
+			assert.NotEmpty(t, path.Warnings())
+			var values []string
+			for _, node := range path.Query(&root) {
+				values = append(values, describe(node))
+			}
+			assert.Equal(t, test.expected, values)
+
+			reparsed, err := NewPath(path.String())
+			require.NoError(t, err)
+			assert.Equal(t, test.translated, reparsed.String())
+		})
+	}
+
+	for _, invalid := range []string{
+		`$..book[(@.length)]`, `$..book[(@.length+1)]`, `$..book[(@.length-0)]`, `$..book[(@.price-1)]`, `$..book[(1)`,
+		`$..book[(@.length*)]`, `$..book[(@.length/@.price)]`, `$..book[((@.length-1)]`,
+	} {
+		_, err := NewPath(invalid)
+		assert.Error(t, err, invalid)
+	}
+	_, err := NewPath(`$..book[(@.length-1)]`, config.WithStrictRFC9535())
+	assert.Error(t, err)
+}
+
+func TestQueryUnions(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(goessnerDoc), &root))
+
+	tests := []struct {
+		query    string
+		str      string
+		expected []string
+	}{
+		{`$.store.bicycle.color | $..book[0].title`, `$.store.bicycle.color | $..book[0].title`, []string{"Sayings of the Century", "red"}},
+		{`$..book[-1].title|$..book[0].author`, `$..book[-1].title | $..book[0].author`, []string{"Nigel Rees", "The Lord of the Rings"}},
+		{`$..book[0].title | $.store.book[0].title`, `$..book[0].title | $.store.book[0].title`, []string{"Sayings of the Century", "Sayings of the Century"}},
+		{`$.store.book[?(@.price > 20)].title | $.store.book[?(@index == 0)].title`, ``, []string{"Sayings of the Century", "The Lord of the Rings"}},
+		{`$.missing | $.store.bicycle.color`, ``, []string{"red"}},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			path, err := NewPath(test.query)
+			require.NoError(t, err)
+			if test.str != "" {
+				assert.Equal(t, test.str, path.String())
+			}
+			assert.False(t, path.IsSingular())
+			var values []string
+			for _, node := range path.Query(&root) {
+				values = append(values, describe(node))
+			}
+			assert.Equal(t, test.expected, values)
+		})
+	}
+
+	path, err := NewPath(`$..book[0].title | $.store.book[0].title`, config.WithDeduplicatedResults())
+	require.NoError(t, err)
+	assert.Len(t, path.Query(&root), 1)
+
+	matches := MustNewPath(`$.store.bicycle.color | $..book[0].title`).QueryMatches(&root)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "$['store']['book'][0]['title']", matches[0].Path)
+	assert.Equal(t, "$['store']['bicycle']['color']", matches[1].Path)
+
+	joined := Join(MustNewPath(`$.store.bicycle | $..book[0]`), MustNewPath(`$.color | $.title`))
+	assert.Equal(t, `$.store.bicycle.color | $..book[0].color | $.store.bicycle.title | $..book[0].title`, joined.String())
+	assert.Len(t, joined.Query(&root), 2)
+	_, err = MustNewPath(`$.a | $.b`).Insert(0, MemberSegment("c"))
+	assert.Error(t, err)
+
+	formatted, err := Format(`$.a|$['b']`)
+	require.NoError(t, err)
+	assert.Equal(t, `$.a | $['b']`, formatted)
+
+	for _, invalid := range []string{`$.a |`, `$.a | .b`, `$.a || $.b`, `$[?@.a | @.b]`} {
+		_, err := NewPath(invalid)
+		assert.Error(t, err, invalid)
+	}
+	_, err = NewPath(`$.a | $.b`, config.WithStrictRFC9535())
+	assert.Error(t, err)
+}
+
+func TestPropertyNameSelector(t *testing.T) {
+	yamlData := `
+paths:
+  /pets: {get: {operationId: listPets}, post: {}}
+  /Users_x: {get: {}}
+components: {schemas: {Pet: {properties: {id: 1, Name: 2}}}}
+tags: [{name: pets}]
+`
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(yamlData), &root))
+
+	tests := []struct {
+		query    string
+		expected []string
+		paths    []string
+	}{
+		{`$.paths.*~`, []string{"/pets", "/Users_x"}, []string{`$['paths']['/pets']~`, `$['paths']['/Users_x']~`}},
+		{`$.paths[?(@.get)].*~`, []string{"get", "post", "get"}, nil},
+		{`$..properties.*~`, []string{"id", "Name"}, nil},
+		{`$.paths~`, []string{"paths"}, nil},
+		{`$.tags[*]~`, nil, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			path, err := NewPath(test.query)
+			require.NoError(t, err)
+			var values []string
+			for _, node := range path.Query(&root) {
+				assert.Equal(t, yaml.ScalarNode, node.Kind)
+				values = append(values, node.Value)
+			}
+			assert.Equal(t, test.expected, values)
+			if test.paths != nil {
+				var paths []string
+				for _, result := range path.QueryResults(&root) {
+					paths = append(paths, result.Path)
+				}
+				assert.Equal(t, test.paths, paths)
+			}
+		})
+	}
+
+	_, err := NewPath(`$.paths.*~`, config.WithStrictRFC9535())
+	assert.Error(t, err)
+	path, err := NewPath(`$.paths.*~`, config.WithStrictRFC9535(), config.WithPropertyNameExtension())
+	require.NoError(t, err)
+	assert.Len(t, path.Query(&root), 2)
+}
+
+func TestKeyPatternSelector(t *testing.T) {
+	yamlData := `
+paths:
+  /v1/users: {get: {x-internal: true}, x-owner: team-a}
+  /v1/pets: {get: {}}
+  /v2/users: {get: {}}
+components: {schemas: {UserV1: {X-Audit: 1}, Pet: {}}}
+`
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(yamlData), &root))
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{`$.paths[/^\/v1\//]`, []string{`$['paths']['/v1/users']`, `$['paths']['/v1/pets']`}},
+		{`$.paths[/users$/].get`, []string{`$['paths']['/v1/users']['get']`, `$['paths']['/v2/users']['get']`}},
+		{`$..[/^x-/i]`, []string{`$['paths']['/v1/users']['x-owner']`, `$['paths']['/v1/users']['get']['x-internal']`, `$['components']['schemas']['UserV1']['X-Audit']`}},
+		{`$.components.schemas[/V1$/, 'Pet']`, []string{`$['components']['schemas']['UserV1']`, `$['components']['schemas']['Pet']`}},
+		{`$.paths[/^\/v1/][?@property == 'get']`, []string{`$['paths']['/v1/users']['get']`, `$['paths']['/v1/pets']['get']`}},
+		{`$.paths[/[/]v2/]~`, []string{`$['paths']['/v2/users']~`}},
+		{`$.components[/nothing/]`, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			path, err := NewPath(test.query)
+			require.NoError(t, err)
+			assert.Equal(t, test.query, path.String())
+			var paths []string
+			for _, result := range path.QueryResults(&root) {
+				paths = append(paths, result.Path)
+			}
+			assert.Equal(t, test.expected, paths)
+		})
+	}
+
+	for _, query := range []string{`$.paths[/(/]`, `$.paths[/a/x]`, `$[?@ == $.paths[/v1/]]`} {
+		_, err := NewPath(query)
+		assert.Error(t, err, query)
+	}
+	_, err := NewPath(`$.paths[/^\/v1/]`, config.WithStrictRFC9535())
+	assert.Error(t, err)
+}
+
+func TestConditionalExpressions(t *testing.T) {
+	yamlData := `
+items:
+  - {name: a, kind: pro, limit: 150}
+  - {name: b, kind: pro, limit: 50}
+  - {name: c, kind: free, limit: 20}
+  - {name: d, kind: free, limit: 5}
+`
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(yamlData), &root))
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{`$.items[?(@.kind == 'pro' ? @.limit > 100 : @.limit > 10)].name`, []string{"a", "c"}},
+		{`$.items[?@.kind == 'pro' ? @.limit > 100 : @.limit > 10].name`, []string{"a", "c"}},
+		{`$.items[?@.kind == 'pro' || @.name == 'd' ? @.limit < 100 : @.limit > 100].name`, []string{"b", "d"}},
+		{`$.items[?@.kind == 'pro' ? @.limit > 100 : @.limit > 10 && @.name == 'd'].name`, []string{"a"}},
+		{`$.items[?(@.kind == 'pro' ? @.limit > 100 : @.limit > 10) || @.name == 'd'].name`, []string{"a", "c", "d"}},
+		{`$.items[?@.limit > 100 ? @.kind == 'pro' : @.limit > 10 ? @.kind == 'free' : @.name == 'd'].name`, []string{"a", "c", "d"}},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			path, err := NewPath(test.query)
+			require.NoError(t, err)
+			assert.Equal(t, test.query, path.String())
+			var names []string
+			for _, node := range path.Query(&root) {
+				names = append(names, node.Value)
+			}
+			assert.Equal(t, test.expected, names)
+		})
+	}
+
+	for _, query := range []string{`$.items[?@.a ? @.b]`, `$.items[?@.a ? : @.b]`, `$.items[?@.a ?]`} {
+		_, err := NewPath(query)
+		assert.Error(t, err, query)
+	}
+	_, err := NewPath(`$.items[?@.a ? @.b : @.c]`, config.WithStrictRFC9535())
+	assert.Error(t, err)
+}
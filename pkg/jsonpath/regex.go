@@ -0,0 +1,93 @@
+package jsonpath
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// regexPattern is the regular expression on the right of the JSONPath Plus =~ operator
+type regexPattern struct {
+	// source is the pattern as written, e.g. /^\/users/i or '^/users'
+	source string
+	re     *regexp.Regexp
+}
+
+// compileRegexLiteral compiles a JavaScript style regular expression literal, /pattern/flags.
+// The flags i, m and s have their usual meaning, and g and u are accepted but have no effect, as
+// only whether the pattern matches is tested and patterns are always Unicode aware.
+func compileRegexLiteral(literal string) (*regexPattern, error) {
+	end := strings.LastIndexByte(literal, '/')
+	if !strings.HasPrefix(literal, "/") || end < 1 {
+		return nil, fmt.Errorf("invalid regular expression %s", literal)
+	}
+	pattern, flags := literal[1:end], literal[end+1:]
+
+	var goFlags strings.Builder
+	for _, flag := range flags {
+		switch flag {
+		case 'i', 'm', 's':
+			if !strings.ContainsRune(goFlags.String(), flag) {
+				goFlags.WriteRune(flag)
+			}
+		case 'g', 'u':
+		default:
+			return nil, fmt.Errorf("unsupported regular expression flag %q", flag)
+		}
+	}
+	if goFlags.Len() > 0 {
+		pattern = "(?" + goFlags.String() + ")" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %s: %w", literal, err)
+	}
+	return &regexPattern{source: literal, re: re}, nil
+}
+
+// compileRegexString compiles a regular expression given as a string literal
+func compileRegexString(pattern string) (*regexPattern, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+	}
+	return &regexPattern{source: literal{string: &pattern}.ToString(), re: re}, nil
+}
+
+// matches reports whether the pattern matches anywhere in value. Numbers and booleans are matched
+// against their text, while null, objects, arrays and Nothing never match.
+func (p *regexPattern) matches(value literal) bool {
+	text, ok := regexSubject(value)
+	return ok && p.re.MatchString(text)
+}
+
+// typeMismatch describes why value cannot be matched, or returns "" if it can or is Nothing
+func (p *regexPattern) typeMismatch(value literal) string {
+	if _, ok := regexSubject(value); ok {
+		return ""
+	}
+	if kind := literalKind(value); kind != "Nothing" {
+		return fmt.Sprintf("cannot match %s with =~", kind)
+	}
+	return ""
+}
+
+func regexSubject(value literal) (string, bool) {
+	switch {
+	case value.string != nil:
+		return *value.string, true
+	case value.integer != nil:
+		return strconv.Itoa(*value.integer), true
+	case value.float64 != nil:
+		return strconv.FormatFloat(*value.float64, 'f', -1, 64), true
+	case value.time != nil:
+		return value.time.Format(time.RFC3339Nano), true
+	case value.version != nil:
+		return value.version.text, true
+	case value.bool != nil:
+		return strconv.FormatBool(*value.bool), true
+	}
+	return "", false
+}
@@ -0,0 +1,114 @@
+package jsonpath
+
+import (
+	"fmt"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// Result is a single node matched by a query, along with its location in the source document.
+type Result struct {
+	// Node is the matched node.
+	Node *yaml.Node
+	// Path is the RFC 9535 normalized path to the node, e.g. $['paths']['/users'].
+	Path string
+	// Line is the 1-based line of the node in the source document (0 if unknown).
+	Line int
+	// Column is the 1-based column of the node in the source document (0 if unknown).
+	Column int
+}
+
+// QueryResults runs the query against root and returns each match with its normalized path and position.
+func (p *JSONPath) QueryResults(root *yaml.Node) []Result {
+	results := p.queryResults(root)
+	copier := newResultCopier(p.config)
+	for i := range results {
+		results[i].Node = copier.node(results[i].Node)
+	}
+	return results
+}
+
+// queryResults is QueryResults, returning the document's own nodes
+func (p *JSONPath) queryResults(root *yaml.Node) []Result {
+	nodes := p.ast.query(root, root, p.config)
+	paths := NormalizedPaths(root)
+	results := make([]Result, len(nodes))
+	for i, node := range nodes {
+		results[i] = Result{
+			Node:   node,
+			Path:   paths[node],
+			Line:   node.Line,
+			Column: node.Column,
+		}
+	}
+	return results
+}
+
+// QueryAs runs the query against root and decodes each match into a T with the YAML decoder, so
+// yaml struct tags apply. Decoding stops at the first match that does not fit T.
+func QueryAs[T any](p *JSONPath, root *yaml.Node) ([]T, error) {
+	// decoding copies the values, so the nodes need not be copied first
+	nodes := p.ast.query(root, root, p.config)
+	values := make([]T, 0, len(nodes))
+	for _, node := range nodes {
+		var value T
+		if err := node.Decode(&value); err != nil {
+			return nil, fmt.Errorf("failed to decode match at line %d, column %d: %w", node.Line, node.Column, err)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// NormalizeKeySegment returns the bracketed selector for the member named key, in the form RFC 9535
+// normalized paths use, such as ['a b']. The name is single-quoted, with ' and \ escaped by a
+// backslash and control characters escaped as \b, \f, \n, \r, \t or \u00XX; every other character
+// is kept as is. Appended to a path, the segment always parses back to a selector for exactly key,
+// whatever characters it holds, so tools generating paths, such as overlay targets, can use it for
+// every key rather than only for those that are not valid shorthand names.
+func NormalizeKeySegment(key string) string {
+	return normalizePathSegment(key)
+}
+
+// NormalizedPaths walks the document and returns the normalized path of every node in it.
+// Mapping keys are addressed with the JSONPath Plus "~" suffix on the path of their value.
+func NormalizedPaths(root *yaml.Node) map[*yaml.Node]string {
+	paths := make(map[*yaml.Node]string)
+	if root == nil {
+		return paths
+	}
+	if root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		paths[root] = "$"
+		root = root.Content[0]
+	}
+	indexPaths(paths, root, "$")
+	return paths
+}
+
+func indexPaths(paths map[*yaml.Node]string, node *yaml.Node, path string) {
+	if node == nil {
+		return
+	}
+	if _, seen := paths[node]; seen && path != "$" {
+		// aliased or otherwise shared nodes keep the first (document order) path
+		return
+	}
+	paths[node] = path
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i] == nil {
+				continue
+			}
+			childPath := path + normalizePathSegment(node.Content[i].Value)
+			if _, seen := paths[node.Content[i]]; !seen {
+				paths[node.Content[i]] = childPath + "~"
+			}
+			indexPaths(paths, node.Content[i+1], childPath)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			indexPaths(paths, child, path+normalizeIndexSegment(i))
+		}
+	}
+}
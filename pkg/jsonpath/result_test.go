@@ -0,0 +1,134 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestQueryResults(t *testing.T) {
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte(`
+paths:
+  /users:
+    get:
+      summary: list
+    post:
+      summary: create
+tags:
+  - name: it's
+`), &node)
+	require.NoError(t, err)
+
+	path, err := NewPath(`$.paths.*.*.summary`)
+	require.NoError(t, err)
+	results := path.QueryResults(&node)
+	require.Len(t, results, 2)
+	assert.Equal(t, "$['paths']['/users']['get']['summary']", results[0].Path)
+	assert.Equal(t, 5, results[0].Line)
+	assert.Equal(t, 16, results[0].Column)
+	assert.Equal(t, "$['paths']['/users']['post']['summary']", results[1].Path)
+
+	path, err = NewPath(`$.tags[0].name`)
+	require.NoError(t, err)
+	results = path.QueryResults(&node)
+	require.Len(t, results, 1)
+	assert.Equal(t, `$['tags'][0]['name']`, results[0].Path)
+}
+
+func TestNormalizedPaths(t *testing.T) {
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte(`{"a": [1, {"b'c": true}]}`), &node)
+	require.NoError(t, err)
+
+	paths := NormalizedPaths(&node)
+	mapping := node.Content[0]
+	assert.Equal(t, "$", paths[mapping])
+	assert.Equal(t, "$['a']~", paths[mapping.Content[0]])
+	assert.Equal(t, "$['a'][0]", paths[mapping.Content[1].Content[0]])
+	assert.Equal(t, `$['a'][1]['b\'c']`, paths[mapping.Content[1].Content[1].Content[1]])
+}
+
+func TestQueryAs(t *testing.T) {
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte(`
+servers:
+  - url: https://api.example.com
+    port: 443
+  - url: https://staging.example.com
+    port: 8443
+limits: [10, 20, nope]
+`), &node)
+	require.NoError(t, err)
+
+	type server struct {
+		URL  string `yaml:"url"`
+		Port int    `yaml:"port"`
+	}
+	path, err := NewPath(`$.servers[*]`)
+	require.NoError(t, err)
+	servers, err := QueryAs[server](path, &node)
+	require.NoError(t, err)
+	assert.Equal(t, []server{{"https://api.example.com", 443}, {"https://staging.example.com", 8443}}, servers)
+
+	path, err = NewPath(`$.servers[*].port`)
+	require.NoError(t, err)
+	ports, err := QueryAs[int](path, &node)
+	require.NoError(t, err)
+	assert.Equal(t, []int{443, 8443}, ports)
+
+	path, err = NewPath(`$.missing`)
+	require.NoError(t, err)
+	none, err := QueryAs[string](path, &node)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	path, err = NewPath(`$.limits[*]`)
+	require.NoError(t, err)
+	_, err = QueryAs[int](path, &node)
+	assert.ErrorContains(t, err, "line 7, column 18")
+}
+
+func TestNormalizeKeySegment(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{key: "plain", expected: `['plain']`},
+		{key: "a]b", expected: `['a]b']`},
+		{key: "it's", expected: `['it\'s']`},
+		{key: `say "hi"`, expected: `['say "hi"']`},
+		{key: `back\slash`, expected: `['back\\slash']`},
+		{key: `\'`, expected: `['\\\'']`},
+		{key: "line\nbreak", expected: `['line\nbreak']`},
+		{key: "\r\t\b\f", expected: `['\r\t\b\f']`},
+		{key: "\x00\x1f", expected: `['\u0000\u001f']`},
+		{key: "del\x7f", expected: "['del\x7f']"},
+		{key: "héllo wörld", expected: `['héllo wörld']`},
+		{key: "😀", expected: `['😀']`},
+		{key: "$.*[?@]", expected: `['$.*[?@]']`},
+		{key: "", expected: `['']`},
+	}
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			segment := NormalizeKeySegment(test.key)
+			assert.Equal(t, test.expected, segment)
+
+			node := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: "other"},
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: "wrong"},
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: test.key},
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: "found"},
+			}}
+			path, err := NewPath("$" + segment)
+			require.NoError(t, err)
+			assert.Equal(t, "$"+segment, path.String())
+			results := path.QueryResults(node)
+			require.Len(t, results, 1)
+			assert.Equal(t, "found", results[0].Node.Value)
+			assert.Equal(t, "$"+segment, results[0].Path)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package jsonpath
+
+// Scope describes how many nodes a query may select, judged from the query alone. Editors of
+// overlays can warn about targets whose scope grows with the document they are applied to.
+type Scope int
+
+const (
+	// ScopeSingular queries select at most one node, as singular queries do.
+	ScopeSingular Scope = iota
+	// ScopeBounded queries select at most a number of nodes the query fixes, such as
+	// $.paths['/users', '/orders'].get or $.tags[0:3].
+	ScopeBounded
+	// ScopeUnbounded queries may select any number of nodes, with wildcards, descendant segments,
+	// filters, open-ended slices, glob or key pattern selectors, or custom selectors.
+	ScopeUnbounded
+)
+
+func (s Scope) String() string {
+	switch s {
+	case ScopeSingular:
+		return "singular"
+	case ScopeBounded:
+		return "bounded"
+	case ScopeUnbounded:
+		return "unbounded"
+	}
+	return "unknown"
+}
+
+// Scope classifies the query by how many nodes it may select. Parent, property name and type
+// selector segments never select more nodes than the segments before them, but they make a query
+// bounded rather than singular. A union of queries is bounded unless one of them is unbounded.
+func (p *JSONPath) Scope() Scope {
+	if p.IsSingular() {
+		return ScopeSingular
+	}
+	for _, query := range p.ast.queries() {
+		for _, seg := range query.segments {
+			if seg.unbounded() {
+				return ScopeUnbounded
+			}
+		}
+	}
+	return ScopeBounded
+}
+
+// unbounded reports whether the segment may select any number of nodes from a single node
+func (s *segment) unbounded() bool {
+	switch s.kind {
+	case segmentKindDescendant:
+		return true
+	case segmentKindChild:
+		if s.child.kind != segmentLongHand {
+			return s.child.kind != segmentDotMemberName
+		}
+		for _, sel := range s.child.selectors {
+			switch sel.kind {
+			case selectorSubKindName, selectorSubKindArrayIndex, selectorSubKindScript:
+			case selectorSubKindArraySlice:
+				if !sel.slice.bounded() {
+					return true
+				}
+			default:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// bounded reports whether the slice selects at most a number of elements it fixes, which it does
+// when both of its bounds count from the same end of the array
+func (s *slice) bounded() bool {
+	step := int64(1)
+	if s.step != nil {
+		step = *s.step
+	}
+	if step == 0 {
+		return true
+	}
+	// an omitted bound is the end of the array the slice starts or stops at
+	startFromEnd, endFromEnd := step < 0, step > 0
+	if s.start != nil {
+		startFromEnd = *s.start < 0
+	}
+	if s.end != nil {
+		endFromEnd = *s.end < 0
+	}
+	return startFromEnd == endFromEnd
+}
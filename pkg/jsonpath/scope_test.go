@@ -0,0 +1,43 @@
+package jsonpath_test
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScope(t *testing.T) {
+	if !config.PlusAvailable {
+		t.Skip("JSONPath Plus extensions are not built in")
+	}
+	tests := map[string]jsonpath.Scope{
+		"$":                                   jsonpath.ScopeSingular,
+		"$.info.title":                        jsonpath.ScopeSingular,
+		"$.tags[-1].name":                     jsonpath.ScopeSingular,
+		"$.paths['/users', '/orders'].get":    jsonpath.ScopeBounded,
+		"$.tags[0,1]":                         jsonpath.ScopeBounded,
+		"$.tags[0:3]":                         jsonpath.ScopeBounded,
+		"$.tags[:3]":                          jsonpath.ScopeBounded,
+		"$.tags[-3:]":                         jsonpath.ScopeBounded,
+		"$.tags[-1:-4:-1]":                    jsonpath.ScopeBounded,
+		"$.tags[::0]":                         jsonpath.ScopeBounded,
+		"$.info^":                             jsonpath.ScopeBounded,
+		"$.info~":                             jsonpath.ScopeBounded,
+		"$.info.title | $.info.version":       jsonpath.ScopeBounded,
+		"$.paths.*":                           jsonpath.ScopeUnbounded,
+		"$.paths[*]":                          jsonpath.ScopeUnbounded,
+		"$..title":                            jsonpath.ScopeUnbounded,
+		"$.tags[1:]":                          jsonpath.ScopeUnbounded,
+		"$.tags[:-1]":                         jsonpath.ScopeUnbounded,
+		"$.tags[::-1]":                        jsonpath.ScopeUnbounded,
+		"$.tags[0, 2:]":                       jsonpath.ScopeUnbounded,
+		"$.tags[?@.name]":                     jsonpath.ScopeUnbounded,
+		"$.info.title | $.paths..operationId": jsonpath.ScopeUnbounded,
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, jsonpath.MustNewPath(input).Scope(), input)
+	}
+	assert.Equal(t, "unbounded", jsonpath.ScopeUnbounded.String())
+}
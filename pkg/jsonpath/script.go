@@ -0,0 +1,198 @@
+package jsonpath
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/token"
+)
+
+// scriptExpr is the arithmetic of a script subscript such as [(@.length/2)], the one part of the
+// JavaScript of Goessner's scripts that is supported: integers and the length of the array, combined
+// with + - * / % and parentheses. Scripts are never evaluated as JavaScript.
+type scriptExpr struct {
+	// op is the operator of an operation, or 0 for an operand. A '-' without a left operand negates
+	// its right operand.
+	op          byte
+	left, right *scriptExpr
+	// length is set for @.length, and value is an integer operand
+	length bool
+	value  int64
+}
+
+// index returns the index of the element the script selects in an array of n elements. As in
+// JavaScript, results that are not integers, or are out of range, select nothing.
+func (e *scriptExpr) index(n int) (int, bool) {
+	f := e.eval(float64(n))
+	if f < 0 || f >= float64(n) || f != math.Trunc(f) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+func (e *scriptExpr) eval(length float64) float64 {
+	switch e.op {
+	case 0:
+		if e.length {
+			return length
+		}
+		return float64(e.value)
+	case '-':
+		if e.left == nil {
+			return -e.right.eval(length)
+		}
+		return e.left.eval(length) - e.right.eval(length)
+	case '+':
+		return e.left.eval(length) + e.right.eval(length)
+	case '*':
+		return e.left.eval(length) * e.right.eval(length)
+	case '/':
+		return e.left.eval(length) / e.right.eval(length)
+	case '%':
+		return math.Mod(e.left.eval(length), e.right.eval(length))
+	}
+	return math.NaN()
+}
+
+// linear returns the script as coefficient*@.length + constant, if it is one with integers
+func (e *scriptExpr) linear() (coefficient int64, constant int64, ok bool) {
+	if e.op == 0 {
+		if e.length {
+			return 1, 0, true
+		}
+		return 0, e.value, true
+	}
+	if e.op == '-' && e.left == nil {
+		a, c, ok := e.right.linear()
+		return -a, -c, ok
+	}
+	a1, c1, ok1 := e.left.linear()
+	a2, c2, ok2 := e.right.linear()
+	if !ok1 || !ok2 {
+		return 0, 0, false
+	}
+	switch {
+	case e.op == '+':
+		return a1 + a2, c1 + c2, true
+	case e.op == '-':
+		return a1 - a2, c1 - c2, true
+	case e.op == '*' && a1 == 0:
+		return c1 * a2, c1 * c2, true
+	case e.op == '*' && a2 == 0:
+		return a1 * c2, c1 * c2, true
+	case e.op == '/' && a1 == 0 && a2 == 0 && c2 != 0 && c1%c2 == 0:
+		return 0, c1 / c2, true
+	case e.op == '%' && a1 == 0 && a2 == 0 && c2 != 0:
+		return 0, c1 % c2, true
+	}
+	return 0, 0, false
+}
+
+func (e *scriptExpr) ToString() string {
+	switch {
+	case e.op == 0 && e.length:
+		return "@.length"
+	case e.op == 0:
+		return strconv.FormatInt(e.value, 10)
+	case e.left == nil:
+		return "-" + e.right.operand(3)
+	}
+	return e.left.operand(e.precedence()) + " " + string(e.op) + " " + e.right.operand(e.precedence()+1)
+}
+
+// operand returns the script as the operand of an operator of the given precedence, in parentheses
+// if it binds less tightly
+func (e *scriptExpr) operand(precedence int) string {
+	if e.precedence() < precedence || precedence > 2 && e.op == 0 && e.value < 0 {
+		return "(" + e.ToString() + ")"
+	}
+	return e.ToString()
+}
+
+func (e *scriptExpr) precedence() int {
+	switch {
+	case e.op == 0 || e.left == nil:
+		return 3
+	case e.op == '+' || e.op == '-':
+		return 1
+	}
+	return 2
+}
+
+// scriptOperators maps the tokens of the operators of scripts to the operators
+var scriptOperators = map[token.Token]byte{
+	token.PLUS: '+', token.MINUS: '-', token.MULTIPLY: '*', token.DIVIDE: '/', token.MODULO: '%',
+}
+
+// parseScript parses the arithmetic of a script subscript, up to the closing parenthesis
+func (p *JSONPath) parseScript(unsupported func() error) (*scriptExpr, error) {
+	left, err := p.parseScriptTerm(unsupported)
+	if err != nil {
+		return nil, err
+	}
+	for p.peekAt(0, token.PLUS) || p.peekAt(0, token.MINUS) {
+		op := scriptOperators[p.tokens[p.current].Token]
+		p.current++
+		right, err := p.parseScriptTerm(unsupported)
+		if err != nil {
+			return nil, err
+		}
+		left = &scriptExpr{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *JSONPath) parseScriptTerm(unsupported func() error) (*scriptExpr, error) {
+	left, err := p.parseScriptOperand(unsupported)
+	if err != nil {
+		return nil, err
+	}
+	for p.peekAt(0, token.MULTIPLY) || p.peekAt(0, token.DIVIDE) || p.peekAt(0, token.MODULO) {
+		op := scriptOperators[p.tokens[p.current].Token]
+		p.current++
+		right, err := p.parseScriptOperand(unsupported)
+		if err != nil {
+			return nil, err
+		}
+		left = &scriptExpr{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *JSONPath) parseScriptOperand(unsupported func() error) (*scriptExpr, error) {
+	switch {
+	case p.peekAt(0, token.INTEGER):
+		literal := p.tokens[p.current].Literal
+		i, err := strconv.ParseInt(literal, 10, 64)
+		if err != nil {
+			return nil, p.parseFailure(&p.tokens[p.current], "expected an integer")
+		}
+		if err := p.checkSafeInteger(i, literal); err != nil {
+			return nil, err
+		}
+		p.current++
+		return &scriptExpr{value: i}, nil
+	case p.peekAt(0, token.CURRENT) && p.peekAt(1, token.CHILD) && p.peekAt(2, token.STRING) && p.tokens[p.current+2].Literal == "length":
+		p.current += 3
+		return &scriptExpr{length: true}, nil
+	case p.peekAt(0, token.MINUS):
+		p.current++
+		operand, err := p.parseScriptOperand(unsupported)
+		if err != nil {
+			return nil, err
+		}
+		return &scriptExpr{op: '-', right: operand}, nil
+	case p.peekAt(0, token.PAREN_LEFT):
+		p.current++
+		inner, err := p.parseScript(unsupported)
+		if err != nil {
+			return nil, err
+		}
+		if !p.peekAt(0, token.PAREN_RIGHT) {
+			return nil, unsupported()
+		}
+		p.current++
+		return inner, nil
+	}
+	return nil, unsupported()
+}
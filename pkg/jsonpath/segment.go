@@ -2,6 +2,7 @@ package jsonpath
 
 import (
     "go.yaml.in/yaml/v4"
+    "strconv"
     "strings"
 )
 
@@ -12,12 +13,18 @@ const (
     segmentKindDescendant                     // ..
     segmentKindProperyName                    // ~ (extension only)
     segmentKindParent                         // ^ (JSONPath Plus parent selector)
+    segmentKindTypeSelector                   // @number() etc. (JSONPath Plus type selector)
 )
 
 type segment struct {
-    kind       segmentKind
-    child      *innerSegment
-    descendant *innerSegment
+    kind         segmentKind
+    child        *innerSegment
+    descendant   *innerSegment
+    typeSelector string // the type named by a type selector, e.g. number
+    // parentLevels is the number of levels a parent selector such as ^2 ascends, 0 for a plain ^
+    parentLevels int
+    // parentKeys are the member names of a keyed ascent such as ^('get', 'post')
+    parentKeys []string
 }
 
 type segmentSubKind int
@@ -26,6 +33,7 @@ const (
     segmentDotWildcard   segmentSubKind = iota // .*
     segmentDotMemberName                       // .property
     segmentLongHand                            // [ selector[] ]
+    segmentContainers                          // nothing after a final .. (JSONPath Plus)
 )
 
 func (s segment) ToString() string {
@@ -41,7 +49,19 @@ func (s segment) ToString() string {
     case segmentKindProperyName:
         return "~"
     case segmentKindParent:
+        if s.parentLevels > 0 {
+            return "^" + strconv.Itoa(s.parentLevels)
+        }
+        if s.parentKeys != nil {
+            keys := make([]string, len(s.parentKeys))
+            for i, key := range s.parentKeys {
+                keys[i] = "'" + escapeString(key) + "'"
+            }
+            return "^(" + strings.Join(keys, ", ") + ")"
+        }
         return "^"
+    case segmentKindTypeSelector:
+        return "@" + s.typeSelector + "()"
     }
     panic("unknown segment kind")
 }
@@ -71,16 +91,26 @@ func (s innerSegment) ToString() string {
         }
         builder.WriteString("]")
         break
+    case segmentContainers:
+        break
     default:
         panic("unknown child segment kind")
     }
     return builder.String()
 }
 
-func descend(value *yaml.Node, root *yaml.Node) []*yaml.Node {
-    result := []*yaml.Node{value}
-    for _, child := range value.Content {
-        result = append(result, descend(child, root)...)
+// descend returns value and its descendants in document order. A node the descent is already inside
+// of is handled as the config's CycleHandling says, so that it terminates on cyclic documents. If the
+// filter context tracks paths, the paths of the nodes are returned too.
+func descend(idx index, value *yaml.Node, root *yaml.Node) ([]*yaml.Node, []descentPath) {
+    cfg := configOf(idx)
+    d := descent{idx: idx, cfg: cfg, inside: make(map[*yaml.Node]int), parents: parentTrackingEnabled(idx)}
+    var start descentPath
+    if fc, ok := idx.(*filterContext); ok && fc.pathTrackingActive {
+        // the nodes below value continue the path a wildcard or slice left pending for it
+        d.tracksPaths = true
+        start.segment = fc.GetAndClearPendingPathSegment(value)
     }
-    return result
+    d.visit(expandAlias(cfg, value), start)
+    return d.result, d.paths
 }
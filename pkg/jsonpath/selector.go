@@ -14,6 +14,13 @@ const (
 	selectorSubKindArraySlice
 	selectorSubKindArrayIndex
 	selectorSubKindFilter
+	selectorSubKindCustom
+	// selectorSubKindGlob selects the members whose names match the glob pattern in name
+	selectorSubKindGlob
+	// selectorSubKindKeyPattern selects the members whose names match the regular expression in pattern
+	selectorSubKindKeyPattern
+	// selectorSubKindScript selects the element of an array at the index script computes
+	selectorSubKindScript
 )
 
 type slice struct {
@@ -28,11 +35,16 @@ type selector struct {
 	index  int64
 	slice  *slice
 	filter *filterSelector
+	custom Selector
+	// pattern is the regular expression of a key pattern selector
+	pattern *regexPattern
+	// script is the arithmetic of a script selector such as (@.length/2)
+	script *scriptExpr
 }
 
 func (s selector) ToString() string {
 	switch s.kind {
-	case selectorSubKindName:
+	case selectorSubKindName, selectorSubKindGlob:
 		return "'" + escapeString(s.name) + "'"
 	case selectorSubKindArrayIndex:
 		// int to string
@@ -41,6 +53,12 @@ func (s selector) ToString() string {
 		return "?" + s.filter.ToString()
 	case selectorSubKindWildcard:
 		return "*"
+	case selectorSubKindCustom:
+		return s.custom.String()
+	case selectorSubKindKeyPattern:
+		return s.pattern.source
+	case selectorSubKindScript:
+		return "(" + s.script.ToString() + ")"
 	case selectorSubKindArraySlice:
 		builder := strings.Builder{}
 		if s.slice.start != nil {
@@ -61,3 +79,18 @@ func (s selector) ToString() string {
 	}
 	return ""
 }
+
+// quotedIndex returns the index a name selector such as ['0'] stands for, as JavaScript tooling
+// writes indices, if the name is a non-negative integer without leading zeros
+func quotedIndex(name string) (int64, bool) {
+	if name == "" || name[0] == '0' && name != "0" {
+		return 0, false
+	}
+	for _, c := range name {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	i, err := strconv.ParseInt(name, 10, 64)
+	return i, err == nil
+}
@@ -0,0 +1,108 @@
+package jsonpath
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SelectorSyntax builds a Selector from the body of a custom bracket selector, the text between its
+// delimiters. The Selector's String should return the selector as written, delimiters included, so
+// that the path's String form can be parsed again.
+type SelectorSyntax func(body string) (Selector, error)
+
+type selectorSyntax struct {
+	closing string
+	parse   SelectorSyntax
+}
+
+var (
+	selectorSyntaxesMu sync.RWMutex
+	selectorSyntaxes   = make(map[string]selectorSyntax)
+)
+
+// RegisterSelectorSyntax extends the bracket selector dialect accepted by lenient parsing with
+// selectors written between the open and closing delimiters, such as [~^x-~] for open and closing
+// "~", or [#anchorName] for open "#" and an empty closing delimiter, in which case the body runs to
+// the next ']' or ','. A closing delimiter in the body may be escaped with a backslash, which is
+// passed on to parse.
+//
+// Syntaxes are keyed by their open delimiter, which may not start with a character that begins a
+// standard selector. When several open delimiters match, the longest is used. Paths parsed without
+// config.WithLenientParsing never recognize registered syntaxes.
+func RegisterSelectorSyntax(open, closing string, parse SelectorSyntax) error {
+	if open == "" || strings.ContainsAny(open[:1], "'\"*?:,-[]()$@ \t\r\n0123456789") ||
+		open[0] == '_' || 'a' <= open[0] && open[0] <= 'z' || 'A' <= open[0] && open[0] <= 'Z' {
+		return fmt.Errorf("selector syntax cannot open with %q", open)
+	}
+	if strings.Contains(closing, "]") {
+		return fmt.Errorf("selector syntax cannot close with %q", closing)
+	}
+	if parse == nil {
+		return fmt.Errorf("selector syntax %q has no parser", open)
+	}
+	selectorSyntaxesMu.Lock()
+	defer selectorSyntaxesMu.Unlock()
+	if _, ok := selectorSyntaxes[open]; ok {
+		return fmt.Errorf("selector syntax %q is already registered", open)
+	}
+	selectorSyntaxes[open] = selectorSyntax{closing: closing, parse: parse}
+	return nil
+}
+
+// UnregisterSelectorSyntax removes the syntax registered with the open delimiter, if any. Paths
+// already parsed keep their custom selectors.
+func UnregisterSelectorSyntax(open string) {
+	selectorSyntaxesMu.Lock()
+	defer selectorSyntaxesMu.Unlock()
+	delete(selectorSyntaxes, open)
+}
+
+// registeredSelectorSyntaxes returns a snapshot of the registered syntaxes, so that a path is
+// tokenized and parsed with the same ones
+func registeredSelectorSyntaxes() map[string]selectorSyntax {
+	selectorSyntaxesMu.RLock()
+	defer selectorSyntaxesMu.RUnlock()
+	if len(selectorSyntaxes) == 0 {
+		return nil
+	}
+	snapshot := make(map[string]selectorSyntax, len(selectorSyntaxes))
+	for open, syntax := range selectorSyntaxes {
+		snapshot[open] = syntax
+	}
+	return snapshot
+}
+
+// selectorSyntaxDelimiters maps the open delimiter of each syntax to its closing delimiter, for the
+// tokenizer
+func selectorSyntaxDelimiters(syntaxes map[string]selectorSyntax) map[string]string {
+	delimiters := make(map[string]string, len(syntaxes))
+	for open, syntax := range syntaxes {
+		delimiters[open] = syntax.closing
+	}
+	return delimiters
+}
+
+// parseCustomSelector builds the selector for a custom selector written as raw, with the syntax of
+// the longest matching open delimiter
+func parseCustomSelector(syntaxes map[string]selectorSyntax, raw string) (*selector, error) {
+	open := ""
+	for candidate := range syntaxes {
+		if len(candidate) > len(open) && strings.HasPrefix(raw, candidate) {
+			open = candidate
+		}
+	}
+	syntax, ok := syntaxes[open]
+	if !ok {
+		return nil, fmt.Errorf("unknown selector syntax %q", raw)
+	}
+	body := strings.TrimSuffix(raw[len(open):], syntax.closing)
+	custom, err := syntax.parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %s: %w", raw, err)
+	}
+	if custom == nil {
+		return nil, fmt.Errorf("invalid selector %s: no selector returned", raw)
+	}
+	return &selector{kind: selectorSubKindCustom, custom: custom}, nil
+}
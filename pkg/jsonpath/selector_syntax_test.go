@@ -0,0 +1,154 @@
+package jsonpath
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const selectorSyntaxDoc = `
+info:
+  title: Pets
+  x-owner: platform
+  x-audience: internal
+  version: 1.0.0
+servers:
+  - &primary {url: https://api.example.com}
+  - &sandbox {url: https://sandbox.example.com}
+  - {url: https://legacy.example.com}
+`
+
+// keyPatternSelector selects the members whose name matches a regular expression
+type keyPatternSelector struct {
+	pattern *regexp.Regexp
+}
+
+func (s keyPatternSelector) Select(node *yaml.Node) []*yaml.Node {
+	var selected []*yaml.Node
+	for i := 0; node.Kind == yaml.MappingNode && i+1 < len(node.Content); i += 2 {
+		if s.pattern.MatchString(node.Content[i].Value) {
+			selected = append(selected, node.Content[i+1])
+		}
+	}
+	return selected
+}
+
+func (s keyPatternSelector) String() string {
+	return "~" + s.pattern.String() + "~"
+}
+
+// anchorSelector selects the children with a YAML anchor
+type anchorSelector string
+
+func (s anchorSelector) Select(node *yaml.Node) []*yaml.Node {
+	var selected []*yaml.Node
+	for _, child := range node.Content {
+		if child.Anchor == string(s) {
+			selected = append(selected, child)
+		}
+	}
+	return selected
+}
+
+func (s anchorSelector) String() string {
+	return "#" + string(s)
+}
+
+func registerTestSelectorSyntaxes(t *testing.T) {
+	t.Helper()
+	require.NoError(t, RegisterSelectorSyntax("~", "~", func(body string) (Selector, error) {
+		pattern, err := regexp.Compile(body)
+		if err != nil {
+			return nil, err
+		}
+		return keyPatternSelector{pattern}, nil
+	}))
+	require.NoError(t, RegisterSelectorSyntax("#", "", func(body string) (Selector, error) {
+		return anchorSelector(body), nil
+	}))
+	t.Cleanup(func() {
+		UnregisterSelectorSyntax("~")
+		UnregisterSelectorSyntax("#")
+	})
+}
+
+func TestSelectorSyntax(t *testing.T) {
+	requirePlus(t)
+	registerTestSelectorSyntaxes(t)
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(selectorSyntaxDoc), &node))
+
+	tests := []struct {
+		input    string
+		expected []string
+		str      string
+	}{
+		{input: `$.info[~^x-~]`, expected: []string{"platform", "internal"}, str: `$.info[~^x-~]`},
+		{input: `$.info[ ~^(title|version)$~ ]`, expected: []string{"Pets", "1.0.0"}, str: `$.info[~^(title|version)$~]`},
+		{input: `$.info[~^x-~, 'title']`, expected: []string{"platform", "internal", "Pets"}, str: `$.info[~^x-~, 'title']`},
+		{input: `$.servers[#sandbox].url`, expected: []string{"https://sandbox.example.com"}, str: `$.servers[#sandbox].url`},
+		{input: `$.servers[#primary,#sandbox].url`, expected: []string{"https://api.example.com", "https://sandbox.example.com"}, str: `$.servers[#primary, #sandbox].url`},
+		{input: `$..[#primary].url`, expected: []string{"https://api.example.com"}, str: `$..[#primary].url`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			_, err := NewPath(test.input)
+			require.Error(t, err)
+
+			path, err := NewPath(test.input, config.WithLenientParsing())
+			require.NoError(t, err)
+			assert.Equal(t, test.str, path.String())
+			assert.NotEmpty(t, path.Warnings())
+
+			var values []string
+			for _, result := range path.Query(&node) {
+				values = append(values, result.Value)
+			}
+			assert.Equal(t, test.expected, values)
+		})
+	}
+}
+
+func TestSelectorSyntaxEscapedDelimiter(t *testing.T) {
+	require.NoError(t, RegisterSelectorSyntax("~", "~", func(body string) (Selector, error) {
+		assert.Equal(t, `a\~b`, body)
+		return anchorSelector(body), nil
+	}))
+	t.Cleanup(func() { UnregisterSelectorSyntax("~") })
+
+	_, err := NewPath(`$.info[~a\~b~]`, config.WithLenientParsing())
+	require.NoError(t, err)
+}
+
+func TestSelectorSyntaxErrors(t *testing.T) {
+	registerTestSelectorSyntaxes(t)
+
+	for _, open := range []string{"", "'", "?", "1", "-", "name", "*"} {
+		assert.Error(t, RegisterSelectorSyntax(open, "", func(string) (Selector, error) { return nil, nil }), open)
+	}
+	assert.Error(t, RegisterSelectorSyntax("%", "]", func(string) (Selector, error) { return nil, nil }))
+	assert.Error(t, RegisterSelectorSyntax("%", "%", nil))
+	assert.ErrorContains(t, RegisterSelectorSyntax("~", "~", func(string) (Selector, error) { return nil, nil }), "already registered")
+
+	for input, valid := range map[string]bool{
+		`$.info[~(~]`:                    false,
+		`$.info[~^x-]`:                   false,
+		`$.info[?@[#primary] == 'a']`:    false,
+		`$.info[?length(@[~x~]) == 1]`:   false,
+		`$.info[?@.title == 'Pets', #a]`: true,
+	} {
+		_, err := NewPath(input, config.WithLenientParsing())
+		if valid {
+			assert.NoError(t, err, input)
+		} else {
+			assert.Error(t, err, input)
+		}
+	}
+	_, err := NewPath(`$.info[~(~]`, config.WithLenientParsing())
+	assert.ErrorContains(t, err, "invalid selector ~(~")
+}
@@ -0,0 +1,168 @@
+package jsonpath
+
+import (
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// semanticVersion is a version parsed by semver(), which orders versions by Semantic Versioning
+// 2.0.0 precedence
+type semanticVersion struct {
+	major, minor, patch uint64
+	// prerelease holds the dot-separated pre-release identifiers, empty for a release
+	prerelease []string
+	// text is the version as written
+	text string
+}
+
+// parseSemanticVersion parses a version such as 3.1.0, 1.0.0-rc.1 or 2.4.1+build.7. A leading v is
+// accepted, as are versions giving only the major and minor numbers, or only the major number, as
+// YAML reads an unquoted 3.1 as a number. Build metadata is accepted and ignored.
+func parseSemanticVersion(text string) (*semanticVersion, bool) {
+	v := &semanticVersion{text: text}
+	s := strings.TrimPrefix(strings.TrimSpace(text), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		if !validIdentifiers(s[i+1:], false) {
+			return nil, false
+		}
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		if !validIdentifiers(s[i+1:], true) {
+			return nil, false
+		}
+		v.prerelease = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+	numbers := strings.Split(s, ".")
+	if len(numbers) > 3 {
+		return nil, false
+	}
+	fields := []*uint64{&v.major, &v.minor, &v.patch}
+	for i, number := range numbers {
+		if !isNumericIdentifier(number) {
+			return nil, false
+		}
+		n, err := strconv.ParseUint(number, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		*fields[i] = n
+	}
+	return v, true
+}
+
+// validIdentifiers reports whether s is a dot-separated list of non-empty identifiers of ASCII
+// letters, digits and hyphens. Numeric pre-release identifiers must not have leading zeros.
+func validIdentifiers(s string, prerelease bool) bool {
+	for _, identifier := range strings.Split(s, ".") {
+		if identifier == "" {
+			return false
+		}
+		for _, c := range identifier {
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+				return false
+			}
+		}
+		if prerelease && isDigits(identifier) && !isNumericIdentifier(identifier) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isNumericIdentifier reports whether s is a number without leading zeros
+func isNumericIdentifier(s string) bool {
+	return isDigits(s) && (s == "0" || s[0] != '0')
+}
+
+// compare returns -1, 0 or 1 as v precedes, has the same precedence as, or follows other
+func (v *semanticVersion) compare(other *semanticVersion) int {
+	for _, pair := range [][2]uint64{{v.major, other.major}, {v.minor, other.minor}, {v.patch, other.patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+	// a pre-release precedes the release
+	switch {
+	case len(v.prerelease) == 0 && len(other.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(other.prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.prerelease) && i < len(other.prerelease); i++ {
+		if c := compareIdentifiers(v.prerelease[i], other.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	// a shorter list of identifiers precedes a longer one it begins
+	switch {
+	case len(v.prerelease) < len(other.prerelease):
+		return -1
+	case len(v.prerelease) > len(other.prerelease):
+		return 1
+	}
+	return 0
+}
+
+// compareIdentifiers compares pre-release identifiers: numerically if both are numbers, which
+// precede the others, and otherwise in ASCII order
+func compareIdentifiers(a string, b string) int {
+	aNumeric, bNumeric := isDigits(a), isDigits(b)
+	switch {
+	case aNumeric && bNumeric:
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// semver parses a string or number into a version that compares by Semantic Versioning precedence.
+// The result is Nothing if the argument is not a version.
+func (e functionExpr) semver(idx index, node *yaml.Node, root *yaml.Node) literal {
+	args := e.args[0].Eval(idx, node, root)
+	if args.kind != functionArgTypeLiteral || args.literal == nil {
+		return literal{}
+	}
+	var text string
+	switch lit := args.literal; {
+	case lit.string != nil:
+		text = *lit.string
+	case lit.integer != nil:
+		text = strconv.Itoa(*lit.integer)
+	case lit.float64 != nil:
+		text = strconv.FormatFloat(*lit.float64, 'f', -1, 64)
+	default:
+		return literal{}
+	}
+	version, ok := parseSemanticVersion(text)
+	if !ok {
+		return literal{}
+	}
+	return literal{version: version}
+}
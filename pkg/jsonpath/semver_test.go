@@ -0,0 +1,36 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemanticVersionPrecedence(t *testing.T) {
+	// the ordering example of Semantic Versioning 2.0.0, with a version ignoring build metadata
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1+exp.sha.5114f85", "v2", "2.1", "10.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, ok := parseSemanticVersion(ordered[i])
+			require.True(t, ok, ordered[i])
+			b, ok := parseSemanticVersion(ordered[j])
+			require.True(t, ok, ordered[j])
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+			assert.Equal(t, expected, a.compare(b), "%s and %s", ordered[i], ordered[j])
+		}
+	}
+
+	for _, invalid := range []string{"", "1.2.3.4", "01.2.3", "1.2.3-01", "1.2.3-", "1.2.3-a..b", "1.2.3+", "1.x", "1.2.3-a_b", "latest"} {
+		_, ok := parseSemanticVersion(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
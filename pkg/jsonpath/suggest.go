@@ -0,0 +1,394 @@
+package jsonpath
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"go.yaml.in/yaml/v4"
+)
+
+// SuggestionKind tells what a Suggestion completes.
+type SuggestionKind int
+
+const (
+	// SuggestMember completes a member name
+	SuggestMember SuggestionKind = iota
+	// SuggestIndex completes an array index
+	SuggestIndex
+	// SuggestFunction completes the name of a filter function
+	SuggestFunction
+)
+
+// Suggestion is a completion of a partial expression.
+type Suggestion struct {
+	Kind SuggestionKind
+	// Label is the member name, index or function name being suggested
+	Label string
+	// Text replaces the last Replace bytes of the partial expression, e.g. title for $.info.ti, or
+	// ['x-logo'] for $.info.x, which replaces the dot as well
+	Text    string
+	Replace int
+	// Count is the number of nodes at the cursor having the member or index, and 0 for functions
+	Count int
+}
+
+// Suggest returns the completions of expr, the text of an expression up to the cursor, against the
+// document rooted at root. After a dot, a descendant segment or an opening bracket it suggests the
+// member names and indices of the nodes selected by the query before it, ranked by how many of those
+// nodes have them and then in document order. At an operand of a filter it suggests the function
+// names, in alphabetical order. Queries relative to a filter, such as $.paths[?@.get.op, are resolved
+// against the nodes the filter tests.
+// A partial expression whose query before the cursor does not parse has no suggestions.
+func Suggest(expr string, root *yaml.Node, opts ...config.Option) []Suggestion {
+	if root != nil && root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		root = root.Content[0]
+	}
+	s := &suggester{root: root, opts: opts, cfg: config.New(opts...)}
+	return s.suggest(expr)
+}
+
+type suggester struct {
+	root *yaml.Node
+	opts []config.Option
+	cfg  config.Config
+}
+
+func (s *suggester) suggest(expr string) []Suggestion {
+	if quote := openQuote(expr); quote >= 0 {
+		// a member name in brackets, as in $.paths['/us
+		if quote == 0 || expr[quote-1] != '[' {
+			return nil
+		}
+		nodes, ok := s.resolve(expr[:quote-1])
+		if !ok {
+			return nil
+		}
+		partial := expr[quote+1:]
+		return s.members(nodes, partial, func(name string) (string, int) {
+			return escapeQuoted(name, expr[quote]) + expr[quote:quote+1] + "]", len(partial)
+		})
+	}
+
+	start := len(expr)
+	for start > 0 && isNameByte(expr[start-1]) {
+		start--
+	}
+	partial, before := expr[start:], expr[:start]
+	switch {
+	case strings.HasSuffix(before, ".."):
+		nodes, ok := s.resolve(before[:len(before)-2])
+		if !ok {
+			return nil
+		}
+		return s.members(s.descendants(nodes), partial, func(name string) (string, int) {
+			if isShorthandName(name) {
+				return name, len(partial)
+			}
+			return normalizePathSegment(name), len(partial)
+		})
+	case strings.HasSuffix(before, "."):
+		nodes, ok := s.resolve(before[:len(before)-1])
+		if !ok {
+			return nil
+		}
+		return s.members(nodes, partial, func(name string) (string, int) {
+			if isShorthandName(name) {
+				return name, len(partial)
+			}
+			return normalizePathSegment(name), len(partial) + 1
+		})
+	case strings.HasSuffix(before, "["):
+		nodes, ok := s.resolve(before[:len(before)-1])
+		if !ok || strings.TrimLeft(partial, "0123456789") != "" {
+			return nil
+		}
+		suggestions := s.indices(nodes, partial)
+		if partial == "" {
+			suggestions = append(suggestions, s.members(nodes, partial, func(name string) (string, int) {
+				return "'" + escapePathSegment(name) + "']", 0
+			})...)
+		}
+		return suggestions
+	case openFilter(before) >= 0 && strings.ContainsAny(lastByte(strings.TrimRight(before, " \t")), "?(!,&|=<>+-*/%"):
+		return s.functions(partial)
+	}
+	return nil
+}
+
+// resolve returns the nodes selected by the query ending expr. A query starting with @ is resolved
+// against each node tested by the innermost filter it is in.
+func (s *suggester) resolve(expr string) ([]*yaml.Node, bool) {
+	start := queryStart(expr)
+	if start < 0 {
+		return nil, false
+	}
+	query := expr[start:]
+	if strings.HasPrefix(query, "@root") && (len(query) == 5 || !isNameByte(query[5])) {
+		query = "$" + query[5:]
+	}
+	if query[0] == '$' {
+		return s.query(query, []*yaml.Node{s.root})
+	}
+	if len(query) > 1 && isNameByte(query[1]) {
+		// other context variables have no nodes to complete
+		return nil, false
+	}
+	filter := openFilter(expr[:start])
+	if filter < 0 {
+		return nil, false
+	}
+	parents, ok := s.resolve(expr[:filter])
+	if !ok {
+		return nil, false
+	}
+	var tested []*yaml.Node
+	for _, parent := range parents {
+		if parent == nil {
+			continue
+		}
+		parent = expandAlias(s.cfg, parent)
+		switch parent.Kind {
+		case yaml.MappingNode:
+			for i := 1; i < len(parent.Content); i += 2 {
+				tested = append(tested, parent.Content[i])
+			}
+		case yaml.SequenceNode:
+			tested = append(tested, parent.Content...)
+		}
+	}
+	return s.query("$"+query[1:], tested)
+}
+
+// query returns the nodes selected by the absolute query from each of roots
+func (s *suggester) query(query string, roots []*yaml.Node) ([]*yaml.Node, bool) {
+	path, err := NewPath(query, s.opts...)
+	if err != nil {
+		return nil, false
+	}
+	var nodes []*yaml.Node
+	for _, root := range roots {
+		if root != nil {
+			nodes = append(nodes, path.Query(root)...)
+		}
+	}
+	return nodes, true
+}
+
+// descendants returns nodes and every node below them, each once
+func (s *suggester) descendants(nodes []*yaml.Node) []*yaml.Node {
+	var all []*yaml.Node
+	seen := make(map[*yaml.Node]bool)
+	var visit func(node *yaml.Node)
+	visit = func(node *yaml.Node) {
+		if node == nil {
+			return
+		}
+		node = expandAlias(s.cfg, node)
+		if seen[node] {
+			return
+		}
+		seen[node] = true
+		all = append(all, node)
+		for i, child := range node.Content {
+			if node.Kind != yaml.MappingNode || i%2 == 1 {
+				visit(child)
+			}
+		}
+	}
+	for _, node := range nodes {
+		visit(node)
+	}
+	return all
+}
+
+// members ranks the member names starting with partial of the mappings among nodes, completed by text.
+// Members with a nil key or value are skipped, as the evaluator skips them.
+func (s *suggester) members(nodes []*yaml.Node, partial string, text func(name string) (string, int)) []Suggestion {
+	var r ranking
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+		node = expandAlias(s.cfg, node)
+		if node.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i] == nil || node.Content[i+1] == nil {
+				continue
+			}
+			name := node.Content[i].Value
+			if strings.HasPrefix(name, partial) {
+				completion, replace := text(name)
+				r.add(Suggestion{Kind: SuggestMember, Label: name, Text: completion, Replace: replace})
+			}
+		}
+	}
+	return r.ranked()
+}
+
+// indices ranks the indices starting with partial of the sequences among nodes, skipping nil elements
+func (s *suggester) indices(nodes []*yaml.Node, partial string) []Suggestion {
+	var r ranking
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+		node = expandAlias(s.cfg, node)
+		if node.Kind != yaml.SequenceNode {
+			continue
+		}
+		for i, element := range node.Content {
+			if element == nil {
+				continue
+			}
+			if index := strconv.Itoa(i); strings.HasPrefix(index, partial) {
+				r.add(Suggestion{Kind: SuggestIndex, Label: index, Text: index + "]", Replace: len(partial)})
+			}
+		}
+	}
+	return r.ranked()
+}
+
+// functions returns the functions whose names start with partial, including registered functions
+// and, in JSONPath Plus mode, the extension and type selector functions
+func (s *suggester) functions(partial string) []Suggestion {
+	var names []string
+	for name := range functionTypeMap {
+		if isStandardFunction(name) || plusEnabled(s.cfg) {
+			names = append(names, name)
+		}
+	}
+	if plusEnabled(s.cfg) {
+		for name := range typeSelectorFunctionMap {
+			names = append(names, name)
+		}
+	}
+	for name := range s.cfg.Functions() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var suggestions []Suggestion
+	for _, name := range names {
+		if strings.HasPrefix(name, partial) {
+			suggestions = append(suggestions, Suggestion{Kind: SuggestFunction, Label: name, Text: name + "(", Replace: len(partial)})
+		}
+	}
+	return suggestions
+}
+
+// ranking counts how many nodes have each suggestion, in the order they are first seen
+type ranking struct {
+	suggestions []Suggestion
+	index       map[string]int
+}
+
+func (r *ranking) add(suggestion Suggestion) {
+	if i, ok := r.index[suggestion.Label]; ok {
+		r.suggestions[i].Count++
+		return
+	}
+	if r.index == nil {
+		r.index = make(map[string]int)
+	}
+	suggestion.Count = 1
+	r.index[suggestion.Label] = len(r.suggestions)
+	r.suggestions = append(r.suggestions, suggestion)
+}
+
+// ranked returns the suggestions by descending count, and in the order they were first seen otherwise
+func (r *ranking) ranked() []Suggestion {
+	sort.SliceStable(r.suggestions, func(i, j int) bool {
+		return r.suggestions[i].Count > r.suggestions[j].Count
+	})
+	return r.suggestions
+}
+
+// openQuote returns the index of the quote opening a string literal left open at the end of expr,
+// or -1 if there is none
+func openQuote(expr string) int {
+	open := -1
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case open >= 0 && c == '\\':
+			i++
+		case open >= 0 && c == expr[open]:
+			open = -1
+		case open < 0 && (c == '\'' || c == '"'):
+			open = i
+		}
+	}
+	return open
+}
+
+// openFilter returns the index of the bracket of the innermost filter selector left open at the end
+// of expr, or -1 if there is none
+func openFilter(expr string) int {
+	var brackets []int
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '\'', '"':
+			end := strings.IndexByte(expr[i+1:], expr[i])
+			if end < 0 {
+				return -1
+			}
+			i += end + 1
+		case '[':
+			brackets = append(brackets, i)
+		case ']':
+			if len(brackets) > 0 {
+				brackets = brackets[:len(brackets)-1]
+			}
+		}
+	}
+	for i := len(brackets) - 1; i >= 0; i-- {
+		if strings.HasPrefix(strings.TrimLeft(expr[brackets[i]+1:], " \t"), "?") {
+			return brackets[i]
+		}
+	}
+	return -1
+}
+
+// queryStart returns the index of the $ or @ starting the query that ends expr, or -1 if expr does
+// not end with a query
+func queryStart(expr string) int {
+	depth := 0
+	for i := len(expr) - 1; i >= 0; i-- {
+		c := expr[i]
+		switch {
+		case c == ']':
+			depth++
+		case c == '[':
+			if depth == 0 {
+				return -1
+			}
+			depth--
+		case depth > 0:
+		case c == '$' || c == '@':
+			return i
+		case isNameByte(c) || c == '.' || c == '*':
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+// escapeQuoted escapes name for a string literal quoted with quote
+func escapeQuoted(name string, quote byte) string {
+	return strings.NewReplacer(`\`, `\\`, string(quote), `\`+string(quote)).Replace(name)
+}
+
+func lastByte(s string) string {
+	if s == "" {
+		return ""
+	}
+	return s[len(s)-1:]
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || c >= 0x80 || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
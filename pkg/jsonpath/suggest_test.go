@@ -0,0 +1,168 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const suggestDoc = `
+info: {title: Pets, x-logo: pets.png, version: 1.0.0}
+paths:
+  /pets:
+    get: {operationId: listPets, tags: [pets]}
+    post: {operationId: createPet, summary: Create a pet}
+  /pets/{id}:
+    get: {operationId: showPet, summary: Show a pet}
+tags: [{name: pets}, {name: store}, {name: users}]
+`
+
+func TestSuggest(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(suggestDoc), &root))
+
+	type completion struct {
+		Label   string
+		Text    string
+		Replace int
+		Count   int
+	}
+	tests := []struct {
+		name     string
+		expr     string
+		expected []completion
+	}{
+		{
+			name:     "members of the root",
+			expr:     `$.`,
+			expected: []completion{{"info", "info", 0, 1}, {"paths", "paths", 0, 1}, {"tags", "tags", 0, 1}},
+		},
+		{
+			name:     "partial member name",
+			expr:     `$.info.ti`,
+			expected: []completion{{"title", "title", 2, 1}},
+		},
+		{
+			name:     "member name needing brackets",
+			expr:     `$.info.x`,
+			expected: []completion{{"x-logo", "['x-logo']", 2, 1}},
+		},
+		{
+			name:     "quoted member name",
+			expr:     `$.paths['/pets/`,
+			expected: []completion{{"/pets/{id}", "/pets/{id}']", 6, 1}},
+		},
+		{
+			name:     "ranked by the nodes having them",
+			expr:     `$.paths.*.*.`,
+			expected: []completion{{"operationId", "operationId", 0, 3}, {"summary", "summary", 0, 2}, {"tags", "tags", 0, 1}},
+		},
+		{
+			name:     "descendant members",
+			expr:     `$..s`,
+			expected: []completion{{"summary", "summary", 1, 2}},
+		},
+		{
+			name:     "indices",
+			expr:     `$.tags[`,
+			expected: []completion{{"0", "0]", 0, 1}, {"1", "1]", 0, 1}, {"2", "2]", 0, 1}},
+		},
+		{
+			name:     "members in brackets",
+			expr:     `$.info[`,
+			expected: []completion{{"title", "'title']", 0, 1}, {"x-logo", "'x-logo']", 0, 1}, {"version", "'version']", 0, 1}},
+		},
+		{
+			name:     "members of the nodes a filter tests",
+			expr:     `$.tags[?@.`,
+			expected: []completion{{"name", "name", 0, 3}},
+		},
+		{
+			name:     "nested filter",
+			expr:     `$.paths[?@[?@.operationId == 'x' || @.s`,
+			expected: []completion{{"summary", "summary", 1, 2}},
+		},
+		{
+			name:     "root in a filter",
+			expr:     `$.tags[?@.name == @root.info.v`,
+			expected: []completion{{"version", "version", 1, 1}},
+		},
+		{
+			name:     "functions",
+			expr:     `$.tags[?@.name == 'x' || le`,
+			expected: []completion{{"length", "length(", 2, 0}},
+		},
+		{
+			name: "query that does not parse",
+			expr: `$.[`,
+		},
+		{
+			name: "no members",
+			expr: `$.info.title.`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var actual []completion
+			for _, s := range Suggest(test.expr, &root) {
+				actual = append(actual, completion{s.Label, s.Text, s.Replace, s.Count})
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestSuggestFunctions(t *testing.T) {
+	requirePlus(t)
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(suggestDoc), &root))
+
+	opts := []config.Option{config.WithFunction("isPet", config.Function{Args: 1, Call: func(config.FunctionCall) any { return true }})}
+	suggestions := Suggest(`$.tags[?is`, &root, opts...)
+	var labels []string
+	for _, s := range suggestions {
+		assert.Equal(t, SuggestFunction, s.Kind)
+		labels = append(labels, s.Label)
+	}
+	assert.Equal(t, []string{"isArray", "isBoolean", "isInteger", "isNull", "isNumber", "isObject", "isPet", "isString"}, labels)
+
+	opts = append(opts, config.WithStrictRFC9535())
+	suggestions = Suggest(`$.tags[?is`, &root, opts...)
+	require.Len(t, suggestions, 1)
+	assert.Equal(t, "isPet", suggestions[0].Label)
+}
+
+func TestSuggestMalformed(t *testing.T) {
+	root := mapping(nil, scalar("v"), scalar("k"), scalar("w"), scalar("a"), nil,
+		scalar("items"), sequence(nil, mapping(scalar("name"), nil), mapping(scalar("id"), scalar("1"))))
+
+	var labels []string
+	assert.NotPanics(t, func() {
+		for _, s := range Suggest(`$.`, root) {
+			labels = append(labels, s.Label)
+		}
+	})
+	assert.Equal(t, []string{"k", "items"}, labels)
+
+	labels = nil
+	assert.NotPanics(t, func() {
+		for _, s := range Suggest(`$.items[`, root) {
+			labels = append(labels, s.Label)
+		}
+	})
+	assert.Equal(t, []string{"1", "2"}, labels)
+
+	labels = nil
+	assert.NotPanics(t, func() {
+		for _, s := range Suggest(`$.items[*].`, root) {
+			labels = append(labels, s.Label)
+		}
+		Suggest(`$..`, root)
+		Suggest(`$.items[?@.`, root)
+	})
+	assert.Equal(t, []string{"id"}, labels)
+}
@@ -5,6 +5,8 @@ import (
     "github.com/pb33f/jsonpath/pkg/jsonpath/config"
     "strconv"
     "strings"
+    "unicode"
+    "unicode/utf16"
 )
 
 // *****************************************************************************
@@ -194,6 +196,14 @@ const (
     LT
     LE
     MATCHES
+    IN
+    PLUS
+    MINUS
+    MULTIPLY
+    DIVIDE
+    MODULO
+    REGEX
+    CUSTOM_SELECTOR
     FUNCTION
 
     // JSONPath Plus context variable tokens
@@ -203,9 +213,22 @@ const (
     CONTEXT_PARENT_PROPERTY // @parentProperty - parent's property name
     CONTEXT_PATH            // @path - absolute path to current node
     CONTEXT_INDEX           // @index - current array index
+    CONTEXT_PROPERTY_PATH   // @propertyPath - keys/indices from the root to the current node
+    CONTEXT_PATH_KEYS       // @pathKeys - another name for @propertyPath
+    CONTEXT_SIBLING         // @sibling - sibling of the current node within its parent
+    CONTEXT_PREV            // @prev - previous element of the current sequence
+    CONTEXT_NEXT            // @next - next element of the current sequence
+    CONTEXT_LINE            // @line - line of the current node in its source
+    CONTEXT_COLUMN          // @column - column of the current node in its source
 
     // JSONPath Plus parent selector
     PARENT_SELECTOR // ^ - select parent of current node
+
+    // JSONPath Plus type selector
+    TYPE_SELECTOR // @number() etc. - keep nodes of a type
+
+    // JSONPath Plus union of complete queries
+    PIPE // | - merge the results of the queries either side
 )
 
 var SimpleTokens = [...]Token{
@@ -257,6 +280,14 @@ var tokens = [...]string{
     LT:            "<",
     LE:            "<=",
     MATCHES:       "=~",
+    IN:            "in",
+    PLUS:          "+",
+    MINUS:         "-",
+    MULTIPLY:      "*",
+    DIVIDE:        "/",
+    MODULO:        "%",
+    REGEX:         "REGEX",
+    CUSTOM_SELECTOR: "CUSTOM_SELECTOR",
     FUNCTION:      "FUNCTION",
 
     // JSONPath Plus context variables
@@ -266,9 +297,22 @@ var tokens = [...]string{
     CONTEXT_PARENT_PROPERTY: "@parentProperty",
     CONTEXT_PATH:            "@path",
     CONTEXT_INDEX:           "@index",
+    CONTEXT_PROPERTY_PATH:   "@propertyPath",
+    CONTEXT_PATH_KEYS:       "@pathKeys",
+    CONTEXT_SIBLING:         "@sibling",
+    CONTEXT_PREV:            "@prev",
+    CONTEXT_NEXT:            "@next",
+    CONTEXT_LINE:            "@line",
+    CONTEXT_COLUMN:          "@column",
 
     // JSONPath Plus parent selector
     PARENT_SELECTOR: "^",
+
+    // JSONPath Plus type selector
+    TYPE_SELECTOR: "TYPE_SELECTOR",
+
+    // JSONPath Plus union of complete queries
+    PIPE: "|",
 }
 
 // String returns the string representation of the token.
@@ -416,6 +460,7 @@ type Tokenizer struct {
     stack             []Token
     illegalWhitespace bool
     config            config.Config
+    selectorSyntaxes  map[string]string
 }
 
 // NewTokenizer creates a new JSONPath tokenizer for the given input string.
@@ -429,6 +474,13 @@ func NewTokenizer(input string, opts ...config.Option) *Tokenizer {
     }
 }
 
+// SetSelectorSyntaxes enables custom bracket selector syntaxes, mapping each opening delimiter to its
+// closing delimiter. They are only recognized in lenient mode, as the first characters of a selector.
+// A selector with an empty closing delimiter ends at the next ']' or ','.
+func (t *Tokenizer) SetSelectorSyntaxes(delimiters map[string]string) {
+    t.selectorSyntaxes = delimiters
+}
+
 // Tokenize tokenizes the input string and returns a slice of TokenInfo.
 func (t *Tokenizer) Tokenize() Tokens {
     for t.pos < len(t.input) {
@@ -439,14 +491,26 @@ func (t *Tokenizer) Tokenize() Tokens {
             break
         }
 
+        if open, ok := t.selectorSyntaxAt(); ok {
+            t.scanCustomSelector(open)
+            t.pos++
+            t.column++
+            continue
+        }
+
         switch ch := t.input[t.pos]; {
         case ch == '$':
             t.addToken(ROOT, 1, "")
         case ch == '@':
             // Check for JSONPath Plus context variables when enabled
             handled := false
-            if t.config.JSONPathPlusEnabled() {
-                if contextToken, length := t.tryContextVariable(); contextToken != ILLEGAL {
+            if t.plusEnabled() {
+                if keyword, length := t.tryTypeSelector(); length > 0 {
+                    t.addToken(TYPE_SELECTOR, length, keyword)
+                    t.pos += length - 1
+                    t.column += length - 1
+                    handled = true
+                } else if contextToken, length := t.tryContextVariable(); contextToken != ILLEGAL {
                     t.addToken(contextToken, length, "")
                     // Advance past the token (minus 1 because main loop does pos++)
                     t.pos += length - 1
@@ -458,7 +522,11 @@ func (t *Tokenizer) Tokenize() Tokens {
                 t.addToken(CURRENT, 1, "")
             }
         case ch == '*':
-            t.addToken(WILDCARD, 1, "")
+            if t.followsValue() {
+                t.addToken(MULTIPLY, 1, "")
+            } else {
+                t.addToken(WILDCARD, 1, "")
+            }
         case ch == '~':
             if t.config.PropertyNameEnabled() {
                 t.addToken(PROPERTY_NAME, 1, "")
@@ -467,8 +535,15 @@ func (t *Tokenizer) Tokenize() Tokens {
             }
         case ch == '^':
             // JSONPath Plus parent selector
-            if t.config.JSONPathPlusEnabled() {
-                t.addToken(PARENT_SELECTOR, 1, "")
+            if t.plusEnabled() {
+                // ^2 ascends two levels, like ^^
+                length := 1
+                for t.pos+length < len(t.input) && isDigit(t.input[t.pos+length]) {
+                    length++
+                }
+                t.addToken(PARENT_SELECTOR, length, t.input[t.pos+1:t.pos+length])
+                t.pos += length - 1
+                t.column += length - 1
             } else {
                 t.addToken(ILLEGAL, 1, "parent selector ^ requires JSONPath Plus mode (enabled by default, disabled with StrictRFC9535)")
             }
@@ -521,6 +596,8 @@ func (t *Tokenizer) Tokenize() Tokens {
                 t.addToken(OR, 2, "")
                 t.pos++
                 t.column++
+            } else if t.plusEnabled() && len(t.stack) == 0 {
+                t.addToken(PIPE, 1, "")
             } else {
                 t.addToken(ILLEGAL, 1, "invalid token")
             }
@@ -576,6 +653,13 @@ func (t *Tokenizer) Tokenize() Tokens {
             }
         case ch == '"' || ch == '\'':
             t.scanString(rune(ch))
+        case ch == '/' && len(t.tokens) > 0 && t.tokens[len(t.tokens)-1].Token == MATCHES:
+            t.scanRegex()
+        case ch == '/' && t.plusEnabled() && t.startsSelector():
+            // JSONPath Plus: a regular expression selecting members by name, as in $.paths[/^\/v1\//]
+            t.scanRegex()
+        case (ch == '+' || ch == '-' || ch == '/' || ch == '%') && t.followsValue():
+            t.addToken(arithmeticTokens[ch], 1, "")
         case ch == '-' && isDigit(t.peek()):
             fallthrough
         case isDigit(ch):
@@ -606,6 +690,92 @@ func (t *Tokenizer) addToken(token Token, len int, literal string) {
     t.illegalWhitespace = false
 }
 
+// scanRegex scans a regular expression literal such as /^\/users/i, which may only follow =~ or
+// start a selector.
+// The literal of the token is the expression as written, including its slashes and flags.
+func (t *Tokenizer) scanRegex() {
+    start := t.pos
+    inClass := false
+    for i := start + 1; i < len(t.input); i++ {
+        switch t.input[i] {
+        case '\\':
+            i++
+        case '[':
+            inClass = true
+        case ']':
+            inClass = false
+        case '/':
+            if inClass {
+                continue
+            }
+            end := i + 1
+            for end < len(t.input) && isLiteralChar(t.input[end]) {
+                end++
+            }
+            t.addToken(REGEX, end-start, t.input[start:end])
+            t.pos = end - 1
+            t.column += end - start - 1
+            return
+        }
+    }
+    t.addToken(ILLEGAL, len(t.input)-start, "unterminated regular expression")
+    t.pos = len(t.input) - 1
+    t.column += len(t.input) - start - 1
+}
+
+// startsSelector reports whether the current position starts a selector: straight after '[' or after
+// a ',' between brackets
+func (t *Tokenizer) startsSelector() bool {
+    if len(t.tokens) == 0 || len(t.stack) == 0 || t.stack[len(t.stack)-1] != BRACKET_LEFT {
+        return false
+    }
+    last := t.tokens[len(t.tokens)-1].Token
+    return last == BRACKET_LEFT || last == COMMA
+}
+
+// selectorSyntaxAt returns the longest opening delimiter of a custom selector syntax at the current
+// position, if it starts a selector: straight after '[' or after a ',' between brackets
+func (t *Tokenizer) selectorSyntaxAt() (string, bool) {
+    if len(t.selectorSyntaxes) == 0 || !t.config.LenientParsingEnabled() || !t.startsSelector() {
+        return "", false
+    }
+    longest, found := "", false
+    for open := range t.selectorSyntaxes {
+        if len(open) > len(longest) && strings.HasPrefix(t.input[t.pos:], open) {
+            longest, found = open, true
+        }
+    }
+    return longest, found
+}
+
+// scanCustomSelector scans a custom selector such as ~^x-~, up to and including its closing
+// delimiter, which may be escaped with a backslash in the body. The literal of the token is the
+// selector as written, including its delimiters.
+func (t *Tokenizer) scanCustomSelector(open string) {
+    start := t.pos
+    closing := t.selectorSyntaxes[open]
+    for i := start + len(open); i < len(t.input); i++ {
+        end := -1
+        switch {
+        case t.input[i] == '\\':
+            i++
+        case closing == "" && (t.input[i] == ']' || t.input[i] == ','):
+            end = i
+        case closing != "" && strings.HasPrefix(t.input[i:], closing):
+            end = i + len(closing)
+        }
+        if end >= 0 {
+            t.addToken(CUSTOM_SELECTOR, end-start, t.input[start:end])
+            t.pos = end - 1
+            t.column += end - start - 1
+            return
+        }
+    }
+    t.addToken(ILLEGAL, len(t.input)-start, "unterminated custom selector")
+    t.pos = len(t.input) - 1
+    t.column += len(t.input) - start - 1
+}
+
 func (t *Tokenizer) scanString(quote rune) {
     start := t.pos + 1
     var literal strings.Builder
@@ -635,9 +805,16 @@ illegal:
             case 'n':
                 literal.WriteByte('\n')
             case 'r':
-                literal.WriteByte('\n')
+                literal.WriteByte('\r')
             case 't':
                 literal.WriteByte('\t')
+            case 'u':
+                r, n, ok := unicodeEscape(t.input[i+1:])
+                if !ok {
+                    break illegal
+                }
+                literal.WriteRune(r)
+                i += n
             case '\'':
                 if quote != '\'' {
                     // don't escape it, when we're not in a single quoted string
@@ -666,6 +843,37 @@ illegal:
     t.column = len(t.input) - 1
 }
 
+// unicodeEscape decodes the four hex digits following \u at the start of s, and the low surrogate
+// following a high one, returning the character and the number of bytes it took
+func unicodeEscape(s string) (rune, int, bool) {
+    hex := func(s string) (rune, bool) {
+        if len(s) < 4 {
+            return 0, false
+        }
+        r, err := strconv.ParseUint(s[:4], 16, 16)
+        return rune(r), err == nil
+    }
+    r, ok := hex(s)
+    if !ok {
+        return 0, 0, false
+    }
+    if !utf16.IsSurrogate(r) {
+        return r, 4, true
+    }
+    if len(s) < 6 || s[4:6] != "\\u" {
+        return 0, 0, false
+    }
+    low, ok := hex(s[6:])
+    if !ok {
+        return 0, 0, false
+    }
+    r = utf16.DecodeRune(r, low)
+    if r == unicode.ReplacementChar {
+        return 0, 0, false
+    }
+    return r, 10, true
+}
+
 func (t *Tokenizer) scanNumber() {
     start := t.pos
     tokenType := INTEGER
@@ -758,10 +966,16 @@ func (t *Tokenizer) scanLiteral() {
                 t.addToken(FALSE, len(literal), literal)
             case "null":
                 t.addToken(NULL, len(literal), literal)
+            case "in":
+                if t.followsOperand() {
+                    t.addToken(IN, len(literal), literal)
+                } else {
+                    t.addToken(STRING, len(literal), literal)
+                }
             default:
                 // Only treat as FUNCTION if it's a function name AND followed by '('
                 // Otherwise it's a property name (STRING)
-                if isFunctionName(literal) && i < len(t.input) && t.input[i] == '(' {
+                if t.isFunctionName(literal) && i < len(t.input) && t.input[i] == '(' {
                     t.addToken(FUNCTION, len(literal), literal)
                     t.illegalWhitespace = true
                 } else {
@@ -788,14 +1002,74 @@ func (t *Tokenizer) scanLiteral() {
     t.column = len(t.input) - 1
 }
 
-func isFunctionName(literal string) bool {
+// arithmeticTokens are the operators of JSONPath Plus arithmetic, which only follow an operand, as in
+// $[?@.price * @.qty > 100]
+var arithmeticTokens = map[byte]Token{'+': PLUS, '-': MINUS, '*': MULTIPLY, '/': DIVIDE, '%': MODULO}
+
+// followsOperand reports whether the last token ends an operand inside brackets, so that a following
+// "in" is the membership operator rather than a member name, as in $[?@.in in @root.allowed]
+func (t *Tokenizer) followsOperand() bool {
+    if len(t.tokens) == 0 || len(t.stack) == 0 {
+        return false
+    }
+    switch t.tokens[len(t.tokens)-1].Token {
+    case STRING, STRING_LITERAL, INTEGER, FLOAT, TRUE, FALSE, NULL, CURRENT, ROOT, WILDCARD, BRACKET_RIGHT, PAREN_RIGHT,
+        CONTEXT_PROPERTY, CONTEXT_ROOT, CONTEXT_PARENT, CONTEXT_PARENT_PROPERTY, CONTEXT_PATH, CONTEXT_INDEX,
+        CONTEXT_PROPERTY_PATH, CONTEXT_PATH_KEYS, CONTEXT_PREV, CONTEXT_NEXT, CONTEXT_LINE, CONTEXT_COLUMN:
+        return true
+    }
+    return false
+}
+
+// followsValue reports whether the last token ends an operand that can be a single value, so that a
+// following "-" or "*" is an arithmetic operator rather than a sign or wildcard
+func (t *Tokenizer) followsValue() bool {
+    return t.followsOperand() && t.tokens[len(t.tokens)-1].Token != WILDCARD
+}
+
+// plusEnabled reports whether JSONPath Plus syntax is tokenized, which it never is in builds with the
+// jsonpath_core tag.
+func (t *Tokenizer) plusEnabled() bool {
+    return config.PlusAvailable && t.config.JSONPathPlusEnabled()
+}
+
+func (t *Tokenizer) isFunctionName(literal string) bool {
+    if _, ok := t.config.Functions()[literal]; ok {
+        return true
+    }
     switch literal {
     // RFC 9535 standard functions
     case "length", "count", "match", "search", "value":
         return true
+    }
+    if !config.PlusAvailable {
+        return false
+    }
+    switch literal {
     // JSONPath Plus type selector functions
     case "isNull", "isBoolean", "isNumber", "isString", "isArray", "isObject", "isInteger":
         return true
+    // binary payload functions
+    case "decodeBase64", "byteLength":
+        return true
+    // membership functions
+    case "contains", "starts_with", "ends_with":
+        return true
+    // aggregation functions
+    case "min", "max", "sum", "avg":
+        return true
+    // string normalization functions
+    case "lowercase", "uppercase", "trim":
+        return true
+    // string list functions
+    case "split", "join":
+        return true
+    // date and time functions
+    case "date", "datetime":
+        return true
+    // semantic version functions
+    case "semver":
+        return true
     }
     return false
 }
@@ -850,11 +1124,43 @@ var contextVariableKeywords = map[string]Token{
     "parentProperty": CONTEXT_PARENT_PROPERTY,
     "path":           CONTEXT_PATH,
     "index":          CONTEXT_INDEX,
+    "propertyPath":   CONTEXT_PROPERTY_PATH,
+    "pathKeys":       CONTEXT_PATH_KEYS,
+    "sibling":        CONTEXT_SIBLING,
+    "prev":           CONTEXT_PREV,
+    "next":           CONTEXT_NEXT,
+    "line":           CONTEXT_LINE,
+    "column":         CONTEXT_COLUMN,
+}
+
+// typeSelectorKeywords are the names of the JSONPath Plus type selectors, such as @number()
+var typeSelectorKeywords = map[string]bool{
+    "null": true, "boolean": true, "number": true, "integer": true, "nonFinite": true, "string": true,
+    "scalar": true, "array": true, "object": true,
+}
+
+// tryTypeSelector checks if the current position starts a type selector segment such as @number(),
+// which may only appear outside brackets. It returns the type and the total length of the selector,
+// or 0 if there is none.
+func (t *Tokenizer) tryTypeSelector() (string, int) {
+    if len(t.stack) > 0 {
+        return "", 0
+    }
+    end := t.pos + 1
+    for end < len(t.input) && isLiteralChar(t.input[end]) {
+        end++
+    }
+    keyword := t.input[t.pos+1 : end]
+    if !typeSelectorKeywords[keyword] || !strings.HasPrefix(t.input[end:], "()") {
+        return "", 0
+    }
+    return keyword, end + 2 - t.pos
 }
 
 // tryContextVariable checks if the current position starts a context variable.
 // It returns the token type and total length (including @) if found, or ILLEGAL and 0 if not.
-// Context variables are @property, @root, @parent, @parentProperty, @path, @index.
+// Context variables are @property, @root, @parent, @parentProperty, @path, @index, @propertyPath, @pathKeys, @sibling,
+// @prev, @next, @line and @column.
 func (t *Tokenizer) tryContextVariable() (Token, int) {
     // Must start with @
     if t.pos >= len(t.input) || t.input[t.pos] != '@' {
@@ -11,6 +11,8 @@ func TestTokenizer(t *testing.T) {
         name     string
         input    string
         expected []TokenInfo
+        // plus is set for JSONPath Plus syntax, which builds with the jsonpath_core tag do not tokenize
+        plus bool
     }{
         {
             name:  "Root",
@@ -352,6 +354,74 @@ func TestTokenizer(t *testing.T) {
                 {Token: BRACKET_RIGHT, Line: 1, Column: 39, Literal: "", Len: 1},
             },
         },
+        {
+            name:  "Membership operator",
+            input: "$[?@.in in ['a',1]]",
+            expected: []TokenInfo{
+                {Token: ROOT, Line: 1, Column: 0, Literal: "", Len: 1},
+                {Token: BRACKET_LEFT, Line: 1, Column: 1, Literal: "", Len: 1},
+                {Token: FILTER, Line: 1, Column: 2, Literal: "", Len: 1},
+                {Token: CURRENT, Line: 1, Column: 3, Literal: "", Len: 1},
+                {Token: CHILD, Line: 1, Column: 4, Literal: "", Len: 1},
+                {Token: STRING, Line: 1, Column: 5, Literal: "in", Len: 2},
+                {Token: IN, Line: 1, Column: 8, Literal: "in", Len: 2},
+                {Token: BRACKET_LEFT, Line: 1, Column: 11, Literal: "", Len: 1},
+                {Token: STRING_LITERAL, Line: 1, Column: 12, Literal: "a", Len: 3},
+                {Token: COMMA, Line: 1, Column: 15, Literal: "", Len: 1},
+                {Token: INTEGER, Line: 1, Column: 16, Literal: "1", Len: 1},
+                {Token: BRACKET_RIGHT, Line: 1, Column: 17, Literal: "", Len: 1},
+                {Token: BRACKET_RIGHT, Line: 1, Column: 18, Literal: "", Len: 1},
+            },
+        },
+        {
+            name:  "Arithmetic operators",
+            input: "$[?@.a*-1>@.b%2]",
+            expected: []TokenInfo{
+                {Token: ROOT, Line: 1, Column: 0, Literal: "", Len: 1},
+                {Token: BRACKET_LEFT, Line: 1, Column: 1, Literal: "", Len: 1},
+                {Token: FILTER, Line: 1, Column: 2, Literal: "", Len: 1},
+                {Token: CURRENT, Line: 1, Column: 3, Literal: "", Len: 1},
+                {Token: CHILD, Line: 1, Column: 4, Literal: "", Len: 1},
+                {Token: STRING, Line: 1, Column: 5, Literal: "a", Len: 1},
+                {Token: MULTIPLY, Line: 1, Column: 6, Literal: "", Len: 1},
+                {Token: INTEGER, Line: 1, Column: 7, Literal: "-1", Len: 2},
+                {Token: GT, Line: 1, Column: 9, Literal: "", Len: 1},
+                {Token: CURRENT, Line: 1, Column: 10, Literal: "", Len: 1},
+                {Token: CHILD, Line: 1, Column: 11, Literal: "", Len: 1},
+                {Token: STRING, Line: 1, Column: 12, Literal: "b", Len: 1},
+                {Token: MODULO, Line: 1, Column: 13, Literal: "", Len: 1},
+                {Token: INTEGER, Line: 1, Column: 14, Literal: "2", Len: 1},
+                {Token: BRACKET_RIGHT, Line: 1, Column: 15, Literal: "", Len: 1},
+            },
+        },
+        {
+            name:  "Union of queries",
+            input: "$.a|$[0]",
+            plus:  true,
+            expected: []TokenInfo{
+                {Token: ROOT, Line: 1, Column: 0, Literal: "", Len: 1},
+                {Token: CHILD, Line: 1, Column: 1, Literal: "", Len: 1},
+                {Token: STRING, Line: 1, Column: 2, Literal: "a", Len: 1},
+                {Token: PIPE, Line: 1, Column: 3, Literal: "", Len: 1},
+                {Token: ROOT, Line: 1, Column: 4, Literal: "", Len: 1},
+                {Token: BRACKET_LEFT, Line: 1, Column: 5, Literal: "", Len: 1},
+                {Token: INTEGER, Line: 1, Column: 6, Literal: "0", Len: 1},
+                {Token: BRACKET_RIGHT, Line: 1, Column: 7, Literal: "", Len: 1},
+            },
+        },
+        {
+            name:  "Key pattern selector",
+            input: "$[/^x-/i,'a']",
+            plus:  true,
+            expected: []TokenInfo{
+                {Token: ROOT, Line: 1, Column: 0, Literal: "", Len: 1},
+                {Token: BRACKET_LEFT, Line: 1, Column: 1, Literal: "", Len: 1},
+                {Token: REGEX, Line: 1, Column: 2, Literal: "/^x-/i", Len: 6},
+                {Token: COMMA, Line: 1, Column: 8, Literal: "", Len: 1},
+                {Token: STRING_LITERAL, Line: 1, Column: 9, Literal: "a", Len: 3},
+                {Token: BRACKET_RIGHT, Line: 1, Column: 12, Literal: "", Len: 1},
+            },
+        },
         //{
         //	name:  "Filter regular expression (illegal right now)",
         //	input: "$[?(@.child=~/.*/)]",
@@ -372,6 +442,9 @@ func TestTokenizer(t *testing.T) {
 
     for _, test := range tests {
         t.Run(test.name, func(t *testing.T) {
+            if test.plus && !config.PlusAvailable {
+                t.Skip("JSONPath Plus extensions are not built in")
+            }
             tokenizer := NewTokenizer(test.input)
             tokens := tokenizer.Tokenize()
 
@@ -575,15 +648,15 @@ func TestTokenizer_categorize(t *testing.T) {
         {name: "filter negation", path: "$[?(!@.child)]"},
         {name: "filter negation of comparison (edge case)", path: "$[?(!@.child>1)]"},
         {name: "filter negation of bracket", path: "$[?(!(@.child))]"},
-        {name: "filter regular expression", path: "$[?(@.child=~/.*/)]", illegal: true},
-        {name: "filter regular expression with escaped /", path: `$[?(@.child=~/\/.*/)]`, illegal: true},
-        {name: "filter regular expression with escaped \\", path: `$[?(@.child=~/\\/)]`, illegal: true},
+        {name: "filter regular expression", path: "$[?(@.child=~/.*/)]"},
+        {name: "filter regular expression with escaped /", path: `$[?(@.child=~/\/.*/)]`},
+        {name: "filter regular expression with escaped \\", path: `$[?(@.child=~/\\/)]`},
         {name: "filter regular expression with missing leading /", path: `$[?(@.child=~.*/)]`, illegal: true},
         {name: "filter regular expression with missing trailing /", path: `$[?(@.child=~/.*)]`, illegal: true},
-        {name: "filter regular expression to match string literal", path: `$[?('x'=~/.*/)]`, illegal: true},
-        {name: "filter regular expression to match integer literal", path: `$[?(0=~/.*/)]`, illegal: true},
-        {name: "filter regular expression to match float literal", path: `$[?(.1=~/.*/)]`, illegal: true},
-        {name: "filter invalid regular expression", path: `$[?(@.child=~/(.*/)]`, illegal: true},
+        {name: "filter regular expression to match string literal", path: `$[?('x'=~/.*/)]`},
+        {name: "filter regular expression to match integer literal", path: `$[?(0=~/.*/)]`},
+        {name: "filter regular expression to match float literal", path: `$[?(.1=~/.*/)]`},
+        {name: "filter invalid regular expression", path: `$[?(@.child=~/(.*/)]`},
         {name: "unescaped single quote in bracket child name", path: `$['single'quote']`, illegal: true},
         {name: "escaped single quote in bracket child name", path: `$['single\']quote']`, simple: true},
         {name: "escaped backslash in bracket child name", path: `$['\\']`, simple: true},
@@ -603,7 +676,8 @@ func TestTokenizer_categorize(t *testing.T) {
             //	}
             //}()
 
-            tokenizer := NewTokenizer(tc.path)
+            // JSONPath Plus mode accepts ~, so categorize the paths as RFC 9535 does
+            tokenizer := NewTokenizer(tc.path, config.WithStrictRFC9535())
             tokenizedJsonPath := tokenizer.Tokenize()
             foundIllegal := false
             for _, token := range tokenizedJsonPath {
@@ -680,6 +754,31 @@ func TestString(t *testing.T) {
             input:    `'te\'st'`,
             expected: `te'st`,
         },
+        {
+            name:     "Valid single quoted string with carriage return",
+            input:    `'a\rb'`,
+            expected: "a\rb",
+        },
+        {
+            name:     "Valid single quoted string with unicode escape",
+            input:    `'\u00e9\u001f'`,
+            expected: "\u00e9\u001f",
+        },
+        {
+            name:     "Valid double quoted string with surrogate pair",
+            input:    `"\ud83d\ude00"`,
+            expected: "\U0001F600",
+        },
+        {
+            name:  "Invalid short unicode escape",
+            input: `'\u00e'`,
+            err:   true,
+        },
+        {
+            name:  "Invalid lone high surrogate",
+            input: `'\ud83d'`,
+            err:   true,
+        },
         {
             name:  "Invalid Unicode control character",
             input: "\u0000",
@@ -775,7 +874,7 @@ func TestPropertyNameExtension(t *testing.T) {
             if test.enabled {
                 tokenizer = NewTokenizer(test.input, config.WithPropertyNameExtension())
             } else {
-                tokenizer = NewTokenizer(test.input)
+                tokenizer = NewTokenizer(test.input, config.WithStrictRFC9535())
             }
 
             tokens := tokenizer.Tokenize()
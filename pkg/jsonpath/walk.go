@@ -0,0 +1,139 @@
+package jsonpath
+
+// astWalker visits the segments, literals, regular expressions and context variables of an AST,
+// including those of the queries in its filters. Any of the callbacks may be nil.
+type astWalker struct {
+	segment  func(seg *segment)
+	literal  func(l *literal)
+	pattern  func(p *regexPattern)
+	variable func(cv *contextVariable)
+}
+
+func (w *astWalker) query(q *jsonPathAST) {
+	if q == nil {
+		return
+	}
+	for _, query := range q.queries() {
+		w.segments(query.segments)
+	}
+}
+
+func (w *astWalker) segments(segments []*segment) {
+	for _, seg := range segments {
+		if w.segment != nil {
+			w.segment(seg)
+		}
+		for _, inner := range []*innerSegment{seg.child, seg.descendant} {
+			if inner == nil {
+				continue
+			}
+			for _, sel := range inner.selectors {
+				switch sel.kind {
+				case selectorSubKindFilter:
+					w.logical(sel.filter.expression)
+				case selectorSubKindKeyPattern:
+					w.regex(sel.pattern)
+				}
+			}
+		}
+	}
+}
+
+func (w *astWalker) logical(expr *logicalOrExpr) {
+	if expr == nil {
+		return
+	}
+	for _, and := range expr.expressions {
+		for _, basic := range and.expressions {
+			switch {
+			case basic.parenExpr != nil:
+				w.logical(basic.parenExpr.expr)
+			case basic.comparisonExpr != nil:
+				e := basic.comparisonExpr
+				w.comparable(e.left)
+				w.comparable(e.right)
+				for _, value := range e.values {
+					w.value(value)
+				}
+				if e.pattern != nil {
+					w.regex(e.pattern)
+				}
+			case basic.testExpr != nil:
+				w.filterQuery(basic.testExpr.filterQuery)
+				w.function(basic.testExpr.functionExpr)
+				w.contextVariable(basic.testExpr.contextVar)
+			}
+		}
+	}
+	if expr.conditional != nil {
+		w.logical(expr.conditional.then)
+		w.logical(expr.conditional.otherwise)
+	}
+}
+
+func (w *astWalker) comparable(c *comparable) {
+	if c == nil {
+		return
+	}
+	w.value(c.literal)
+	if c.singularQuery != nil {
+		if c.singularQuery.relQuery != nil {
+			w.segments(c.singularQuery.relQuery.segments)
+		}
+		if c.singularQuery.absQuery != nil {
+			w.segments(c.singularQuery.absQuery.segments)
+		}
+	}
+	w.function(c.functionExpr)
+	w.contextVariable(c.contextVar)
+	if c.arithmetic != nil {
+		w.comparable(c.arithmetic.left)
+		w.comparable(c.arithmetic.right)
+	}
+}
+
+func (w *astWalker) filterQuery(q *filterQuery) {
+	if q == nil {
+		return
+	}
+	if q.relQuery != nil {
+		w.segments(q.relQuery.segments)
+	}
+	w.query(q.jsonPathQuery)
+	w.contextVariable(q.contextVar)
+}
+
+func (w *astWalker) function(e *functionExpr) {
+	if e == nil {
+		return
+	}
+	for _, arg := range e.args {
+		w.value(arg.literal)
+		w.filterQuery(arg.filterQuery)
+		w.logical(arg.logicalExpr)
+		w.function(arg.functionExpr)
+		w.contextVariable(arg.contextVar)
+	}
+}
+
+func (w *astWalker) contextVariable(cv *contextVariable) {
+	if cv == nil {
+		return
+	}
+	if w.variable != nil {
+		w.variable(cv)
+	}
+	w.segments(cv.segments)
+}
+
+func (w *astWalker) value(l *literal) {
+	if l != nil && w.literal != nil {
+		w.literal(l)
+	}
+}
+
+func (w *astWalker) regex(p *regexPattern) {
+	if p != nil && w.pattern != nil {
+		w.pattern(p)
+	}
+}
@@ -0,0 +1,78 @@
+package jsonpath
+
+import (
+	"fmt"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"go.yaml.in/yaml/v4"
+)
+
+// Window is a run of consecutive elements of a sequence.
+type Window struct {
+	// Sequence is the matched sequence the window was taken from.
+	Sequence *yaml.Node
+	// Start is the index in Sequence of the first element of the window.
+	Start int
+	// Nodes are the elements of the window, in order.
+	Nodes []*yaml.Node
+}
+
+// WindowQuery slides a window over every sequence matched by a query and tests each window with a
+// filter expression. Inside the filter, @ is the window as an array, so @[0] is its first element
+// and @[-1] its last, while $ is still the document root. This generalizes @prev and @next to rules
+// about order, monotonicity and consecutive duplicates, e.g. a window of 2 with the filter
+// @[0].version >= @[1].version finds versions that do not increase.
+type WindowQuery struct {
+	path   *JSONPath
+	size   int
+	filter *filterSelector
+}
+
+// NewWindowQuery compiles a window query over the sequences matched by path, with windows of size
+// elements tested by filter, a logical expression as written after "?" in a filter selector.
+func NewWindowQuery(path string, size int, filter string, opts ...config.Option) (*WindowQuery, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("window size must be at least 1, got %d", size)
+	}
+	p, err := NewPath(path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	f, err := NewPath("$[?"+filter+"]", opts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window filter %q: %w", filter, err)
+	}
+	// the filter must not close the selector and go on with more of a query, as in @[0]] | $[0
+	segments := f.ast.segments
+	if len(f.ast.union) > 0 || len(segments) != 1 || segments[0].kind != segmentKindChild ||
+		segments[0].child == nil || len(segments[0].child.selectors) != 1 || segments[0].child.selectors[0].kind != selectorSubKindFilter {
+		return nil, fmt.Errorf("invalid window filter %q: not a single logical expression", filter)
+	}
+	return &WindowQuery{path: p, size: size, filter: segments[0].child.selectors[0].filter}, nil
+}
+
+// Query returns the windows the filter matches, in document order. Sequences shorter than the window
+// size have no windows.
+func (w *WindowQuery) Query(root *yaml.Node) []Window {
+	docRoot := root
+	if docRoot != nil && docRoot.Kind == yaml.DocumentNode && len(docRoot.Content) == 1 {
+		docRoot = docRoot.Content[0]
+	}
+
+	copier := newResultCopier(w.path.config)
+	var windows []Window
+	for _, seq := range w.path.ast.query(root, root, w.path.config) {
+		if seq.Kind != yaml.SequenceNode {
+			continue
+		}
+		for start := 0; start+w.size <= len(seq.Content); start++ {
+			nodes := seq.Content[start : start+w.size : start+w.size]
+			window := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: nodes}
+			if w.filter.Matches(newFilterContext(docRoot, w.path.config), window, docRoot) {
+				copied := copier.node(seq)
+				windows = append(windows, Window{Sequence: copied, Start: start, Nodes: copied.Content[start : start+w.size : start+w.size]})
+			}
+		}
+	}
+	return windows
+}
@@ -0,0 +1,98 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const windowDoc = `
+releases:
+  - version: 1
+  - version: 2
+  - version: 2
+  - version: 4
+  - version: 3
+steps: [build, test, test, deploy]
+limit: 3
+`
+
+func TestWindowQuery(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(windowDoc), &node))
+
+	tests := []struct {
+		name     string
+		path     string
+		size     int
+		filter   string
+		expected []int
+	}{
+		{
+			name:     "not increasing",
+			path:     "$.releases",
+			size:     2,
+			filter:   "@[0].version >= @[1].version",
+			expected: []int{1, 3},
+		},
+		{
+			name:     "consecutive duplicates",
+			path:     "$.steps",
+			size:     2,
+			filter:   "@[0] == @[1]",
+			expected: []int{1},
+		},
+		{
+			name:     "root in filter",
+			path:     "$.releases",
+			size:     1,
+			filter:   "@[0].version > $.limit",
+			expected: []int{3},
+		},
+		{
+			name:     "window of three",
+			path:     "$.releases",
+			size:     3,
+			filter:   "@[0].version < @[1].version && @[1].version < @[2].version",
+			expected: nil,
+		},
+		{
+			name:     "window larger than sequence",
+			path:     "$.steps",
+			size:     5,
+			filter:   "@[0] == @[0]",
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := NewWindowQuery(test.path, test.size, test.filter)
+			require.NoError(t, err)
+			var starts []int
+			for _, w := range q.Query(&node) {
+				assert.Len(t, w.Nodes, test.size)
+				assert.Same(t, w.Sequence.Content[w.Start], w.Nodes[0])
+				starts = append(starts, w.Start)
+			}
+			assert.Equal(t, test.expected, starts)
+		})
+	}
+}
+
+func TestWindowQuery_Errors(t *testing.T) {
+	_, err := NewWindowQuery("$.steps", 0, "@[0] == @[1]")
+	assert.Error(t, err)
+	_, err = NewWindowQuery("$.steps[", 2, "@[0] == @[1]")
+	assert.Error(t, err)
+	_, err = NewWindowQuery("$.steps", 2, "@[0] ==")
+	assert.Error(t, err)
+
+	// a filter may not go on past its selector
+	for _, filter := range []string{"@[0]] | $[0", "@[0]]['x'][?@", "@[0], ?@[1]", "@[0]]..[?@"} {
+		_, err = NewWindowQuery("$.steps", 2, filter)
+		assert.Error(t, err, filter)
+	}
+}
@@ -0,0 +1,92 @@
+package jsonpath
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+var (
+	// yaml11Int matches the YAML 1.1 integer forms: binary, octal (0 or 0o prefixed), decimal and
+	// hexadecimal digits, any of which may be separated by underscores
+	yaml11Int = regexp.MustCompile(`^[-+]?(0b[01_]+|0o?[0-7_]+|0|[1-9][0-9_]*|0x[0-9a-fA-F_]+)$`)
+	// yaml11Float matches the YAML 1.1 decimal float form, which allows underscores
+	yaml11Float = regexp.MustCompile(`^[-+]?([0-9][0-9_]*)?\.[0-9_]*([eE][-+][0-9]+)?$`)
+	// yaml11Sexagesimal matches base 60 integers and floats, such as 1:30 and 190:20:30.15
+	yaml11Sexagesimal = regexp.MustCompile(`^[-+]?[0-9][0-9_]*(:[0-5]?[0-9])+(\.[0-9_]*)?$`)
+)
+
+// yaml11Number reads a plain scalar the way YAML 1.1 resolves numbers, returning false if node is
+// not one. Quoted scalars and scalars with an explicit tag are never numbers.
+func yaml11Number(node *yaml.Node) (literal, bool) {
+	if node.Kind != yaml.ScalarNode || node.Style != 0 {
+		return literal{}, false
+	}
+	switch node.Tag {
+	case "!!int", "!!float", "!!str":
+	default:
+		return literal{}, false
+	}
+
+	value := node.Value
+	switch {
+	case yaml11Int.MatchString(value):
+		digits := strings.ReplaceAll(value, "_", "")
+		if unsigned := strings.TrimLeft(digits, "+-"); len(unsigned) > 1 && unsigned[0] == '0' && unsigned[1] != 'b' && unsigned[1] != 'x' && unsigned[1] != 'o' {
+			// a leading zero makes the number octal
+			digits = strings.Replace(digits, "0", "0o", 1)
+		}
+		i, err := strconv.ParseInt(digits, 0, strconv.IntSize)
+		if err != nil {
+			return literal{}, false
+		}
+		n := int(i)
+		return literal{integer: &n}, true
+	case yaml11Float.MatchString(value) && value != ".":
+		f, err := strconv.ParseFloat(strings.ReplaceAll(value, "_", ""), 64)
+		if err != nil {
+			return literal{}, false
+		}
+		return literal{float64: &f}, true
+	case yaml11Sexagesimal.MatchString(value):
+		return sexagesimal(strings.ReplaceAll(value, "_", ""))
+	}
+	return literal{}, false
+}
+
+// sexagesimal reads a base 60 number, in which each : separated part is worth 60 times the next
+func sexagesimal(value string) (literal, bool) {
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimLeft(value, "+-")
+
+	fraction := ""
+	if dot := strings.IndexByte(value, '.'); dot >= 0 {
+		value, fraction = value[:dot], value[dot:]
+	}
+	total := 0
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return literal{}, false
+		}
+		total = total*60 + n
+	}
+	if negative {
+		total = -total
+	}
+	if fraction == "" {
+		return literal{integer: &total}, true
+	}
+
+	f, err := strconv.ParseFloat("0"+fraction, 64)
+	if err != nil {
+		return literal{}, false
+	}
+	if negative {
+		f = -f
+	}
+	f += float64(total)
+	return literal{float64: &f}, true
+}
@@ -1,12 +1,17 @@
 package jsonpath
 
 import (
+    "encoding/base64"
     "fmt"
+    "math"
     "reflect"
     "regexp"
     "strconv"
+    "strings"
+    "time"
     "unicode/utf8"
 
+    "github.com/pb33f/jsonpath/pkg/jsonpath/config"
     "go.yaml.in/yaml/v4"
 )
 
@@ -32,6 +37,12 @@ func (l literal) Equals(value literal) bool {
     if l.string != nil && value.string != nil {
         return *l.string == *value.string
     }
+    if l.time != nil && value.time != nil {
+        return l.time.Equal(*value.time)
+    }
+    if l.version != nil && value.version != nil {
+        return l.version.compare(value.version) == 0
+    }
     if l.bool != nil && value.bool != nil {
         return *l.bool == *value.bool
     }
@@ -104,6 +115,12 @@ func (l literal) LessThan(value literal) bool {
     if l.string != nil && value.string != nil {
         return *l.string < *value.string
     }
+    if l.time != nil && value.time != nil {
+        return l.time.Before(*value.time)
+    }
+    if l.version != nil && value.version != nil {
+        return l.version.compare(value.version) < 0
+    }
     return false
 }
 
@@ -111,6 +128,58 @@ func (l literal) LessThanOrEqual(value literal) bool {
     return l.LessThan(value) || l.Equals(value)
 }
 
+// lessThanIn is LessThan with strings ordered by the configured string order
+func (l literal) lessThanIn(value literal, cfg config.Config) bool {
+    if l.string != nil && value.string != nil {
+        return cfg.CompareStrings(*l.string, *value.string) < 0
+    }
+    return l.LessThan(value)
+}
+
+// lessThanOrEqualIn is LessThanOrEqual with strings ordered by the configured string order
+func (l literal) lessThanOrEqualIn(value literal, cfg config.Config) bool {
+    if l.string != nil && value.string != nil {
+        return cfg.CompareStrings(*l.string, *value.string) <= 0
+    }
+    return l.LessThanOrEqual(value)
+}
+
+// equalsIn is Equals with strings holding numbers equal to those numbers if the config asks for it
+func equalsIn(l literal, value literal, cfg config.Config) bool {
+    if cfg.NumericStrings() {
+        l, value = coerceNumericStrings(l, value)
+    }
+    return l.Equals(value)
+}
+
+// jsonNumber matches a string holding a JSON number, such as 42, -1.5 or 1e3
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// coerceNumericStrings converts a string holding a number to the number when the other value is a
+// number, as config.WithNumericStrings asks
+func coerceNumericStrings(left literal, right literal) (literal, literal) {
+    if literalKind(right) == "number" {
+        left = numericString(left)
+    } else if literalKind(left) == "number" {
+        right = numericString(right)
+    }
+    return left, right
+}
+
+func numericString(lit literal) literal {
+    if lit.string == nil || !jsonNumber.MatchString(*lit.string) {
+        return lit
+    }
+    if i, err := strconv.Atoi(*lit.string); err == nil {
+        return literal{integer: &i}
+    }
+    f, err := strconv.ParseFloat(*lit.string, 64)
+    if err != nil {
+        return lit
+    }
+    return literal{float64: &f}
+}
+
 func (c comparable) Evaluate(idx index, node *yaml.Node, root *yaml.Node) literal {
     if c.literal != nil {
         return *c.literal
@@ -121,15 +190,157 @@ func (c comparable) Evaluate(idx index, node *yaml.Node, root *yaml.Node) litera
     if c.functionExpr != nil {
         return c.functionExpr.Evaluate(idx, node, root)
     }
-    if c.contextVar != nil {
+    if config.PlusAvailable && c.contextVar != nil {
         return c.contextVar.Evaluate(idx, node, root)
     }
+    if config.PlusAvailable && c.arithmetic != nil {
+        return c.arithmetic.Evaluate(idx, node, root)
+    }
     return literal{}
 }
 
-// Evaluate returns the value of a context variable from the FilterContext.
-// Returns an empty literal if the idx is not a FilterContext.
+// Evaluate applies the operator to the values of both operands. Integers stay integers unless a
+// division leaves a remainder or the result overflows; an operand that is not a number, or a zero
+// divisor, gives Nothing.
+func (e arithmeticExpr) Evaluate(idx index, node *yaml.Node, root *yaml.Node) literal {
+    left := e.left.Evaluate(idx, node, root)
+    right := e.right.Evaluate(idx, node, root)
+    if literalKind(left) != "number" || literalKind(right) != "number" {
+        if handler := configOf(idx).TypeErrorHandler(); handler != nil && literalKind(left) != "Nothing" && literalKind(right) != "Nothing" {
+            handler(node, e.ToString(), fmt.Sprintf("cannot apply %s to %s and %s", e.op.ToString(), literalKind(left), literalKind(right)))
+        }
+        return literal{}
+    }
+    if left.integer != nil && right.integer != nil {
+        if result, ok := e.op.applyIntegers(*left.integer, *right.integer); ok {
+            return literal{integer: &result}
+        }
+    }
+    result := e.op.applyFloats(numberValue(left), numberValue(right))
+    if math.IsNaN(result) || math.IsInf(result, 0) {
+        return literal{}
+    }
+    return literal{float64: &result}
+}
+
+// applyIntegers applies the operator to integers, reporting false if the result is not an integer
+// or does not fit in one
+func (o arithmeticOperator) applyIntegers(a, b int) (int, bool) {
+    switch o {
+    case add:
+        result := a + b
+        return result, (result > a) == (b > 0)
+    case subtract:
+        result := a - b
+        return result, (result < a) == (b > 0)
+    case multiply:
+        if a == 0 || b == 0 {
+            return 0, true
+        }
+        result := a * b
+        return result, result/b == a && !(a == -1 && b == math.MinInt) && !(b == -1 && a == math.MinInt)
+    case divide:
+        if b == 0 || a%b != 0 || b == -1 && a == math.MinInt {
+            return 0, false
+        }
+        return a / b, true
+    case modulo:
+        if b == 0 {
+            return 0, false
+        }
+        if b == -1 {
+            return 0, true
+        }
+        return a % b, true
+    }
+    return 0, false
+}
+
+// applyFloats applies the operator to floats, giving NaN for a zero divisor
+func (o arithmeticOperator) applyFloats(a, b float64) float64 {
+    switch o {
+    case add:
+        return a + b
+    case subtract:
+        return a - b
+    case multiply:
+        return a * b
+    case divide:
+        if b == 0 {
+            return math.NaN()
+        }
+        return a / b
+    case modulo:
+        return math.Mod(a, b)
+    }
+    return math.NaN()
+}
+
+// numberValue returns the value of a number literal as a float
+func numberValue(l literal) float64 {
+    if l.integer != nil {
+        return float64(*l.integer)
+    }
+    return *l.float64
+}
+
+// Evaluate returns the value of a context variable from the FilterContext, with any trailing
+// segments applied to it. Returns an empty literal if the idx is not a FilterContext.
 func (cv contextVariable) Evaluate(idx index, node *yaml.Node, root *yaml.Node) literal {
+    if len(cv.segments) == 0 {
+        return cv.value(idx, node, root)
+    }
+    result := cv.nodes(idx, node, root)
+    if len(result) == 1 {
+        return documentValue(idx, result[0])
+    }
+    return literal{}
+}
+
+// nodes returns the nodes the segments of the variable select from its value, which must be a node.
+// The segments of @path address its elements, as those of @propertyPath and @pathKeys do, and on
+// all three a final .length selects the number of elements.
+func (cv contextVariable) nodes(idx index, node *yaml.Node, root *yaml.Node) []*yaml.Node {
+    segments := cv.segments
+    length := false
+    if cv.kind == contextVarPath || cv.kind == contextVarPropertyPath || cv.kind == contextVarPathKeys {
+        cv = contextVariable{kind: contextVarPropertyPath}
+        if n := len(segments); n > 0 && segments[n-1].isMember("length") {
+            segments, length = segments[:n-1], true
+        }
+    }
+    value := cv.value(idx, node, root)
+    if value.node == nil {
+        return nil
+    }
+    // segments run against a detached index so they cannot disturb the filter context's path
+    result := relQuery{segments: segments}.Query(&ascendingIndex{outer: idx}, value.node, root)
+    if !length {
+        return result
+    }
+    if len(result) != 1 || result[0].Kind != yaml.SequenceNode {
+        return nil
+    }
+    return []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(len(result[0].Content))}}
+}
+
+// ascendingIndex is a detached index that finds the parents of nodes through the index it was
+// detached from, so that a parent selector such as the ^ of @parent^.name can climb out of the value
+// of a context variable. The ancestors it reaches are read by the filter.
+type ascendingIndex struct {
+    _index
+    outer index
+}
+
+func (i *ascendingIndex) getParentNode(child *yaml.Node) *yaml.Node {
+    parent := i.outer.getParentNode(child)
+    if parent != nil {
+        recordReads(i.outer, parent)
+    }
+    return parent
+}
+
+func (cv contextVariable) value(idx index, node *yaml.Node, root *yaml.Node) literal {
     fc, ok := idx.(FilterContext)
     if !ok {
         // Not in JSONPath Plus mode or no context available
@@ -143,14 +354,20 @@ func (cv contextVariable) Evaluate(idx index, node *yaml.Node, root *yaml.Node)
     case contextVarRoot:
         // This case is handled in the parser - @root becomes an absQuery
         // But if we get here, return the root node
-        return nodeToLiteral(fc.Root())
+        recordReads(idx, fc.Root())
+        return documentValue(idx, fc.Root())
     case contextVarParent:
         parent := fc.Parent()
         if parent != nil {
-            return nodeToLiteral(parent)
+            recordReads(idx, parent)
+            return documentValue(idx, parent)
         }
         return literal{}
     case contextVarParentProperty:
+        if fc.Parent() != nil && fc.Parent() == fc.Root() {
+            // the children of the root have a parent, but it was not reached through a property
+            return literal{}
+        }
         parentProp := fc.ParentPropertyName()
         return literal{string: &parentProp}
     case contextVarPath:
@@ -164,11 +381,69 @@ func (cv contextVariable) Evaluate(idx index, node *yaml.Node, root *yaml.Node)
         // Not in array context - return -1 as indication
         minusOne := -1
         return literal{integer: &minusOne}
+    case contextVarPropertyPath, contextVarPathKeys:
+        return literal{node: propertyPathNode(fc.Path())}
+    case contextVarSibling:
+        if sibling := cv.siblingNode(fc.Parent(), node); sibling != nil {
+            recordReads(idx, sibling)
+            return documentValue(idx, sibling)
+        }
+        return literal{}
+    case contextVarLine:
+        line := node.Line
+        return literal{integer: &line}
+    case contextVarColumn:
+        column := node.Column
+        return literal{integer: &column}
+    case contextVarPrev, contextVarNext:
+        parent := fc.Parent()
+        i := fc.Index()
+        if cv.kind == contextVarPrev {
+            i--
+        } else {
+            i++
+        }
+        if parent == nil || parent.Kind != yaml.SequenceNode || fc.Index() < 0 || i < 0 || i >= len(parent.Content) {
+            return literal{}
+        }
+        recordReads(idx, parent.Content[i])
+        return documentValue(idx, parent.Content[i])
     default:
         return literal{}
     }
 }
 
+// siblingNode looks up the @sibling argument in the parent of the current node: a member name
+// when the parent is a mapping, an index (negative counts from the end) when it is a sequence.
+// The current node is not its own sibling.
+func (cv contextVariable) siblingNode(parent *yaml.Node, node *yaml.Node) *yaml.Node {
+    if parent == nil || cv.sibling == nil {
+        return nil
+    }
+    var sibling *yaml.Node
+    switch {
+    case parent.Kind == yaml.MappingNode && cv.sibling.string != nil:
+        for i := 0; i+1 < len(parent.Content); i += 2 {
+            if parent.Content[i].Value == *cv.sibling.string {
+                sibling = parent.Content[i+1]
+                break
+            }
+        }
+    case parent.Kind == yaml.SequenceNode && cv.sibling.integer != nil:
+        i := *cv.sibling.integer
+        if i < 0 {
+            i += len(parent.Content)
+        }
+        if i >= 0 && i < len(parent.Content) {
+            sibling = parent.Content[i]
+        }
+    }
+    if sibling == node {
+        return nil
+    }
+    return sibling
+}
+
 func (e functionExpr) length(idx index, node *yaml.Node, root *yaml.Node) literal {
     args := e.args[0].Eval(idx, node, root)
     if args.kind != functionArgTypeLiteral {
@@ -202,6 +477,238 @@ func (e functionExpr) length(idx index, node *yaml.Node, root *yaml.Node) litera
     return literal{}
 }
 
+// decodeBase64 decodes a base64 string, in the standard or URL-safe alphabet with or without padding,
+// ignoring line breaks and other blank space. The result is Nothing if the argument is not a string or
+// not valid base64.
+func (e functionExpr) decodeBase64(idx index, node *yaml.Node, root *yaml.Node) literal {
+    args := e.args[0].Eval(idx, node, root)
+    if args.kind != functionArgTypeLiteral || args.literal == nil {
+        return literal{}
+    }
+    var encoded string
+    switch {
+    case args.literal.string != nil:
+        encoded = *args.literal.string
+    case args.literal.node != nil && args.literal.node.Tag == "!!binary":
+        encoded = args.literal.node.Value
+    default:
+        return literal{}
+    }
+    decoded, ok := decodeBase64String(encoded)
+    if !ok {
+        return literal{}
+    }
+    return literal{string: &decoded}
+}
+
+func decodeBase64String(encoded string) (string, bool) {
+    encoded = strings.Join(strings.Fields(encoded), "")
+    for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+        if decoded, err := encoding.DecodeString(encoded); err == nil {
+            return string(decoded), true
+        }
+    }
+    return "", false
+}
+
+// byteLength returns the length in bytes of a string, unlike length() which counts Unicode scalar
+// values. For a YAML !!binary scalar it is the length of the decoded data.
+func (e functionExpr) byteLength(idx index, node *yaml.Node, root *yaml.Node) literal {
+    args := e.args[0].Eval(idx, node, root)
+    if args.kind != functionArgTypeLiteral || args.literal == nil {
+        return literal{}
+    }
+    var res int
+    switch {
+    case args.literal.string != nil:
+        res = len(*args.literal.string)
+    case args.literal.node != nil && args.literal.node.Tag == "!!binary":
+        decoded, ok := decodeBase64String(args.literal.node.Value)
+        if !ok {
+            return literal{}
+        }
+        res = len(decoded)
+    default:
+        return literal{}
+    }
+    return literal{integer: &res}
+}
+
+// normalizeString applies normalize to a string, as lowercase(), uppercase() and trim() do. The result
+// is Nothing if the argument is not a string.
+func (e functionExpr) normalizeString(idx index, node *yaml.Node, root *yaml.Node, normalize func(string) string) literal {
+    args := e.args[0].Eval(idx, node, root)
+    if args.kind != functionArgTypeLiteral || args.literal == nil || args.literal.string == nil {
+        return literal{}
+    }
+    res := normalize(*args.literal.string)
+    return literal{string: &res}
+}
+
+// split splits a string around each occurrence of a separator into an array of strings, so that
+// string-encoded lists such as "a,b,c" can be tested like arrays. An empty separator splits the
+// string into its characters. The result is Nothing unless both arguments are strings.
+func (e functionExpr) split(idx index, node *yaml.Node, root *yaml.Node) literal {
+    value := e.args[0].Eval(idx, node, root)
+    separator := e.args[1].Eval(idx, node, root)
+    if value.kind != functionArgTypeLiteral || separator.kind != functionArgTypeLiteral ||
+        value.literal == nil || separator.literal == nil || value.literal.string == nil || separator.literal.string == nil {
+        return literal{}
+    }
+    parts := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+    for _, part := range strings.Split(*value.literal.string, *separator.literal.string) {
+        parts.Content = append(parts.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: part})
+    }
+    return literal{node: parts}
+}
+
+// join joins the elements of an array into a string, with a separator between them. Elements are
+// joined as they are written, so numbers and booleans join too. The result is Nothing unless the
+// arguments are an array of scalars and a string.
+func (e functionExpr) join(idx index, node *yaml.Node, root *yaml.Node) literal {
+    value := e.args[0].Eval(idx, node, root)
+    separator := e.args[1].Eval(idx, node, root)
+    if value.kind != functionArgTypeLiteral || separator.kind != functionArgTypeLiteral ||
+        value.literal == nil || separator.literal == nil || separator.literal.string == nil ||
+        value.literal.node == nil || value.literal.node.Kind != yaml.SequenceNode {
+        return literal{}
+    }
+    parts := make([]string, 0, len(value.literal.node.Content))
+    for _, element := range value.literal.node.Content {
+        element = expandAlias(configOf(idx), element)
+        if element == nil || element.Kind != yaml.ScalarNode {
+            return literal{}
+        }
+        parts = append(parts, element.Value)
+    }
+    res := strings.Join(parts, *separator.literal.string)
+    return literal{string: &res}
+}
+
+// timestampFormats are the forms a date-time may take: RFC 3339 date-times, which are YAML timestamps
+// too, the other YAML timestamps and plain dates. Values are upper-cased first, so t and z are
+// accepted as RFC 3339 allows.
+var timestampFormats = []string{
+    "2006-1-2T15:4:5.999999999Z07:00",
+    "2006-1-2 15:4:5.999999999Z07:00",
+    "2006-1-2 15:4:5.999999999",
+    "2006-1-2",
+}
+
+// dateTime parses a string or YAML timestamp into a time that compares chronologically, as
+// datetime() does. date() keeps only the calendar date, as midnight UTC, so date-times on the same
+// day are equal. The result is Nothing if the argument is not a date or date-time.
+func (e functionExpr) dateTime(idx index, node *yaml.Node, root *yaml.Node) literal {
+    args := e.args[0].Eval(idx, node, root)
+    if args.kind != functionArgTypeLiteral || args.literal == nil {
+        return literal{}
+    }
+    var value string
+    switch {
+    case args.literal.string != nil:
+        value = *args.literal.string
+    case args.literal.node != nil && args.literal.node.Tag == "!!timestamp":
+        value = args.literal.node.Value
+    default:
+        return literal{}
+    }
+    value = strings.ToUpper(strings.TrimSpace(value))
+    for _, format := range timestampFormats {
+        t, err := time.Parse(format, value)
+        if err != nil {
+            continue
+        }
+        if e.funcType == functionTypeDate {
+            t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+        }
+        return literal{time: &t}
+    }
+    return literal{}
+}
+
+// contains tests whether a string contains a substring, or an array an element equal to a value.
+// Any other haystack contains nothing.
+func (e functionExpr) contains(idx index, node *yaml.Node, root *yaml.Node) literal {
+    haystack := e.args[0].Eval(idx, node, root)
+    needle := e.args[1].Eval(idx, node, root)
+    found := false
+    if haystack.kind != functionArgTypeLiteral || needle.kind != functionArgTypeLiteral ||
+        haystack.literal == nil || needle.literal == nil || literalKind(*needle.literal) == "Nothing" {
+        return literal{bool: &found}
+    }
+    switch {
+    case haystack.literal.string != nil:
+        found = needle.literal.string != nil && strings.Contains(*haystack.literal.string, *needle.literal.string)
+    case haystack.literal.node != nil && haystack.literal.node.Kind == yaml.SequenceNode:
+        for _, element := range haystack.literal.node.Content {
+            if element != nil && needle.literal.Equals(documentValue(idx, expandAlias(configOf(idx), element))) {
+                found = true
+                break
+            }
+        }
+    }
+    return literal{bool: &found}
+}
+
+// affix tests a string against a prefix or suffix with test, as starts_with() and ends_with() do.
+// Anything but two strings is false.
+func (e functionExpr) affix(idx index, node *yaml.Node, root *yaml.Node, test func(s, affix string) bool) literal {
+    value := e.args[0].Eval(idx, node, root)
+    affix := e.args[1].Eval(idx, node, root)
+    found := value.kind == functionArgTypeLiteral && affix.kind == functionArgTypeLiteral &&
+        value.literal != nil && affix.literal != nil && value.literal.string != nil && affix.literal.string != nil &&
+        test(*value.literal.string, *affix.literal.string)
+    return literal{bool: &found}
+}
+
+// aggregate folds the numbers among the nodes its query selects into their minimum, maximum, sum or
+// average. Nodes that are not numbers are skipped, and without any numbers the result is Nothing.
+// The sum of integers is an integer, while the average is always a float.
+func (e functionExpr) aggregate(idx index, node *yaml.Node, root *yaml.Node) literal {
+    args := e.args[0].Eval(idx, node, root)
+    values := args.nodes
+    if args.kind == functionArgTypeLiteral {
+        values = []*literal{args.literal}
+    }
+    var numbers []literal
+    for _, value := range values {
+        if value != nil && (value.integer != nil || value.float64 != nil) {
+            numbers = append(numbers, *value)
+        }
+    }
+    if len(numbers) == 0 {
+        return literal{}
+    }
+    switch e.funcType {
+    case functionTypeMin, functionTypeMax:
+        result := numbers[0]
+        for _, number := range numbers[1:] {
+            if e.funcType == functionTypeMin && number.LessThan(result) || e.funcType == functionTypeMax && result.LessThan(number) {
+                result = number
+            }
+        }
+        return result
+    }
+    intSum, floatSum, integers := 0, 0.0, true
+    for _, number := range numbers {
+        if number.integer != nil {
+            intSum += *number.integer
+            floatSum += float64(*number.integer)
+        } else {
+            floatSum += *number.float64
+            integers = false
+        }
+    }
+    if e.funcType == functionTypeAvg {
+        avg := floatSum / float64(len(numbers))
+        return literal{float64: &avg}
+    }
+    if integers {
+        return literal{integer: &intSum}
+    }
+    return literal{float64: &floatSum}
+}
+
 func (e functionExpr) count(idx index, node *yaml.Node, root *yaml.Node) literal {
     args := e.args[0].Eval(idx, node, root)
     if args.kind == functionArgTypeNodes {
@@ -222,7 +729,8 @@ func (e functionExpr) match(idx index, node *yaml.Node, root *yaml.Node) literal
     if arg1.literal.string == nil || arg2.literal.string == nil {
         return literal{bool: &[]bool{false}[0]}
     }
-    matched, _ := regexp.MatchString(fmt.Sprintf("^(%s)$", *arg2.literal.string), *arg1.literal.string)
+    re := compilePattern(*arg2.literal.string, true, !plusEnabled(configOf(idx)))
+    matched := re != nil && re.MatchString(*arg1.literal.string)
     return literal{bool: &matched}
 }
 
@@ -235,7 +743,8 @@ func (e functionExpr) search(idx index, node *yaml.Node, root *yaml.Node) litera
     if arg1.literal.string == nil || arg2.literal.string == nil {
         return literal{bool: &[]bool{false}[0]}
     }
-    matched, _ := regexp.MatchString(*arg2.literal.string, *arg1.literal.string)
+    re := compilePattern(*arg2.literal.string, false, !plusEnabled(configOf(idx)))
+    matched := re != nil && re.MatchString(*arg1.literal.string)
     return literal{bool: &matched}
 }
 
@@ -270,10 +779,17 @@ func nodeToLiteral(node *yaml.Node) literal {
     case "!!str":
         return literal{string: &node.Value}
     case "!!int":
-        i, _ := strconv.Atoi(node.Value)
+        i, err := strconv.Atoi(node.Value)
+        if err != nil {
+            // forms such as 0o17 and 1_000 are compared as they are written
+            return literal{string: &node.Value}
+        }
         return literal{integer: &i}
     case "!!float":
-        f, _ := strconv.ParseFloat(node.Value, 64)
+        f, err := strconv.ParseFloat(node.Value, 64)
+        if err != nil {
+            return literal{string: &node.Value}
+        }
         return literal{float64: &f}
     case "!!bool":
         b, _ := strconv.ParseBool(node.Value)
@@ -286,6 +802,29 @@ func nodeToLiteral(node *yaml.Node) literal {
     }
 }
 
+// documentValue converts a node a filter reads from the document to a literal, resolving string
+// references with the configured value resolver
+func documentValue(idx index, node *yaml.Node) literal {
+    cfg := configOf(idx)
+    if cfg.YAML11Numbers() {
+        if lit, ok := yaml11Number(node); ok {
+            return lit
+        }
+    }
+    lit := nodeToLiteral(node)
+    if lit.string == nil {
+        return lit
+    }
+    resolved, ok := cfg.ResolveValue(*lit.string)
+    if !ok {
+        return literal{}
+    }
+    if resolved != *lit.string {
+        lit.string = &resolved
+    }
+    return lit
+}
+
 func (e functionExpr) Evaluate(idx index, node *yaml.Node, root *yaml.Node) literal {
     switch e.funcType {
     case functionTypeLength:
@@ -298,6 +837,18 @@ func (e functionExpr) Evaluate(idx index, node *yaml.Node, root *yaml.Node) lite
         return e.search(idx, node, root)
     case functionTypeValue:
         return e.value(idx, node, root)
+    case functionTypeCustom:
+        return e.callCustom(idx, node, root)
+    }
+    if config.PlusAvailable {
+        return e.evaluateExtension(idx, node, root)
+    }
+    return literal{}
+}
+
+// evaluateExtension evaluates the functions RFC 9535 does not define
+func (e functionExpr) evaluateExtension(idx index, node *yaml.Node, root *yaml.Node) literal {
+    switch e.funcType {
     // JSONPath Plus type selector functions
     case functionTypeIsNull:
         return e.isNull(idx, node, root)
@@ -313,6 +864,32 @@ func (e functionExpr) Evaluate(idx index, node *yaml.Node, root *yaml.Node) lite
         return e.isObject(idx, node, root)
     case functionTypeIsInteger:
         return e.isInteger(idx, node, root)
+    case functionTypeDecodeBase64:
+        return e.decodeBase64(idx, node, root)
+    case functionTypeByteLength:
+        return e.byteLength(idx, node, root)
+    case functionTypeContains:
+        return e.contains(idx, node, root)
+    case functionTypeMin, functionTypeMax, functionTypeSum, functionTypeAvg:
+        return e.aggregate(idx, node, root)
+    case functionTypeStartsWith:
+        return e.affix(idx, node, root, strings.HasPrefix)
+    case functionTypeEndsWith:
+        return e.affix(idx, node, root, strings.HasSuffix)
+    case functionTypeLowercase:
+        return e.normalizeString(idx, node, root, strings.ToLower)
+    case functionTypeUppercase:
+        return e.normalizeString(idx, node, root, strings.ToUpper)
+    case functionTypeTrim:
+        return e.normalizeString(idx, node, root, strings.TrimSpace)
+    case functionTypeSplit:
+        return e.split(idx, node, root)
+    case functionTypeJoin:
+        return e.join(idx, node, root)
+    case functionTypeDate, functionTypeDateTime:
+        return e.dateTime(idx, node, root)
+    case functionTypeSemver:
+        return e.semver(idx, node, root)
     }
     return literal{}
 }
@@ -330,7 +907,10 @@ func (q singularQuery) Evaluate(idx index, node *yaml.Node, root *yaml.Node) lit
 func (q relQuery) Evaluate(idx index, node *yaml.Node, root *yaml.Node) literal {
     result := q.Query(idx, node, root)
     if len(result) == 1 {
-        return nodeToLiteral(result[0])
+        return documentValue(idx, result[0])
+    }
+    if len(result) == 0 && lengthProperty(idx, q.segments) {
+        return lengthOf(idx, relQuery{segments: q.segments[:len(q.segments)-1]}.Query(idx, node, root))
     }
     return literal{}
 
@@ -339,11 +919,40 @@ func (q relQuery) Evaluate(idx index, node *yaml.Node, root *yaml.Node) literal
 func (q absQuery) Evaluate(idx index, node *yaml.Node, root *yaml.Node) literal {
     result := q.Query(idx, root, root)
     if len(result) == 1 {
-        return nodeToLiteral(result[0])
+        return documentValue(idx, result[0])
+    }
+    if len(result) == 0 && lengthProperty(idx, q.segments) {
+        return lengthOf(idx, absQuery{segments: q.segments[:len(q.segments)-1]}.Query(idx, root, root))
     }
     return literal{}
 }
 
+// lengthProperty reports whether a singular query that selected nothing ends with .length, which in
+// JSONPath Plus mode gives the length of an array or string, as the length property does in
+// JavaScript, so that @.tags.length stands for length(@.tags)
+func lengthProperty(idx index, segments []*segment) bool {
+    return len(segments) > 0 && plusEnabled(configOf(idx)) && segments[len(segments)-1].isMember("length")
+}
+
+// lengthOf returns the number of elements of a single sequence, or of Unicode scalar values of a
+// single string, or nothing
+func lengthOf(idx index, nodes []*yaml.Node) literal {
+    if len(nodes) != 1 {
+        return literal{}
+    }
+    node := expandAlias(configOf(idx), nodes[0])
+    var n int
+    switch {
+    case node.Kind == yaml.SequenceNode:
+        n = len(node.Content)
+    case node.Kind == yaml.ScalarNode && node.ShortTag() == "!!str":
+        n = utf8.RuneCountInString(node.Value)
+    default:
+        return literal{}
+    }
+    return literal{integer: &n}
+}
+
 // Type checker functions for JSONPath Plus type selectors
 
 func isNullLiteral(lit *literal) bool {
@@ -1,8 +1,14 @@
 package jsonpath
 
 import (
+	"fmt"
+	"math"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
 	"go.yaml.in/yaml/v4"
 )
 
@@ -54,30 +60,100 @@ func (i *_index) getParentNode(child *yaml.Node) *yaml.Node {
 var _ Evaluator = jsonPathAST{}
 
 func (q jsonPathAST) Query(current *yaml.Node, root *yaml.Node) []*yaml.Node {
-	if root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+	return q.query(current, root, defaultConfig)
+}
+
+// query evaluates the AST with the given config
+func (q jsonPathAST) query(current *yaml.Node, root *yaml.Node, cfg config.Config) []*yaml.Node {
+	result, _ := q.tryQuery(current, root, cfg)
+	return result
+}
+
+// tryQuery is query, also returning the error evaluation failed with, such as a *CyclicDocumentError
+func (q jsonPathAST) tryQuery(current *yaml.Node, root *yaml.Node, cfg config.Config) ([]*yaml.Node, error) {
+	if root != nil && root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
 		root = root.Content[0]
 	}
+	if root == nil {
+		return nil, nil
+	}
+	ctx := newFilterContext(root, cfg)
+	result := q.evaluate(ctx, root)
+	if err := ctx.err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// evaluate runs the AST against root with the given filter context
+func (q jsonPathAST) evaluate(ctx *filterContext, root *yaml.Node) []*yaml.Node {
+	if len(q.union) == 0 {
+		return q.evaluateQuery(ctx, root)
+	}
+	var result []*yaml.Node
+	for _, query := range q.queries() {
+		// each query starts from the root, with nothing left over from the one before
+		state := ctx.saveState()
+		clear(ctx.pendingPathSegments)
+		clear(ctx.pendingPropertyNames)
+		result = append(result, query.evaluateQuery(ctx, root)...)
+		ctx.restoreState(state)
+		if ctx.interrupted() {
+			return nil
+		}
+	}
+	result = inDocumentOrder(root, result)
+	if ctx.config.DeduplicateResults() {
+		result = unique(result)
+	}
+	return result
+}
 
-	ctx := NewFilterContext(root)
+// evaluateQuery runs a single query, one that is not a union, against root
+func (q jsonPathAST) evaluateQuery(ctx *filterContext, root *yaml.Node) []*yaml.Node {
+	cfg := ctx.config
 
 	// Only enable parent tracking if the query uses ^ or @parent
 	if q.hasParentReferences() {
 		ctx.EnableParentTracking()
 	}
+	if q.hasPathReferences() {
+		ctx.pathTrackingActive = true
+	}
 
 	result := make([]*yaml.Node, 0)
 	result = append(result, root)
 
 	for _, segment := range q.segments {
+		var start time.Time
+		if ctx.profile != nil {
+			start = ctx.profile.begin(segment, len(result))
+		}
 		newValue := []*yaml.Node{}
 		for _, value := range result {
+			if ctx.interrupted() {
+				return nil
+			}
 			newValue = append(newValue, segment.Query(ctx, value, root)...)
 		}
+		if cfg.DeduplicateResults() {
+			newValue = unique(newValue)
+		}
+		if ctx.profile != nil {
+			ctx.profile.end(start, len(newValue))
+		}
 		result = newValue
 	}
 	return result
 }
 
+// hasPathReferences checks if the filters of the AST read the path of the node under test
+func (q jsonPathAST) hasPathReferences() bool {
+	reads := &filterReads{}
+	reads.segments(q.segments)
+	return reads.paths
+}
+
 // hasParentReferences checks if the AST uses parent selectors (^) or @parent context variable
 func (q jsonPathAST) hasParentReferences() bool {
 	for _, seg := range q.segments {
@@ -85,9 +161,60 @@ func (q jsonPathAST) hasParentReferences() bool {
 			return true
 		}
 	}
+	for _, query := range q.union {
+		if query.hasParentReferences() {
+			return true
+		}
+	}
 	return false
 }
 
+// inDocumentOrder stably sorts nodes by where they first appear in a depth-first walk of root, with
+// mapping keys before their values. Nodes outside the document, such as the views of YAML sets,
+// keep their order after the others. The walk stops once every node has been placed.
+func inDocumentOrder(root *yaml.Node, nodes []*yaml.Node) []*yaml.Node {
+	if len(nodes) < 2 {
+		return nodes
+	}
+	positions := make(map[*yaml.Node]int, len(nodes))
+	for _, node := range nodes {
+		positions[node] = -1
+	}
+	remaining := len(positions)
+	visited := make(map[*yaml.Node]bool)
+	var walk func(node *yaml.Node) bool
+	walk = func(node *yaml.Node) bool {
+		if node == nil || visited[node] {
+			return false
+		}
+		visited[node] = true
+		if p, ok := positions[node]; ok && p < 0 {
+			positions[node] = len(positions) - remaining
+			remaining--
+			if remaining == 0 {
+				return true
+			}
+		}
+		for _, child := range node.Content {
+			if walk(child) {
+				return true
+			}
+		}
+		return node.Kind == yaml.AliasNode && walk(node.Alias)
+	}
+	walk(root)
+	position := func(node *yaml.Node) int {
+		if p := positions[node]; p >= 0 {
+			return p
+		}
+		return len(positions)
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return position(nodes[i]) < position(nodes[j])
+	})
+	return nodes
+}
+
 func (s *segment) hasParentReferences() bool {
 	if s.kind == segmentKindParent {
 		return true
@@ -130,6 +257,9 @@ func (e *logicalOrExpr) hasParentReferences() bool {
 			return true
 		}
 	}
+	if e.conditional != nil {
+		return e.conditional.then.hasParentReferences() || e.conditional.otherwise.hasParentReferences()
+	}
 	return false
 }
 
@@ -169,6 +299,9 @@ func (c *comparable) hasParentReferences() bool {
 	if c.contextVar != nil && c.contextVar.kind == contextVarParent {
 		return true
 	}
+	if c.arithmetic != nil {
+		return c.arithmetic.left.hasParentReferences() || c.arithmetic.right.hasParentReferences()
+	}
 	if c.singularQuery != nil {
 		if c.singularQuery.relQuery != nil {
 			for _, seg := range c.singularQuery.relQuery.segments {
@@ -182,6 +315,12 @@ func (c *comparable) hasParentReferences() bool {
 }
 
 func (e *testExpr) hasParentReferences() bool {
+	if e.contextVar != nil && e.contextVar.kind == contextVarParent {
+		return true
+	}
+	if e.filterQuery != nil && e.filterQuery.contextVar != nil && e.filterQuery.contextVar.kind == contextVarParent {
+		return true
+	}
 	if e.filterQuery != nil {
 		if e.filterQuery.relQuery != nil {
 			for _, seg := range e.filterQuery.relQuery.segments {
@@ -209,12 +348,25 @@ func (s segment) Query(idx index, value *yaml.Node, root *yaml.Node) []*yaml.Nod
     case segmentKindDescendant:
         // run the inner segment against this node
         var result = []*yaml.Node{}
-        children := descend(value, root)
-        for _, child := range children {
+        children, paths := descend(idx, value, root)
+        fc, _ := idx.(*filterContext)
+        if fc != nil && fc.profile != nil {
+            fc.profile.visit(len(children) - 1)
+        }
+        // RFC 9535 2.5.2.2: visit the node and its descendants in document order, and
+        // concatenate the results. Unions may legitimately produce duplicates here; the
+        // deduplicated mode removes them once the segment has been evaluated.
+        for i, child := range children {
+            if paths != nil {
+                paths[i].leavePending(fc, child)
+            }
             result = append(result, s.descendant.Query(idx, child, root)...)
+            if paths != nil {
+                // the inner segment consumed the path, which the segments after this one may
+                // still need if it selected child from its parent
+                paths[i].leavePending(fc, child)
+            }
         }
-        // make children unique by pointer value
-        result = unique(result)
         return result
     case segmentKindProperyName:
         found := idx.getPropertyKey(value)
@@ -224,16 +376,91 @@ func (s segment) Query(idx index, value *yaml.Node, root *yaml.Node) []*yaml.Nod
         return []*yaml.Node{}
     case segmentKindParent:
         // JSONPath Plus parent selector: ^ returns the parent of the current node
-        parent := idx.getParentNode(value)
+        if s.parentKeys != nil {
+            return s.keyedAscent(idx, value)
+        }
+        parent := value
+        for level := 0; level < max(s.parentLevels, 1) && parent != nil; level++ {
+            parent = idx.getParentNode(parent)
+        }
         if parent != nil {
             return []*yaml.Node{parent}
         }
         // No parent found (could be root node)
         return []*yaml.Node{}
+    case segmentKindTypeSelector:
+        if hasType(expandAlias(configOf(idx), value), s.typeSelector) {
+            return []*yaml.Node{value}
+        }
+        return []*yaml.Node{}
     }
     panic("no segment type")
 }
 
+// keyedAscent returns the nearest ancestor of value that is the value of a member named by one of
+// the keys of the segment, or nothing if there is none
+func (s segment) keyedAscent(idx index, value *yaml.Node) []*yaml.Node {
+    caseInsensitive := configOf(idx).CaseInsensitiveKeys()
+    for node := idx.getParentNode(value); node != nil; {
+        parent := idx.getParentNode(node)
+        if parent == nil {
+            break
+        }
+        if parent.Kind == yaml.MappingNode {
+            for i := 1; i < len(parent.Content); i += 2 {
+                if parent.Content[i] != node {
+                    continue
+                }
+                key := parent.Content[i-1].Value
+                for _, name := range s.parentKeys {
+                    if key == name || caseInsensitive && strings.EqualFold(key, name) {
+                        return []*yaml.Node{node}
+                    }
+                }
+            }
+        }
+        node = parent
+    }
+    return []*yaml.Node{}
+}
+
+// hasType reports whether node has the type named by a JSONPath Plus type selector. As in
+// JavaScript, arrays are objects too, and numbers are finite.
+func hasType(node *yaml.Node, typeName string) bool {
+    switch typeName {
+    case "array":
+        return node.Kind == yaml.SequenceNode
+    case "object":
+        return node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode
+    case "scalar":
+        return node.Kind == yaml.ScalarNode
+    }
+    if node.Kind != yaml.ScalarNode {
+        return false
+    }
+    switch typeName {
+    case "null":
+        return node.ShortTag() == "!!null"
+    case "boolean":
+        return node.ShortTag() == "!!bool"
+    case "string":
+        return node.ShortTag() == "!!str"
+    case "integer":
+        return node.ShortTag() == "!!int"
+    case "number", "nonFinite":
+        if node.ShortTag() == "!!int" {
+            return typeName == "number"
+        }
+        var value float64
+        if node.ShortTag() != "!!float" || node.Decode(&value) != nil {
+            return false
+        }
+        finite := !math.IsInf(value, 0) && !math.IsNaN(value)
+        return finite == (typeName == "number")
+    }
+    return false
+}
+
 func unique(nodes []*yaml.Node) []*yaml.Node {
     // stably returns a new slice containing only the unique elements from nodes
     res := make([]*yaml.Node, 0)
@@ -249,9 +476,14 @@ func unique(nodes []*yaml.Node) []*yaml.Node {
 
 func (s innerSegment) Query(idx index, value *yaml.Node, root *yaml.Node) []*yaml.Node {
     result := []*yaml.Node{}
+    value = collectionView(idx, value)
     trackParents := parentTrackingEnabled(idx)
 
     switch s.kind {
+    case segmentContainers:
+        if value.Kind == yaml.MappingNode || value.Kind == yaml.SequenceNode {
+            result = append(result, value)
+        }
     case segmentDotWildcard:
         // Check for inherited pending segment from previous wildcard/slice
         var inheritedPending string
@@ -294,6 +526,9 @@ func (s innerSegment) Query(idx index, value *yaml.Node, root *yaml.Node) []*yam
         }
         return result
     case segmentDotMemberName:
+        if member := setMember(value, s.dotName); member != nil {
+            return []*yaml.Node{member}
+        }
         if value.Kind == yaml.MappingNode {
             // Check for inherited pending segment from wildcard/slice
             var inheritedPending string
@@ -301,11 +536,12 @@ func (s innerSegment) Query(idx index, value *yaml.Node, root *yaml.Node) []*yam
                 inheritedPending = fc.GetAndClearPendingPathSegment(value)
             }
 
+            name := memberName(configOf(idx), value, s.dotName)
             for i := 0; i < len(value.Content); i += 2 {
                 key := value.Content[i]
                 val := value.Content[i+1]
 
-                if key.Value == s.dotName {
+                if key.Value == name {
                     idx.setPropertyKey(key, value)
                     idx.setPropertyKey(val, key)
                     if trackParents {
@@ -340,14 +576,81 @@ func (s innerSegment) Query(idx index, value *yaml.Node, root *yaml.Node) []*yam
 
 }
 
+// memberName returns the key of the member of the mapping value that name selects: name itself, or
+// with config.WithCaseInsensitiveKeys and no key spelled exactly as name, the first key equal to it
+// ignoring case
+func memberName(cfg config.Config, value *yaml.Node, name string) string {
+    if !cfg.CaseInsensitiveKeys() {
+        return name
+    }
+    folded := ""
+    for i := 0; i < len(value.Content); i += 2 {
+        key := value.Content[i].Value
+        if key == name {
+            return name
+        }
+        if folded == "" && strings.EqualFold(key, name) {
+            folded = key
+        }
+    }
+    if folded == "" {
+        return name
+    }
+    return folded
+}
+
+// queryMembers selects the members of value whose names satisfy match, for the glob and key pattern
+// selectors
+func (s selector) queryMembers(idx index, value *yaml.Node, match func(name string) bool) []*yaml.Node {
+    if value.Kind != yaml.MappingNode {
+        return nil
+    }
+    trackParents := parentTrackingEnabled(idx)
+    // Check for inherited pending segment from previous wildcard/slice
+    var inheritedPending string
+    if fc, ok := idx.(FilterContext); ok {
+        inheritedPending = fc.GetAndClearPendingPathSegment(value)
+    }
+
+    var result []*yaml.Node
+    for i := 1; i < len(value.Content); i += 2 {
+        keyNode, child := value.Content[i-1], value.Content[i]
+        if !match(keyNode.Value) {
+            continue
+        }
+        idx.setPropertyKey(keyNode, value)
+        idx.setPropertyKey(child, keyNode)
+        if trackParents {
+            idx.setParentNode(child, value)
+        }
+        // Track pending path segment and property name for this node
+        if fc, ok := idx.(FilterContext); ok {
+            fc.SetPendingPathSegment(child, inheritedPending+normalizePathSegment(keyNode.Value))
+            fc.SetPendingPropertyName(child, keyNode.Value) // For @parentProperty
+        }
+        result = append(result, child)
+    }
+    return result
+}
+
 func (s selector) Query(idx index, value *yaml.Node, root *yaml.Node) []*yaml.Node {
     trackParents := parentTrackingEnabled(idx)
 
     switch s.kind {
     case selectorSubKindName:
+        if member := setMember(value, s.name); member != nil {
+            return []*yaml.Node{member}
+        }
+        if value.Kind == yaml.SequenceNode && configOf(idx).LenientParsingEnabled() {
+            // lenient parsing reads ['0'] on an array as [0]
+            if i, ok := quotedIndex(s.name); ok {
+                return selector{kind: selectorSubKindArrayIndex, index: i}.Query(idx, value, root)
+            }
+        }
         if value.Kind != yaml.MappingNode {
             return nil
         }
+        name := memberName(configOf(idx), value, s.name)
         // Check for inherited pending segment from wildcard/slice
         var inheritedPending string
         if fc, ok := idx.(FilterContext); ok {
@@ -360,7 +663,7 @@ func (s selector) Query(idx index, value *yaml.Node, root *yaml.Node) []*yaml.No
                 key = child.Value
                 continue
             }
-            if key == s.name && i%2 == 1 {
+            if key == name && i%2 == 1 {
                 idx.setPropertyKey(value.Content[i], value.Content[i-1])
                 idx.setPropertyKey(value.Content[i-1], value)
                 if trackParents {
@@ -380,6 +683,15 @@ func (s selector) Query(idx index, value *yaml.Node, root *yaml.Node) []*yaml.No
                 return []*yaml.Node{child}
             }
         }
+    case selectorSubKindScript:
+        if value.Kind != yaml.SequenceNode {
+            return nil
+        }
+        i, ok := s.script.index(len(value.Content))
+        if !ok {
+            return nil
+        }
+        return selector{kind: selectorSubKindArrayIndex, index: int64(i)}.Query(idx, value, root)
     case selectorSubKindArrayIndex:
         if value.Kind != yaml.SequenceNode {
             return nil
@@ -458,6 +770,19 @@ func (s selector) Query(idx index, value *yaml.Node, root *yaml.Node) []*yaml.No
             return result
         }
         return nil
+    case selectorSubKindGlob:
+        if configOf(idx).CaseInsensitiveKeys() {
+            pattern := strings.ToLower(s.name)
+            return s.queryMembers(idx, value, func(name string) bool { return matchGlob(pattern, strings.ToLower(name)) })
+        }
+        return s.queryMembers(idx, value, func(name string) bool { return matchGlob(s.name, name) })
+    case selectorSubKindKeyPattern:
+        return s.queryMembers(idx, value, s.pattern.re.MatchString)
+    case selectorSubKindCustom:
+        if !config.PlusAvailable {
+            return nil
+        }
+        return s.queryCustom(idx, value)
     case selectorSubKindArraySlice:
         if value.Kind != yaml.SequenceNode {
             return nil
@@ -561,6 +886,10 @@ func (s selector) Query(idx index, value *yaml.Node, root *yaml.Node) []*yaml.No
                 }
             }
         case yaml.SequenceNode:
+            if fc, ok := idx.(*filterContext); ok && fc.parallelizes(s.filter, value) {
+                result = fc.filterParallel(s.filter, value, root, parentPropName)
+                break
+            }
             for i, child := range value.Content {
                 if trackParents {
                     idx.setParentNode(child, value)
@@ -631,16 +960,34 @@ func bounds(start, end *int64, step, length int64) (int64, int64) {
 }
 
 func (s filterSelector) Matches(idx index, node *yaml.Node, root *yaml.Node) bool {
-    return s.expression.Matches(idx, node, root)
+    fc, ok := idx.(*filterContext)
+    if ok && fc.interrupted() {
+        return false
+    }
+    if !ok || fc.dependencies == nil {
+        return s.expression.Matches(idx, node, root)
+    }
+    fc.dependencies.begin()
+    matched := s.expression.Matches(idx, node, root)
+    fc.dependencies.end(node, matched)
+    return matched
 }
 
 func (e logicalOrExpr) Matches(idx index, node *yaml.Node, root *yaml.Node) bool {
+    matched := false
     for _, expr := range e.expressions {
         if expr.Matches(idx, node, root) {
-            return true
+            matched = true
+            break
         }
     }
-    return false
+    if e.conditional != nil {
+        if matched {
+            return e.conditional.then.Matches(idx, node, root)
+        }
+        return e.conditional.otherwise.Matches(idx, node, root)
+    }
+    return matched
 }
 
 func (e logicalAndExpr) Matches(idx index, node *yaml.Node, root *yaml.Node) bool {
@@ -669,7 +1016,26 @@ func (e basicExpr) Matches(idx index, node *yaml.Node, root *yaml.Node) bool {
 
 func (e comparisonExpr) Matches(idx index, node *yaml.Node, root *yaml.Node) bool {
     leftValue := e.left.Evaluate(idx, node, root)
+    if e.op == matches {
+        if handler := configOf(idx).TypeErrorHandler(); handler != nil {
+            if message := e.pattern.typeMismatch(leftValue); message != "" {
+                handler(node, e.ToString(), message)
+            }
+        }
+        return e.pattern.matches(leftValue)
+    }
+    if e.op == in {
+        return e.contains(idx, node, root, leftValue)
+    }
     rightValue := e.right.Evaluate(idx, node, root)
+    if configOf(idx).NumericStrings() {
+        leftValue, rightValue = coerceNumericStrings(leftValue, rightValue)
+    }
+    if handler := configOf(idx).TypeErrorHandler(); handler != nil {
+        if message := typeMismatch(e.op, leftValue, rightValue); message != "" {
+            handler(node, e.ToString(), message)
+        }
+    }
 
     switch e.op {
     case equalTo:
@@ -677,29 +1043,61 @@ func (e comparisonExpr) Matches(idx index, node *yaml.Node, root *yaml.Node) boo
     case notEqualTo:
         return !leftValue.Equals(rightValue)
     case lessThan:
-        return leftValue.LessThan(rightValue)
+        return leftValue.lessThanIn(rightValue, configOf(idx))
     case lessThanEqualTo:
-        return leftValue.LessThanOrEqual(rightValue)
+        return leftValue.lessThanOrEqualIn(rightValue, configOf(idx))
     case greaterThan:
-        return rightValue.LessThan(leftValue)
+        return rightValue.lessThanIn(leftValue, configOf(idx))
     case greaterThanEqualTo:
-        return rightValue.LessThanOrEqual(leftValue)
+        return rightValue.lessThanOrEqualIn(leftValue, configOf(idx))
     default:
         return false
     }
 }
 
+// contains reports whether value equals a member of the list or array on the right of an in operator
+func (e comparisonExpr) contains(idx index, node *yaml.Node, root *yaml.Node, value literal) bool {
+    if literalKind(value) == "Nothing" {
+        return false
+    }
+    if e.right == nil {
+        for _, member := range e.values {
+            if equalsIn(value, *member, configOf(idx)) {
+                return true
+            }
+        }
+        return false
+    }
+    members := e.right.Evaluate(idx, node, root)
+    if members.node == nil || members.node.Kind != yaml.SequenceNode {
+        if handler := configOf(idx).TypeErrorHandler(); handler != nil && literalKind(members) != "Nothing" {
+            handler(node, e.ToString(), fmt.Sprintf("cannot test membership in %s", literalKind(members)))
+        }
+        return false
+    }
+    for _, member := range members.node.Content {
+        if member != nil && equalsIn(value, documentValue(idx, expandAlias(configOf(idx), member)), configOf(idx)) {
+            return true
+        }
+    }
+    return false
+}
+
 func (e testExpr) Matches(idx index, node *yaml.Node, root *yaml.Node) bool {
     var result bool
     if e.filterQuery != nil {
         result = len(e.filterQuery.Query(idx, node, root)) > 0
     } else if e.functionExpr != nil {
         funcResult := e.functionExpr.Evaluate(idx, node, root)
-        if funcResult.bool != nil {
+        if e.functionExpr.funcType == functionTypeCustom {
+            result = funcResult.bool != nil && *funcResult.bool
+        } else if funcResult.bool != nil {
             result = *funcResult.bool
         } else if funcResult.null == nil {
             result = true
         }
+    } else if config.PlusAvailable && e.contextVar != nil {
+        result = literalKind(e.contextVar.Evaluate(idx, node, root)) != "Nothing"
     }
     if e.not {
         return !result
@@ -712,12 +1110,25 @@ func (q filterQuery) Query(idx index, node *yaml.Node, root *yaml.Node) []*yaml.
         return q.relQuery.Query(idx, node, root)
     }
     if q.jsonPathQuery != nil {
-        return q.jsonPathQuery.Query(node, root)
+        result, err := q.jsonPathQuery.tryQuery(node, root, configOf(idx))
+        if err != nil {
+            failQuery(idx, err)
+        }
+        recordReads(idx, result...)
+        return result
+    }
+    if config.PlusAvailable && q.contextVar != nil {
+        return q.contextVar.nodes(idx, node, root)
     }
     return nil
 }
 
 func (q relQuery) Query(idx index, node *yaml.Node, root *yaml.Node) []*yaml.Node {
+    if fc, ok := idx.(*filterContext); ok {
+        // the query must not change what @property, @path and the other context variables
+        // describe for the rest of the filter
+        defer fc.restoreState(fc.saveState())
+    }
     result := []*yaml.Node{node}
     for _, seg := range q.segments {
         var newResult []*yaml.Node
@@ -730,6 +1141,9 @@ func (q relQuery) Query(idx index, node *yaml.Node, root *yaml.Node) []*yaml.Nod
 }
 
 func (q absQuery) Query(idx index, node *yaml.Node, root *yaml.Node) []*yaml.Node {
+    if fc, ok := idx.(*filterContext); ok {
+        defer fc.restoreState(fc.saveState())
+    }
     result := []*yaml.Node{root}
     for _, seg := range q.segments {
         var newResult []*yaml.Node
@@ -738,5 +1152,6 @@ func (q absQuery) Query(idx index, node *yaml.Node, root *yaml.Node) []*yaml.Nod
         }
         result = newResult
     }
+    recordReads(idx, result...)
     return result
 }
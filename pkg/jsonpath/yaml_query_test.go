@@ -4,7 +4,9 @@ import (
     "github.com/pb33f/jsonpath/pkg/jsonpath/config"
     "github.com/pb33f/jsonpath/pkg/jsonpath/token"
     "go.yaml.in/yaml/v4"
+    "fmt"
     "reflect"
+    "regexp"
     "strings"
     "testing"
 )
@@ -286,3 +288,1434 @@ paths:
         })
     }
 }
+
+func TestQueryDuplicates(t *testing.T) {
+    tests := []struct {
+        name         string
+        input        string
+        yaml         string
+        expected     []string
+        deduplicated []string
+    }{
+        {
+            name:         "Union of the same index",
+            input:        "$[0,0]",
+            yaml:         "[a, b]",
+            expected:     []string{"a", "a"},
+            deduplicated: []string{"a"},
+        },
+        {
+            name:         "Descendant union of the same name",
+            input:        "$..['a','a']",
+            yaml:         "{a: 1, b: {a: 2}}",
+            expected:     []string{"1", "1", "2", "2"},
+            deduplicated: []string{"1", "2"},
+        },
+        {
+            name:         "Descendant wildcard keeps document order",
+            input:        "$..*",
+            yaml:         "{a: [1, 2], b: 3}",
+            expected:     []string{"[1, 2]", "3", "1", "2"},
+            deduplicated: []string{"[1, 2]", "3", "1", "2"},
+        },
+        {
+            name:         "Overlapping wildcard and index",
+            input:        "$[*,1]",
+            yaml:         "[a, b]",
+            expected:     []string{"a", "b", "b"},
+            deduplicated: []string{"a", "b"},
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            err := yaml.Unmarshal([]byte(test.yaml), &root)
+            if err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+
+            for _, mode := range []struct {
+                opts     []config.Option
+                expected []string
+            }{
+                {nil, test.expected},
+                {[]config.Option{config.WithDeduplicatedResults()}, test.deduplicated},
+            } {
+                path, err := NewPath(test.input, mode.opts...)
+                if err != nil {
+                    t.Fatalf("Error parsing JSON Path: %v", err)
+                }
+                var actual []string
+                for _, node := range path.Query(&root) {
+                    actual = append(actual, nodeToString(node))
+                }
+                if !reflect.DeepEqual(actual, mode.expected) {
+                    t.Errorf("Expected:\n%v\nGot:\n%v", mode.expected, actual)
+                }
+            }
+        })
+    }
+}
+
+func TestQueryStringOrder(t *testing.T) {
+    const doc = "[item2, item10, Item3, v1.9, v1.10, item02]"
+    caseInsensitive := func(a, b string) int {
+        return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+    }
+    tests := []struct {
+        name     string
+        input    string
+        opts     []config.Option
+        expected []string
+    }{
+        {
+            name:     "Byte order by default",
+            input:    "$[?@ < 'item3']",
+            expected: []string{"item2", "item10", "Item3", "item02"},
+        },
+        {
+            name:     "Natural order",
+            input:    "$[?@ < 'item3']",
+            opts:     []config.Option{config.WithStringOrder(config.StringOrderNatural)},
+            expected: []string{"item2", "Item3", "item02"},
+        },
+        {
+            name:     "Natural order of versions",
+            input:    "$[?@ > 'v1.9']",
+            opts:     []config.Option{config.WithStringOrder(config.StringOrderNatural)},
+            expected: []string{"v1.10"},
+        },
+        {
+            name:     "Leading zeros only compare equal to themselves",
+            input:    "$[?@ <= 'item2' && @ >= 'item2']",
+            opts:     []config.Option{config.WithStringOrder(config.StringOrderNatural)},
+            expected: []string{"item2"},
+        },
+        {
+            name:     "Collator",
+            input:    "$[?@ >= 'item3' && @ <= 'item3']",
+            opts:     []config.Option{config.WithStringCollator(caseInsensitive)},
+            expected: []string{"Item3"},
+        },
+        {
+            name:     "Collator does not change equality",
+            input:    "$[?@ == 'item3']",
+            opts:     []config.Option{config.WithStringCollator(caseInsensitive)},
+            expected: nil,
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            path, err := NewPath(test.input, test.opts...)
+            if err != nil {
+                t.Fatalf("Error parsing JSON Path: %v", err)
+            }
+            var actual []string
+            for _, node := range path.Query(&root) {
+                actual = append(actual, node.Value)
+            }
+            if !reflect.DeepEqual(actual, test.expected) {
+                t.Errorf("Expected:\n%v\nGot:\n%v", test.expected, actual)
+            }
+        })
+    }
+}
+
+func TestQueryCaseInsensitiveKeys(t *testing.T) {
+    const doc = `
+Info: {Title: Pets, version: 1.0.0}
+paths:
+  /Pets: {GET: {summary: list}}
+  /pets/{id}: {get: {summary: show}}
+tags: [{Name: a, name: b}, {NAME: c}]
+`
+    caseInsensitive := []config.Option{config.WithCaseInsensitiveKeys()}
+    tests := []struct {
+        name     string
+        input    string
+        opts     []config.Option
+        expected []string
+    }{
+        {
+            name:  "Exact by default",
+            input: "$.info.title",
+        },
+        {
+            name:     "Dot member names",
+            input:    "$.info.title",
+            opts:     caseInsensitive,
+            expected: []string{"Pets"},
+        },
+        {
+            name:     "Bracketed names",
+            input:    "$['INFO']['Version']",
+            opts:     caseInsensitive,
+            expected: []string{"1.0.0"},
+        },
+        {
+            name:     "Exact spelling preferred",
+            input:    "$.tags[*].name",
+            opts:     caseInsensitive,
+            expected: []string{"b", "c"},
+        },
+        {
+            name:     "Descendants",
+            input:    "$..get.summary",
+            opts:     caseInsensitive,
+            expected: []string{"list", "show"},
+        },
+        {
+            name:     "Filters",
+            input:    "$.tags[?@.name == 'c'].NAME",
+            opts:     caseInsensitive,
+            expected: []string{"c"},
+        },
+        {
+            name:     "Globs",
+            input:    "$.paths['/pets*'].*.summary",
+            opts:     append([]config.Option{config.WithGlobNames()}, caseInsensitive...),
+            expected: []string{"list", "show"},
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            path, err := NewPath(test.input, test.opts...)
+            if err != nil {
+                t.Fatalf("Error parsing JSON Path: %v", err)
+            }
+            var actual []string
+            for _, node := range path.Query(&root) {
+                actual = append(actual, node.Value)
+            }
+            if !reflect.DeepEqual(actual, test.expected) {
+                t.Errorf("Expected:\n%v\nGot:\n%v", test.expected, actual)
+            }
+        })
+    }
+}
+
+func TestQueryBinaryFunctions(t *testing.T) {
+    requirePlus(t)
+    const doc = `
+examples:
+  - name: small
+    data: aGVsbG8=
+  - name: unpadded
+    data: aGVsbG8gd29ybGQ
+  - name: invalid
+    data: "not base64!"
+  - name: binary
+    data: !!binary |
+      iVBORw0KGgoAAAANSUhEUgAAAAEAAAAB
+  - name: multibyte
+    data: "héllo"
+`
+    tests := []struct {
+        name     string
+        input    string
+        expected []string
+    }{
+        {
+            name:     "Decoded size",
+            input:    "$.examples[?byteLength(decodeBase64(@.data)) > 5].name",
+            expected: []string{"unpadded", "binary"},
+        },
+        {
+            name:     "Decoded content",
+            input:    "$.examples[?decodeBase64(@.data) == 'hello'].name",
+            expected: []string{"small"},
+        },
+        {
+            name:     "Invalid base64 is Nothing",
+            input:    "$.examples[?decodeBase64(@.data) == decodeBase64(@.missing)].name",
+            expected: []string{"invalid", "multibyte"},
+        },
+        {
+            name:     "Byte length of a string",
+            input:    "$.examples[?byteLength(@.data) == 6 && length(@.data) == 5].name",
+            expected: []string{"multibyte"},
+        },
+        {
+            name:     "Byte length of a binary scalar is decoded",
+            input:    "$.examples[?byteLength(@.data) == 24].name",
+            expected: []string{"binary"},
+        },
+        {
+            name:     "Decoded payload matched against a pattern",
+            input:    "$.examples[?search(decodeBase64(@.data), '^.PNG')].name",
+            expected: []string{"binary"},
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            path, err := NewPath(test.input)
+            if err != nil {
+                t.Fatalf("Error parsing JSON Path: %v", err)
+            }
+            var actual []string
+            for _, node := range path.Query(&root) {
+                actual = append(actual, node.Value)
+            }
+            if !reflect.DeepEqual(actual, test.expected) {
+                t.Errorf("Expected:\n%v\nGot:\n%v", test.expected, actual)
+            }
+        })
+    }
+
+    for _, input := range []string{"$[?decodeBase64(@.data)]", "$[?byteLength(@.data)]"} {
+        if _, err := NewPath(input); err == nil {
+            t.Errorf("Expected %s to fail to parse", input)
+        }
+    }
+}
+
+func TestQueryValueResolver(t *testing.T) {
+    const doc = `
+services:
+  - name: api
+    password: vault:secret/api
+  - name: db
+    password: vault:secret/db
+  - name: cache
+    password: plain-text
+  - name: legacy
+    password: vault:secret/missing
+`
+    secrets := map[string]string{
+        "vault:secret/api": "hunter2",
+        "vault:secret/db":  "correct horse battery staple",
+    }
+    calls := 0
+    resolver := config.WithValueResolver(regexp.MustCompile(`^vault:`), func(reference string) (string, error) {
+        calls++
+        if secret, ok := secrets[reference]; ok {
+            return secret, nil
+        }
+        return "", fmt.Errorf("no secret at %s", reference)
+    })
+
+    tests := []struct {
+        name     string
+        input    string
+        expected []string
+    }{
+        {
+            name:     "Comparison sees resolved values",
+            input:    "$.services[?@.password == 'hunter2'].password",
+            expected: []string{"vault:secret/api"},
+        },
+        {
+            name:     "Functions see resolved values",
+            input:    "$.services[?length(@.password) < 12].name",
+            expected: []string{"api", "cache"},
+        },
+        {
+            name:     "Unresolvable references are Nothing",
+            input:    "$.services[?!match(@.password, '.{12,}')].name",
+            expected: []string{"api", "cache", "legacy"},
+        },
+        {
+            name:     "Values not matching the pattern are unchanged",
+            input:    "$.services[?@.password == 'plain-text'].name",
+            expected: []string{"cache"},
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            path, err := NewPath(test.input, resolver)
+            if err != nil {
+                t.Fatalf("Error parsing JSON Path: %v", err)
+            }
+            var actual []string
+            for _, node := range path.Query(&root) {
+                actual = append(actual, node.Value)
+            }
+            if !reflect.DeepEqual(actual, test.expected) {
+                t.Errorf("Expected:\n%v\nGot:\n%v", test.expected, actual)
+            }
+        })
+    }
+    if calls == 0 {
+        t.Errorf("Expected the resolver to be called")
+    }
+}
+
+func TestQuerySetAndOrderedMap(t *testing.T) {
+    requirePlus(t)
+    doc := `
+regions: !!set
+  ? eu-west
+  ? us-east
+  ? ap-south
+steps: !!omap
+  - build: {image: golang}
+  - test: {image: golang, retries: 2}
+  - deploy: {image: alpine}
+`
+    tests := []struct {
+        name     string
+        input    string
+        expected []string
+    }{
+        {
+            name:     "Set members",
+            input:    "$.regions[*]",
+            expected: []string{"eu-west", "us-east", "ap-south"},
+        },
+        {
+            name:     "Set member by index",
+            input:    "$.regions[-1]",
+            expected: []string{"ap-south"},
+        },
+        {
+            name:     "Set member by name",
+            input:    "$.regions['us-east']",
+            expected: []string{"us-east"},
+        },
+        {
+            name:     "Missing set member",
+            input:    "$.regions['us-west']",
+            expected: nil,
+        },
+        {
+            name:     "Filtered set members",
+            input:    "$.regions[?match(@, '.*-(east|west)')]",
+            expected: []string{"eu-west", "us-east"},
+        },
+        {
+            name:     "Ordered map value by name",
+            input:    "$.steps.test.retries",
+            expected: []string{"2"},
+        },
+        {
+            name:     "Ordered map values in order",
+            input:    "$.steps[*].image",
+            expected: []string{"golang", "golang", "alpine"},
+        },
+        {
+            name:     "Filtered ordered map",
+            input:    "$.steps[?@.image == 'golang' && @property != 'build'].retries",
+            expected: []string{"2"},
+        },
+        {
+            name:     "Descendants of an ordered map",
+            input:    "$..image",
+            expected: []string{"golang", "golang", "alpine"},
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            path, err := NewPath(test.input)
+            if err != nil {
+                t.Fatalf("Error parsing JSON Path: %v", err)
+            }
+            var actual []string
+            for _, node := range path.Query(&root) {
+                actual = append(actual, node.Value)
+            }
+            if !reflect.DeepEqual(actual, test.expected) {
+                t.Errorf("Expected:\n%v\nGot:\n%v", test.expected, actual)
+            }
+        })
+    }
+}
+
+func TestQueryYAML11Numbers(t *testing.T) {
+    doc := `
+files:
+  - {name: script, mode: 0755}
+  - {name: config, mode: 0o644}
+  - {name: secret, mode: "0600"}
+timeouts:
+  - {name: short, after: 1:30}
+  - {name: long, after: 1:00:00}
+  - {name: fraction, after: 2:30.5}
+  - {name: quoted, after: '1:30'}
+limits:
+  - {name: hex, max: 0x1F}
+  - {name: separated, max: 1_000}
+  - {name: binary, max: -0b101}
+`
+    tests := []struct {
+        name     string
+        input    string
+        legacy   []string
+        standard []string
+    }{
+        {
+            name:     "Leading zero octal",
+            input:    "$.files[?@.mode == 493].name",
+            legacy:   []string{"script"},
+            standard: nil,
+        },
+        {
+            name:     "Leading zero decimal",
+            input:    "$.files[?@.mode == 755].name",
+            legacy:   nil,
+            standard: []string{"script"},
+        },
+        {
+            name:     "Prefixed octal",
+            input:    "$.files[?@.mode == 420].name",
+            legacy:   []string{"config"},
+            standard: nil,
+        },
+        {
+            name:     "Unresolved forms are strings",
+            input:    "$.files[?@.mode == '0o644'].name",
+            legacy:   nil,
+            standard: []string{"config"},
+        },
+        {
+            name:     "Quoted values are strings",
+            input:    "$.files[?@.mode == '0600'].name",
+            legacy:   []string{"secret"},
+            standard: []string{"secret"},
+        },
+        {
+            name:     "Sexagesimal",
+            input:    "$.timeouts[?@.after > 60].name",
+            legacy:   []string{"short", "long", "fraction"},
+            standard: nil,
+        },
+        {
+            name:     "Sexagesimal float",
+            input:    "$.timeouts[?@.after == 150.5].name",
+            legacy:   []string{"fraction"},
+            standard: nil,
+        },
+        {
+            name:     "Hexadecimal, separated and binary",
+            input:    "$.limits[?@.max == 31 || @.max == 1000 || @.max == -5].name",
+            legacy:   []string{"hex", "separated", "binary"},
+            standard: nil,
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            for _, mode := range []struct {
+                opts     []config.Option
+                expected []string
+            }{
+                {opts: []config.Option{config.WithYAML11Numbers()}, expected: test.legacy},
+                {expected: test.standard},
+            } {
+                path, err := NewPath(test.input, mode.opts...)
+                if err != nil {
+                    t.Fatalf("Error parsing JSON Path: %v", err)
+                }
+                var actual []string
+                for _, node := range path.Query(&root) {
+                    actual = append(actual, node.Value)
+                }
+                if !reflect.DeepEqual(actual, mode.expected) {
+                    t.Errorf("Expected (YAML 1.1 numbers: %v):\n%v\nGot:\n%v", len(mode.opts) > 0, mode.expected, actual)
+                }
+            }
+        })
+    }
+}
+
+func TestQueryNumericStrings(t *testing.T) {
+    requirePlus(t)
+    doc := `
+responses:
+  - {name: ok, code: "200", ratio: "0.5"}
+  - {name: created, code: 201, ratio: 1e0}
+  - {name: missing, code: "404", ratio: "1e1"}
+  - {name: padded, code: " 500", ratio: "0x10"}
+  - {name: text, code: "two hundred", ratio: "1."}
+`
+    tests := []struct {
+        name     string
+        input    string
+        coerced  []string
+        standard []string
+    }{
+        {
+            name:     "Equal to a number",
+            input:    "$.responses[?@.code == 200].name",
+            coerced:  []string{"ok"},
+            standard: nil,
+        },
+        {
+            name:     "Ordered against a number",
+            input:    "$.responses[?@.code >= 201].name",
+            coerced:  []string{"created", "missing"},
+            standard: []string{"created"},
+        },
+        {
+            name:     "Number on the left",
+            input:    "$.responses[?300 > @.code].name",
+            coerced:  []string{"ok", "created"},
+            standard: []string{"created"},
+        },
+        {
+            name:     "Fractions and exponents",
+            input:    "$.responses[?@.ratio < 2].name",
+            coerced:  []string{"ok", "created"},
+            standard: []string{"created"},
+        },
+        {
+            name:     "Strings compare as strings",
+            input:    "$.responses[?@.code == '200'].name",
+            coerced:  []string{"ok"},
+            standard: []string{"ok"},
+        },
+        {
+            name:     "Strings are ordered as strings",
+            input:    "$.responses[?@.code < '3'].name",
+            coerced:  []string{"ok", "padded"},
+            standard: []string{"ok", "padded"},
+        },
+        {
+            name:     "Membership",
+            input:    "$.responses[?@.code in [200, 404]].name",
+            coerced:  []string{"ok", "missing"},
+            standard: nil,
+        },
+        {
+            name:     "Only JSON numbers",
+            input:    "$.responses[?@.code != 200 && @.code != 201 && @.code != 404].name",
+            coerced:  []string{"padded", "text"},
+            standard: []string{"ok", "missing", "padded", "text"},
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            for _, mode := range []struct {
+                opts     []config.Option
+                expected []string
+            }{
+                {opts: []config.Option{config.WithNumericStrings()}, expected: test.coerced},
+                {expected: test.standard},
+            } {
+                path, err := NewPath(test.input, mode.opts...)
+                if err != nil {
+                    t.Fatalf("Error parsing JSON Path: %v", err)
+                }
+                var actual []string
+                for _, node := range path.Query(&root) {
+                    actual = append(actual, node.Value)
+                }
+                if !reflect.DeepEqual(actual, mode.expected) {
+                    t.Errorf("Expected (numeric strings: %v):\n%v\nGot:\n%v", len(mode.opts) > 0, mode.expected, actual)
+                }
+            }
+        })
+    }
+}
+
+func TestQueryLengthAndCount(t *testing.T) {
+    doc := `
+items:
+  - name: text
+    value: héllo
+  - name: list
+    value: [1, 2, 3, 4, 5]
+  - name: object
+    value: {a: 1, b: 2, c: 3, d: 4, e: 5}
+  - name: number
+    value: 12345
+  - name: missing
+`
+    tests := []struct {
+        name     string
+        input    string
+        expected []string
+    }{
+        {
+            name:     "Length of strings, arrays and objects",
+            input:    "$.items[?length(@.value) == 5].name",
+            expected: []string{"text", "list", "object"},
+        },
+        {
+            name:     "Length of a number or missing value is Nothing",
+            input:    "$.items[?length(@.value) != 5].name",
+            expected: []string{"number", "missing"},
+        },
+        {
+            name:     "Count of child nodes",
+            input:    "$.items[?count(@.value.*) == 5].name",
+            expected: []string{"list", "object"},
+        },
+        {
+            name:     "Count of an empty nodelist",
+            input:    "$.items[?count(@.value) == 0].name",
+            expected: []string{"missing"},
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            for _, opts := range [][]config.Option{nil, {config.WithStrictRFC9535()}} {
+                path, err := NewPath(test.input, opts...)
+                if err != nil {
+                    t.Fatalf("Error parsing JSON Path: %v", err)
+                }
+                var actual []string
+                for _, node := range path.Query(&root) {
+                    actual = append(actual, node.Value)
+                }
+                if !reflect.DeepEqual(actual, test.expected) {
+                    t.Errorf("Expected (strict: %v):\n%v\nGot:\n%v", len(opts) > 0, test.expected, actual)
+                }
+            }
+        })
+    }
+}
+
+func TestQueryValue(t *testing.T) {
+    doc := `
+cars:
+  - name: roadster
+    paint: {color: red}
+  - name: coupe
+    paint: {color: red, trim: {color: black}}
+  - name: van
+  - name: sedan
+    paint: {color: blue}
+default: blue
+`
+    tests := []struct {
+        name     string
+        input    string
+        expected []string
+    }{
+        {
+            name:     "Single descendant",
+            input:    `$.cars[?value(@..color) == "red"].name`,
+            expected: []string{"roadster"},
+        },
+        {
+            name:     "Several or no nodes are Nothing",
+            input:    `$.cars[?value(@..color) != "red"].name`,
+            expected: []string{"coupe", "van", "sedan"},
+        },
+        {
+            name:     "Compared with a root query",
+            input:    `$.cars[?value(@.paint.color) == value($.default)].name`,
+            expected: []string{"sedan"},
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            for _, opts := range [][]config.Option{nil, {config.WithStrictRFC9535()}} {
+                path, err := NewPath(test.input, opts...)
+                if err != nil {
+                    t.Fatalf("Error parsing JSON Path: %v", err)
+                }
+                var actual []string
+                for _, node := range path.Query(&root) {
+                    actual = append(actual, node.Value)
+                }
+                if !reflect.DeepEqual(actual, test.expected) {
+                    t.Errorf("Expected (strict: %v):\n%v\nGot:\n%v", len(opts) > 0, test.expected, actual)
+                }
+            }
+        })
+    }
+}
+
+func TestQueryContains(t *testing.T) {
+    requirePlus(t)
+    doc := `
+servers:
+  - url: https://staging.example.com
+    tags: [internal, beta]
+  - url: https://api.example.com
+    tags: [public, 2]
+  - url: https://sandbox.example.com
+    tags: {internal: true}
+stage: sandbox
+`
+    tests := []struct {
+        name     string
+        input    string
+        expected []string
+    }{
+        {
+            name:     "Substring",
+            input:    `$.servers[?contains(@.url, 'staging')].url`,
+            expected: []string{"https://staging.example.com"},
+        },
+        {
+            name:     "Substring from a query",
+            input:    `$.servers[?contains(@.url, $.stage)].url`,
+            expected: []string{"https://sandbox.example.com"},
+        },
+        {
+            name:     "Array element",
+            input:    `$.servers[?contains(@.tags, 'internal')].url`,
+            expected: []string{"https://staging.example.com"},
+        },
+        {
+            name:     "Array element of another type",
+            input:    `$.servers[?contains(@.tags, 2)].url`,
+            expected: []string{"https://api.example.com"},
+        },
+        {
+            name:     "Negated",
+            input:    `$.servers[?!contains(@.url, 'example')].url`,
+            expected: nil,
+        },
+        {
+            name:     "Strings do not contain numbers",
+            input:    `$.servers[?contains(@.url, 2)].url`,
+            expected: nil,
+        },
+        {
+            name:     "Missing values contain nothing",
+            input:    `$.servers[?contains(@.missing, 'a') || contains(@.url, @.missing)].url`,
+            expected: nil,
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            path, err := NewPath(test.input)
+            if err != nil {
+                t.Fatalf("Error parsing JSON Path: %v", err)
+            }
+            var actual []string
+            for _, node := range path.Query(&root) {
+                actual = append(actual, node.Value)
+            }
+            if !reflect.DeepEqual(actual, test.expected) {
+                t.Errorf("Expected:\n%v\nGot:\n%v", test.expected, actual)
+            }
+        })
+    }
+
+    for _, input := range []string{"$[?contains(@.a, 'b') == true]", "$[?contains(@.a)]", "$[?contains(@..a, 'b')]", "$[?contains(@.a, 'b', 'c')]"} {
+        if _, err := NewPath(input); err == nil {
+            t.Errorf("Expected an error parsing %s", input)
+        }
+    }
+}
+
+func TestQueryStartsAndEndsWith(t *testing.T) {
+    requirePlus(t)
+    doc := `
+prefix: user_
+suffix: "9"
+entries:
+  - id: user_123
+  - id: admin_456
+  - id: user_789
+  - id: 789
+`
+    tests := []struct {
+        name     string
+        input    string
+        expected []string
+    }{
+        {
+            name:     "Prefix literal",
+            input:    `$.entries[?starts_with(@.id, 'admin_')].id`,
+            expected: []string{"admin_456"},
+        },
+        {
+            name:     "Prefix from the root",
+            input:    `$.entries[?starts_with(@.id, $.prefix)].id`,
+            expected: []string{"user_123", "user_789"},
+        },
+        {
+            name:     "Suffix from the root",
+            input:    `$.entries[?ends_with(@.id, $.suffix)].id`,
+            expected: []string{"user_789"},
+        },
+        {
+            name:     "Relative paths on both sides",
+            input:    `$.entries[?starts_with(@.id, @.id) && ends_with(@.id, @.id)].id`,
+            expected: []string{"user_123", "admin_456", "user_789"},
+        },
+        {
+            name:     "Negated",
+            input:    `$.entries[?!(starts_with(@.id, 'user_'))].id`,
+            expected: []string{"admin_456", "789"},
+        },
+        {
+            name:     "Numbers are not strings",
+            input:    `$.entries[?ends_with(@.id, '9') || starts_with(@.id, 7)].id`,
+            expected: []string{"user_789"},
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            path, err := NewPath(test.input)
+            if err != nil {
+                t.Fatalf("Error parsing JSON Path: %v", err)
+            }
+            if path.String() != test.input {
+                t.Errorf("Expected %s to print as itself, got %s", test.input, path.String())
+            }
+            var actual []string
+            for _, node := range path.Query(&root) {
+                actual = append(actual, node.Value)
+            }
+            if !reflect.DeepEqual(actual, test.expected) {
+                t.Errorf("Expected:\n%v\nGot:\n%v", test.expected, actual)
+            }
+        })
+    }
+
+    for _, input := range []string{"$[?starts_with(@.a, 'b') == true]", "$[?ends_with(@.a)]", "$[?starts_with(@.*, 'b')]"} {
+        if _, err := NewPath(input); err == nil {
+            t.Errorf("Expected an error parsing %s", input)
+        }
+    }
+}
+
+func TestQueryAggregates(t *testing.T) {
+    requirePlus(t)
+    doc := `
+limit: 25
+items:
+  - {name: a, price: 10}
+  - {name: b, price: 20}
+  - {name: c, price: 4.5}
+  - {name: d, price: free}
+  - {name: e}
+responses:
+  ok: {schema: {maxItems: 100}}
+  list: {schema: {maxItems: 250}}
+  error: {schema: {}}
+`
+    tests := []struct {
+        name     string
+        input    string
+        expected []string
+    }{
+        {
+            name:     "Above the average",
+            input:    `$.items[?@.price > avg($.items[*].price)].name`,
+            expected: []string{"b"},
+        },
+        {
+            name:     "Maximum of descendants",
+            input:    `$.responses[?@.schema.maxItems == max($..maxItems)]~`,
+            expected: []string{"list"},
+        },
+        {
+            name:     "Minimum",
+            input:    `$.items[?@.price == min($.items[*].price)].name`,
+            expected: []string{"c"},
+        },
+        {
+            name:     "Sum of integers",
+            input:    `$.items[?@.name == 'a' && sum($.items[0:2].price) == 30].name`,
+            expected: []string{"a"},
+        },
+        {
+            name:     "Sum with a float",
+            input:    `$.items[?@.name == 'a' && sum($.items[*].price) == 34.5].name`,
+            expected: []string{"a"},
+        },
+        {
+            name:     "Single node",
+            input:    `$.items[?sum(@.price) < $.limit].name`,
+            expected: []string{"a", "b", "c"},
+        },
+        {
+            name:     "No numbers is Nothing",
+            input:    `$.items[?max(@.name) == min(@.name)].name`,
+            expected: []string{"a", "b", "c", "d", "e"},
+        },
+        {
+            name:     "Relative aggregate",
+            input:    `$.responses[?avg(@..maxItems) >= 100]~`,
+            expected: []string{"ok", "list"},
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            path, err := NewPath(test.input)
+            if err != nil {
+                t.Fatalf("Error parsing JSON Path: %v", err)
+            }
+            if path.String() != test.input {
+                t.Errorf("Expected %s to print as itself, got %s", test.input, path.String())
+            }
+            var actual []string
+            for _, node := range path.Query(&root) {
+                actual = append(actual, node.Value)
+            }
+            if !reflect.DeepEqual(actual, test.expected) {
+                t.Errorf("Expected:\n%v\nGot:\n%v", test.expected, actual)
+            }
+        })
+    }
+
+    for _, input := range []string{"$[?max(1, 2) > 1]", "$[?sum(@.a)]", "$[?avg(@.a, @.b) > 1]"} {
+        if _, err := NewPath(input); err == nil {
+            t.Errorf("Expected an error parsing %s", input)
+        }
+    }
+}
+
+func TestQueryStringNormalization(t *testing.T) {
+    requirePlus(t)
+    doc := `
+paths:
+  /pets: {GET: {operationId: a}, post: {operationId: b}, Get: {operationId: c}}
+tags: [{name: " Pets "}, {name: pets}, {name: 5}, {name: STORE}]
+`
+    tests := []struct {
+        name     string
+        input    string
+        expected []string
+    }{
+        {
+            name:     "Lowercase property names",
+            input:    `$.paths[*][?(lowercase(@property) == 'get')].operationId`,
+            expected: []string{"a", "c"},
+        },
+        {
+            name:     "Uppercase",
+            input:    `$.tags[?uppercase(@.name) == 'STORE'].name`,
+            expected: []string{"STORE"},
+        },
+        {
+            name:     "Trim",
+            input:    `$.tags[?trim(@.name) == 'Pets'].name`,
+            expected: []string{" Pets "},
+        },
+        {
+            name:     "Nested",
+            input:    `$.tags[?lowercase(trim(@.name)) == 'pets'].name`,
+            expected: []string{" Pets ", "pets"},
+        },
+        {
+            name:     "Literal argument",
+            input:    `$.tags[?lowercase(@.name) == lowercase('Store')].name`,
+            expected: []string{"STORE"},
+        },
+        {
+            name:     "Not a string is Nothing",
+            input:    `$.tags[?uppercase(@.name) == 5 || trim(@.missing) == ''].name`,
+            expected: nil,
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            path, err := NewPath(test.input)
+            if err != nil {
+                t.Fatalf("Error parsing JSON Path: %v", err)
+            }
+            if path.String() != test.input {
+                t.Errorf("Expected %s to print as itself, got %s", test.input, path.String())
+            }
+            var actual []string
+            for _, node := range path.Query(&root) {
+                actual = append(actual, node.Value)
+            }
+            if !reflect.DeepEqual(actual, test.expected) {
+                t.Errorf("Expected:\n%v\nGot:\n%v", test.expected, actual)
+            }
+        })
+    }
+
+    for _, input := range []string{"$[?lowercase(@.a)]", "$[?trim(@.*) == 'a']", "$[?uppercase(@.a, @.b) == 'A']"} {
+        if _, err := NewPath(input); err == nil {
+            t.Errorf("Expected an error parsing %s", input)
+        }
+    }
+}
+
+func TestQuerySplitAndJoin(t *testing.T) {
+    requirePlus(t)
+    doc := `
+items:
+  - {name: a, tags: "x,y,z", parts: [api, v1, users]}
+  - {name: b, tags: "y", parts: [api, 2]}
+  - {name: c, tags: 5, parts: [api, [v1]]}
+`
+    tests := []struct {
+        name     string
+        input    string
+        expected []string
+    }{
+        {
+            name:     "Split into an array",
+            input:    `$.items[?contains(split(@.tags, ','), 'x')].name`,
+            expected: []string{"a"},
+        },
+        {
+            name:     "Length of a split",
+            input:    `$.items[?length(split(@.tags, ',')) == 1].name`,
+            expected: []string{"b"},
+        },
+        {
+            name:     "Membership in a split",
+            input:    `$.items[?'z' in split(@.tags, ',')].name`,
+            expected: []string{"a"},
+        },
+        {
+            name:     "Join",
+            input:    `$.items[?join(@.parts, '/') == 'api/v1/users'].name`,
+            expected: []string{"a"},
+        },
+        {
+            name:     "Join numbers",
+            input:    `$.items[?join(@.parts, '/') == 'api/2'].name`,
+            expected: []string{"b"},
+        },
+        {
+            name:     "Join a split",
+            input:    `$.items[?join(split(@.tags, ','), '|') == 'x|y|z'].name`,
+            expected: []string{"a"},
+        },
+        {
+            name:     "Not a string or array is Nothing",
+            input:    `$.items[?split(@.tags, ',') == split(@.missing, ',') || join(@.parts, '/') == join(@.missing, '/')].name`,
+            expected: []string{"c"},
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            path, err := NewPath(test.input)
+            if err != nil {
+                t.Fatalf("Error parsing JSON Path: %v", err)
+            }
+            if path.String() != test.input {
+                t.Errorf("Expected %s to print as itself, got %s", test.input, path.String())
+            }
+            var actual []string
+            for _, node := range path.Query(&root) {
+                actual = append(actual, node.Value)
+            }
+            if !reflect.DeepEqual(actual, test.expected) {
+                t.Errorf("Expected:\n%v\nGot:\n%v", test.expected, actual)
+            }
+        })
+    }
+
+    for _, input := range []string{"$[?split(@.a, ',')]", "$[?join(@.a) == 'a']", "$[?split(@.*, ',') == 'a']"} {
+        if _, err := NewPath(input); err == nil {
+            t.Errorf("Expected an error parsing %s", input)
+        }
+    }
+}
+
+func TestQueryDateTime(t *testing.T) {
+    requirePlus(t)
+    doc := `
+releases:
+  - {name: a, publishedAt: "2023-12-31T23:59:59Z", day: 2023-12-31}
+  - {name: b, publishedAt: "2024-01-01T02:00:00+02:00", day: 2024-01-01}
+  - {name: c, publishedAt: 2024-03-01T10:30:00.5Z, day: "2024-3-1"}
+  - {name: d, publishedAt: "2024-06-15 08:00:00", day: 2024-06-15 21:00:00}
+  - {name: e, publishedAt: "soon", day: 42}
+`
+    tests := []struct {
+        name     string
+        input    string
+        expected []string
+    }{
+        {
+            name:     "Later than a date-time",
+            input:    `$.releases[?datetime(@.publishedAt) > datetime('2024-01-01T00:00:00Z')].name`,
+            expected: []string{"c", "d"},
+        },
+        {
+            name:     "Offsets are taken into account",
+            input:    `$.releases[?datetime(@.publishedAt) == datetime('2024-01-01T00:00:00Z')].name`,
+            expected: []string{"b"},
+        },
+        {
+            name:     "Lowercase separators",
+            input:    `$.releases[?datetime(@.publishedAt) < datetime('2023-12-31t23:59:59.5z')].name`,
+            expected: []string{"a"},
+        },
+        {
+            name:     "YAML timestamps",
+            input:    `$.releases[?datetime(@.day) >= datetime('2024-03-01')].name`,
+            expected: []string{"c", "d"},
+        },
+        {
+            name:     "Dates ignore the time of day",
+            input:    `$.releases[?date(@.day) == date(@.publishedAt)].name`,
+            expected: []string{"a", "b", "c", "d", "e"},
+        },
+        {
+            name:     "Date-times compared with each other",
+            input:    `$.releases[?datetime(@.publishedAt) > datetime(@.day)].name`,
+            expected: []string{"a", "c"},
+        },
+        {
+            name:     "Unparseable values are Nothing",
+            input:    `$.releases[?datetime(@.publishedAt) == datetime(@.missing)].name`,
+            expected: []string{"e"},
+        },
+        {
+            name:     "Nothing is never ordered",
+            input:    `$.releases[?datetime(@.publishedAt) < datetime('2025-02-30')].name`,
+            expected: nil,
+        },
+        {
+            name:     "Date-times are not strings",
+            input:    `$.releases[?datetime(@.publishedAt) == '2023-12-31T23:59:59Z'].name`,
+            expected: nil,
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            path, err := NewPath(test.input)
+            if err != nil {
+                t.Fatalf("Error parsing JSON Path: %v", err)
+            }
+            if path.String() != test.input {
+                t.Errorf("Expected %s to print as itself, got %s", test.input, path.String())
+            }
+            var actual []string
+            for _, node := range path.Query(&root) {
+                actual = append(actual, node.Value)
+            }
+            if !reflect.DeepEqual(actual, test.expected) {
+                t.Errorf("Expected:\n%v\nGot:\n%v", test.expected, actual)
+            }
+        })
+    }
+
+    for _, input := range []string{"$[?datetime(@.a)]", "$[?date(@.a, @.b) == @.c]", "$[?date(@.*) == @.c]"} {
+        if _, err := NewPath(input); err == nil {
+            t.Errorf("Expected an error parsing %s", input)
+        }
+    }
+}
+
+func TestQuerySemver(t *testing.T) {
+    requirePlus(t)
+    doc := `
+specs:
+  - {name: a, openapi: 3.0.3}
+  - {name: b, openapi: "3.1.0"}
+  - {name: c, openapi: v3.1.1}
+  - {name: d, openapi: 3.1.0-rc.2}
+  - {name: e, openapi: 3.1}
+  - {name: f, openapi: 3.10.0+build.5}
+  - {name: g, openapi: "3.01.0"}
+`
+    tests := []struct {
+        name     string
+        input    string
+        expected []string
+    }{
+        {
+            name:     "At least a version",
+            input:    `$.specs[?semver(@.openapi) >= semver('3.1.0')].name`,
+            expected: []string{"b", "c", "e", "f"},
+        },
+        {
+            name:     "Pre-releases precede the release",
+            input:    `$.specs[?semver(@.openapi) < semver('3.1.0')].name`,
+            expected: []string{"a", "d"},
+        },
+        {
+            name:     "Missing numbers are zero",
+            input:    `$.specs[?semver(@.openapi) == semver('3.1.0')].name`,
+            expected: []string{"b", "e"},
+        },
+        {
+            name:     "Build metadata is ignored",
+            input:    `$.specs[?semver(@.openapi) == semver('3.10.0')].name`,
+            expected: []string{"f"},
+        },
+        {
+            name:     "Pre-release identifiers",
+            input:    `$.specs[?semver(@.openapi) > semver('3.1.0-rc.10')].name`,
+            expected: []string{"b", "c", "e", "f"},
+        },
+        {
+            name:     "Invalid versions are Nothing",
+            input:    `$.specs[?semver(@.openapi) == semver(@.missing)].name`,
+            expected: []string{"g"},
+        },
+        {
+            name:     "Versions are not strings",
+            input:    `$.specs[?semver(@.openapi) == '3.1.0'].name`,
+            expected: nil,
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            path, err := NewPath(test.input)
+            if err != nil {
+                t.Fatalf("Error parsing JSON Path: %v", err)
+            }
+            if path.String() != test.input {
+                t.Errorf("Expected %s to print as itself, got %s", test.input, path.String())
+            }
+            var actual []string
+            for _, node := range path.Query(&root) {
+                actual = append(actual, node.Value)
+            }
+            if !reflect.DeepEqual(actual, test.expected) {
+                t.Errorf("Expected:\n%v\nGot:\n%v", test.expected, actual)
+            }
+        })
+    }
+
+    for _, input := range []string{"$[?semver(@.a)]", "$[?semver(@.a, @.b) == @.c]", "$[?semver(@.*) == @.c]"} {
+        if _, err := NewPath(input); err == nil {
+            t.Errorf("Expected an error parsing %s", input)
+        }
+    }
+}
+
+func TestQueryNegation(t *testing.T) {
+    requirePlus(t)
+    doc := `
+operations:
+  - {name: list, deprecated: true}
+  - {name: Sync, x-internal: true}
+  - {name: show}
+  - {name: v2, deprecated: false}
+`
+    tests := []struct {
+        name     string
+        input    string
+        expected []string
+        str      string
+    }{
+        {
+            name:     "Negated group",
+            input:    `$.operations[?!(@.deprecated == true || @['x-internal'] == true)].name`,
+            expected: []string{"show", "v2"},
+            str:      `$.operations[?!(@.deprecated == true || @['x-internal'] == true)].name`,
+        },
+        {
+            name:     "Negated function",
+            input:    `$.operations[?!match(@.name, '^[a-z]+$')].name`,
+            expected: []string{"Sync", "v2"},
+            str:      `$.operations[?!(match(@.name, '^[a-z]+$'))].name`,
+        },
+        {
+            name:     "Negation binds tighter than and",
+            input:    `$.operations[?!search(@.name, '[0-9]') && !(@.deprecated == true)].name`,
+            expected: []string{"Sync", "show"},
+            str:      `$.operations[?!(search(@.name, '[0-9]')) && !(@.deprecated == true)].name`,
+        },
+        {
+            name:     "Negation binds tighter than or",
+            input:    `$.operations[?!@.deprecated || @.deprecated == false].name`,
+            expected: []string{"Sync", "show", "v2"},
+            str:      `$.operations[?!(@.deprecated) || @.deprecated == false].name`,
+        },
+        {
+            name:     "Negated group in a group",
+            input:    `$.operations[?(!(@.deprecated) && @.name != 'show')].name`,
+            expected: []string{"Sync"},
+            str:      `$.operations[?(!(@.deprecated) && @.name != 'show')].name`,
+        },
+        {
+            name:     "Double negation",
+            input:    `$.operations[?!!@.deprecated].name`,
+            expected: []string{"list", "v2"},
+            str:      `$.operations[?(@.deprecated)].name`,
+        },
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            var root yaml.Node
+            if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+                t.Fatalf("Error parsing YAML: %v", err)
+            }
+            path, err := NewPath(test.input)
+            if err != nil {
+                t.Fatalf("Error parsing JSON Path: %v", err)
+            }
+            if path.String() != test.str {
+                t.Errorf("Expected %s to print as %s, got %s", test.input, test.str, path.String())
+            }
+            var actual []string
+            for _, node := range path.Query(&root) {
+                actual = append(actual, node.Value)
+            }
+            if !reflect.DeepEqual(actual, test.expected) {
+                t.Errorf("Expected:\n%v\nGot:\n%v", test.expected, actual)
+            }
+        })
+    }
+}
@@ -0,0 +1,213 @@
+// Package openapi queries OpenAPI documents held by libopenapi's low-level models, which wrap the
+// go.yaml.in/yaml/v4 nodes they were parsed from. Query takes anything with a GetRootNode method,
+// such as libopenapi's *index.SpecIndex, so results are the nodes behind the model, with the line
+// and column information the low-level model reports.
+//
+// With WithReferences, queries look through $ref: a mapping holding a $ref that the resolver
+// resolves is queried as the node it refers to, so $.paths.*.*.responses.*.content.*.schema.properties
+// finds properties of referenced schemas too. LocalReferences resolves the references within a
+// document; an index can resolve the rest:
+//
+//	nodes, err := openapi.Query(idx, `$..properties[?@.format == 'uuid']`,
+//		openapi.WithReferences(openapi.LocalReferences(idx.GetRootNode())))
+package openapi
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"go.yaml.in/yaml/v4"
+)
+
+// RootNoder is a parsed document, such as libopenapi's *index.SpecIndex.
+type RootNoder interface {
+	GetRootNode() *yaml.Node
+}
+
+// Resolver returns the node a $ref value refers to, or nil if it cannot be resolved.
+type Resolver func(ref string) *yaml.Node
+
+// Option configures a query.
+type Option func(*options)
+
+type options struct {
+	resolve     Resolver
+	pathOptions []config.Option
+}
+
+// WithReferences makes queries look through the $ref mappings that resolve resolves.
+// By default, a $ref mapping is queried as the mapping it is.
+func WithReferences(resolve Resolver) Option {
+	return func(o *options) {
+		o.resolve = resolve
+	}
+}
+
+// WithPathOptions compiles the query with opts.
+func WithPathOptions(opts ...config.Option) Option {
+	return func(o *options) {
+		o.pathOptions = append(o.pathOptions, opts...)
+	}
+}
+
+// Document adapts a root node, such as the RootNode of libopenapi's SpecInfo, to RootNoder.
+func Document(root *yaml.Node) RootNoder {
+	return document{root}
+}
+
+type document struct {
+	root *yaml.Node
+}
+
+func (d document) GetRootNode() *yaml.Node {
+	return d.root
+}
+
+// Root returns the root mapping of doc, looking through the document node wrapping it.
+func Root(doc RootNoder) *yaml.Node {
+	root := doc.GetRootNode()
+	if root != nil && root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		return root.Content[0]
+	}
+	return root
+}
+
+// Query compiles expr and evaluates it against doc. Every node returned is a node of doc, or with
+// WithReferences, a node a reference was resolved to.
+func Query(doc RootNoder, expr string, opts ...Option) ([]*yaml.Node, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	root := Root(doc)
+	if o.resolve == nil {
+		path, err := jsonpath.NewPath(expr, o.pathOptions...)
+		if err != nil {
+			return nil, err
+		}
+		return path.Query(root), nil
+	}
+
+	// $ref mappings become aliases to the nodes they refer to, in a copy of the containers of the
+	// document, which alias expansion then looks through
+	path, err := jsonpath.NewPath(expr, append(o.pathOptions, config.WithAliasExpansion())...)
+	if err != nil {
+		return nil, err
+	}
+	v := &view{resolve: o.resolve, copies: make(map[*yaml.Node]*yaml.Node), originals: make(map[*yaml.Node]*yaml.Node)}
+	nodes := path.Query(v.of(root))
+	for i, node := range nodes {
+		if original, ok := v.originals[node]; ok {
+			nodes[i] = original
+		}
+	}
+	return nodes, nil
+}
+
+// view copies the containers of a document, replacing the $ref mappings it resolves with aliases
+type view struct {
+	resolve   Resolver
+	copies    map[*yaml.Node]*yaml.Node
+	originals map[*yaml.Node]*yaml.Node
+}
+
+func (v *view) of(node *yaml.Node) *yaml.Node {
+	if node == nil || (node.Kind != yaml.MappingNode && node.Kind != yaml.SequenceNode) {
+		return node
+	}
+	if c, ok := v.copies[node]; ok {
+		return c
+	}
+	if ref, ok := reference(node); ok {
+		if target := v.resolve(ref); target != nil {
+			alias := &yaml.Node{Kind: yaml.AliasNode, Value: ref, Line: node.Line, Column: node.Column}
+			v.copies[node] = alias
+			v.originals[alias] = node
+			resolved := v.of(target)
+			// a reference to a reference refers to what that one refers to
+			for resolved.Kind == yaml.AliasNode && resolved.Alias != nil && resolved != alias {
+				resolved = resolved.Alias
+			}
+			if resolved == alias {
+				// a reference to itself is left as the mapping it is
+				resolved = node
+			}
+			alias.Alias = resolved
+			return alias
+		}
+	}
+	c := *node
+	// registered before the children are copied, so references back to node find the copy
+	v.copies[node] = &c
+	v.originals[&c] = node
+	c.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		c.Content[i] = v.of(child)
+	}
+	return &c
+}
+
+// reference returns the value of the $ref of a mapping
+func reference(node *yaml.Node) (string, bool) {
+	if node.Kind != yaml.MappingNode {
+		return "", false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "$ref" && node.Content[i+1].Kind == yaml.ScalarNode {
+			return node.Content[i+1].Value, true
+		}
+	}
+	return "", false
+}
+
+// LocalReferences resolves references to the document rooted at root, such as
+// #/components/schemas/Pet, following the JSON pointer in their fragment.
+func LocalReferences(root *yaml.Node) Resolver {
+	if root != nil && root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		root = root.Content[0]
+	}
+	return func(ref string) *yaml.Node {
+		pointer, ok := strings.CutPrefix(ref, "#")
+		if !ok {
+			return nil
+		}
+		if unescaped, err := url.PathUnescape(pointer); err == nil {
+			pointer = unescaped
+		}
+		if pointer == "" {
+			return root
+		}
+		if !strings.HasPrefix(pointer, "/") {
+			return nil
+		}
+		node := root
+		for _, token := range strings.Split(pointer[1:], "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			node = child(node, token)
+			if node == nil {
+				return nil
+			}
+		}
+		return node
+	}
+}
+
+// child returns the member of a mapping or element of a sequence named by a JSON pointer token
+func child(node *yaml.Node, token string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == token {
+				return node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package openapi_test
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"github.com/pb33f/jsonpath/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const petstore = `openapi: 3.1.0
+paths:
+  /pets:
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Pets'}
+components:
+  schemas:
+    Pets:
+      type: array
+      items: {$ref: '#/components/schemas/Pet'}
+    Pet:
+      properties:
+        id: {type: string, format: uuid}
+        owner: {$ref: '#/components/schemas/Owner'}
+        parent: {$ref: '#/components/schemas/Pet'}
+    Owner:
+      properties:
+        id: {type: string, format: uuid}
+    Alias: {$ref: '#/components/schemas/Owner'}
+    AliasOfAlias: {$ref: '#/components/schemas/Alias'}
+    Self: {$ref: '#/components/schemas/Self'}
+    Broken: {$ref: 'other.yaml#/Pet'}
+`
+
+func TestQuery(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(petstore), &root))
+	doc := openapi.Document(&root)
+	assert.Equal(t, yaml.MappingNode, openapi.Root(doc).Kind)
+
+	// without references, a $ref mapping is just a mapping
+	nodes, err := openapi.Query(doc, `$.paths.*.get.responses.*.content.*.schema.items`)
+	require.NoError(t, err)
+	assert.Empty(t, nodes)
+
+	refs := openapi.WithReferences(openapi.LocalReferences(&root))
+	nodes, err = openapi.Query(doc, `$.paths.*.get.responses.*.content.*.schema.items.properties.owner.properties.id.format`, refs)
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, "uuid", nodes[0].Value)
+	// results are the nodes of the document, with their positions
+	assert.Equal(t, 22, nodes[0].Line)
+
+	// a reference is queried as the node it refers to, so Alias and AliasOfAlias match as Owner
+	nodes, err = openapi.Query(doc, `$.components.schemas[?@.properties.id]`, refs)
+	require.NoError(t, err)
+	var lines []int
+	for _, node := range nodes {
+		lines = append(lines, node.Line)
+	}
+	assert.Equal(t, []int{16, 21, 21, 21}, lines)
+
+	nodes, err = openapi.Query(doc, `$.components.schemas.Self['$ref']`, refs)
+	require.NoError(t, err)
+	assert.Len(t, nodes, 1)
+
+	// descendants terminate on cyclic references
+	nodes, err = openapi.Query(doc, `$.components.schemas.Pet..format`, refs)
+	require.NoError(t, err)
+	assert.NotEmpty(t, nodes)
+
+	nodes, err = openapi.Query(doc, `$.components.schemas.Broken['$ref']`, refs)
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, "other.yaml#/Pet", nodes[0].Value)
+
+	nodes, err = openapi.Query(doc, `$.components.schemas.Pet.properties.*`, refs,
+		openapi.WithPathOptions(config.WithDeduplicatedResults()))
+	require.NoError(t, err)
+	assert.Len(t, nodes, 3)
+
+	_, err = openapi.Query(doc, `$.paths[`)
+	assert.Error(t, err)
+}
+
+func TestLocalReferences(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+paths:
+  /pets/{id}: {get: {tags: [a, b]}}
+a~b: 1
+`), &root))
+	resolve := openapi.LocalReferences(&root)
+
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		{"#/paths/~1pets~1%7Bid%7D/get/tags/1", "b"},
+		{"#/a~0b", "1"},
+		{"#/paths/missing", ""},
+		{"#/paths/~1pets~1{id}/get/tags/9", ""},
+		{"other.yaml#/a", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.ref, func(t *testing.T) {
+			node := resolve(test.ref)
+			if test.expected == "" {
+				assert.Nil(t, node)
+				return
+			}
+			require.NotNil(t, node)
+			assert.Equal(t, test.expected, node.Value)
+		})
+	}
+	assert.Equal(t, root.Content[0], resolve("#"))
+}
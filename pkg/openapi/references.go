@@ -0,0 +1,126 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"go.yaml.in/yaml/v4"
+)
+
+// FindReferences returns the $ref mappings of doc that refer to target or to a node within it, each
+// with its normalized path and position, in document order. target locates a node of doc, such as a
+// component, as a JSON pointer like #/components/schemas/Pet or a query selecting one node like
+// $.components.schemas.Pet. References into the target, such as #/components/schemas/Pet/properties/id,
+// are found too, as renaming or moving it breaks them as well.
+//
+// References are resolved with resolve, or LocalReferences if it is nil, once for each distinct
+// $ref value. Only the references written in doc are found: a reference to a reference to target
+// refers to the intermediate node, not to target.
+func FindReferences(doc RootNoder, target string, resolve Resolver) ([]jsonpath.Result, error) {
+	root := Root(doc)
+	if resolve == nil {
+		resolve = LocalReferences(root)
+	}
+	node, err := locate(root, target)
+	if err != nil {
+		return nil, err
+	}
+	within := make(map[*yaml.Node]bool)
+	mark(within, node)
+
+	f := &referenceFinder{resolve: resolve, within: within, resolved: make(map[string]bool), inside: make(map[*yaml.Node]bool)}
+	f.find(root)
+	return f.results, nil
+}
+
+// locate returns the node target locates in the document rooted at root
+func locate(root *yaml.Node, target string) (*yaml.Node, error) {
+	if strings.HasPrefix(target, "$") {
+		path, err := jsonpath.NewPath(target)
+		if err != nil {
+			return nil, err
+		}
+		nodes := path.Query(root)
+		if len(nodes) != 1 {
+			return nil, fmt.Errorf("target %s selects %d nodes, not one", target, len(nodes))
+		}
+		return nodes[0], nil
+	}
+	if strings.HasPrefix(target, "/") {
+		target = "#" + target
+	}
+	if !strings.HasPrefix(target, "#") {
+		return nil, errors.New("target must be a JSON pointer or a query")
+	}
+	node := LocalReferences(root)(target)
+	if node == nil {
+		return nil, fmt.Errorf("target %s is not in the document", target)
+	}
+	return node, nil
+}
+
+// mark records node and every node below it
+func mark(within map[*yaml.Node]bool, node *yaml.Node) {
+	if node == nil || within[node] {
+		return
+	}
+	within[node] = true
+	for _, child := range node.Content {
+		mark(within, child)
+	}
+}
+
+// referenceFinder scans a document for the $ref mappings that refer into a set of nodes. Only the
+// keys of mappings are compared while scanning, and paths are only built for the references found.
+type referenceFinder struct {
+	resolve  Resolver
+	within   map[*yaml.Node]bool
+	resolved map[string]bool // whether each $ref value seen refers into the set
+	inside   map[*yaml.Node]bool
+	segments []string
+	results  []jsonpath.Result
+}
+
+func (f *referenceFinder) find(node *yaml.Node) {
+	if node == nil || f.inside[node] {
+		return
+	}
+	f.inside[node] = true
+	defer delete(f.inside, node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		if ref, ok := reference(node); ok && f.refersWithin(ref) {
+			f.results = append(f.results, jsonpath.Result{
+				Node:   node,
+				Path:   "$" + strings.Join(f.segments, ""),
+				Line:   node.Line,
+				Column: node.Column,
+			})
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i] == nil {
+				continue
+			}
+			f.segments = append(f.segments, jsonpath.NormalizeKeySegment(node.Content[i].Value))
+			f.find(node.Content[i+1])
+			f.segments = f.segments[:len(f.segments)-1]
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			f.segments = append(f.segments, fmt.Sprintf("[%d]", i))
+			f.find(child)
+			f.segments = f.segments[:len(f.segments)-1]
+		}
+	}
+}
+
+func (f *referenceFinder) refersWithin(ref string) bool {
+	within, ok := f.resolved[ref]
+	if !ok {
+		within = f.within[f.resolve(ref)]
+		f.resolved[ref] = within
+	}
+	return within
+}
@@ -0,0 +1,70 @@
+package openapi_test
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestFindReferences(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(petstore), &root))
+	doc := openapi.Document(&root)
+
+	paths := func(target string) []string {
+		results, err := openapi.FindReferences(doc, target, nil)
+		require.NoError(t, err)
+		var out []string
+		for _, result := range results {
+			out = append(out, result.Path)
+		}
+		return out
+	}
+
+	pet := []string{"$['components']['schemas']['Pets']['items']", "$['components']['schemas']['Pet']['properties']['parent']"}
+	assert.Equal(t, pet, paths("#/components/schemas/Pet"))
+	assert.Equal(t, pet, paths("/components/schemas/Pet"))
+	assert.Equal(t, pet, paths("$.components.schemas.Pet"))
+
+	// references into a component refer to it too, while references to references do not
+	assert.Equal(t, []string{
+		"$['components']['schemas']['Pet']['properties']['owner']",
+		"$['components']['schemas']['Alias']",
+	}, paths("#/components/schemas/Owner"))
+	assert.Equal(t, []string{
+		"$['paths']['/pets']['get']['responses']['200']['content']['application/json']['schema']",
+		"$['components']['schemas']['Pets']['items']",
+		"$['components']['schemas']['Pet']['properties']['owner']",
+		"$['components']['schemas']['Pet']['properties']['parent']",
+		"$['components']['schemas']['Alias']",
+		"$['components']['schemas']['AliasOfAlias']",
+		"$['components']['schemas']['Self']",
+	}, paths("#/components"))
+	assert.Empty(t, paths("#/paths"))
+
+	results, err := openapi.FindReferences(doc, "#/components/schemas/Pets", nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, 9, results[0].Line)
+	assert.Equal(t, "$ref", results[0].Node.Content[0].Value)
+
+	// a resolver decides what each reference refers to
+	owner := openapi.LocalReferences(&root)("#/components/schemas/Owner")
+	results, err = openapi.FindReferences(doc, "#/components/schemas/Owner", func(ref string) *yaml.Node {
+		if ref == "other.yaml#/Pet" {
+			return owner
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "$['components']['schemas']['Broken']", results[0].Path)
+
+	for _, target := range []string{"#/components/schemas/Missing", "$.components.schemas.*", "$.paths[", "components"} {
+		_, err := openapi.FindReferences(doc, target, nil)
+		assert.Error(t, err, target)
+	}
+}
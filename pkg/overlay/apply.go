@@ -1,21 +1,44 @@
 package overlay
 
 import (
+    "fmt"
     "github.com/pb33f/jsonpath/pkg/jsonpath"
     "github.com/pb33f/jsonpath/pkg/jsonpath/config"
     "go.yaml.in/yaml/v4"
+    "slices"
 )
 
 // ApplyTo will take an overlay and apply its changes to the given YAML
 // document.
-func (o *Overlay) ApplyTo(root *yaml.Node) error {
-    for _, action := range o.Actions {
+func (o *Overlay) ApplyTo(root *yaml.Node, opts ...ApplyOption) error {
+    return o.apply(root, nil, newApplyConfig(opts))
+}
+
+// apply applies the overlay, recording the origin of every node it adds or changes in origins
+// unless origins is nil.
+func (o *Overlay) apply(root *yaml.Node, origins map[*yaml.Node]Origin, cfg applyConfig) error {
+    targets := newTargetIndex(root)
+    targets.cow = cfg.copyOnWrite
+    vars := cfg.variables(o)
+    for i, action := range o.Actions {
+        var matched int
         var err error
-        if action.Remove {
-            err = applyRemoveAction(root, action)
-        } else {
-            err = applyUpdateAction(root, action)
+        var audited []auditedNode
+        action, err = cfg.prepareAction(action, vars)
+        if err == nil && cfg.audit != nil {
+            audited, err = cfg.audit.begin(targets, i, action)
+        }
+        if err == nil {
+            if action.Remove {
+                matched, err = applyRemoveAction(targets, action)
+            } else {
+                matched, err = applyUpdateAction(targets, action, &merger{action: i, origins: origins, targets: targets})
+            }
         }
+        if err == nil && cfg.audit != nil {
+            cfg.audit.complete(audited)
+        }
+        cfg.logAction(i, action, matched, err)
 
         if err != nil {
             return err
@@ -25,34 +48,110 @@ func (o *Overlay) ApplyTo(root *yaml.Node) error {
     return nil
 }
 
-func applyRemoveAction(root *yaml.Node, action Action) error {
-    if action.Target == "" {
-        return nil
+// targetIndex resolves action targets while an overlay is applied. Targets sharing a prefix such
+// as $.paths.*.* reuse its results, and parents are indexed once rather than once per action.
+type targetIndex struct {
+    root    *yaml.Node
+    queries *jsonpath.QueryCache
+    parents parentIndex
+    // cow is set when the overlay is applied to a copy of the document by ApplyToCopy
+    cow *copyOnWrite
+}
+
+func newTargetIndex(root *yaml.Node) *targetIndex {
+    return &targetIndex{
+        root:    root,
+        queries: jsonpath.NewQueryCache(root),
+        parents: newParentIndex(root),
+    }
+}
+
+func (t *targetIndex) query(target string) ([]*yaml.Node, error) {
+    p, err := jsonpath.NewPath(target, config.WithPropertyNameExtension())
+    if err != nil {
+        return nil, err
     }
+    nodes := t.queries.Query(p)
+    if t.cow != nil {
+        // cached results may hold nodes of the input that have been copied since
+        nodes = slices.Clone(nodes)
+        for i, node := range nodes {
+            nodes[i] = t.current(node)
+        }
+    }
+    return nodes, nil
+}
 
-    idx := newParentIndex(root)
+// updated records that node has been merged into, indexing any nodes that were added below it.
+func (t *targetIndex) updated(node *yaml.Node) {
+    t.invalidate(node)
+    t.parents.indexNodeRecursively(node)
+}
 
-    p, err := jsonpath.NewPath(action.Target, config.WithPropertyNameExtension())
+// removed records that a node has been removed from parent.
+func (t *targetIndex) removed(parent *yaml.Node) {
+    t.invalidate(parent)
+}
+
+// ApplyToSubtree applies the overlay to the subtree rooted at at, a node inside the document rooted
+// at root. Targets are evaluated with $ bound to at, so an overlay authored against a fragment (such
+// as a single component) can be reused wherever that fragment appears.
+func (o *Overlay) ApplyToSubtree(root *yaml.Node, at *yaml.Node, opts ...ApplyOption) error {
+    if at != root {
+        if _, ok := newParentIndex(root)[at]; !ok {
+            return fmt.Errorf("subtree is not part of the document")
+        }
+    }
+    return o.ApplyTo(at, opts...)
+}
+
+// ApplyAt applies the overlay to every node matched by base in the document rooted at root, with
+// $ bound to each match in turn. It is an error if base matches nothing.
+func (o *Overlay) ApplyAt(root *yaml.Node, base string, opts ...ApplyOption) error {
+    p, err := jsonpath.NewPath(base, config.WithPropertyNameExtension())
     if err != nil {
-        return err
+        return fmt.Errorf("invalid base path %q: %w", base, err)
     }
 
     nodes := p.Query(root)
+    if len(nodes) == 0 {
+        return fmt.Errorf("base path %q matched no nodes", base)
+    }
+
+    for _, node := range nodes {
+        if err := o.ApplyTo(node, opts...); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// applyRemoveAction removes the targets of action, returning how many nodes the target matched.
+func applyRemoveAction(targets *targetIndex, action Action) (int, error) {
+    if action.Target == "" {
+        return 0, nil
+    }
+
+    nodes, err := targets.query(action.Target)
     if err != nil {
-        return err
+        return 0, err
     }
 
     for _, node := range nodes {
-        removeNode(idx, node)
+        targets.writable(targets.parents.getParent(node))
+        if parent := removeNode(targets.parents, node); parent != nil {
+            targets.removed(parent)
+        }
     }
 
-    return nil
+    return len(nodes), nil
 }
 
-func removeNode(idx parentIndex, node *yaml.Node) {
+// removeNode removes node from its parent, returning the parent it was removed from (nil if it had none).
+func removeNode(idx parentIndex, node *yaml.Node) *yaml.Node {
     parent := idx.getParent(node)
     if parent == nil {
-        return
+        return nil
     }
 
     for i, child := range parent.Content {
@@ -66,63 +165,83 @@ func removeNode(idx parentIndex, node *yaml.Node) {
                     // if we select a key, we should delete the value
                     parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
                 }
-                return
+                return parent
             case yaml.SequenceNode:
                 parent.Content = append(parent.Content[:i], parent.Content[i+1:]...)
-                return
+                return parent
             }
         }
     }
+    return nil
 }
 
-func applyUpdateAction(root *yaml.Node, action Action) error {
+// applyUpdateAction merges the update of action into its targets, returning how many nodes the
+// target matched.
+func applyUpdateAction(targets *targetIndex, action Action, m *merger) (int, error) {
     if action.Target == "" {
-        return nil
+        return 0, nil
     }
 
     if action.Update.IsZero() {
-        return nil
+        return 0, nil
     }
 
-    p, err := jsonpath.NewPath(action.Target, config.WithPropertyNameExtension())
+    nodes, err := targets.query(action.Target)
     if err != nil {
-        return err
+        return 0, err
     }
 
-    nodes := p.Query(root)
-
     for _, node := range nodes {
-        if err := updateNode(node, &action.Update); err != nil {
-            return err
+        node = targets.writable(node)
+        if err := m.updateNode(node, &action.Update); err != nil {
+            return 0, err
         }
+        targets.updated(node)
     }
 
-    return nil
+    return len(nodes), nil
+}
+
+// merger merges the update of an action into its targets, recording where the nodes it adds or
+// changes came from if origins is set.
+type merger struct {
+    action  int
+    origins map[*yaml.Node]Origin
+    targets *targetIndex
+}
+
+// record notes that node now holds content from src, a node in the update of the action
+func (m *merger) record(node *yaml.Node, src *yaml.Node) {
+    if m.origins != nil {
+        m.origins[node] = Origin{Action: m.action, Line: src.Line, Column: src.Column}
+    }
 }
 
-func updateNode(node *yaml.Node, updateNode *yaml.Node) error {
-    mergeNode(node, updateNode)
+func (m *merger) updateNode(node *yaml.Node, updateNode *yaml.Node) error {
+    m.mergeNode(node, updateNode)
     return nil
 }
 
-func mergeNode(node *yaml.Node, merge *yaml.Node) {
+func (m *merger) mergeNode(node *yaml.Node, merge *yaml.Node) {
     if node.Kind != merge.Kind {
-        *node = *clone(merge)
+        *node = *m.clone(merge)
+        m.record(node, merge)
         return
     }
     switch node.Kind {
     default:
         node.Value = merge.Value
+        m.record(node, merge)
     case yaml.MappingNode:
-        mergeMappingNode(node, merge)
+        m.mergeMappingNode(node, merge)
     case yaml.SequenceNode:
-        mergeSequenceNode(node, merge)
+        m.mergeSequenceNode(node, merge)
     }
 }
 
 // mergeMappingNode will perform a shallow merge of the merge node into the main
 // node.
-func mergeMappingNode(node *yaml.Node, merge *yaml.Node) {
+func (m *merger) mergeMappingNode(node *yaml.Node, merge *yaml.Node) {
 NextKey:
     for i := 0; i < len(merge.Content); i += 2 {
         mergeKey := merge.Content[i].Value
@@ -131,21 +250,22 @@ NextKey:
         for j := 0; j < len(node.Content); j += 2 {
             nodeKey := node.Content[j].Value
             if nodeKey == mergeKey {
-                mergeNode(node.Content[j+1], mergeValue)
+                m.mergeNode(m.targets.writable(node.Content[j+1]), mergeValue)
                 continue NextKey
             }
         }
 
-        node.Content = append(node.Content, merge.Content[i], clone(mergeValue))
+        m.record(merge.Content[i], merge.Content[i])
+        node.Content = append(node.Content, merge.Content[i], m.clone(mergeValue))
     }
 }
 
 // mergeSequenceNode will append the merge node's content to the original node.
-func mergeSequenceNode(node *yaml.Node, merge *yaml.Node) {
-    node.Content = append(node.Content, clone(merge).Content...)
+func (m *merger) mergeSequenceNode(node *yaml.Node, merge *yaml.Node) {
+    node.Content = append(node.Content, m.clone(merge).Content...)
 }
 
-func clone(node *yaml.Node) *yaml.Node {
+func (m *merger) clone(node *yaml.Node) *yaml.Node {
     newNode := &yaml.Node{
         Kind:        node.Kind,
         Style:       node.Style,
@@ -157,13 +277,14 @@ func clone(node *yaml.Node) *yaml.Node {
         FootComment: node.FootComment,
     }
     if node.Alias != nil {
-        newNode.Alias = clone(node.Alias)
+        newNode.Alias = m.clone(node.Alias)
     }
     if node.Content != nil {
         newNode.Content = make([]*yaml.Node, len(node.Content))
         for i, child := range node.Content {
-            newNode.Content[i] = clone(child)
+            newNode.Content[i] = m.clone(child)
         }
     }
+    m.record(newNode, node)
     return newNode
 }
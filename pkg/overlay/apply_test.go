@@ -2,12 +2,16 @@ package overlay_test
 
 import (
     "bytes"
+    "github.com/pb33f/jsonpath/pkg/jsonpath"
+    "github.com/pb33f/jsonpath/pkg/overlay"
     "github.com/stretchr/testify/assert"
     "github.com/stretchr/testify/require"
     "go.yaml.in/yaml/v4"
+    "log/slog"
     "os"
     "strings"
     "testing"
+    "testing/fstest"
 )
 
 // NodeMatchesFile is a test that marshals the YAML file from the given node,
@@ -45,10 +49,10 @@ func NodeMatchesFile(
 func TestApplyTo(t *testing.T) {
     t.Parallel()
 
-    node, err := LoadSpecification("testdata/openapi.yaml")
+    node, err := overlay.LoadSpecification("testdata/openapi.yaml")
     require.NoError(t, err)
 
-    o, err := LoadOverlay("testdata/overlay.yaml")
+    o, err := overlay.LoadOverlay("testdata/overlay.yaml")
     require.NoError(t, err)
 
     err = o.ApplyTo(node)
@@ -56,3 +60,214 @@ func TestApplyTo(t *testing.T) {
 
     NodeMatchesFile(t, node, "testdata/openapi-overlayed.yaml")
 }
+
+func TestApplyToSubtree(t *testing.T) {
+    t.Parallel()
+
+    var root yaml.Node
+    require.NoError(t, yaml.Unmarshal([]byte(`
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        id: {type: integer}
+        password: {type: string}
+    Admin:
+      type: object
+      properties:
+        password: {type: string}
+`), &root))
+
+    fragment, err := overlay.LoadOverlayBytes([]byte(`
+overlay: 1.0.0
+info: {title: redact passwords, version: 1.0.0}
+actions:
+  - target: $.properties.password
+    remove: true
+  - target: $
+    update:
+      x-redacted: true
+`))
+    require.NoError(t, err)
+
+    user := root.Content[0].Content[1].Content[1].Content[1]
+    require.NoError(t, fragment.ApplyToSubtree(&root, user))
+
+    var detached yaml.Node
+    require.NoError(t, yaml.Unmarshal([]byte(`type: object`), &detached))
+    assert.Error(t, fragment.ApplyToSubtree(&root, &detached))
+
+    out, err := yaml.Marshal(&root)
+    require.NoError(t, err)
+    assert.Equal(t, `components:
+    schemas:
+        User:
+            type: object
+            properties:
+                id: {type: integer}
+            x-redacted: true
+        Admin:
+            type: object
+            properties:
+                password: {type: string}
+`, string(out))
+}
+
+func TestApplyAt(t *testing.T) {
+    t.Parallel()
+
+    var root yaml.Node
+    require.NoError(t, yaml.Unmarshal([]byte(`
+components:
+  schemas:
+    User:
+      properties:
+        password: {type: string}
+    Admin:
+      properties:
+        password: {type: string}
+`), &root))
+
+    fragment, err := overlay.LoadOverlayBytes([]byte(`
+overlay: 1.0.0
+info: {title: redact passwords, version: 1.0.0}
+actions:
+  - target: $.properties.password
+    update:
+      writeOnly: true
+`))
+    require.NoError(t, err)
+
+    require.NoError(t, fragment.ApplyAt(&root, `$.components.schemas.*`))
+    writeOnly, err := jsonpath.NewPath(`$..password.writeOnly`)
+    require.NoError(t, err)
+    assert.Len(t, writeOnly.Query(&root), 2)
+
+    assert.Error(t, fragment.ApplyAt(&root, `$.components.responses.*`))
+    assert.Error(t, fragment.ApplyAt(&root, `$.components[`))
+}
+
+func TestApplyTo_SharedTargetPrefixes(t *testing.T) {
+    t.Parallel()
+
+    var root yaml.Node
+    require.NoError(t, yaml.Unmarshal([]byte(`
+paths:
+  /users:
+    get: {summary: List users}
+  /internal:
+    get: {summary: Internal}
+`), &root))
+
+    // later actions must see the paths added and removed by earlier ones
+    o, err := overlay.LoadOverlayBytes([]byte(`
+overlay: 1.0.0
+info: {title: shared prefixes, version: 1.0.0}
+actions:
+  - target: $.paths.*.get
+    update: {x-seen: 1}
+  - target: $.paths
+    update:
+      /orders:
+        get: {summary: List orders}
+  - target: $.paths['/internal']
+    remove: true
+  - target: $.paths.*.get
+    update: {x-seen: 2}
+  - target: $.paths.*.get.summary~
+    remove: true
+`))
+    require.NoError(t, err)
+    require.NoError(t, o.ApplyTo(&root))
+
+    out, err := yaml.Marshal(&root)
+    require.NoError(t, err)
+    assert.Equal(t, `paths:
+    /users:
+        get: {x-seen: 2}
+    /orders:
+        get: {x-seen: 2}
+`, string(out))
+}
+
+func TestApplyTo_Logger(t *testing.T) {
+    t.Parallel()
+
+    var root yaml.Node
+    require.NoError(t, yaml.Unmarshal([]byte(`
+paths:
+  /users: {get: {summary: list}, post: {summary: create}}
+  /orders: {get: {summary: list}}
+`), &root))
+
+    o, err := overlay.LoadOverlayBytes([]byte(`
+overlay: 1.0.0
+info: {title: logged, version: 1.0.0}
+actions:
+  - target: $.paths.*.get
+    update: {x-public: true}
+  - target: $.paths['/users'].post
+    remove: true
+  - target: $.paths[
+    remove: true
+`))
+    require.NoError(t, err)
+
+    var logs bytes.Buffer
+    logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+    require.Error(t, o.ApplyTo(&root, overlay.WithLogger(logger)))
+
+    lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+    require.Len(t, lines, 3)
+    assert.Contains(t, lines[0], `msg="overlay: action applied" action=0 target=$.paths.*.get kind=update matched=2`)
+    assert.Contains(t, lines[1], `msg="overlay: action applied" action=1 target=$.paths['/users'].post kind=remove matched=1`)
+    assert.Contains(t, lines[2], `msg="overlay: action failed" action=2 target=$.paths[ kind=remove error=`)
+}
+
+func TestApplyTo_UpdateFiles(t *testing.T) {
+    t.Parallel()
+
+    var root yaml.Node
+    require.NoError(t, yaml.Unmarshal([]byte(`
+components:
+  schemas:
+    User: {type: object}
+`), &root))
+
+    o, err := overlay.LoadOverlayBytes([]byte(`
+overlay: 1.0.0
+info: {title: shared components, version: 1.0.0}
+actions:
+  - target: $.components.schemas
+    x-update-file: shared/errors.yaml
+  - target: $.components.schemas.User
+    x-update-file: user.json
+`))
+    require.NoError(t, err)
+    require.Len(t, o.Actions, 2)
+    assert.Equal(t, "shared/errors.yaml", o.Actions[0].UpdateFile)
+    assert.Empty(t, o.Actions[0].Extensions)
+    require.NoError(t, o.Validate())
+
+    files := fstest.MapFS{
+        "shared/errors.yaml": {Data: []byte("Error:\n  type: object\n  properties:\n    message: {type: string}\n")},
+        "user.json":          {Data: []byte(`{"description": "A user"}`)},
+    }
+    assert.Error(t, o.ApplyTo(&root), "update files need a file system")
+    require.NoError(t, o.ApplyTo(&root, overlay.WithUpdateFiles(files)))
+
+    out, err := yaml.Marshal(&root)
+    require.NoError(t, err)
+    assert.Equal(t, `components:
+    schemas:
+        User: {type: object, "description": "A user"}
+        Error:
+            type: object
+            properties:
+                message: {type: string}
+`, string(out))
+
+    o.Actions[1].UpdateFile = "missing.yaml"
+    assert.Error(t, o.ApplyTo(&root, overlay.WithUpdateFiles(files)))
+}
@@ -0,0 +1,109 @@
+package overlay
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strconv"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"go.yaml.in/yaml/v4"
+)
+
+// AuditRecord describes a change an action made to one node of a document.
+type AuditRecord struct {
+	// Action is the index of the action that made the change.
+	Action int
+	// Operation is "update" or "remove".
+	Operation string
+	// Path is the normalized path of the node when the action was applied.
+	Path string
+	// OldHash is the hash of the node before the change.
+	OldHash string
+	// NewHash is the hash of the node after an update, or "" for a removal.
+	NewHash string
+}
+
+// ApplyWithAuditLog applies the overlay to the document rooted at root like ApplyTo, and returns a
+// record of every node each action updated or removed, in the order the changes were made. Hashes
+// are the hex SHA-256 of the content of a node and the nodes below it, so comments and formatting do
+// not change them. If an action fails, the records of the actions applied before it are returned
+// with the error, as the document has already been changed by them.
+func (o *Overlay) ApplyWithAuditLog(root *yaml.Node, opts ...ApplyOption) ([]AuditRecord, error) {
+	cfg := newApplyConfig(opts)
+	cfg.audit = &auditLog{}
+	err := o.apply(root, nil, cfg)
+	return cfg.audit.records, err
+}
+
+// auditLog collects the records of an overlay being applied
+type auditLog struct {
+	records []AuditRecord
+}
+
+// auditedNode is a node an action is about to change, with the record that change will complete
+type auditedNode struct {
+	node   *yaml.Node
+	record AuditRecord
+}
+
+// begin returns the nodes action is about to change, with their paths and hashes before the change
+func (l *auditLog) begin(targets *targetIndex, i int, action Action) ([]auditedNode, error) {
+	if action.Target == "" || (!action.Remove && action.Update.IsZero()) {
+		return nil, nil
+	}
+	nodes, err := targets.query(action.Target)
+	if err != nil || len(nodes) == 0 {
+		return nil, err
+	}
+	paths := jsonpath.NormalizedPaths(targets.root)
+	audited := make([]auditedNode, len(nodes))
+	for j, node := range nodes {
+		audited[j] = auditedNode{node: node, record: AuditRecord{
+			Action:    i,
+			Operation: verb(action),
+			Path:      paths[node],
+			OldHash:   valueHash(node),
+		}}
+	}
+	return audited, nil
+}
+
+// complete records the changes to the nodes begin returned, now that the action has been applied
+func (l *auditLog) complete(audited []auditedNode) {
+	for _, a := range audited {
+		if a.record.Operation == "update" {
+			a.record.NewHash = valueHash(a.node)
+		}
+		l.records = append(l.records, a.record)
+	}
+}
+
+// valueHash returns the hex SHA-256 of the kinds, tags and values of node and the nodes below it
+func valueHash(node *yaml.Node) string {
+	h := sha256.New()
+	writeValue(h, node)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeValue(w io.Writer, node *yaml.Node) {
+	if node == nil {
+		_, _ = w.Write([]byte{0})
+		return
+	}
+	_, _ = io.WriteString(w, strconv.Itoa(int(node.Kind)))
+	_, _ = io.WriteString(w, node.Tag)
+	_, _ = w.Write([]byte{0})
+	if node.Kind == yaml.AliasNode {
+		// an alias stands for the anchor it refers to, which also keeps cycles finite
+		_, _ = io.WriteString(w, "*"+node.Value)
+		_, _ = w.Write([]byte{0})
+		return
+	}
+	_, _ = io.WriteString(w, node.Value)
+	_, _ = w.Write([]byte{0})
+	_, _ = io.WriteString(w, strconv.Itoa(len(node.Content)))
+	for _, child := range node.Content {
+		writeValue(w, child)
+	}
+}
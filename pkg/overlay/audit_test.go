@@ -0,0 +1,82 @@
+package overlay_test
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/overlay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestApplyWithAuditLog(t *testing.T) {
+	t.Parallel()
+
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+info: {title: API}
+tags: [{name: a}, {name: b}, {name: c}]
+paths:
+  /users: {get: {}}
+`), &root))
+	o := mustLoadOverlay(t, `
+overlay: 1.0.0
+info: {title: audited, version: 1.0.0}
+actions:
+  - target: $.tags[0]
+    remove: true
+  - target: $.tags[?@.name == 'c']
+    update: {x-internal: true}
+  - target: $.paths.*.get
+    update: {summary: List users}
+  - target: $.servers
+    remove: true
+`)
+
+	records, err := o.ApplyWithAuditLog(&root)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	// the removal of tags[0] has moved c to index 1 by the time the second action is applied
+	assert.Equal(t, overlay.AuditRecord{Action: 0, Operation: "remove", Path: "$['tags'][0]", OldHash: records[0].OldHash}, records[0])
+	assert.Equal(t, 1, records[1].Action)
+	assert.Equal(t, "update", records[1].Operation)
+	assert.Equal(t, "$['tags'][1]", records[1].Path)
+	assert.Equal(t, "$['paths']['/users']['get']", records[2].Path)
+	for _, record := range records {
+		assert.Len(t, record.OldHash, 64)
+	}
+	assert.NotEqual(t, records[1].OldHash, records[1].NewHash)
+	assert.Len(t, records[2].NewHash, 64)
+
+	// hashes cover content, not formatting
+	var reformatted yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("tags:\n  - name: c # comment\n"), &reformatted))
+	again := mustLoadOverlay(t, `
+overlay: 1.0.0
+info: {title: audited, version: 1.0.0}
+actions:
+  - target: $.tags[0]
+    update: {x-internal: true}
+`)
+	records2, err := again.ApplyWithAuditLog(&reformatted)
+	require.NoError(t, err)
+	require.Len(t, records2, 1)
+	assert.Equal(t, records[1].OldHash, records2[0].OldHash)
+	assert.Equal(t, records[1].NewHash, records2[0].NewHash)
+
+	// the records of the actions applied before a failure are returned with the error
+	failing := mustLoadOverlay(t, `
+overlay: 1.0.0
+info: {title: failing, version: 1.0.0}
+actions:
+  - target: $.info
+    update: {version: 1.0.0}
+  - target: $.info[
+    remove: true
+`)
+	records, err = failing.ApplyWithAuditLog(&root)
+	assert.Error(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "$['info']", records[0].Path)
+}
@@ -6,6 +6,7 @@ import (
     "log"
     "strings"
 
+    "github.com/pb33f/jsonpath/pkg/jsonpath"
     "go.yaml.in/yaml/v4"
 )
 
@@ -48,7 +49,7 @@ func keyPart(key string) simplePart {
 
 func (p simplePart) String() string {
     if p.isKey {
-        return fmt.Sprintf("[%q]", p.key)
+        return jsonpath.NormalizeKeySegment(p.key)
     }
     return fmt.Sprintf("[%d]", p.index)
 }
@@ -1,49 +1,22 @@
 package overlay_test
 
 import (
-    "fmt"
     "github.com/pb33f/jsonpath/pkg/overlay"
     "github.com/stretchr/testify/assert"
     "github.com/stretchr/testify/require"
-    "go.yaml.in/yaml/v4"
-    "os"
     "strings"
     "testing"
 )
 
-func LoadSpecification(path string) (*yaml.Node, error) {
-    rs, err := os.Open(path)
-    if err != nil {
-        return nil, fmt.Errorf("failed to open schema from path %q: %w", path, err)
-    }
-
-    var ys yaml.Node
-    err = yaml.NewDecoder(rs).Decode(&ys)
-    if err != nil {
-        return nil, fmt.Errorf("failed to parse schema at path %q: %w", path, err)
-    }
-
-    return &ys, nil
-}
-
-func LoadOverlay(path string) (*overlay.Overlay, error) {
-    o, err := overlay.Parse(path)
-    if err != nil {
-        return nil, fmt.Errorf("failed to parse overlay from path %q: %w", path, err)
-    }
-
-    return o, nil
-}
-
 func TestCompare(t *testing.T) {
     t.Parallel()
 
-    node, err := LoadSpecification("testdata/openapi.yaml")
+    node, err := overlay.LoadSpecification("testdata/openapi.yaml")
     require.NoError(t, err)
-    node2, err := LoadSpecification("testdata/openapi-overlayed.yaml")
+    node2, err := overlay.LoadSpecification("testdata/openapi-overlayed.yaml")
     require.NoError(t, err)
 
-    o, err := LoadOverlay("testdata/overlay-generated.yaml")
+    o, err := overlay.LoadOverlay("testdata/overlay-generated.yaml")
     require.NoError(t, err)
 
     o2, err := overlay.Compare("Drinks Overlay", node, *node2)
@@ -0,0 +1,109 @@
+package overlay
+
+import (
+	"fmt"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"go.yaml.in/yaml/v4"
+)
+
+// ConflictKind describes how two actions contradict each other.
+type ConflictKind int
+
+const (
+	// ConflictUpdateOfRemoved is an update of a node that an earlier action removes, so the update
+	// has no effect.
+	ConflictUpdateOfRemoved ConflictKind = iota
+	// ConflictRemoveOfUpdated is a removal of a node that an earlier action updates, discarding
+	// the update.
+	ConflictRemoveOfUpdated
+)
+
+func (k ConflictKind) String() string {
+	switch k {
+	case ConflictUpdateOfRemoved:
+		return "update of removed node"
+	case ConflictRemoveOfUpdated:
+		return "removal of updated node"
+	}
+	return "unknown"
+}
+
+// Conflict is a pair of actions whose targets overlap with contradictory effects.
+type Conflict struct {
+	Kind ConflictKind
+	// First and Second are the indices of the actions, in the order they are applied.
+	First  int
+	Second int
+	// FirstTarget and SecondTarget are the target expressions of the actions.
+	FirstTarget  string
+	SecondTarget string
+	// Path is the normalized path of the updated node both actions affect.
+	Path string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s at %s: action %d targets %q, action %d targets %q",
+		c.Kind, c.Path, c.First, c.FirstTarget, c.Second, c.SecondTarget)
+}
+
+// Conflicts evaluates the targets of every action against the document rooted at root, without
+// modifying it, and reports each pair of actions where one removes a node (or an ancestor of a node)
+// that the other updates. Each pair is reported once, for the first such node in document order.
+func (o *Overlay) Conflicts(root *yaml.Node) ([]Conflict, error) {
+	parents := newParentIndex(root)
+	paths := jsonpath.NormalizedPaths(root)
+
+	removedBy := make(map[*yaml.Node][]int)
+	updates := make([][]*yaml.Node, len(o.Actions))
+	for i, action := range o.Actions {
+		if action.Target == "" || (!action.Remove && action.Update.IsZero() && action.UpdateFile == "") {
+			continue
+		}
+		p, err := jsonpath.NewPath(action.Target, config.WithPropertyNameExtension())
+		if err != nil {
+			return nil, fmt.Errorf("overlay action at index %d: invalid target %q: %w", i, action.Target, err)
+		}
+		nodes := p.Query(root)
+		if !action.Remove {
+			updates[i] = nodes
+			continue
+		}
+		for _, node := range nodes {
+			removedBy[node] = append(removedBy[node], i)
+			// removing a key removes its value too
+			if parent := parents.getParent(node); parent != nil && parent.Kind == yaml.MappingNode {
+				for k := 0; k+1 < len(parent.Content); k += 2 {
+					if parent.Content[k] == node {
+						removedBy[parent.Content[k+1]] = append(removedBy[parent.Content[k+1]], i)
+					}
+				}
+			}
+		}
+	}
+
+	var conflicts []Conflict
+	reported := make(map[[2]int]bool)
+	for u, nodes := range updates {
+		for _, node := range nodes {
+			for ancestor := node; ancestor != nil; ancestor = parents.getParent(ancestor) {
+				for _, r := range removedBy[ancestor] {
+					conflict := Conflict{Kind: ConflictUpdateOfRemoved, First: r, Second: u, Path: paths[node]}
+					if u < r {
+						conflict = Conflict{Kind: ConflictRemoveOfUpdated, First: u, Second: r, Path: paths[node]}
+					}
+					pair := [2]int{conflict.First, conflict.Second}
+					if reported[pair] {
+						continue
+					}
+					reported[pair] = true
+					conflict.FirstTarget = o.Actions[conflict.First].Target
+					conflict.SecondTarget = o.Actions[conflict.Second].Target
+					conflicts = append(conflicts, conflict)
+				}
+			}
+		}
+	}
+	return conflicts, nil
+}
@@ -0,0 +1,73 @@
+package overlay_test
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/overlay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestConflicts(t *testing.T) {
+	t.Parallel()
+
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+paths:
+  /users:
+    get: {summary: List}
+  /orders:
+    get: {summary: Orders}
+info:
+  title: API
+`), &root))
+
+	o := mustLoadOverlay(t, `
+overlay: 1.0.0
+info: {title: conflicts, version: 1.0.0}
+actions:
+  - target: $.paths['/users'].get
+    update: {deprecated: true}
+  - target: $.paths['/users']
+    remove: true
+  - target: $.paths.*.get
+    update: {x-checked: true}
+  - target: $.info.title~
+    remove: true
+  - target: $.info.title
+    update: Renamed
+  - target: $.paths['/orders'].get
+    update: {x-owner: orders}
+`)
+
+	conflicts, err := o.Conflicts(&root)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 3)
+
+	assert.Equal(t, overlay.Conflict{
+		Kind:         overlay.ConflictRemoveOfUpdated,
+		First:        0,
+		Second:       1,
+		FirstTarget:  "$.paths['/users'].get",
+		SecondTarget: "$.paths['/users']",
+		Path:         "$['paths']['/users']['get']",
+	}, conflicts[0])
+	assert.Equal(t, overlay.ConflictUpdateOfRemoved, conflicts[1].Kind)
+	assert.Equal(t, [2]int{1, 2}, [2]int{conflicts[1].First, conflicts[1].Second})
+	assert.Equal(t, "update of removed node at $['info']['title']: action 3 targets \"$.info.title~\", action 4 targets \"$.info.title\"",
+		conflicts[2].String())
+
+	// the document is left untouched
+	assert.Len(t, root.Content[0].Content[1].Content, 4)
+
+	bad := mustLoadOverlay(t, `
+overlay: 1.0.0
+info: {title: bad, version: 1.0.0}
+actions:
+  - target: $.paths[
+    remove: true
+`)
+	_, err = bad.Conflicts(&root)
+	assert.Error(t, err)
+}
@@ -0,0 +1,98 @@
+package overlay
+
+import (
+	"slices"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// ApplyToCopy applies the overlay like ApplyTo, but to a copy of the document rooted at root, which
+// it returns, leaving root untouched so that it can go on being read while the copy is produced. Only
+// the nodes the overlay changes, and the nodes above them, are copied; the rest of the copy shares
+// its nodes with root, so neither document should be changed in place afterwards. Aliases in the
+// copy go on referring to the anchored nodes of root. If an action fails, no copy is returned.
+func (o *Overlay) ApplyToCopy(root *yaml.Node, opts ...ApplyOption) (*yaml.Node, error) {
+	cfg := newApplyConfig(opts)
+	cfg.copyOnWrite = newCopyOnWrite(root)
+	doc := cfg.copyOnWrite.copy(root)
+	if err := o.apply(doc, nil, cfg); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// copyOnWrite tracks the nodes of a document that an overlay is applied to a copy of
+type copyOnWrite struct {
+	// shared holds the nodes of the input, which are copied before they are changed
+	shared map[*yaml.Node]bool
+	// copies maps the nodes of the input that have been copied to their copies, and originals maps
+	// the copies back
+	copies    map[*yaml.Node]*yaml.Node
+	originals map[*yaml.Node]*yaml.Node
+}
+
+func newCopyOnWrite(root *yaml.Node) *copyOnWrite {
+	c := &copyOnWrite{
+		shared:    make(map[*yaml.Node]bool),
+		copies:    make(map[*yaml.Node]*yaml.Node),
+		originals: make(map[*yaml.Node]*yaml.Node),
+	}
+	walkSourceNodes(root, func(node *yaml.Node) {
+		c.shared[node] = true
+	})
+	return c
+}
+
+// copy returns a shallow copy of node, with content of its own that shares the nodes below it
+func (c *copyOnWrite) copy(node *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Content = slices.Clone(node.Content)
+	c.copies[node] = &copied
+	c.originals[&copied] = node
+	return &copied
+}
+
+// current returns the node that node, a node of the input of ApplyToCopy that may since have been
+// copied, now is in the document being changed
+func (t *targetIndex) current(node *yaml.Node) *yaml.Node {
+	if t.cow != nil {
+		if copied, ok := t.cow.copies[node]; ok {
+			return copied
+		}
+	}
+	return node
+}
+
+// writable returns node, ready to be changed: if it is still shared with the input of ApplyToCopy,
+// it is copied in place, along with the nodes above it.
+func (t *targetIndex) writable(node *yaml.Node) *yaml.Node {
+	node = t.current(node)
+	if t.cow == nil || !t.cow.shared[node] {
+		return node
+	}
+	copied := t.cow.copy(node)
+	if parent := t.parents.getParent(node); parent != nil {
+		parent = t.writable(parent)
+		for i, child := range parent.Content {
+			if child == node {
+				parent.Content[i] = copied
+			}
+		}
+		t.parents[copied] = parent
+	}
+	for _, child := range copied.Content {
+		t.parents[child] = copied
+	}
+	return copied
+}
+
+// invalidate drops the cached query results that depend on node, or on the node of the input it
+// was copied from
+func (t *targetIndex) invalidate(node *yaml.Node) {
+	t.queries.Invalidate(node)
+	if t.cow != nil {
+		if original, ok := t.cow.originals[node]; ok {
+			t.queries.Invalidate(original)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package overlay_test
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/overlay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestApplyToCopy(t *testing.T) {
+	t.Parallel()
+
+	node, err := overlay.LoadSpecification("testdata/openapi.yaml")
+	require.NoError(t, err)
+	before, err := yaml.Marshal(node)
+	require.NoError(t, err)
+
+	o, err := overlay.LoadOverlay("testdata/overlay.yaml")
+	require.NoError(t, err)
+
+	copied, err := o.ApplyToCopy(node)
+	require.NoError(t, err)
+
+	NodeMatchesFile(t, copied, "testdata/openapi-overlayed.yaml")
+	after, err := yaml.Marshal(node)
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+}
+
+func TestApplyToCopy_Sharing(t *testing.T) {
+	t.Parallel()
+
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+paths:
+  /users:
+    get: {summary: List users, tags: [users]}
+  /internal:
+    get: {summary: Internal}
+  /orders:
+    get: {summary: List orders}
+`), &root))
+
+	o, err := overlay.LoadOverlayBytes([]byte(`
+overlay: 1.0.0
+info: {title: copy on write, version: 1.0.0}
+actions:
+  - target: $.paths['/users'].get
+    update: {x-seen: 1, tags: [admin]}
+  - target: $.paths['/internal']
+    remove: true
+  - target: $.paths.*.get
+    update: {x-seen: 2}
+  - target: $.paths['/users'].get.summary~
+    remove: true
+`))
+	require.NoError(t, err)
+
+	copied, err := o.ApplyToCopy(&root)
+	require.NoError(t, err)
+
+	out, err := yaml.Marshal(copied)
+	require.NoError(t, err)
+	assert.Equal(t, `paths:
+    /users:
+        get: {tags: [users, admin], x-seen: 2}
+    /orders:
+        get: {summary: List orders, x-seen: 2}
+`, string(out))
+
+	out, err = yaml.Marshal(&root)
+	require.NoError(t, err)
+	assert.Equal(t, `paths:
+    /users:
+        get: {summary: List users, tags: [users]}
+    /internal:
+        get: {summary: Internal}
+    /orders:
+        get: {summary: List orders}
+`, string(out))
+
+	// nodes the overlay did not change are shared with the input, the rest are copies
+	query := func(root *yaml.Node, path string) *yaml.Node {
+		return jsonpath.MustNewPath(path).Query(root)[0]
+	}
+	assert.Same(t, query(&root, `$.paths['/orders'].get.summary`), query(copied, `$.paths['/orders'].get.summary`))
+	assert.Same(t, query(&root, `$.paths['/users'].get.tags[0]`), query(copied, `$.paths['/users'].get.tags[0]`))
+	assert.NotSame(t, query(&root, `$.paths['/orders'].get`), query(copied, `$.paths['/orders'].get`))
+	assert.NotSame(t, query(&root, `$.paths`), query(copied, `$.paths`))
+}
+
+func TestApplyToCopy_Error(t *testing.T) {
+	t.Parallel()
+
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`info: {title: API}`), &root))
+
+	o, err := overlay.LoadOverlayBytes([]byte(`
+overlay: 1.0.0
+info: {title: failing, version: 1.0.0}
+actions:
+  - target: $.info
+    update: {x-seen: true}
+  - target: $.info[
+    remove: true
+`))
+	require.NoError(t, err)
+
+	copied, err := o.ApplyToCopy(&root)
+	assert.Error(t, err)
+	assert.Nil(t, copied)
+
+	out, err := yaml.Marshal(&root)
+	require.NoError(t, err)
+	assert.Equal(t, "info: {title: API}\n", string(out))
+}
@@ -0,0 +1,115 @@
+package overlay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+	"go.yaml.in/yaml/v4"
+)
+
+// DocumentFormat is the serialization format of a loaded document.
+type DocumentFormat int
+
+const (
+	FormatYAML DocumentFormat = iota
+	FormatJSON
+)
+
+func (f DocumentFormat) String() string {
+	if f == FormatJSON {
+		return "JSON"
+	}
+	return "YAML"
+}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Decompress returns data unchanged unless it starts with a gzip or zstd header, in which
+// case the decompressed content is returned.
+func Decompress(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip data: %w", err)
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case bytes.HasPrefix(data, zstdMagic):
+		r, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zstd data: %w", err)
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	}
+	return data, nil
+}
+
+// DetectFormat sniffs whether data is JSON or YAML. Documents whose first significant
+// character opens a JSON object or array are treated as JSON.
+func DetectFormat(data []byte) DocumentFormat {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) > 0 && (data[0] == '{' || data[0] == '[') {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// LoadSpecification reads and parses the document at path, transparently decompressing
+// gzip and zstd files.
+func LoadSpecification(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open schema from path %q: %w", path, err)
+	}
+	node, err := LoadSpecificationBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema at path %q: %w", path, err)
+	}
+	return node, nil
+}
+
+// LoadSpecificationBytes parses a JSON or YAML document, which may be gzip or zstd compressed.
+func LoadSpecificationBytes(data []byte) (*yaml.Node, error) {
+	data, err := Decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", DetectFormat(data), err)
+	}
+	return &node, nil
+}
+
+// LoadOverlay reads and parses the overlay at path, transparently decompressing gzip and
+// zstd files.
+func LoadOverlay(path string) (*Overlay, error) {
+	o, err := Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse overlay from path %q: %w", path, err)
+	}
+	return o, nil
+}
+
+// LoadOverlayBytes parses a JSON or YAML overlay, which may be gzip or zstd compressed.
+func LoadOverlayBytes(data []byte) (*Overlay, error) {
+	data, err := Decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	var overlay Overlay
+	if err := yaml.Unmarshal(data, &overlay); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", DetectFormat(data), err)
+	}
+	return &overlay, nil
+}
@@ -0,0 +1,91 @@
+package overlay_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pb33f/jsonpath/pkg/overlay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func zstdBytes(t *testing.T, data []byte) []byte {
+	w, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer w.Close()
+	return w.EncodeAll(data, nil)
+}
+
+func TestLoadSpecification_Compressed(t *testing.T) {
+	t.Parallel()
+
+	raw, err := os.ReadFile("testdata/openapi.yaml")
+	require.NoError(t, err)
+	expected, err := overlay.LoadSpecification("testdata/openapi.yaml")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	for name, data := range map[string][]byte{
+		"openapi.yaml.gz":  gzipBytes(t, raw),
+		"openapi.yaml.zst": zstdBytes(t, raw),
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0644))
+		node, err := overlay.LoadSpecification(path)
+		require.NoError(t, err, name)
+		assert.Equal(t, expected.Content[0].Content[1].Value, node.Content[0].Content[1].Value, name)
+		assert.Equal(t, len(expected.Content[0].Content), len(node.Content[0].Content), name)
+	}
+}
+
+func TestLoadOverlay_Compressed(t *testing.T) {
+	t.Parallel()
+
+	raw, err := os.ReadFile("testdata/overlay.yaml")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "overlay.yaml.gz")
+	require.NoError(t, os.WriteFile(path, gzipBytes(t, raw), 0644))
+
+	expected, err := overlay.LoadOverlay("testdata/overlay.yaml")
+	require.NoError(t, err)
+	o, err := overlay.LoadOverlay(path)
+	require.NoError(t, err)
+	expectedString, err := expected.ToString()
+	require.NoError(t, err)
+	actualString, err := o.ToString()
+	require.NoError(t, err)
+	assert.Equal(t, expectedString, actualString)
+}
+
+func TestLoadSpecificationBytes_JSON(t *testing.T) {
+	t.Parallel()
+
+	node, err := overlay.LoadSpecificationBytes(zstdBytes(t, []byte(`{"openapi": "3.1.0", "paths": {}}`)))
+	require.NoError(t, err)
+	assert.Equal(t, "3.1.0", node.Content[0].Content[1].Value)
+
+	_, err = overlay.LoadSpecificationBytes([]byte(`{"openapi": `))
+	assert.ErrorContains(t, err, "invalid JSON")
+}
+
+func TestDetectFormat(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, overlay.FormatJSON, overlay.DetectFormat([]byte("\xef\xbb\xbf  \n{}")))
+	assert.Equal(t, overlay.FormatJSON, overlay.DetectFormat([]byte("[1]")))
+	assert.Equal(t, overlay.FormatYAML, overlay.DetectFormat([]byte("openapi: 3.1.0")))
+}
@@ -0,0 +1,111 @@
+package overlay
+
+import (
+	"fmt"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"go.yaml.in/yaml/v4"
+)
+
+// Merge composes overlays into a single overlay whose actions are those of each overlay in turn.
+// The version, info and extends of the first overlay are kept, and extensions and variables are
+// combined with earlier overlays taking precedence.
+//
+// Targets are normalized to the canonical form of their JSONPath, and an action identical to an
+// earlier one (same target, same update or removal) is dropped. Actions that remove a target which
+// another action updates are conflicts: Merge reports all of them as ValidationErrors.
+func Merge(overlays ...*Overlay) (*Overlay, error) {
+	if len(overlays) == 0 {
+		return nil, fmt.Errorf("no overlays to merge")
+	}
+
+	first := overlays[0]
+	merged := &Overlay{
+		Version:         first.Version,
+		JSONPathVersion: first.JSONPathVersion,
+		Info:            first.Info,
+		Extends:         first.Extends,
+	}
+
+	errs := make(ValidationErrors, 0)
+	byTarget := make(map[string][]int)
+	for i, o := range overlays {
+		if o.Extends != "" && merged.Extends != "" && o.Extends != merged.Extends {
+			errs = append(errs, fmt.Errorf("overlay %d extends %q, but overlay 0 extends %q", i, o.Extends, merged.Extends))
+		} else if merged.Extends == "" {
+			merged.Extends = o.Extends
+		}
+		for k, v := range o.Extensions {
+			if _, ok := merged.Extensions[k]; !ok {
+				if merged.Extensions == nil {
+					merged.Extensions = make(Extensions)
+				}
+				merged.Extensions[k] = v
+			}
+		}
+		for k, v := range o.Variables {
+			if _, ok := merged.Variables[k]; !ok {
+				if merged.Variables == nil {
+					merged.Variables = make(map[string]string)
+				}
+				merged.Variables[k] = v
+			}
+		}
+
+	NextAction:
+		for j, action := range o.Actions {
+			target, err := normalizeTarget(action.Target)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("overlay %d action at index %d: invalid target %q: %w", i, j, action.Target, err))
+				continue
+			}
+			action.Target = target
+
+			for _, k := range byTarget[target] {
+				existing := merged.Actions[k]
+				if existing.Remove == action.Remove && existing.UpdateFile == action.UpdateFile && sameUpdate(&existing.Update, &action.Update) {
+					continue NextAction
+				}
+				if existing.Remove != action.Remove {
+					errs = append(errs, fmt.Errorf("overlay %d action at index %d conflicts with merged action at index %d: %s and %s %q",
+						i, j, k, verb(existing), verb(action), target))
+				}
+			}
+			byTarget[target] = append(byTarget[target], len(merged.Actions))
+			merged.Actions = append(merged.Actions, action)
+		}
+	}
+
+	if err := errs.Return(); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// normalizeTarget returns the canonical form of a target expression. Targets referring to variables
+// are kept as written, as they may only parse once the variables are substituted.
+func normalizeTarget(target string) (string, error) {
+	if variablePattern.MatchString(target) {
+		return target, nil
+	}
+	p, err := jsonpath.NewPath(target, config.WithPropertyNameExtension())
+	if err != nil {
+		return "", err
+	}
+	return p.String(), nil
+}
+
+func sameUpdate(a, b *yaml.Node) bool {
+	if a.IsZero() || b.IsZero() {
+		return a.IsZero() && b.IsZero()
+	}
+	return yamlEquals([]*yaml.Node{a}, []*yaml.Node{b})
+}
+
+func verb(action Action) string {
+	if action.Remove {
+		return "remove"
+	}
+	return "update"
+}
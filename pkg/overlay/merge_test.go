@@ -0,0 +1,90 @@
+package overlay_test
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/overlay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustLoadOverlay(t *testing.T, src string) *overlay.Overlay {
+	o, err := overlay.LoadOverlayBytes([]byte(src))
+	require.NoError(t, err)
+	return o
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	a := mustLoadOverlay(t, `
+overlay: 1.0.0
+x-owner: api-team
+info: {title: base, version: 1.0.0}
+extends: https://example.com/openapi.yaml
+actions:
+  - target: $['info']
+    update: {x-audience: public}
+  - target: $.paths['/internal']
+    remove: true
+`)
+	b := mustLoadOverlay(t, `
+overlay: 1.0.0
+x-owner: other-team
+x-reviewed: true
+info: {title: extra, version: 2.0.0}
+actions:
+  - target: '$[ "info" ]'
+    update: {x-audience: public}
+  - target: $.servers
+    update: [{url: https://api.example.com}]
+`)
+
+	merged, err := overlay.Merge(a, b)
+	require.NoError(t, err)
+	assert.Equal(t, "base", merged.Info.Title)
+	assert.Equal(t, "https://example.com/openapi.yaml", merged.Extends)
+	assert.Equal(t, overlay.Extensions{"x-owner": "api-team", "x-reviewed": true}, merged.Extensions)
+
+	var targets []string
+	for _, action := range merged.Actions {
+		targets = append(targets, action.Target)
+	}
+	assert.Equal(t, []string{"$['info']", "$.paths['/internal']", "$.servers"}, targets)
+	require.NoError(t, merged.Validate())
+}
+
+func TestMerge_Conflicts(t *testing.T) {
+	t.Parallel()
+
+	a := mustLoadOverlay(t, `
+overlay: 1.0.0
+info: {title: a, version: 1.0.0}
+extends: https://example.com/a.yaml
+actions:
+  - target: $.paths['/users']
+    remove: true
+`)
+	b := mustLoadOverlay(t, `
+overlay: 1.0.0
+info: {title: b, version: 1.0.0}
+extends: https://example.com/b.yaml
+actions:
+  - target: $.paths["/users"]
+    update: {summary: Users}
+  - target: $.paths[
+    remove: true
+`)
+
+	_, err := overlay.Merge(a, b)
+	require.Error(t, err)
+	var errs overlay.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 3)
+	assert.Contains(t, err.Error(), `overlay 1 extends "https://example.com/b.yaml"`)
+	assert.Contains(t, err.Error(), `overlay 1 action at index 0 conflicts with merged action at index 0: remove and update "$.paths['/users']"`)
+	assert.Contains(t, err.Error(), `overlay 1 action at index 1: invalid target`)
+
+	_, err = overlay.Merge()
+	assert.Error(t, err)
+}
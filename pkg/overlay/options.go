@@ -0,0 +1,112 @@
+package overlay
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+)
+
+// ApplyOption configures how an overlay is applied.
+type ApplyOption func(*applyConfig)
+
+type applyConfig struct {
+	logger *slog.Logger
+	files  fs.FS
+	vars   map[string]string
+	audit  *auditLog
+	// copyOnWrite is set by ApplyToCopy
+	copyOnWrite *copyOnWrite
+}
+
+// WithLogger logs the outcome of every action to logger at debug level: its target, whether it
+// updates or removes, and how many nodes the target matched, or why the action failed.
+func WithLogger(logger *slog.Logger) ApplyOption {
+	return func(c *applyConfig) {
+		c.logger = logger
+	}
+}
+
+// WithUpdateFiles reads the update files that actions name with x-update-file from files. Names are
+// slash-separated and relative to the root of files, so os.DirFS(filepath.Dir(overlayPath)) resolves
+// them relative to the overlay. Without it, applying an action with an update file is an error.
+func WithUpdateFiles(files fs.FS) ApplyOption {
+	return func(c *applyConfig) {
+		c.files = files
+	}
+}
+
+// WithVariables sets variables referenced as {{name}} in the targets and updates of the actions,
+// overriding the defaults the overlay gives in x-variables, so that one overlay can serve several
+// environments.
+func WithVariables(vars map[string]string) ApplyOption {
+	return func(c *applyConfig) {
+		if c.vars == nil {
+			c.vars = make(map[string]string)
+		}
+		for name, value := range vars {
+			c.vars[name] = value
+		}
+	}
+}
+
+func newApplyConfig(opts []ApplyOption) applyConfig {
+	var cfg applyConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// logAction logs the outcome of the action at index i
+func (c applyConfig) logAction(i int, action Action, matched int, err error) {
+	if c.logger == nil {
+		return
+	}
+	kind := "update"
+	if action.Remove {
+		kind = "remove"
+	}
+	if err != nil {
+		c.logger.Debug("overlay: action failed", "action", i, "target", action.Target, "kind", kind, "error", err)
+		return
+	}
+	c.logger.Debug("overlay: action applied", "action", i, "target", action.Target, "kind", kind, "matched", matched)
+}
+
+// prepareAction returns action as it is applied: with the content of its update file as its update,
+// and its variables substituted
+func (c applyConfig) prepareAction(action Action, vars variables) (Action, error) {
+	if !action.Remove {
+		var err error
+		if action, err = c.resolveUpdate(action); err != nil {
+			return action, err
+		}
+	}
+	return vars.substituteAction(action)
+}
+
+// resolveUpdate returns action with the content of its update file, if it names one, as its update
+func (c applyConfig) resolveUpdate(action Action) (Action, error) {
+	if action.UpdateFile == "" {
+		return action, nil
+	}
+	if !action.Update.IsZero() {
+		return action, fmt.Errorf("action with target %q sets both update and x-update-file", action.Target)
+	}
+	if c.files == nil {
+		return action, fmt.Errorf("action with target %q has the update file %q, but no files were given with WithUpdateFiles", action.Target, action.UpdateFile)
+	}
+	data, err := fs.ReadFile(c.files, action.UpdateFile)
+	if err != nil {
+		return action, fmt.Errorf("failed to read update file %q: %w", action.UpdateFile, err)
+	}
+	doc, err := LoadSpecificationBytes(data)
+	if err != nil {
+		return action, fmt.Errorf("failed to parse update file %q: %w", action.UpdateFile, err)
+	}
+	if len(doc.Content) == 0 {
+		return action, fmt.Errorf("update file %q is empty", action.UpdateFile)
+	}
+	action.Update = *doc.Content[0]
+	return action, nil
+}
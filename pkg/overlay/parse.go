@@ -8,28 +8,20 @@ import (
     "path/filepath"
 )
 
-// Parse will parse the given reader as an overlay file.
+// Parse will parse the file at the given path as an overlay file. Gzip and zstd
+// compressed files are decompressed transparently.
 func Parse(path string) (*Overlay, error) {
     filePath, err := filepath.Abs(path)
     if err != nil {
         return nil, fmt.Errorf("failed to get absolute path for %q: %w", path, err)
     }
 
-    ro, err := os.Open(filePath)
+    data, err := os.ReadFile(filePath)
     if err != nil {
         return nil, fmt.Errorf("failed to open overlay file at path %q: %w", path, err)
     }
-    defer ro.Close()
 
-    var overlay Overlay
-    dec := yaml.NewDecoder(ro)
-
-    err = dec.Decode(&overlay)
-    if err != nil {
-        return nil, err
-    }
-
-    return &overlay, err
+    return LoadOverlayBytes(data)
 }
 
 // Format will validate reformat the given file
@@ -25,6 +25,10 @@ type Overlay struct {
     // Extends is a URL to the OpenAPI specification this overlay applies to.
     Extends string `yaml:"extends,omitempty"`
 
+    // Variables are the default values of the {{name}} references in the targets and updates of the
+    // actions, which WithVariables can override when the overlay is applied.
+    Variables map[string]string `yaml:"x-variables,omitempty"`
+
     // Actions is the list of actions to perform to apply the overlay.
     Actions []Action `yaml:"actions"`
 }
@@ -61,6 +65,11 @@ type Action struct {
     // ignored if Remove is set.
     Update yaml.Node `yaml:"update,omitempty"`
 
+    // UpdateFile names a YAML or JSON file holding the update, so that large blocks such as shared
+    // components need not be inlined. It is read with the file system given to WithUpdateFiles, and
+    // cannot be combined with Update.
+    UpdateFile string `yaml:"x-update-file,omitempty"`
+
     // Remove marks the target node for removal rather than update.
     Remove bool `yaml:"remove,omitempty"`
 }
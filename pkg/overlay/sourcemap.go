@@ -0,0 +1,85 @@
+package overlay
+
+import (
+	"go.yaml.in/yaml/v4"
+)
+
+// Origin is where a node of an overlaid document came from.
+type Origin struct {
+	// Action is the index of the action whose update supplied the node, or -1 if the node comes
+	// from the input document.
+	Action int
+	// Line and Column are the 1-based position of the node in the input document, or in the overlay
+	// file or the action's update file for content supplied by an action (0 if unknown, e.g. for an
+	// update built in code).
+	Line   int
+	Column int
+}
+
+// FromInput reports whether the node comes from the input document rather than an action.
+func (o Origin) FromInput() bool {
+	return o.Action < 0
+}
+
+// Position is a 1-based line and column in a YAML document.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// SourceMap maps the nodes of an overlaid document back to the input document, and to the updates
+// of the overlay's actions for content they added or changed.
+type SourceMap struct {
+	origins map[*yaml.Node]Origin
+}
+
+// ApplyWithSourceMap applies the overlay to the document rooted at root like ApplyTo, and returns a
+// source map from the nodes of the overlaid document to where they came from.
+func (o *Overlay) ApplyWithSourceMap(root *yaml.Node, opts ...ApplyOption) (*SourceMap, error) {
+	origins := make(map[*yaml.Node]Origin)
+	walkSourceNodes(root, func(node *yaml.Node) {
+		origins[node] = Origin{Action: -1, Line: node.Line, Column: node.Column}
+	})
+	if err := o.apply(root, origins, newApplyConfig(opts)); err != nil {
+		return nil, err
+	}
+	return &SourceMap{origins: origins}, nil
+}
+
+// Origin returns where node, a node of the overlaid document, came from.
+func (m *SourceMap) Origin(node *yaml.Node) (Origin, bool) {
+	origin, ok := m.origins[node]
+	return origin, ok
+}
+
+// OutputPositions maps positions in the overlaid document as written out to where they came from.
+// applied is the document ApplyWithSourceMap returned the source map for, and reparsed is the result
+// of encoding applied and parsing it again, which is where errors reported against the output file
+// point. Nodes are matched by their place in the two trees, so reparsed must not have been modified.
+func (m *SourceMap) OutputPositions(applied *yaml.Node, reparsed *yaml.Node) map[Position]Origin {
+	positions := make(map[Position]Origin)
+	var walk func(a, r *yaml.Node)
+	walk = func(a, r *yaml.Node) {
+		if a == nil || r == nil {
+			return
+		}
+		if origin, ok := m.origins[a]; ok {
+			positions[Position{Line: r.Line, Column: r.Column}] = origin
+		}
+		if a.Kind == yaml.AliasNode || r.Kind == yaml.AliasNode || len(a.Content) != len(r.Content) {
+			return
+		}
+		for i := range a.Content {
+			walk(a.Content[i], r.Content[i])
+		}
+	}
+	walk(applied, reparsed)
+	return positions
+}
+
+func walkSourceNodes(node *yaml.Node, visit func(*yaml.Node)) {
+	visit(node)
+	for _, child := range node.Content {
+		walkSourceNodes(child, visit)
+	}
+}
@@ -0,0 +1,95 @@
+package overlay_test
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/overlay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const sourceMapSpec = `openapi: 3.1.0
+info:
+  title: API
+  version: 1.0.0
+tags:
+  - name: users
+`
+
+const sourceMapOverlay = `overlay: 1.0.0
+info: {title: overlay, version: 1.0.0}
+actions:
+  - target: $.info
+    update:
+      title: Overlaid API
+      contact:
+        name: api team
+  - target: $.tags
+    update:
+      - name: admin
+  - target: $.openapi
+    remove: true
+`
+
+func TestApplyWithSourceMap(t *testing.T) {
+	t.Parallel()
+
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(sourceMapSpec), &root))
+	o := mustLoadOverlay(t, sourceMapOverlay)
+
+	sources, err := o.ApplyWithSourceMap(&root)
+	require.NoError(t, err)
+
+	doc := root.Content[0]
+	origin := func(node *yaml.Node) overlay.Origin {
+		o, ok := sources.Origin(node)
+		require.True(t, ok)
+		return o
+	}
+
+	// info: untouched key, replaced value, added key and value
+	info := doc.Content[1]
+	assert.Equal(t, overlay.Origin{Action: -1, Line: 3, Column: 3}, origin(info.Content[0]))
+	assert.True(t, origin(info.Content[0]).FromInput())
+	assert.Equal(t, overlay.Origin{Action: 0, Line: 6, Column: 14}, origin(info.Content[1]))
+	assert.Equal(t, overlay.Origin{Action: -1, Line: 4, Column: 3}, origin(info.Content[2]))
+	assert.Equal(t, overlay.Origin{Action: 0, Line: 7, Column: 7}, origin(info.Content[4]))
+	assert.Equal(t, overlay.Origin{Action: 0, Line: 8, Column: 9}, origin(info.Content[5]))
+	assert.Equal(t, overlay.Origin{Action: 0, Line: 8, Column: 15}, origin(info.Content[5].Content[1]))
+
+	// tags: original and appended items
+	tags := doc.Content[3]
+	assert.Equal(t, overlay.Origin{Action: -1, Line: 6, Column: 5}, origin(tags.Content[0]))
+	assert.Equal(t, overlay.Origin{Action: 1, Line: 11, Column: 9}, origin(tags.Content[1]))
+}
+
+func TestSourceMap_OutputPositions(t *testing.T) {
+	t.Parallel()
+
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(sourceMapSpec), &root))
+	sources, err := mustLoadOverlay(t, sourceMapOverlay).ApplyWithSourceMap(&root)
+	require.NoError(t, err)
+
+	out, err := yaml.Marshal(&root)
+	require.NoError(t, err)
+	// info:
+	//     title: Overlaid API
+	//     version: 1.0.0
+	//     contact:
+	//         name: api team
+	// tags:
+	//     - name: users
+	//     - name: admin
+	var reparsed yaml.Node
+	require.NoError(t, yaml.Unmarshal(out, &reparsed))
+
+	positions := sources.OutputPositions(&root, &reparsed)
+	assert.Equal(t, overlay.Origin{Action: 0, Line: 6, Column: 14}, positions[overlay.Position{Line: 2, Column: 12}])
+	assert.Equal(t, overlay.Origin{Action: -1, Line: 4, Column: 12}, positions[overlay.Position{Line: 3, Column: 14}])
+	assert.Equal(t, overlay.Origin{Action: 0, Line: 8, Column: 15}, positions[overlay.Position{Line: 5, Column: 15}])
+	assert.Equal(t, overlay.Origin{Action: -1, Line: 6, Column: 11}, positions[overlay.Position{Line: 7, Column: 13}])
+	assert.Equal(t, overlay.Origin{Action: 1, Line: 11, Column: 15}, positions[overlay.Position{Line: 8, Column: 13}])
+}
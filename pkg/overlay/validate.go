@@ -51,6 +51,15 @@ func (o *Overlay) Validate() error {
 		if action.Remove && !action.Update.IsZero() {
 			errs = append(errs, fmt.Errorf("overlay action at index %d should not both set remove and define update", i))
 		}
+
+		if action.UpdateFile != "" {
+			if action.Remove {
+				errs = append(errs, fmt.Errorf("overlay action at index %d should not both set remove and x-update-file", i))
+			}
+			if !action.Update.IsZero() {
+				errs = append(errs, fmt.Errorf("overlay action at index %d should not both define update and x-update-file", i))
+			}
+		}
 	}
 
 	return errs.Return()
@@ -0,0 +1,90 @@
+package overlay
+
+import (
+	"fmt"
+	"regexp"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// variablePattern matches a reference to a variable, {{name}}, allowing spaces inside the braces
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+// variables are the values of the variables an overlay is applied with, or nil if it has none, in
+// which case {{name}} is left as written
+type variables map[string]string
+
+// variables returns the defaults of the overlay overridden by those given with WithVariables
+func (c applyConfig) variables(o *Overlay) variables {
+	if len(o.Variables) == 0 && len(c.vars) == 0 {
+		return nil
+	}
+	vars := make(variables, len(o.Variables)+len(c.vars))
+	for name, value := range o.Variables {
+		vars[name] = value
+	}
+	for name, value := range c.vars {
+		vars[name] = value
+	}
+	return vars
+}
+
+// substitute replaces the variable references in text with their values. A reference to a variable
+// that is not defined is an error.
+func (v variables) substitute(text string) (string, error) {
+	var err error
+	result := variablePattern.ReplaceAllStringFunc(text, func(ref string) string {
+		name := variablePattern.FindStringSubmatch(ref)[1]
+		value, ok := v[name]
+		if !ok && err == nil {
+			err = fmt.Errorf("undefined variable %q", name)
+		}
+		return value
+	})
+	return result, err
+}
+
+// substituteAction returns action with the variable references in its target and update replaced.
+// The update is copied rather than changed, so the overlay can be applied again with other values.
+func (v variables) substituteAction(action Action) (Action, error) {
+	if v == nil {
+		return action, nil
+	}
+	target, err := v.substitute(action.Target)
+	if err != nil {
+		return action, fmt.Errorf("action with target %q: %w", action.Target, err)
+	}
+	action.Target = target
+	if !action.Update.IsZero() {
+		update, err := v.substituteNode(&action.Update)
+		if err != nil {
+			return action, fmt.Errorf("action with target %q: %w", action.Target, err)
+		}
+		action.Update = *update
+	}
+	return action, nil
+}
+
+// substituteNode returns a copy of node with the variable references in its scalars replaced, keys
+// included. Positions are kept, so source maps still point into the overlay.
+func (v variables) substituteNode(node *yaml.Node) (*yaml.Node, error) {
+	substituted := *node
+	if node.Kind == yaml.ScalarNode {
+		value, err := v.substitute(node.Value)
+		if err != nil {
+			return nil, err
+		}
+		substituted.Value = value
+	}
+	if node.Content != nil {
+		substituted.Content = make([]*yaml.Node, len(node.Content))
+		for i, child := range node.Content {
+			copied, err := v.substituteNode(child)
+			if err != nil {
+				return nil, err
+			}
+			substituted.Content[i] = copied
+		}
+	}
+	return &substituted, nil
+}
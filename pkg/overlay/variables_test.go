@@ -0,0 +1,102 @@
+package overlay_test
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/overlay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const templateOverlay = `
+overlay: 1.0.0
+info: {title: environments, version: 1.0.0}
+x-variables:
+  env: staging
+  host: staging.example.com
+actions:
+  - target: $.info
+    update:
+      title: "Pets ({{env}})"
+  - target: $.servers
+    update:
+      - url: "https://{{ host }}/v1"
+  - target: $.paths['/{{internal}}']
+    remove: true
+`
+
+func TestApplyTo_Variables(t *testing.T) {
+	t.Parallel()
+
+	const doc = `
+info: {title: Pets}
+servers: []
+paths:
+  /pets: {}
+  /debug: {}
+`
+	o := mustLoadOverlay(t, templateOverlay)
+	assert.Equal(t, map[string]string{"env": "staging", "host": "staging.example.com"}, o.Variables)
+
+	tests := []struct {
+		name     string
+		vars     map[string]string
+		expected string
+	}{
+		{
+			name: "defaults",
+			vars: map[string]string{"internal": "debug"},
+			expected: `info: {title: Pets (staging)}
+servers: [{url: "https://staging.example.com/v1"}]
+paths:
+    /pets: {}
+`,
+		},
+		{
+			name: "overridden",
+			vars: map[string]string{"internal": "pets", "env": "production", "host": "api.example.com"},
+			expected: `info: {title: Pets (production)}
+servers: [{url: "https://api.example.com/v1"}]
+paths:
+    /debug: {}
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var root yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte(doc), &root))
+			require.NoError(t, o.ApplyTo(&root, overlay.WithVariables(test.vars)))
+			out, err := yaml.Marshal(&root)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(out))
+		})
+	}
+
+	// the overlay itself is left as written, so it can be applied again
+	assert.Equal(t, "$.paths['/{{internal}}']", o.Actions[2].Target)
+	assert.Equal(t, "Pets ({{env}})", o.Actions[0].Update.Content[1].Value)
+
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(doc), &root))
+	err := o.ApplyTo(&root)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `undefined variable "internal"`)
+}
+
+func TestMerge_Variables(t *testing.T) {
+	t.Parallel()
+
+	merged, err := overlay.Merge(mustLoadOverlay(t, templateOverlay), mustLoadOverlay(t, `
+overlay: 1.0.0
+info: {title: more, version: 1.0.0}
+x-variables: {env: dev, internal: debug}
+actions:
+  - target: $.paths['/{{internal}}']
+    remove: true
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "staging", "host": "staging.example.com", "internal": "debug"}, merged.Variables)
+	assert.Len(t, merged.Actions, 3)
+}
@@ -0,0 +1,563 @@
+// Package patch follows a document through a stream of RFC 6902 JSON Patch operations and reports
+// which compiled queries each operation may affect, so incremental validation pipelines re-run only
+// those queries rather than every query after every edit.
+//
+//	tracker := patch.NewTracker(&doc, patch.Query{Name: "operation-ids", Path: ids})
+//	decoder := patch.NewDecoder(stream)
+//	for {
+//	    op, err := decoder.Next()
+//	    if err == io.EOF {
+//	        break
+//	    }
+//	    affected, err := tracker.Apply(op)
+//	    // re-run the affected queries against tracker.Document()
+//	}
+//
+// Whether a query is affected is judged by jsonpath.JSONPath.DependsOn from the locations an
+// operation changes, so it errs on the side of reporting a query.
+//
+// Queries can also be subscribed to, with a callback told how their results changed after each
+// operation, or each change made through Set, Delete and Transform, that changes them.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"go.yaml.in/yaml/v4"
+)
+
+// Operation is an RFC 6902 JSON Patch operation.
+type Operation struct {
+	// Op is add, remove, replace, move, copy or test.
+	Op string `json:"op"`
+	// Path is the JSON Pointer the operation applies to.
+	Path string `json:"path"`
+	// From is the JSON Pointer that move and copy take their value from.
+	From string `json:"from,omitempty"`
+	// Value is the JSON value that add, replace and test use.
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Decoder reads the operations of a patch stream: JSON Patch documents, each an array of
+// operations, or single operations, one after another.
+type Decoder struct {
+	decoder *json.Decoder
+	pending []Operation
+}
+
+// NewDecoder returns a decoder reading a patch stream from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{decoder: json.NewDecoder(r)}
+}
+
+// Next returns the next operation of the stream, or io.EOF at its end.
+func (d *Decoder) Next() (Operation, error) {
+	for len(d.pending) == 0 {
+		var raw json.RawMessage
+		if err := d.decoder.Decode(&raw); err != nil {
+			return Operation{}, err
+		}
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 && raw[0] == '[' {
+			if err := json.Unmarshal(raw, &d.pending); err != nil {
+				return Operation{}, fmt.Errorf("invalid JSON Patch document: %w", err)
+			}
+			continue
+		}
+		var op Operation
+		if err := json.Unmarshal(raw, &op); err != nil {
+			return Operation{}, fmt.Errorf("invalid JSON Patch operation: %w", err)
+		}
+		d.pending = append(d.pending, op)
+	}
+	op := d.pending[0]
+	d.pending = d.pending[1:]
+	return op, nil
+}
+
+// Query is a named compiled query whose results the tracker reports changes to.
+type Query struct {
+	Name string
+	Path *jsonpath.JSONPath
+}
+
+// Tracker applies patch operations to a document and reports the queries each may affect. It is
+// safe for concurrent use, but the document it returns must not be read while changes are applied.
+type Tracker struct {
+	mu            sync.Mutex
+	root          *yaml.Node
+	queries       []Query
+	subscriptions []*Subscription
+}
+
+// NewTracker returns a tracker starting from the baseline document, which Apply modifies in place.
+func NewTracker(baseline *yaml.Node, queries ...Query) *Tracker {
+	return &Tracker{root: baseline, queries: queries}
+}
+
+// Document returns the document with every operation applied so far.
+func (t *Tracker) Document() *yaml.Node {
+	return t.root
+}
+
+// Affected returns the names of the queries whose results op may change, in the order the queries
+// were given, without applying it. A test operation changes nothing, and an operation that cannot be
+// applied to the document is an error.
+func (t *Tracker) Affected(op Operation) ([]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	affects, err := t.affects(op)
+	if err != nil {
+		return nil, err
+	}
+	return t.names(affects), nil
+}
+
+// Apply applies op to the document and returns the names of the queries whose results it may have
+// changed, as Affected does. A failed test operation is an error, and the document is left as it
+// was by an operation that fails.
+func (t *Tracker) Apply(op Operation) ([]string, error) {
+	return t.apply(func() (Operation, *yaml.Node, error) {
+		switch op.Op {
+		case "add", "replace", "test":
+			value, err := decodeValue(op)
+			return op, value, err
+		}
+		return op, nil, nil
+	})
+}
+
+// apply applies the operation prepare returns, with the value that add, replace and test use, and
+// then notifies the subscriptions whose results it changed
+func (t *Tracker) apply(prepare func() (Operation, *yaml.Node, error)) ([]string, error) {
+	t.mu.Lock()
+	names, notifications, err := t.applyLocked(prepare)
+	t.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	// callbacks run once the tracker is unlocked, so that they may change the document themselves
+	for _, n := range notifications {
+		n.subscription.callback(n.change)
+	}
+	return names, nil
+}
+
+func (t *Tracker) applyLocked(prepare func() (Operation, *yaml.Node, error)) ([]string, []notification, error) {
+	op, value, err := prepare()
+	if err != nil {
+		return nil, nil, err
+	}
+	affects, err := t.affects(op)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := t.mutate(op, value); err != nil {
+		return nil, nil, err
+	}
+	return t.names(affects), t.refresh(affects), nil
+}
+
+// affects returns a function reporting whether op may change the results of a query
+func (t *Tracker) affects(op Operation) (func(*jsonpath.JSONPath) bool, error) {
+	var changes []string
+	everything := false
+	record := func(pointer string, kind change) error {
+		paths, anchored, err := t.changed(pointer, kind)
+		changes = append(changes, paths...)
+		everything = everything || anchored
+		return err
+	}
+	var err error
+	switch op.Op {
+	case "add":
+		err = record(op.Path, changeAdd)
+	case "remove":
+		err = record(op.Path, changeRemove)
+	case "replace":
+		err = record(op.Path, changeReplace)
+	case "move":
+		if err = record(op.From, changeRemove); err == nil {
+			err = record(op.Path, changeAdd)
+		}
+	case "copy":
+		if _, err = t.resolve(op.From); err == nil {
+			err = record(op.Path, changeAdd)
+		}
+	case "test":
+		_, err = t.resolve(op.Path)
+	default:
+		err = fmt.Errorf("unknown JSON Patch operation %q", op.Op)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return func(path *jsonpath.JSONPath) bool {
+		return everything || dependsOnAny(path, changes)
+	}, nil
+}
+
+// names returns the names of the queries affected
+func (t *Tracker) names(affects func(*jsonpath.JSONPath) bool) []string {
+	var names []string
+	for _, query := range t.queries {
+		if affects(query.Path) {
+			names = append(names, query.Name)
+		}
+	}
+	return names
+}
+
+// mutate applies op to the document, with the value that add, replace and test use
+func (t *Tracker) mutate(op Operation, value *yaml.Node) error {
+	switch op.Op {
+	case "add", "replace":
+		return t.put(op.Path, value, op.Op == "replace")
+	case "remove":
+		_, _, err := t.remove(op.Path)
+		return err
+	case "move":
+		if op.Path != op.From && strings.HasPrefix(op.Path, op.From+"/") {
+			return fmt.Errorf("cannot move %q into itself", op.From)
+		}
+		if op.Path == op.From {
+			return nil
+		}
+		value, undo, err := t.remove(op.From)
+		if err != nil {
+			return err
+		}
+		// the target is resolved once the value has been removed, and may no longer exist
+		if err := t.put(op.Path, value, false); err != nil {
+			undo()
+			return err
+		}
+	case "copy":
+		value, _ := t.resolve(op.From)
+		return t.put(op.Path, copyNode(value), false)
+	case "test":
+		current, _ := t.resolve(op.Path)
+		if !equalValues(current, value) {
+			return fmt.Errorf("test of %q failed", op.Path)
+		}
+	}
+	return nil
+}
+
+// dependsOnAny reports whether the query depends on a change at any of the normalized paths
+func dependsOnAny(path *jsonpath.JSONPath, changes []string) bool {
+	for _, change := range changes {
+		if path.DependsOn(change) {
+			return true
+		}
+	}
+	return false
+}
+
+type change int
+
+const (
+	changeAdd change = iota
+	changeRemove
+	changeReplace
+)
+
+// changed returns the normalized paths of the nodes an operation of the given kind at pointer adds,
+// removes or replaces. Adding or removing an array element moves the elements after it, which
+// changes them too. anchored is set if the change is within an anchored node, which changes its
+// aliases as well.
+func (t *Tracker) changed(pointer string, kind change) (paths []string, anchored bool, err error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(tokens) == 0 {
+		if kind == changeRemove {
+			return nil, false, errors.New("cannot remove the whole document")
+		}
+		return []string{"$"}, false, nil
+	}
+	path := "$"
+	node := t.content()
+	for _, token := range tokens[:len(tokens)-1] {
+		anchored = anchored || node.Anchor != "" || node.Kind == yaml.AliasNode
+		var segment string
+		if node, segment, err = child(node, token, pointer); err != nil {
+			return nil, false, err
+		}
+		path += segment
+	}
+	anchored = anchored || node.Anchor != "" || node.Kind == yaml.AliasNode
+	node = resolveAlias(node)
+
+	last := tokens[len(tokens)-1]
+	switch node.Kind {
+	case yaml.MappingNode:
+		if kind != changeAdd && keyIndex(node, last) < 0 {
+			return nil, false, fmt.Errorf("%q does not exist", pointer)
+		}
+		return []string{path + jsonpath.NormalizeKeySegment(last)}, anchored, nil
+	case yaml.SequenceNode:
+		length := len(node.Content)
+		i := length
+		if last != "-" || kind != changeAdd {
+			if i, err = arrayIndex(last, pointer); err != nil {
+				return nil, false, err
+			}
+		}
+		if i > length || i == length && kind != changeAdd {
+			return nil, false, fmt.Errorf("%q is out of range", pointer)
+		}
+		if kind == changeReplace {
+			return []string{path + "[" + strconv.Itoa(i) + "]"}, anchored, nil
+		}
+		if kind == changeAdd {
+			length++
+		}
+		for ; i < length; i++ {
+			paths = append(paths, path+"["+strconv.Itoa(i)+"]")
+		}
+		return paths, anchored, nil
+	}
+	return nil, false, fmt.Errorf("%q is not within an object or array", pointer)
+}
+
+// content returns the top-level node of the document
+func (t *Tracker) content() *yaml.Node {
+	if t.root.Kind == yaml.DocumentNode && len(t.root.Content) == 1 {
+		return t.root.Content[0]
+	}
+	return t.root
+}
+
+// resolve returns the node at pointer
+func (t *Tracker) resolve(pointer string) (*yaml.Node, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	node := t.content()
+	for _, token := range tokens {
+		if node, _, err = child(node, token, pointer); err != nil {
+			return nil, err
+		}
+	}
+	return resolveAlias(node), nil
+}
+
+// put adds value at pointer, or replaces the value there
+func (t *Tracker) put(pointer string, value *yaml.Node, replace bool) error {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		if t.root.Kind == yaml.DocumentNode && len(t.root.Content) == 1 {
+			t.root.Content[0] = value
+		} else {
+			*t.root = *value
+		}
+		return nil
+	}
+	parent, err := t.resolve(joinPointer(tokens[:len(tokens)-1]))
+	if err != nil {
+		return err
+	}
+	last := tokens[len(tokens)-1]
+	if parent.Kind == yaml.MappingNode {
+		if i := keyIndex(parent, last); i >= 0 {
+			parent.Content[i+1] = value
+			return nil
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: last}
+		parent.Content = append(parent.Content, key, value)
+		return nil
+	}
+	if parent.Kind != yaml.SequenceNode {
+		return fmt.Errorf("%q is not within an object or array", pointer)
+	}
+	i := len(parent.Content)
+	if last != "-" {
+		if i, err = arrayIndex(last, pointer); err != nil {
+			return err
+		}
+	}
+	if i > len(parent.Content) || i == len(parent.Content) && replace {
+		return fmt.Errorf("%q is out of range", pointer)
+	}
+	if replace {
+		parent.Content[i] = value
+		return nil
+	}
+	parent.Content = append(parent.Content, nil)
+	copy(parent.Content[i+1:], parent.Content[i:])
+	parent.Content[i] = value
+	return nil
+}
+
+// remove removes the value at pointer, which Affected has checked exists, and returns it with a
+// function putting it back
+func (t *Tracker) remove(pointer string) (*yaml.Node, func(), error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	parent, err := t.resolve(joinPointer(tokens[:len(tokens)-1]))
+	if err != nil {
+		return nil, nil, err
+	}
+	last := tokens[len(tokens)-1]
+	i, width := keyIndex(parent, last), 2
+	if parent.Kind == yaml.SequenceNode {
+		i, _ = arrayIndex(last, pointer)
+		width = 1
+	}
+	removed := append([]*yaml.Node(nil), parent.Content[i:i+width]...)
+	parent.Content = append(parent.Content[:i], parent.Content[i+width:]...)
+	undo := func() {
+		parent.Content = append(parent.Content[:i], append(removed, parent.Content[i:]...)...)
+	}
+	return removed[width-1], undo, nil
+}
+
+// child returns the member or element of node that a pointer token names, and the normalized path
+// segment selecting it
+func child(node *yaml.Node, token string, pointer string) (*yaml.Node, string, error) {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		if i := keyIndex(node, token); i >= 0 {
+			return node.Content[i+1], jsonpath.NormalizeKeySegment(token), nil
+		}
+	case yaml.SequenceNode:
+		i, err := arrayIndex(token, pointer)
+		if err != nil {
+			return nil, "", err
+		}
+		if i < len(node.Content) {
+			return node.Content[i], "[" + strconv.Itoa(i) + "]", nil
+		}
+	}
+	return nil, "", fmt.Errorf("%q does not exist", pointer)
+}
+
+// keyIndex returns the index of the key named key in a mapping's content, or -1
+func keyIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// arrayIndex parses a pointer token naming an array element, which RFC 6901 writes without leading
+// zeros
+func arrayIndex(token string, pointer string) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || token != strconv.Itoa(i) {
+		return 0, fmt.Errorf("%q has an invalid array index %q", pointer, token)
+	}
+	return i, nil
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// splitPointer splits an RFC 6901 JSON Pointer into its unescaped reference tokens
+func splitPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON Pointer %q", pointer)
+	}
+	for i := 0; i < len(pointer); i++ {
+		if pointer[i] == '~' && (i+1 == len(pointer) || pointer[i+1] != '0' && pointer[i+1] != '1') {
+			return nil, fmt.Errorf("invalid JSON Pointer %q", pointer)
+		}
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = pointerUnescaper.Replace(token)
+	}
+	return tokens, nil
+}
+
+var (
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+)
+
+// joinPointer escapes reference tokens into a JSON Pointer
+func joinPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/")
+		b.WriteString(pointerEscaper.Replace(token))
+	}
+	return b.String()
+}
+
+// decodeValue decodes the value of an add, replace or test operation, dropping the flow style JSON
+// is written in so that the value is written like the rest of a YAML document
+func decodeValue(op Operation) (*yaml.Node, error) {
+	if len(op.Value) == 0 {
+		return nil, fmt.Errorf("%s operation at %q has no value", op.Op, op.Path)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(op.Value, &doc); err != nil || len(doc.Content) != 1 {
+		return nil, fmt.Errorf("%s operation at %q has an invalid value", op.Op, op.Path)
+	}
+	clearStyle(doc.Content[0])
+	return doc.Content[0], nil
+}
+
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// equalValues compares two nodes as JSON values, so that numbers compare by value and objects
+// regardless of the order of their members
+func equalValues(a *yaml.Node, b *yaml.Node) bool {
+	var x, y any
+	return jsonValue(a, &x) == nil && jsonValue(b, &y) == nil && reflect.DeepEqual(x, y)
+}
+
+func jsonValue(node *yaml.Node, value *any) error {
+	var decoded any
+	if err := node.Decode(&decoded); err != nil {
+		return err
+	}
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+// copyNode deep copies a node, expanding aliases and dropping anchors
+func copyNode(node *yaml.Node) *yaml.Node {
+	node = resolveAlias(node)
+	out := &yaml.Node{Kind: node.Kind, Style: node.Style, Tag: node.Tag, Value: node.Value}
+	for _, child := range node.Content {
+		out.Content = append(out.Content, copyNode(child))
+	}
+	return out
+}
@@ -0,0 +1,191 @@
+package patch_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/patch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const spec = `openapi: 3.1.0
+info:
+  title: pets
+  version: 1.0.0
+tags:
+  - name: pets
+  - name: store
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      tags: [pets]
+  /store:
+    get:
+      operationId: getInventory
+      tags: [store]
+`
+
+func newTracker(t *testing.T) *patch.Tracker {
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(spec), &doc))
+	return patch.NewTracker(&doc,
+		patch.Query{Name: "title", Path: jsonpath.MustNewPath(`$.info.title`)},
+		patch.Query{Name: "ids", Path: jsonpath.MustNewPath(`$.paths.*.*.operationId`)},
+		patch.Query{Name: "first-tag", Path: jsonpath.MustNewPath(`$.tags[0].name`)},
+		patch.Query{Name: "tagged", Path: jsonpath.MustNewPath(`$.paths.*[?@.tags[0] == $.tags[1].name]`)},
+	)
+}
+
+func TestTracker_Affected(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       patch.Operation
+		expected []string
+	}{
+		{
+			name:     "replace a leaf",
+			op:       patch.Operation{Op: "replace", Path: "/info/title", Value: []byte(`"cats"`)},
+			expected: []string{"title"},
+		},
+		{
+			name:     "replace an ancestor",
+			op:       patch.Operation{Op: "replace", Path: "/info", Value: []byte(`{}`)},
+			expected: []string{"title"},
+		},
+		{
+			name:     "add an unrelated member",
+			op:       patch.Operation{Op: "add", Path: "/info/x-owner", Value: []byte(`"team"`)},
+			expected: nil,
+		},
+		{
+			name:     "add an operation",
+			op:       patch.Operation{Op: "add", Path: "/paths/~1pets/post", Value: []byte(`{"operationId": "addPet"}`)},
+			expected: []string{"ids", "tagged"},
+		},
+		{
+			name:     "insert a tag moves the others",
+			op:       patch.Operation{Op: "add", Path: "/tags/0", Value: []byte(`{"name": "users"}`)},
+			expected: []string{"first-tag", "tagged"},
+		},
+		{
+			name:     "append a tag",
+			op:       patch.Operation{Op: "add", Path: "/tags/-", Value: []byte(`{"name": "users"}`)},
+			expected: nil,
+		},
+		{
+			name:     "remove the last tag",
+			op:       patch.Operation{Op: "remove", Path: "/tags/1"},
+			expected: []string{"tagged"},
+		},
+		{
+			name:     "move between queries",
+			op:       patch.Operation{Op: "move", From: "/info/title", Path: "/paths/~1pets/get/operationId"},
+			expected: []string{"title", "ids", "tagged"},
+		},
+		{
+			name:     "copy",
+			op:       patch.Operation{Op: "copy", From: "/info/title", Path: "/info/summary"},
+			expected: nil,
+		},
+		{
+			name:     "test changes nothing",
+			op:       patch.Operation{Op: "test", Path: "/info/title", Value: []byte(`"pets"`)},
+			expected: nil,
+		},
+		{
+			name:     "replace the document",
+			op:       patch.Operation{Op: "replace", Path: "", Value: []byte(`{}`)},
+			expected: []string{"title", "ids", "first-tag", "tagged"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			affected, err := newTracker(t).Affected(test.op)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, affected)
+		})
+	}
+}
+
+func TestTracker_Apply(t *testing.T) {
+	tracker := newTracker(t)
+	ops := `[
+  {"op": "add", "path": "/tags/0", "value": {"name": "users"}},
+  {"op": "test", "path": "/tags/1/name", "value": "pets"}
+]
+{"op": "move", "from": "/tags/2", "path": "/tags/0"}
+[{"op": "replace", "path": "/paths/~1store/get/tags/0", "value": "users"}]`
+	decoder := patch.NewDecoder(strings.NewReader(ops))
+	var affected [][]string
+	for {
+		op, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names, err := tracker.Apply(op)
+		require.NoError(t, err)
+		affected = append(affected, names)
+	}
+	assert.Equal(t, [][]string{{"first-tag", "tagged"}, nil, {"first-tag", "tagged"}, {"tagged"}}, affected)
+
+	out, err := yaml.Marshal(tracker.Document())
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "tags:\n    - name: store\n    - name: users\n    - name: pets\n")
+
+	tagged := jsonpath.MustNewPath(`$.paths.*[?@.tags[0] == $.tags[1].name]`).Query(tracker.Document())
+	require.Len(t, tagged, 1)
+	assert.Equal(t, "getInventory", tagged[0].Content[1].Value)
+}
+
+func TestTracker_Anchors(t *testing.T) {
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+defaults: &defaults
+  timeout: 30
+service:
+  settings: *defaults
+`), &doc))
+	tracker := patch.NewTracker(&doc, patch.Query{Name: "timeout", Path: jsonpath.MustNewPath(`$.service.settings.timeout`)})
+
+	affected, err := tracker.Affected(patch.Operation{Op: "replace", Path: "/defaults/timeout", Value: []byte(`60`)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"timeout"}, affected)
+}
+
+func TestTracker_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		op   patch.Operation
+	}{
+		{name: "unknown operation", op: patch.Operation{Op: "merge", Path: "/info"}},
+		{name: "invalid pointer", op: patch.Operation{Op: "remove", Path: "info"}},
+		{name: "invalid escape", op: patch.Operation{Op: "remove", Path: "/in~2fo"}},
+		{name: "missing member", op: patch.Operation{Op: "replace", Path: "/info/summary", Value: []byte(`"x"`)}},
+		{name: "missing parent", op: patch.Operation{Op: "add", Path: "/servers/0", Value: []byte(`{}`)}},
+		{name: "index out of range", op: patch.Operation{Op: "add", Path: "/tags/3", Value: []byte(`{}`)}},
+		{name: "leading zero", op: patch.Operation{Op: "remove", Path: "/tags/01"}},
+		{name: "missing value", op: patch.Operation{Op: "add", Path: "/info/summary"}},
+		{name: "failed test", op: patch.Operation{Op: "test", Path: "/info/title", Value: []byte(`"cats"`)}},
+		{name: "move into itself", op: patch.Operation{Op: "move", From: "/info", Path: "/info/copy"}},
+		{name: "move past the end", op: patch.Operation{Op: "move", From: "/tags/0", Path: "/tags/2"}},
+		{name: "remove the document", op: patch.Operation{Op: "remove", Path: ""}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tracker := newTracker(t)
+			before, err := yaml.Marshal(tracker.Document())
+			require.NoError(t, err)
+			_, err = tracker.Apply(test.op)
+			assert.Error(t, err)
+			after, err := yaml.Marshal(tracker.Document())
+			require.NoError(t, err)
+			assert.Equal(t, string(before), string(after))
+		})
+	}
+}
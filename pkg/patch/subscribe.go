@@ -0,0 +1,163 @@
+package patch
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"go.yaml.in/yaml/v4"
+)
+
+// Change describes how the results of a subscribed query changed. Results are matched on their
+// normalized paths.
+type Change struct {
+	// Results are the results of the query once the change was applied.
+	Results []jsonpath.Result
+	Added   []jsonpath.Result
+	Removed []jsonpath.Result
+	Changed []jsonpath.Result
+}
+
+// IsEmpty returns true if the results did not change.
+func (c Change) IsEmpty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// Subscription is a query whose subscriber is told whenever a change to the tracker's document
+// changes its results.
+type Subscription struct {
+	tracker  *Tracker
+	path     *jsonpath.JSONPath
+	callback func(Change)
+	results  []jsonpath.Result
+	// values holds the encoded value of each result when it was found, as the nodes are the
+	// document's own and later changes may modify them in place
+	values map[string][]byte
+}
+
+type notification struct {
+	subscription *Subscription
+	change       Change
+}
+
+// Subscribe evaluates path against the document and calls callback with the difference each time
+// a change made through the tracker changes its results. Only the subscriptions a change may affect,
+// as DependsOn judges, are evaluated again, and callbacks run after the change has been applied, in
+// the order the subscriptions were made.
+func (t *Tracker) Subscribe(path *jsonpath.JSONPath, callback func(Change)) *Subscription {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := &Subscription{tracker: t, path: path, callback: callback}
+	s.results, s.values = evaluate(path, t.root)
+	t.subscriptions = append(t.subscriptions, s)
+	return s
+}
+
+// Results returns the results of the query as of the last change to the document.
+func (s *Subscription) Results() []jsonpath.Result {
+	s.tracker.mu.Lock()
+	defer s.tracker.mu.Unlock()
+	return s.results
+}
+
+// Cancel stops the subscription, whose callback is not called for changes made after it returns.
+func (s *Subscription) Cancel() {
+	s.tracker.mu.Lock()
+	defer s.tracker.mu.Unlock()
+	s.tracker.subscriptions = slices.DeleteFunc(s.tracker.subscriptions, func(other *Subscription) bool {
+		return other == s
+	})
+}
+
+// Set sets the value at pointer, replacing the value there or adding one, which inserts it into an
+// array. value is copied into the document.
+func (t *Tracker) Set(pointer string, value *yaml.Node) error {
+	if value == nil {
+		return fmt.Errorf("no value to set at %q", pointer)
+	}
+	_, err := t.apply(func() (Operation, *yaml.Node, error) {
+		op := Operation{Op: "add", Path: pointer}
+		if _, err := t.resolve(pointer); err == nil && !strings.HasSuffix(pointer, "/-") {
+			op.Op = "replace"
+		}
+		return op, copyNode(value), nil
+	})
+	return err
+}
+
+// Delete removes the value at pointer.
+func (t *Tracker) Delete(pointer string) error {
+	_, err := t.apply(func() (Operation, *yaml.Node, error) {
+		return Operation{Op: "remove", Path: pointer}, nil, nil
+	})
+	return err
+}
+
+// Transform replaces the value at pointer with the one fn returns, given a copy of the value that
+// it may modify and return. fn is called with the tracker locked, so it must not use the tracker.
+func (t *Tracker) Transform(pointer string, fn func(*yaml.Node) (*yaml.Node, error)) error {
+	_, err := t.apply(func() (Operation, *yaml.Node, error) {
+		op := Operation{Op: "replace", Path: pointer}
+		current, err := t.resolve(pointer)
+		if err != nil {
+			return op, nil, err
+		}
+		value, err := fn(copyNode(current))
+		if err == nil && value == nil {
+			err = fmt.Errorf("transform of %q returned no value", pointer)
+		}
+		return op, value, err
+	})
+	return err
+}
+
+// refresh evaluates the subscriptions a change affects again and returns those whose results it changed
+func (t *Tracker) refresh(affects func(*jsonpath.JSONPath) bool) []notification {
+	var notifications []notification
+	for _, s := range t.subscriptions {
+		if !affects(s.path) {
+			continue
+		}
+		results, values := evaluate(s.path, t.root)
+		change := Change{Results: results}
+		for _, result := range results {
+			old, ok := s.values[result.Path]
+			if !ok {
+				change.Added = append(change.Added, result)
+			} else if !bytes.Equal(old, values[result.Path]) {
+				change.Changed = append(change.Changed, result)
+			}
+		}
+		for _, result := range s.results {
+			if _, ok := values[result.Path]; !ok {
+				change.Removed = append(change.Removed, result)
+			}
+		}
+		s.results, s.values = results, values
+		if !change.IsEmpty() {
+			notifications = append(notifications, notification{subscription: s, change: change})
+		}
+	}
+	return notifications
+}
+
+// evaluate returns the results of path with the encoded value of each
+func evaluate(path *jsonpath.JSONPath, root *yaml.Node) ([]jsonpath.Result, map[string][]byte) {
+	results := path.QueryResults(root)
+	values := make(map[string][]byte, len(results))
+	for _, result := range results {
+		values[result.Path] = encode(result.Node)
+	}
+	return results, values
+}
+
+func encode(node *yaml.Node) []byte {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	if err := enc.Encode(node); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,111 @@
+package patch_test
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/patch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func paths(results []jsonpath.Result) []string {
+	var out []string
+	for _, result := range results {
+		out = append(out, result.Path)
+	}
+	return out
+}
+
+func TestTracker_Subscribe(t *testing.T) {
+	tracker := newTracker(t)
+	var ids, titles []patch.Change
+	idSub := tracker.Subscribe(jsonpath.MustNewPath(`$.paths.*.*.operationId`), func(c patch.Change) {
+		ids = append(ids, c)
+	})
+	tracker.Subscribe(jsonpath.MustNewPath(`$.info.title`), func(c patch.Change) {
+		titles = append(titles, c)
+	})
+	assert.Equal(t, []string{"$['paths']['/pets']['get']['operationId']", "$['paths']['/store']['get']['operationId']"}, paths(idSub.Results()))
+
+	// adding an operation adds a result
+	require.NoError(t, tracker.Set("/paths/~1pets/post", &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "operationId"}, {Kind: yaml.ScalarNode, Value: "addPet"},
+	}}))
+	require.Len(t, ids, 1)
+	assert.Equal(t, []string{"$['paths']['/pets']['post']['operationId']"}, paths(ids[0].Added))
+	assert.Len(t, ids[0].Results, 3)
+	assert.Empty(t, titles)
+
+	// changing an id in place changes a result
+	require.NoError(t, tracker.Transform("/paths/~1store/get", func(node *yaml.Node) (*yaml.Node, error) {
+		node.Content[1].Value = "listInventory"
+		return node, nil
+	}))
+	require.Len(t, ids, 2)
+	assert.Equal(t, []string{"$['paths']['/store']['get']['operationId']"}, paths(ids[1].Changed))
+	assert.Equal(t, "listInventory", ids[1].Changed[0].Node.Value)
+
+	// an edit that leaves the results as they were is not reported
+	require.NoError(t, tracker.Set("/info/title", &yaml.Node{Kind: yaml.ScalarNode, Value: "pets"}))
+	assert.Empty(t, titles)
+	require.NoError(t, tracker.Set("/info/title", &yaml.Node{Kind: yaml.ScalarNode, Value: "cats"}))
+	require.Len(t, titles, 1)
+	assert.Equal(t, "cats", titles[0].Changed[0].Node.Value)
+
+	// removing a path item removes a result
+	require.NoError(t, tracker.Delete("/paths/~1store"))
+	require.Len(t, ids, 3)
+	assert.Equal(t, []string{"$['paths']['/store']['get']['operationId']"}, paths(ids[2].Removed))
+	assert.Len(t, idSub.Results(), 2)
+
+	idSub.Cancel()
+	require.NoError(t, tracker.Delete("/paths/~1pets"))
+	assert.Len(t, ids, 3)
+}
+
+func TestTracker_SubscribeApply(t *testing.T) {
+	tracker := newTracker(t)
+	var tags []patch.Change
+	tracker.Subscribe(jsonpath.MustNewPath(`$.tags[0].name`), func(c patch.Change) {
+		tags = append(tags, c)
+	})
+	_, err := tracker.Apply(patch.Operation{Op: "add", Path: "/tags/0", Value: []byte(`{"name": "users"}`)})
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	assert.Equal(t, "users", tags[0].Changed[0].Node.Value)
+}
+
+func TestTracker_SubscribeReentrant(t *testing.T) {
+	tracker := newTracker(t)
+	// a callback may change the document, and is told of its own changes
+	var titles []string
+	tracker.Subscribe(jsonpath.MustNewPath(`$.info.title`), func(c patch.Change) {
+		title := c.Results[0].Node.Value
+		titles = append(titles, title)
+		if title != "PETS" {
+			require.NoError(t, tracker.Set("/info/title", &yaml.Node{Kind: yaml.ScalarNode, Value: "PETS"}))
+		}
+	})
+	require.NoError(t, tracker.Set("/info/title", &yaml.Node{Kind: yaml.ScalarNode, Value: "cats"}))
+	assert.Equal(t, []string{"cats", "PETS"}, titles)
+}
+
+func TestTracker_MutationErrors(t *testing.T) {
+	tracker := newTracker(t)
+	var changes []patch.Change
+	tracker.Subscribe(jsonpath.MustNewPath(`$..*`), func(c patch.Change) {
+		changes = append(changes, c)
+	})
+	assert.Error(t, tracker.Set("/servers/0", &yaml.Node{Kind: yaml.ScalarNode, Value: "x"}))
+	assert.Error(t, tracker.Set("/info/title", nil))
+	assert.Error(t, tracker.Delete("/info/summary"))
+	assert.Error(t, tracker.Transform("/info/summary", func(node *yaml.Node) (*yaml.Node, error) {
+		return node, nil
+	}))
+	assert.Error(t, tracker.Transform("/info/title", func(node *yaml.Node) (*yaml.Node, error) {
+		return nil, nil
+	}))
+	assert.Empty(t, changes)
+}
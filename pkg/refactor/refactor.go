@@ -0,0 +1,262 @@
+// Package refactor moves and renames the members of YAML and JSON documents, such as OpenAPI
+// components, rewriting the local $ref values and overlay targets that point at or into them, so
+// that they keep referring to the same nodes.
+//
+//	changes, err := refactor.Move(&doc, `$.components.schemas.Pet`, `$.components.schemas.Animal`, o)
+//	for _, change := range changes {
+//		fmt.Println(change) // reference at $['paths']['/pets']['get']...['$ref']: #/components/schemas/Pet -> ...
+//	}
+package refactor
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/overlay"
+	"go.yaml.in/yaml/v4"
+)
+
+// ChangeKind describes what a move changed.
+type ChangeKind int
+
+const (
+	// ChangeMoved is the member that was moved or renamed.
+	ChangeMoved ChangeKind = iota
+	// ChangeReference is a $ref value that was rewritten.
+	ChangeReference
+	// ChangeTarget is the target of an overlay action that was rewritten.
+	ChangeTarget
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeMoved:
+		return "moved"
+	case ChangeReference:
+		return "reference"
+	case ChangeTarget:
+		return "target"
+	}
+	return "unknown"
+}
+
+// Change is a location a move changed.
+type Change struct {
+	Kind ChangeKind
+	// Path is the normalized path of the moved member or of the $ref value after the move, or for a
+	// target, the path of its action in the overlay, such as $['actions'][2]['target'].
+	Path string
+	// Overlay is the index of the overlay, among those given to Move, whose target changed.
+	Overlay int
+	// Old and New are the path of the moved member, the $ref value or the target before and after
+	// the move.
+	Old string
+	New string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s at %s: %s -> %s", c.Kind, c.Path, c.Old, c.New)
+}
+
+// Move moves the member of the document rooted at root that from selects to to, and returns the
+// locations it changed. Both paths must select a single member by name, such as
+// $.components.schemas.Pet. The mapping the member is moved into must exist and not have a member
+// named as to does; a member moved within its mapping is renamed in place, keeping its position.
+//
+// Local $ref values in the document that point at the member or into it, such as
+// #/components/schemas/Pet/properties/id, are rewritten to point at its new location, as are the
+// targets of the actions of overlays that begin with the path of the member in any notation.
+// References to other documents, and targets reaching the member through wildcards, descendant
+// segments or filters, are left as they are.
+func Move(root *yaml.Node, from string, to string, overlays ...*overlay.Overlay) ([]Change, error) {
+	fromPath, err := jsonpath.NewPath(from)
+	if err != nil {
+		return nil, err
+	}
+	toPath, err := jsonpath.NewPath(to)
+	if err != nil {
+		return nil, err
+	}
+	source, name, err := member(root, fromPath)
+	if err != nil {
+		return nil, fmt.Errorf("from: %w", err)
+	}
+	dest, newName, err := member(root, toPath)
+	if err != nil {
+		return nil, fmt.Errorf("to: %w", err)
+	}
+	if child(dest, newName) >= 0 {
+		return nil, fmt.Errorf("to: %s already exists", to)
+	}
+	i := child(source, name)
+	if i < 0 {
+		return nil, fmt.Errorf("from: %s selects nothing", from)
+	}
+	if within(source.Content[i+1], dest) {
+		return nil, errors.New("cannot move a member into itself")
+	}
+
+	oldPointer := pointer(root, source.Content[i+1])
+	oldPath := jsonpath.NormalizedPaths(root)[source.Content[i+1]]
+	key, value := source.Content[i], source.Content[i+1]
+	key.Value = newName
+	if dest != source {
+		source.Content = append(source.Content[:i], source.Content[i+2:]...)
+		dest.Content = append(dest.Content, key, value)
+	}
+	newPointer := pointer(root, value)
+	paths := jsonpath.NormalizedPaths(root)
+
+	changes := []Change{{Kind: ChangeMoved, Path: paths[value], Old: oldPath, New: paths[value]}}
+	for _, ref := range references(root) {
+		rewritten, ok := rebasePointer(ref.Value, oldPointer, newPointer)
+		if !ok {
+			continue
+		}
+		changes = append(changes, Change{Kind: ChangeReference, Path: paths[ref], Old: ref.Value, New: rewritten})
+		ref.Value = rewritten
+	}
+	for n, o := range overlays {
+		for a := range o.Actions {
+			action := &o.Actions[a]
+			target, err := jsonpath.NewPath(action.Target)
+			if err != nil {
+				continue
+			}
+			rebased, ok := target.Rebase(fromPath, toPath)
+			if !ok {
+				continue
+			}
+			changes = append(changes, Change{
+				Kind:    ChangeTarget,
+				Path:    fmt.Sprintf("$['actions'][%d]['target']", a),
+				Overlay: n,
+				Old:     action.Target,
+				New:     rebased.String(),
+			})
+			action.Target = rebased.String()
+		}
+	}
+	return changes, nil
+}
+
+// member returns the mapping holding the member path selects and the member's name
+func member(root *yaml.Node, path *jsonpath.JSONPath) (*yaml.Node, string, error) {
+	parentPath, name, ok := path.SplitMember()
+	if !ok {
+		return nil, "", fmt.Errorf("%s does not select a member by name", path)
+	}
+	parents := parentPath.Query(root)
+	if len(parents) != 1 || parents[0].Kind != yaml.MappingNode {
+		return nil, "", fmt.Errorf("%s does not select a single mapping", parentPath)
+	}
+	return parents[0], name, nil
+}
+
+// child returns the index of the key of the member of mapping named name, or -1
+func child(mapping *yaml.Node, name string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i] != nil && mapping.Content[i].Value == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// within reports whether node is ancestor or below it
+func within(ancestor *yaml.Node, node *yaml.Node) bool {
+	if ancestor == node {
+		return true
+	}
+	for _, child := range ancestor.Content {
+		if child != nil && within(child, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// pointer returns the JSON pointer of node in the document rooted at root, such as
+// /components/schemas/Pet
+func pointer(root *yaml.Node, node *yaml.Node) string {
+	if root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		root = root.Content[0]
+	}
+	tokens, _ := tokensOf(root, node, nil)
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/")
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+func tokensOf(parent *yaml.Node, node *yaml.Node, tokens []string) ([]string, bool) {
+	if parent == node {
+		return tokens, true
+	}
+	switch parent.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(parent.Content); i += 2 {
+			if parent.Content[i] == nil || parent.Content[i+1] == nil {
+				continue
+			}
+			if found, ok := tokensOf(parent.Content[i+1], node, append(tokens, parent.Content[i].Value)); ok {
+				return found, true
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range parent.Content {
+			if child == nil {
+				continue
+			}
+			if found, ok := tokensOf(child, node, append(tokens, fmt.Sprint(i))); ok {
+				return found, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// references returns the scalar values of the $ref members of the document, in document order
+func references(root *yaml.Node) []*yaml.Node {
+	var refs []*yaml.Node
+	var find func(node *yaml.Node)
+	find = func(node *yaml.Node) {
+		if node == nil {
+			return
+		}
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i] != nil && node.Content[i].Value == "$ref" && node.Content[i+1] != nil && node.Content[i+1].Kind == yaml.ScalarNode {
+					refs = append(refs, node.Content[i+1])
+				}
+			}
+		}
+		for _, child := range node.Content {
+			find(child)
+		}
+	}
+	find(root)
+	return refs
+}
+
+// rebasePointer rewrites a local reference to oldPointer, or to a location below it, to refer to
+// newPointer instead
+func rebasePointer(ref string, oldPointer string, newPointer string) (string, bool) {
+	fragment, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return "", false
+	}
+	if unescaped, err := url.PathUnescape(fragment); err == nil {
+		fragment = unescaped
+	}
+	rest, ok := strings.CutPrefix(fragment, oldPointer)
+	if !ok || rest != "" && !strings.HasPrefix(rest, "/") {
+		return "", false
+	}
+	return "#" + newPointer + rest, true
+}
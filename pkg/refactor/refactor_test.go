@@ -0,0 +1,162 @@
+package refactor_test
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/overlay"
+	"github.com/pb33f/jsonpath/pkg/refactor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const spec = `openapi: 3.1.0
+paths:
+  /pets:
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+components:
+  schemas:
+    Pet:
+      properties:
+        id:
+          type: integer
+        owner:
+          $ref: '#/components/schemas/Owner'
+    PetId:
+      $ref: '#/components/schemas/Pet/properties/id'
+    Pets:
+      items:
+        $ref: '#/components/schemas/PetStore'
+    Owner:
+      type: object
+    Remote:
+      $ref: 'other.yaml#/components/schemas/Pet'
+  legacy: {}
+`
+
+const targets = `overlay: 1.0.0
+info:
+  title: pets
+  version: 1.0.0
+actions:
+  - target: $.components.schemas.Pet.properties
+    update:
+      name:
+        type: string
+  - target: $['components']['schemas']['Pet']
+    update:
+      description: A pet
+  - target: $.components.schemas.Pets
+    remove: true
+  - target: $.components.schemas[*]
+    update:
+      x-checked: true
+`
+
+func parse(t *testing.T) (*yaml.Node, *overlay.Overlay) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(spec), &root))
+	var o overlay.Overlay
+	require.NoError(t, yaml.Unmarshal([]byte(targets), &o))
+	return &root, &o
+}
+
+func values(nodes []*yaml.Node) []string {
+	var out []string
+	for _, node := range nodes {
+		out = append(out, node.Value)
+	}
+	return out
+}
+
+func TestMove_Rename(t *testing.T) {
+	root, o := parse(t)
+	changes, err := refactor.Move(root, `$.components.schemas.Pet`, `$.components.schemas.Animal`, o)
+	require.NoError(t, err)
+
+	assert.Equal(t, []refactor.Change{
+		{Kind: refactor.ChangeMoved, Path: "$['components']['schemas']['Animal']", Old: "$['components']['schemas']['Pet']", New: "$['components']['schemas']['Animal']"},
+		{
+			Kind: refactor.ChangeReference,
+			Path: "$['paths']['/pets']['get']['responses']['200']['content']['application/json']['schema']['$ref']",
+			Old:  "#/components/schemas/Pet",
+			New:  "#/components/schemas/Animal",
+		},
+		{
+			Kind: refactor.ChangeReference,
+			Path: "$['components']['schemas']['PetId']['$ref']",
+			Old:  "#/components/schemas/Pet/properties/id",
+			New:  "#/components/schemas/Animal/properties/id",
+		},
+		{
+			Kind: refactor.ChangeTarget,
+			Path: "$['actions'][0]['target']",
+			Old:  "$.components.schemas.Pet.properties",
+			New:  "$.components.schemas.Animal.properties",
+		},
+		{
+			Kind: refactor.ChangeTarget,
+			Path: "$['actions'][1]['target']",
+			Old:  "$['components']['schemas']['Pet']",
+			New:  "$.components.schemas.Animal",
+		},
+	}, changes)
+
+	// renamed in place, keeping its position
+	schemas := jsonpath.MustNewPath(`$.components.schemas`).Query(root)[0]
+	assert.Equal(t, "Animal", schemas.Content[0].Value)
+	// PetStore and the reference to another document are not references to Pet
+	assert.Equal(t, []string{
+		"#/components/schemas/Owner",
+		"#/components/schemas/Animal/properties/id",
+		"#/components/schemas/PetStore",
+		"other.yaml#/components/schemas/Pet",
+	}, values(jsonpath.MustNewPath(`$.components..['$ref']`).Query(root)))
+	assert.Equal(t, "$.components.schemas.Pets", o.Actions[2].Target)
+	assert.Equal(t, "$.components.schemas[*]", o.Actions[3].Target)
+}
+
+func TestMove_Relocate(t *testing.T) {
+	root, _ := parse(t)
+	changes, err := refactor.Move(root, `$.components.schemas.Owner`, `$.components.legacy['Owner/v1']`)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "$['components']['legacy']['Owner/v1']", changes[0].Path)
+	assert.Equal(t, "$['components']['schemas']['Pet']['properties']['owner']['$ref']", changes[1].Path)
+	assert.Equal(t, "#/components/legacy/Owner~1v1", changes[1].New)
+
+	var doc map[string]any
+	require.NoError(t, root.Decode(&doc))
+	components := doc["components"].(map[string]any)
+	assert.NotContains(t, components["schemas"], "Owner")
+	assert.Equal(t, map[string]any{"Owner/v1": map[string]any{"type": "object"}}, components["legacy"])
+	assert.Equal(t, "moved at $['components']['legacy']['Owner/v1']: $['components']['schemas']['Owner'] -> $['components']['legacy']['Owner/v1']", changes[0].String())
+}
+
+func TestMove_Errors(t *testing.T) {
+	tests := []struct {
+		from, to string
+		err      string
+	}{
+		{from: `$.components.schemas.Missing`, to: `$.components.schemas.Other`, err: "selects nothing"},
+		{from: `$.components.schemas.Pet`, to: `$.components.schemas.Owner`, err: "already exists"},
+		{from: `$.components.schemas.*`, to: `$.components.schemas.Other`, err: "does not select a member by name"},
+		{from: `$.components.schemas.Pet`, to: `$.missing.Pet`, err: "does not select a single mapping"},
+		{from: `$.components.schemas.Pet`, to: `$.components.schemas.Pet.properties.Pet`, err: "into itself"},
+		{from: `$.components[`, to: `$.components.Other`, err: "unexpected"},
+	}
+	for _, test := range tests {
+		t.Run(test.from+" "+test.to, func(t *testing.T) {
+			root, _ := parse(t)
+			_, err := refactor.Move(root, test.from, test.to)
+			assert.ErrorContains(t, err, test.err)
+		})
+	}
+}
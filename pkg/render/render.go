@@ -0,0 +1,364 @@
+// Package render writes query results back out as YAML or JSON, with control over how numbers
+// are formatted so rendered results can match the literals of the source document.
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"go.yaml.in/yaml/v4"
+)
+
+// Option configures how nodes are rendered.
+type Option func(*options)
+
+type options struct {
+	sourceLiterals bool
+	floatFormat    byte
+	floatPrecision int
+	integerBits    int
+	indent         int
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		sourceLiterals: true,
+		floatFormat:    'g',
+		floatPrecision: -1,
+		integerBits:    64,
+		indent:         2,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithSourceLiterals controls whether numbers keep the literal they were written with in the source
+// document (e.g. 10.0 stays 10.0) when it is valid in the output format. Enabled by default;
+// when disabled every number is reformatted.
+func WithSourceLiterals(enabled bool) Option {
+	return func(o *options) {
+		o.sourceLiterals = enabled
+	}
+}
+
+// WithFloatFormat sets the strconv format ('f' for plain decimals, 'e' for scientific notation, 'g'
+// for whichever is shorter) and precision (-1 for the fewest digits that round-trip) used for
+// floats that are reformatted. The default is 'g' with precision -1.
+func WithFloatFormat(format byte, precision int) Option {
+	return func(o *options) {
+		o.floatFormat = format
+		o.floatPrecision = precision
+	}
+}
+
+// WithIntegerWidth sets the width in bits of the signed integers the consumer can represent, e.g. 53
+// for JavaScript. Integers outside that range are rendered as floats. The default is 64.
+func WithIntegerWidth(bits int) Option {
+	return func(o *options) {
+		o.integerBits = bits
+	}
+}
+
+// WithIndent sets the number of spaces used for each level of indentation. The default is 2.
+func WithIndent(spaces int) Option {
+	return func(o *options) {
+		o.indent = spaces
+	}
+}
+
+// YAML writes node as a YAML document. The node is not modified. To render a nodelist, wrap it in a
+// sequence node.
+func YAML(w io.Writer, node *yaml.Node, opts ...Option) error {
+	o := newOptions(opts...)
+	formatted, err := o.formatTree(node, make(map[*yaml.Node]*yaml.Node))
+	if err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(o.indent)
+	if err := enc.Encode(formatted); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// JSON writes node as a JSON value followed by a newline. Aliases are expanded, and non-finite
+// floats, which JSON cannot represent, are an error.
+func JSON(w io.Writer, node *yaml.Node, opts ...Option) error {
+	o := newOptions(opts...)
+	var buf bytes.Buffer
+	if err := o.writeJSON(&buf, node, 0); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Results writes query results in the JSON form that compliance suites and comparison tools use
+// to check implementations against each other: an object with the values of the results in order,
+// and their normalized paths in the same order, followed by a newline.
+//
+//	{"values": ["listUsers"], "paths": ["$['paths']['/users']['get']['operationId']"]}
+func Results(w io.Writer, results []jsonpath.Result, opts ...Option) error {
+	o := newOptions(opts...)
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	o.newline(&buf, 1)
+	buf.WriteString(`"values":`)
+	if o.indent > 0 {
+		buf.WriteByte(' ')
+	}
+	err := o.writeJSONArray(&buf, len(results), 1, func(i int) error {
+		return o.writeJSON(&buf, results[i].Node, 2)
+	})
+	if err != nil {
+		return err
+	}
+	buf.WriteByte(',')
+	o.newline(&buf, 1)
+	buf.WriteString(`"paths":`)
+	if o.indent > 0 {
+		buf.WriteByte(' ')
+	}
+	_ = o.writeJSONArray(&buf, len(results), 1, func(i int) error {
+		writeJSONString(&buf, results[i].Path)
+		return nil
+	})
+	o.newline(&buf, 0)
+	buf.WriteString("}\n")
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// formatTree copies the tree rooted at node, reformatting its numbers. copies maps original nodes to
+// their copies so anchors and aliases keep pointing at each other.
+func (o *options) formatTree(node *yaml.Node, copies map[*yaml.Node]*yaml.Node) (*yaml.Node, error) {
+	if node == nil {
+		return nil, nil
+	}
+	if c, ok := copies[node]; ok {
+		return c, nil
+	}
+	c := *node
+	copies[node] = &c
+	if node.Kind == yaml.ScalarNode {
+		value, err := o.formatNumber(node, false)
+		if err != nil {
+			return nil, err
+		}
+		if node.ShortTag() == "!!int" && strings.ContainsAny(value, ".eE") {
+			// wider than the integer width, so it was rendered as a float
+			c.Tag = "!!float"
+		}
+		c.Value = value
+	}
+	if node.Alias != nil {
+		alias, err := o.formatTree(node.Alias, copies)
+		if err != nil {
+			return nil, err
+		}
+		c.Alias = alias
+	}
+	if node.Content != nil {
+		c.Content = make([]*yaml.Node, len(node.Content))
+		for i, child := range node.Content {
+			formatted, err := o.formatTree(child, copies)
+			if err != nil {
+				return nil, err
+			}
+			c.Content[i] = formatted
+		}
+	}
+	return &c, nil
+}
+
+func (o *options) writeJSON(buf *bytes.Buffer, node *yaml.Node, depth int) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return o.writeJSON(buf, node.Content[0], depth)
+	case yaml.AliasNode:
+		if depth > 1000 {
+			return fmt.Errorf("alias nesting too deep at line %d", node.Line)
+		}
+		return o.writeJSON(buf, node.Alias, depth)
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			o.newline(buf, depth+1)
+			writeJSONString(buf, node.Content[i].Value)
+			buf.WriteByte(':')
+			if o.indent > 0 {
+				buf.WriteByte(' ')
+			}
+			if err := o.writeJSON(buf, node.Content[i+1], depth+1); err != nil {
+				return err
+			}
+		}
+		o.newline(buf, depth)
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		return o.writeJSONArray(buf, len(node.Content), depth, func(i int) error {
+			return o.writeJSON(buf, node.Content[i], depth+1)
+		})
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!int", "!!float":
+			value, err := o.formatNumber(node, true)
+			if err != nil {
+				return err
+			}
+			buf.WriteString(value)
+		case "!!bool":
+			buf.WriteString(strconv.FormatBool(strings.EqualFold(node.Value, "true")))
+		case "!!null":
+			buf.WriteString("null")
+		default:
+			writeJSONString(buf, node.Value)
+		}
+	}
+	return nil
+}
+
+// writeJSONArray writes an array of n elements, written by element, at depth
+func (o *options) writeJSONArray(buf *bytes.Buffer, n int, depth int, element func(i int) error) error {
+	if n == 0 {
+		buf.WriteString("[]")
+		return nil
+	}
+	buf.WriteByte('[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		o.newline(buf, depth+1)
+		if err := element(i); err != nil {
+			return err
+		}
+	}
+	o.newline(buf, depth)
+	buf.WriteByte(']')
+	return nil
+}
+
+func (o *options) newline(buf *bytes.Buffer, depth int) {
+	if o.indent <= 0 {
+		return
+	}
+	buf.WriteByte('\n')
+	buf.WriteString(strings.Repeat(" ", depth*o.indent))
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	// Encode terminates the value with a newline
+	buf.Truncate(buf.Len() - 1)
+}
+
+// jsonNumber matches the number grammar of RFC 8259
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// formatNumber returns the rendered literal of a scalar, which only changes for integers and floats.
+func (o *options) formatNumber(node *yaml.Node, forJSON bool) (string, error) {
+	switch node.ShortTag() {
+	case "!!int":
+		i, err := strconv.ParseInt(node.Value, 0, 64)
+		if err != nil {
+			// too wide for int64: render it as a float
+			return o.formatFloat(node, forJSON)
+		}
+		if o.integerBits > 0 && o.integerBits < 64 {
+			limit := int64(1) << (o.integerBits - 1)
+			if i < -limit || i > limit-1 {
+				return o.formatFloat(node, forJSON)
+			}
+		}
+		if o.keepLiteral(node.Value, forJSON) {
+			return node.Value, nil
+		}
+		return strconv.FormatInt(i, 10), nil
+	case "!!float":
+		return o.formatFloat(node, forJSON)
+	}
+	return node.Value, nil
+}
+
+func (o *options) formatFloat(node *yaml.Node, forJSON bool) (string, error) {
+	if node.ShortTag() == "!!float" && o.keepLiteral(node.Value, forJSON) {
+		return node.Value, nil
+	}
+	f, err := parseFloat(node.Value)
+	if err != nil {
+		return "", fmt.Errorf("invalid number %q at line %d, column %d", node.Value, node.Line, node.Column)
+	}
+	switch {
+	case math.IsInf(f, 1):
+		if forJSON {
+			return "", fmt.Errorf("%q at line %d, column %d cannot be represented in JSON", node.Value, node.Line, node.Column)
+		}
+		return ".inf", nil
+	case math.IsInf(f, -1):
+		if forJSON {
+			return "", fmt.Errorf("%q at line %d, column %d cannot be represented in JSON", node.Value, node.Line, node.Column)
+		}
+		return "-.inf", nil
+	case math.IsNaN(f):
+		if forJSON {
+			return "", fmt.Errorf("%q at line %d, column %d cannot be represented in JSON", node.Value, node.Line, node.Column)
+		}
+		return ".nan", nil
+	}
+	s := strconv.FormatFloat(f, o.floatFormat, o.floatPrecision, 64)
+	if !forJSON && node.ShortTag() == "!!float" && !strings.ContainsAny(s, ".eE") {
+		// keep the value a float when the YAML is read back
+		s += ".0"
+	}
+	return s, nil
+}
+
+// keepLiteral reports whether a source literal can be written out unchanged.
+func (o *options) keepLiteral(literal string, forJSON bool) bool {
+	if !o.sourceLiterals {
+		return false
+	}
+	return !forJSON || jsonNumber.MatchString(literal)
+}
+
+// parseFloat parses YAML float and integer literals, including .inf and .nan.
+func parseFloat(s string) (float64, error) {
+	switch strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(s, "+"), "-")) {
+	case ".inf":
+		if strings.HasPrefix(s, "-") {
+			return math.Inf(-1), nil
+		}
+		return math.Inf(1), nil
+	case ".nan":
+		return math.NaN(), nil
+	}
+	if i, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return float64(i), nil
+	}
+	return strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64)
+}
@@ -0,0 +1,122 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const numbersDoc = `
+whole: 10.0
+ratio: 0.000015
+big: 12345678901234567
+hex: 0x1F
+count: 7
+name: api
+flag: true
+none: null
+list: [1, 2.50]
+`
+
+func parse(t *testing.T, src string) *yaml.Node {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(src), &node))
+	return &node
+}
+
+func TestJSON(t *testing.T) {
+	t.Run("source literals", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, JSON(&buf, parse(t, numbersDoc), WithIndent(0)))
+		assert.Equal(t, `{"whole":10.0,"ratio":0.000015,"big":12345678901234567,"hex":31,"count":7,"name":"api","flag":true,"none":null,"list":[1,2.50]}`+"\n", buf.String())
+	})
+
+	t.Run("reformatted", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, JSON(&buf, parse(t, numbersDoc), WithIndent(0), WithSourceLiterals(false), WithFloatFormat('f', -1)))
+		assert.Equal(t, `{"whole":10,"ratio":0.000015,"big":12345678901234567,"hex":31,"count":7,"name":"api","flag":true,"none":null,"list":[1,2.5]}`+"\n", buf.String())
+	})
+
+	t.Run("scientific notation", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, JSON(&buf, parse(t, "ratio: 0.000015"), WithIndent(0), WithSourceLiterals(false), WithFloatFormat('e', 2)))
+		assert.Equal(t, `{"ratio":1.50e-05}`+"\n", buf.String())
+	})
+
+	t.Run("integer width", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, JSON(&buf, parse(t, "[12345678901234567, 42]"), WithIndent(0), WithIntegerWidth(53)))
+		assert.Equal(t, `[1.2345678901234568e+16,42]`+"\n", buf.String())
+	})
+
+	t.Run("indented with aliases", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, JSON(&buf, parse(t, "a: &x {b: \"<1>\"}\nc: *x\nd: []")))
+		assert.Equal(t, "{\n  \"a\": {\n    \"b\": \"<1>\"\n  },\n  \"c\": {\n    \"b\": \"<1>\"\n  },\n  \"d\": []\n}\n", buf.String())
+	})
+
+	t.Run("non-finite floats", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.Error(t, JSON(&buf, parse(t, "x: .inf")))
+	})
+}
+
+func TestYAML(t *testing.T) {
+	t.Run("source literals are unchanged", func(t *testing.T) {
+		var buf bytes.Buffer
+		node := parse(t, "whole: 10.0\nhex: 0x1F\n")
+		require.NoError(t, YAML(&buf, node))
+		assert.Equal(t, "whole: 10.0\nhex: 0x1F\n", buf.String())
+	})
+
+	t.Run("reformatted floats stay floats", func(t *testing.T) {
+		var buf bytes.Buffer
+		node := parse(t, "whole: 10.0\nhex: 0x1F\nsmall: 1.5e-3\nbad: .NaN\n")
+		require.NoError(t, YAML(&buf, node, WithSourceLiterals(false)))
+		assert.Equal(t, "whole: 10.0\nhex: 31\nsmall: 0.0015\nbad: .nan\n", buf.String())
+
+		// the input is left untouched
+		assert.Equal(t, "10.0", node.Content[0].Content[1].Value)
+	})
+
+	t.Run("wide integers become floats", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, YAML(&buf, parse(t, "id: 12345678901234567\n"), WithIntegerWidth(53)))
+		assert.Equal(t, "id: 1.2345678901234568e+16\n", buf.String())
+	})
+}
+
+func TestResults(t *testing.T) {
+	root := parse(t, "store:\n  book:\n    - {title: A, price: 8.50}\n    - {title: B, price: 12}\n")
+	results := jsonpath.MustNewPath(`$.store.book[?@.price < 10, 1].title`).QueryResults(root)
+
+	var buf bytes.Buffer
+	require.NoError(t, Results(&buf, results, WithIndent(0)))
+	assert.Equal(t, `{"values":["A","B"],"paths":["$['store']['book'][0]['title']","$['store']['book'][1]['title']"]}`+"\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, Results(&buf, jsonpath.MustNewPath(`$.store.book[0]`).QueryResults(root)))
+	assert.Equal(t, `{
+  "values": [
+    {
+      "title": "A",
+      "price": 8.50
+    }
+  ],
+  "paths": [
+    "$['store']['book'][0]"
+  ]
+}
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, Results(&buf, nil))
+	assert.Equal(t, "{\n  \"values\": [],\n  \"paths\": []\n}\n", buf.String())
+
+	buf.Reset()
+	assert.Error(t, Results(&buf, jsonpath.MustNewPath(`$.x`).QueryResults(parse(t, "x: .inf"))))
+}
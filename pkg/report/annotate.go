@@ -0,0 +1,123 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// AnnotationMarker starts every comment written by Annotate, so RemoveAnnotations can tell them
+// apart from comments written by people.
+const AnnotationMarker = "# jsonpath:"
+
+// Annotate writes each finding into the document rooted at root as a comment next to the matched
+// node, producing an annotated copy of the source for review. Annotations written by an earlier call
+// are removed first, so annotating again reflects only the current findings. Existing comments are
+// kept, and findings for nodes outside the document are ignored.
+//
+// Scalars and flow collections get a line comment. A block mapping or sequence that is the value of
+// a key gets the comment on its key, and any other block collection gets a head comment, as those
+// are the places the comment survives encoding.
+func Annotate(root *yaml.Node, findings []Finding) {
+	RemoveAnnotations(root)
+
+	keys := make(map[*yaml.Node]*yaml.Node)
+	inDocument := make(map[*yaml.Node]bool)
+	walkNodes(root, func(node *yaml.Node) {
+		inDocument[node] = true
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				keys[node.Content[i+1]] = node.Content[i]
+			}
+		}
+	})
+
+	var order []*yaml.Node
+	messages := make(map[*yaml.Node][]string)
+	for _, finding := range findings {
+		node := finding.Result.Node
+		if node == nil || !inDocument[node] {
+			continue
+		}
+		if _, ok := messages[node]; !ok {
+			order = append(order, node)
+		}
+		messages[node] = append(messages[node], fmt.Sprintf("%s %s: %s", finding.Severity, finding.RuleID, finding.Message))
+	}
+
+	for _, node := range order {
+		lines := messages[node]
+		if node.Kind != yaml.MappingNode && node.Kind != yaml.SequenceNode || node.Style&yaml.FlowStyle != 0 || len(node.Content) == 0 {
+			node.LineComment = appendLineComment(node.LineComment, lines)
+		} else if key, ok := keys[node]; ok {
+			key.LineComment = appendLineComment(key.LineComment, lines)
+		} else {
+			var head []string
+			if node.HeadComment != "" {
+				head = append(head, node.HeadComment)
+			}
+			for _, line := range lines {
+				head = append(head, AnnotationMarker+" "+line)
+			}
+			node.HeadComment = strings.Join(head, "\n")
+		}
+	}
+}
+
+// RemoveAnnotations removes every comment written by Annotate from the document rooted at root,
+// leaving other comments in place.
+func RemoveAnnotations(root *yaml.Node) {
+	walkNodes(root, func(node *yaml.Node) {
+		node.LineComment = removeLineAnnotation(node.LineComment)
+		node.HeadComment = removeAnnotationLines(node.HeadComment)
+		node.FootComment = removeAnnotationLines(node.FootComment)
+	})
+}
+
+func appendLineComment(comment string, lines []string) string {
+	annotation := AnnotationMarker + " " + strings.Join(lines, "; ")
+	if comment == "" {
+		return annotation
+	}
+	return comment + " " + annotation
+}
+
+func removeLineAnnotation(comment string) string {
+	if strings.HasPrefix(comment, AnnotationMarker) {
+		return ""
+	}
+	if i := strings.Index(comment, " "+AnnotationMarker); i >= 0 {
+		return comment[:i]
+	}
+	return comment
+}
+
+func removeAnnotationLines(comment string) string {
+	if !strings.Contains(comment, AnnotationMarker) {
+		return comment
+	}
+	var kept []string
+	for _, line := range strings.Split(comment, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), AnnotationMarker) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+func walkNodes(node *yaml.Node, visit func(*yaml.Node)) {
+	seen := make(map[*yaml.Node]bool)
+	var walk func(*yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n == nil || seen[n] {
+			return
+		}
+		seen[n] = true
+		visit(n)
+		for _, child := range n.Content {
+			walk(child)
+		}
+	}
+	walk(node)
+}
@@ -0,0 +1,87 @@
+package report_test
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const annotateSpec = `openapi: 3.1.0 # version
+paths:
+  /users:
+    get:
+      summary: list users
+    post:
+      description: create a user
+tags:
+  - name: users
+  - [admin]
+`
+
+func annotateFindings(t *testing.T, root *yaml.Node, expr, rule, message string) []report.Finding {
+	path, err := jsonpath.NewPath(expr)
+	require.NoError(t, err)
+	return report.NewFindings(rule, message, report.SeverityWarning, "openapi.yaml", path.QueryResults(root))
+}
+
+func TestAnnotate(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(annotateSpec), &node))
+
+	var findings []report.Finding
+	findings = append(findings, annotateFindings(t, &node, `$.openapi`, "version", "use 3.1.1")...)
+	findings = append(findings, annotateFindings(t, &node, `$.paths.*[?(!@.summary)]`, "summary", "missing summary")...)
+	findings = append(findings, annotateFindings(t, &node, `$.paths.*[?(!@.summary)]`, "tags", "missing tags")...)
+	findings = append(findings, annotateFindings(t, &node, `$.tags[*]`, "tag-name", "check tag")...)
+	report.Annotate(&node, findings)
+
+	out, err := yaml.Marshal(&node)
+	require.NoError(t, err)
+	assert.Equal(t, `openapi: 3.1.0 # version # jsonpath: warning version: use 3.1.1
+paths:
+    /users:
+        get:
+            summary: list users
+        post: # jsonpath: warning summary: missing summary; warning tags: missing tags
+            description: create a user
+tags:
+    # jsonpath: warning tag-name: check tag
+    - name: users
+    - [admin] # jsonpath: warning tag-name: check tag
+`, string(out))
+
+	// annotations survive a round trip and can be removed again
+	var reparsed yaml.Node
+	require.NoError(t, yaml.Unmarshal(out, &reparsed))
+	report.RemoveAnnotations(&reparsed)
+	out, err = yaml.Marshal(&reparsed)
+	require.NoError(t, err)
+	assert.Equal(t, `openapi: 3.1.0 # version
+paths:
+    /users:
+        get:
+            summary: list users
+        post:
+            description: create a user
+tags:
+    - name: users
+    - [admin]
+`, string(out))
+}
+
+func TestAnnotate_ReplacesPreviousAnnotations(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(annotateSpec), &node))
+
+	report.Annotate(&node, annotateFindings(t, &node, `$.openapi`, "version", "use 3.1.1"))
+	report.Annotate(&node, annotateFindings(t, &node, `$.paths['/users'].get.summary`, "summary", "too short"))
+
+	out, err := yaml.Marshal(&node)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "openapi: 3.1.0 # version\n")
+	assert.Contains(t, string(out), "summary: list users # jsonpath: warning summary: too short\n")
+}
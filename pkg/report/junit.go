@@ -0,0 +1,122 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr,omitempty"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the findings as a JUnit XML report. Each rule becomes a test suite and
+// each finding a failed test case named after the normalized path of the matched node.
+func WriteJUnit(w io.Writer, name string, findings []Finding) error {
+	report := junitTestSuites{Name: name}
+	suiteIndex := make(map[string]int)
+	for _, finding := range findings {
+		index, ok := suiteIndex[finding.RuleID]
+		if !ok {
+			index = len(report.Suites)
+			suiteIndex[finding.RuleID] = index
+			report.Suites = append(report.Suites, junitTestSuite{Name: finding.RuleID})
+		}
+		suite := &report.Suites[index]
+		suite.Tests++
+		suite.Failures++
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      finding.Result.Path,
+			ClassName: finding.File,
+			Failure: &junitFailure{
+				Message: finding.Message,
+				Type:    finding.Severity.String(),
+				Text:    fmt.Sprintf("%s:%d:%d: %s", finding.File, finding.Result.Line, finding.Result.Column, finding.Message),
+			},
+		})
+		report.Tests++
+		report.Failures++
+	}
+	return writeXML(w, report)
+}
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// WriteCheckstyle writes the findings as a checkstyle XML report, grouped by file.
+func WriteCheckstyle(w io.Writer, findings []Finding) error {
+	report := checkstyleReport{Version: "4.3"}
+	fileIndex := make(map[string]int)
+	for _, finding := range findings {
+		index, ok := fileIndex[finding.File]
+		if !ok {
+			index = len(report.Files)
+			fileIndex[finding.File] = index
+			report.Files = append(report.Files, checkstyleFile{Name: finding.File})
+		}
+		message := finding.Message
+		if finding.Result.Path != "" {
+			message += " (" + finding.Result.Path + ")"
+		}
+		report.Files[index].Errors = append(report.Files[index].Errors, checkstyleError{
+			Line:     finding.Result.Line,
+			Column:   finding.Result.Column,
+			Severity: finding.Severity.String(),
+			Message:  message,
+			Source:   finding.RuleID,
+		})
+	}
+	return writeXML(w, report)
+}
+
+func writeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
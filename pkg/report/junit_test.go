@@ -0,0 +1,70 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	findings := queryFindings(t, `$.paths.*.*`, "operation-present", "operation found", report.SeverityError)
+	require.Len(t, findings, 2)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteJUnit(&buf, "jsonpath rules", findings))
+
+	var parsed struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Suites   []struct {
+			Name      string `xml:"name,attr"`
+			TestCases []struct {
+				Name    string `xml:"name,attr"`
+				Failure struct {
+					Message string `xml:"message,attr"`
+					Type    string `xml:"type,attr"`
+				} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &parsed))
+	assert.Equal(t, 2, parsed.Tests)
+	assert.Equal(t, 2, parsed.Failures)
+	require.Len(t, parsed.Suites, 1)
+	assert.Equal(t, "operation-present", parsed.Suites[0].Name)
+	require.Len(t, parsed.Suites[0].TestCases, 2)
+	assert.Equal(t, "$['paths']['/users']['get']", parsed.Suites[0].TestCases[0].Name)
+	assert.Equal(t, "operation found", parsed.Suites[0].TestCases[0].Failure.Message)
+	assert.Equal(t, "error", parsed.Suites[0].TestCases[0].Failure.Type)
+}
+
+func TestWriteCheckstyle(t *testing.T) {
+	findings := queryFindings(t, `$.paths.*[?(!@.summary)]`, "operation-summary", "missing summary", report.SeverityWarning)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteCheckstyle(&buf, findings))
+
+	var parsed struct {
+		Files []struct {
+			Name   string `xml:"name,attr"`
+			Errors []struct {
+				Line     int    `xml:"line,attr"`
+				Severity string `xml:"severity,attr"`
+				Message  string `xml:"message,attr"`
+				Source   string `xml:"source,attr"`
+			} `xml:"error"`
+		} `xml:"file"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &parsed))
+	require.Len(t, parsed.Files, 1)
+	assert.Equal(t, "openapi.yaml", parsed.Files[0].Name)
+	require.Len(t, parsed.Files[0].Errors, 1)
+	assert.Equal(t, 7, parsed.Files[0].Errors[0].Line)
+	assert.Equal(t, "warning", parsed.Files[0].Errors[0].Severity)
+	assert.Equal(t, "missing summary ($['paths']['/users']['post'])", parsed.Files[0].Errors[0].Message)
+	assert.Equal(t, "operation-summary", parsed.Files[0].Errors[0].Source)
+}
@@ -0,0 +1,64 @@
+// Package report renders JSONPath query results as findings in formats understood by
+// CI systems, code scanning tools and linters such as vacuum.
+package report
+
+import (
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+)
+
+// Severity describes how serious a finding is.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	}
+	return "unknown"
+}
+
+// Finding is a single query result that has been attributed to a rule.
+type Finding struct {
+	// RuleID identifies the rule (usually a JSONPath-based check) that produced the finding.
+	RuleID string
+	// Message is the human-readable description of the finding.
+	Message string
+	// Severity is the severity of the finding.
+	Severity Severity
+	// File is the path or URI of the document the result was found in.
+	File string
+	// Result is the matched node, including its normalized path and position.
+	Result jsonpath.Result
+}
+
+// Tool describes the program that produced a set of findings.
+type Tool struct {
+	Name           string
+	Version        string
+	InformationURI string
+}
+
+// NewFindings attributes every result to the same rule, message, severity and file.
+func NewFindings(ruleID, message string, severity Severity, file string, results []jsonpath.Result) []Finding {
+	findings := make([]Finding, len(results))
+	for i, result := range results {
+		findings[i] = Finding{
+			RuleID:   ruleID,
+			Message:  message,
+			Severity: severity,
+			File:     file,
+			Result:   result,
+		}
+	}
+	return findings
+}
@@ -0,0 +1,66 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const testSpec = `openapi: 3.1.0
+paths:
+  /users:
+    get:
+      summary: list users
+    post:
+      description: create a user
+`
+
+func queryFindings(t *testing.T, expr, rule, message string, severity report.Severity) []report.Finding {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(testSpec), &node))
+	path, err := jsonpath.NewPath(expr)
+	require.NoError(t, err)
+	return report.NewFindings(rule, message, severity, "openapi.yaml", path.QueryResults(&node))
+}
+
+func TestWriteSARIF(t *testing.T) {
+	findings := queryFindings(t, `$.paths.*[?(!@.summary)]`, "operation-summary", "operation is missing a summary", report.SeverityWarning)
+	require.Len(t, findings, 1)
+
+	var buf bytes.Buffer
+	err := report.WriteSARIF(&buf, report.Tool{Name: "jsonpath", Version: "1.0.0"}, findings)
+	require.NoError(t, err)
+
+	var log map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log["version"])
+
+	run := log["runs"].([]any)[0].(map[string]any)
+	driver := run["tool"].(map[string]any)["driver"].(map[string]any)
+	assert.Equal(t, "jsonpath", driver["name"])
+	assert.Equal(t, "operation-summary", driver["rules"].([]any)[0].(map[string]any)["id"])
+
+	result := run["results"].([]any)[0].(map[string]any)
+	assert.Equal(t, "warning", result["level"])
+	assert.Equal(t, "operation is missing a summary", result["message"].(map[string]any)["text"])
+
+	location := result["locations"].([]any)[0].(map[string]any)
+	physical := location["physicalLocation"].(map[string]any)
+	assert.Equal(t, "openapi.yaml", physical["artifactLocation"].(map[string]any)["uri"])
+	assert.Equal(t, float64(7), physical["region"].(map[string]any)["startLine"])
+	logical := location["logicalLocations"].([]any)[0].(map[string]any)
+	assert.Equal(t, "$['paths']['/users']['post']", logical["fullyQualifiedName"])
+}
+
+func TestWriteSARIF_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	err := report.WriteSARIF(&buf, report.Tool{Name: "jsonpath"}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), `"results": []`)
+}
@@ -0,0 +1,130 @@
+package report
+
+import (
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"go.yaml.in/yaml/v4"
+)
+
+// Position is a place in a document, as vacuum reports it: a 1-based line and a 1-based character
+// within that line.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is the stretch of a document a rule result covers, from the start of the matched node to
+// the end of its last descendant.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// RuleResult is a finding in the shape of the rule results of vacuum and the other pb33f linters,
+// so that findings can be handed to their reports and tooling without each caller mapping them.
+// It marshals to the same JSON as vacuum's results.
+type RuleResult struct {
+	// Message is the human-readable description of the result.
+	Message string `json:"message"`
+	// Range covers the matched node, and is zero when its position is unknown.
+	Range Range `json:"range"`
+	// Path is the RFC 9535 normalized path to the matched node.
+	Path string `json:"path"`
+	// RuleID identifies the rule that produced the result.
+	RuleID string `json:"ruleId"`
+	// RuleSeverity is the severity in vacuum's vocabulary: error, warn or info.
+	RuleSeverity string `json:"ruleSeverity"`
+	// StartNode is the matched node.
+	StartNode *yaml.Node `json:"-"`
+	// EndNode is the last node within the matched node, where Range ends.
+	EndNode *yaml.Node `json:"-"`
+}
+
+// RuleSeverity maps a severity onto vacuum's severity vocabulary.
+func RuleSeverity(s Severity) string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warn"
+	default:
+		return "info"
+	}
+}
+
+// ParseRuleSeverity maps one of vacuum's severities onto a Severity, reading hint as info. It
+// returns false for a severity vacuum does not have.
+func ParseRuleSeverity(severity string) (Severity, bool) {
+	switch severity {
+	case "error":
+		return SeverityError, true
+	case "warn":
+		return SeverityWarning, true
+	case "info", "hint":
+		return SeverityInfo, true
+	}
+	return SeverityInfo, false
+}
+
+// NewRuleResult converts a finding into a rule result. The File of the finding is not kept, as
+// rule results belong to the document they were produced for.
+func NewRuleResult(finding Finding) RuleResult {
+	result := RuleResult{
+		Message:      finding.Message,
+		Path:         finding.Result.Path,
+		RuleID:       finding.RuleID,
+		RuleSeverity: RuleSeverity(finding.Severity),
+		StartNode:    finding.Result.Node,
+	}
+	if finding.Result.Node != nil {
+		result.EndNode = lastNode(finding.Result.Node)
+	}
+	if finding.Result.Line > 0 {
+		result.Range.Start = Position{Line: finding.Result.Line, Character: finding.Result.Column}
+		result.Range.End = result.Range.Start
+		if result.EndNode != nil && result.EndNode.Line > 0 {
+			result.Range.End = Position{
+				Line:      result.EndNode.Line,
+				Character: result.EndNode.Column + len(result.EndNode.Value),
+			}
+		}
+	}
+	return result
+}
+
+// NewRuleResults converts each finding into a rule result.
+func NewRuleResults(findings []Finding) []RuleResult {
+	results := make([]RuleResult, len(findings))
+	for i, finding := range findings {
+		results[i] = NewRuleResult(finding)
+	}
+	return results
+}
+
+// Finding converts the rule result back into a finding of the given file. A severity vacuum does not
+// have becomes SeverityInfo.
+func (r RuleResult) Finding(file string) Finding {
+	severity, _ := ParseRuleSeverity(r.RuleSeverity)
+	return Finding{
+		RuleID:   r.RuleID,
+		Message:  r.Message,
+		Severity: severity,
+		File:     file,
+		Result: jsonpath.Result{
+			Node:   r.StartNode,
+			Path:   r.Path,
+			Line:   r.Range.Start.Line,
+			Column: r.Range.Start.Character,
+		},
+	}
+}
+
+// lastNode returns the node a collection ends with, following the last entries down to a scalar,
+// or the node itself when it has no content
+func lastNode(node *yaml.Node) *yaml.Node {
+	seen := make(map[*yaml.Node]bool)
+	for len(node.Content) > 0 && !seen[node] {
+		seen[node] = true
+		node = node.Content[len(node.Content)-1]
+	}
+	return node
+}
@@ -0,0 +1,53 @@
+package report_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRuleResults(t *testing.T) {
+	findings := queryFindings(t, `$.paths.*[?(!@.summary)]`, "operation-summary", "operation is missing a summary", report.SeverityWarning)
+	require.Len(t, findings, 1)
+
+	results := report.NewRuleResults(findings)
+	require.Len(t, results, 1)
+	result := results[0]
+	assert.Equal(t, "operation-summary", result.RuleID)
+	assert.Equal(t, "warn", result.RuleSeverity)
+	assert.Equal(t, "$['paths']['/users']['post']", result.Path)
+	assert.Same(t, findings[0].Result.Node, result.StartNode)
+	assert.Equal(t, "create a user", result.EndNode.Value)
+	assert.Equal(t, report.Range{
+		Start: report.Position{Line: 7, Character: 7},
+		End:   report.Position{Line: 7, Character: 33},
+	}, result.Range)
+
+	out, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"message": "operation is missing a summary",
+		"range": {"start": {"line": 7, "character": 7}, "end": {"line": 7, "character": 33}},
+		"path": "$['paths']['/users']['post']",
+		"ruleId": "operation-summary",
+		"ruleSeverity": "warn"
+	}`, string(out))
+
+	assert.Equal(t, findings[0], result.Finding("openapi.yaml"))
+}
+
+func TestRuleSeverity(t *testing.T) {
+	for _, severity := range []report.Severity{report.SeverityError, report.SeverityWarning, report.SeverityInfo} {
+		parsed, ok := report.ParseRuleSeverity(report.RuleSeverity(severity))
+		assert.True(t, ok)
+		assert.Equal(t, severity, parsed)
+	}
+	parsed, ok := report.ParseRuleSeverity("hint")
+	assert.True(t, ok)
+	assert.Equal(t, report.SeverityInfo, parsed)
+	_, ok = report.ParseRuleSeverity("fatal")
+	assert.False(t, ok)
+}
@@ -0,0 +1,261 @@
+// Package transform reshapes YAML documents into new documents described by templates, turning
+// JSONPath queries into a declarative way to build API response stubs, reports and summaries.
+//
+// A template is a YAML document whose string values may be queries. A value starting with $ is a
+// query against the input document, and a value starting with @ is a query against the current
+// node, which is the input document unless inside $each. A singular query such as $.info.title is
+// replaced by a copy of the node it matches, and its key is left out if it matches nothing. Any other
+// query, such as $.tags[*].name, is replaced by a sequence of copies of its matches.
+//
+// A mapping with an $each key builds a sequence from the matches of the $each query: the rest of the
+// mapping is the template for each match, or the template under $value if there is one.
+//
+//	title: $.info.title
+//	operations:
+//	  $each: $.paths.*[?@.operationId]
+//	  id: "@.operationId"
+//	  tags: "@.tags[*]"
+//	  source: openapi
+//
+// Every other value is copied as is. To write a string that starts with $ or @, double the first
+// character: $$5 becomes $5.
+package transform
+
+import (
+	"fmt"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/jsonpath/config"
+	"go.yaml.in/yaml/v4"
+)
+
+const (
+	eachKey  = "$each"
+	valueKey = "$value"
+)
+
+// Transform is a compiled template.
+type Transform struct {
+	root *part
+}
+
+type partKind int
+
+const (
+	partLiteral partKind = iota
+	partQuery
+	partMapping
+	partSequence
+	partEach
+)
+
+// part is a compiled template node
+type part struct {
+	kind partKind
+	// node is the template node, copied as is for literals
+	node *yaml.Node
+	// path is the query of a query or $each part, evaluated against the current node if relative
+	path     *jsonpath.JSONPath
+	relative bool
+	// keys and values are the members of a mapping part
+	keys   []*yaml.Node
+	values []*part
+	// items are the elements of a sequence part
+	items []*part
+	// body is the template applied to every match of an $each part
+	body *part
+}
+
+// Parse compiles a template written in YAML.
+func Parse(template string, opts ...config.Option) (*Transform, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(template), &node); err != nil {
+		return nil, err
+	}
+	return Compile(&node, opts...)
+}
+
+// Compile compiles a template, parsing its queries with opts.
+func Compile(template *yaml.Node, opts ...config.Option) (*Transform, error) {
+	if template.Kind == yaml.DocumentNode && len(template.Content) == 1 {
+		template = template.Content[0]
+	}
+	root, err := compile(template, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Transform{root: root}, nil
+}
+
+func compile(node *yaml.Node, opts []config.Option) (*part, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return compile(node.Alias, opts)
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" || node.Value == "" || (node.Value[0] != '$' && node.Value[0] != '@') {
+			return &part{kind: partLiteral, node: node}, nil
+		}
+		if len(node.Value) > 1 && node.Value[1] == node.Value[0] {
+			literal := *node
+			literal.Value = node.Value[1:]
+			return &part{kind: partLiteral, node: &literal}, nil
+		}
+		return compileQuery(node, opts)
+	case yaml.SequenceNode:
+		p := &part{kind: partSequence, node: node}
+		for _, item := range node.Content {
+			compiled, err := compile(item, opts)
+			if err != nil {
+				return nil, err
+			}
+			p.items = append(p.items, compiled)
+		}
+		return p, nil
+	case yaml.MappingNode:
+		return compileMapping(node, opts)
+	}
+	return nil, fmt.Errorf("line %d, column %d: unsupported template node", node.Line, node.Column)
+}
+
+func compileQuery(node *yaml.Node, opts []config.Option) (*part, error) {
+	expr := node.Value
+	relative := expr[0] == '@'
+	if relative {
+		expr = "$" + expr[1:]
+	}
+	path, err := jsonpath.NewPath(expr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("line %d, column %d: invalid query %q: %w", node.Line, node.Column, node.Value, err)
+	}
+	return &part{kind: partQuery, node: node, path: path, relative: relative}, nil
+}
+
+func compileMapping(node *yaml.Node, opts []config.Option) (*part, error) {
+	var each, value *yaml.Node
+	mapping := &part{kind: partMapping, node: node}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, v := node.Content[i], node.Content[i+1]
+		switch key.Value {
+		case eachKey:
+			each = v
+			continue
+		case valueKey:
+			value = v
+			continue
+		}
+		compiled, err := compile(v, opts)
+		if err != nil {
+			return nil, err
+		}
+		mapping.keys = append(mapping.keys, key)
+		mapping.values = append(mapping.values, compiled)
+	}
+
+	if each == nil {
+		if value != nil {
+			return nil, fmt.Errorf("line %d, column %d: %s without %s", node.Line, node.Column, valueKey, eachKey)
+		}
+		return mapping, nil
+	}
+	if each.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("line %d, column %d: %s must be a query", each.Line, each.Column, eachKey)
+	}
+	query, err := compile(each, opts)
+	if err != nil {
+		return nil, err
+	}
+	if query.kind != partQuery {
+		return nil, fmt.Errorf("line %d, column %d: %s must be a query", each.Line, each.Column, eachKey)
+	}
+	query.kind = partEach
+	query.body = mapping
+	if value != nil {
+		if len(mapping.keys) > 0 {
+			return nil, fmt.Errorf("line %d, column %d: %s cannot be combined with other keys", value.Line, value.Column, valueKey)
+		}
+		if query.body, err = compile(value, opts); err != nil {
+			return nil, err
+		}
+	}
+	return query, nil
+}
+
+// Apply builds the document the template describes from the document rooted at root. The result
+// shares no nodes with root or the template.
+func (t *Transform) Apply(root *yaml.Node) *yaml.Node {
+	if root.Kind == yaml.DocumentNode && len(root.Content) == 1 {
+		root = root.Content[0]
+	}
+	if out := t.root.apply(root, root); len(out) > 0 {
+		return out[0]
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
+
+// apply returns the nodes the part produces for the current node: none for a singular query with
+// no match, and exactly one otherwise.
+func (p *part) apply(root *yaml.Node, current *yaml.Node) []*yaml.Node {
+	switch p.kind {
+	case partQuery:
+		matches := p.query(root, current)
+		if p.path.IsSingular() {
+			if len(matches) == 0 {
+				return nil
+			}
+			return []*yaml.Node{copyNode(matches[0])}
+		}
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, match := range matches {
+			seq.Content = append(seq.Content, copyNode(match))
+		}
+		return []*yaml.Node{seq}
+	case partEach:
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, match := range p.query(root, current) {
+			seq.Content = append(seq.Content, p.body.apply(root, match)...)
+		}
+		return []*yaml.Node{seq}
+	case partMapping:
+		mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Style: p.node.Style}
+		for i, key := range p.keys {
+			if value := p.values[i].apply(root, current); len(value) > 0 {
+				mapping.Content = append(mapping.Content, copyNode(key), value[0])
+			}
+		}
+		return []*yaml.Node{mapping}
+	case partSequence:
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: p.node.Style}
+		for _, item := range p.items {
+			seq.Content = append(seq.Content, item.apply(root, current)...)
+		}
+		return []*yaml.Node{seq}
+	}
+	return []*yaml.Node{copyNode(p.node)}
+}
+
+func (p *part) query(root *yaml.Node, current *yaml.Node) []*yaml.Node {
+	if p.relative {
+		return p.path.Query(current)
+	}
+	return p.path.Query(root)
+}
+
+// copyNode deep copies a node, expanding aliases and dropping anchors and source positions
+func copyNode(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	out := &yaml.Node{
+		Kind:        node.Kind,
+		Style:       node.Style,
+		Tag:         node.Tag,
+		Value:       node.Value,
+		HeadComment: node.HeadComment,
+		LineComment: node.LineComment,
+		FootComment: node.FootComment,
+	}
+	for _, child := range node.Content {
+		out.Content = append(out.Content, copyNode(child))
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package transform_test
+
+import (
+	"testing"
+
+	"github.com/pb33f/jsonpath/pkg/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const spec = `openapi: 3.1.0
+info:
+  title: Users API
+  version: &version 1.2.0
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      tags: [users]
+    post:
+      operationId: createUser
+      tags: [users, admin]
+  /health:
+    get:
+      summary: health check
+x-release: *version
+`
+
+func apply(t *testing.T, template string) string {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(spec), &root))
+	tr, err := transform.Parse(template)
+	require.NoError(t, err)
+	out, err := yaml.Marshal(tr.Apply(&root))
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestApply(t *testing.T) {
+	out := apply(t, `
+title: $.info.title
+release: $['x-release']
+missing: $.info.description
+tags: $.paths.*.*.tags[*]
+price: $$5
+operations:
+  $each: $.paths.*[?@.operationId]
+  id: "@.operationId"
+  tags: "@.tags[*]"
+  source: openapi
+`)
+	assert.Equal(t, `title: Users API
+release: 1.2.0
+tags:
+    - users
+    - users
+    - admin
+price: $5
+operations:
+    - id: listUsers
+      tags:
+        - users
+      source: openapi
+    - id: createUser
+      tags:
+        - users
+        - admin
+      source: openapi
+`, out)
+}
+
+func TestApply_EachValue(t *testing.T) {
+	out := apply(t, `
+ids:
+  $each: $.paths.*.*
+  $value: "@.operationId"
+summaries:
+  $each: $.paths.*.*
+  $value: ["@.summary", "@@home"]
+`)
+	assert.Equal(t, `ids:
+    - listUsers
+    - createUser
+summaries:
+    - ["@home"]
+    - ["@home"]
+    - [health check, "@home"]
+`, out)
+}
+
+func TestApply_DoesNotShareNodes(t *testing.T) {
+	var root yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(spec), &root))
+	tr, err := transform.Parse(`info: $.info`)
+	require.NoError(t, err)
+
+	out := tr.Apply(&root)
+	out.Content[1].Content[1].Value = "changed"
+	assert.Equal(t, "Users API", root.Content[0].Content[3].Content[1].Value)
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, template := range []string{
+		`title: $.info[`,
+		`ids: {$value: "@.id"}`,
+		`ids: {$each: [a]}`,
+		`ids: {$each: plain}`,
+		`ids: {$each: $.paths.*, $value: "@", other: x}`,
+	} {
+		_, err := transform.Parse(template)
+		assert.Error(t, err, template)
+	}
+}
@@ -0,0 +1,243 @@
+// Package watch re-evaluates overlays and JSONPath queries whenever the documents they
+// depend on change on disk. It is the engine behind live-preview developer tooling.
+package watch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/overlay"
+	"go.yaml.in/yaml/v4"
+)
+
+// DefaultDebounce is how long the watcher waits for a burst of file events to settle
+// before re-evaluating.
+const DefaultDebounce = 100 * time.Millisecond
+
+// Query is a named JSONPath expression that is re-run on every change.
+type Query struct {
+	Name string
+	Path *jsonpath.JSONPath
+}
+
+// Diff describes how the results of a query changed between two evaluations.
+// Results are matched on their normalized paths.
+type Diff struct {
+	Added   []jsonpath.Result
+	Removed []jsonpath.Result
+	Changed []jsonpath.Result
+}
+
+// IsEmpty returns true if the results did not change.
+func (d Diff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Event is delivered to the callback after every evaluation.
+type Event struct {
+	// Document is the specification with all overlays applied.
+	Document *yaml.Node
+	// Results holds the current results of every query, keyed by query name.
+	Results map[string][]jsonpath.Result
+	// Diffs holds the change in results since the previous evaluation, keyed by query name.
+	Diffs map[string]Diff
+	// Err is set if the specification or an overlay could not be loaded or applied.
+	Err error
+}
+
+// Option configures a Watcher.
+type Option func(*Watcher)
+
+// WithOverlays applies the overlay files, in order, to the specification on every change.
+// The overlay files are watched as well.
+func WithOverlays(paths ...string) Option {
+	return func(w *Watcher) {
+		w.overlays = append(w.overlays, paths...)
+	}
+}
+
+// WithQuery re-runs the named query against the (overlaid) specification on every change.
+func WithQuery(name string, path *jsonpath.JSONPath) Option {
+	return func(w *Watcher) {
+		w.queries = append(w.queries, Query{Name: name, Path: path})
+	}
+}
+
+// WithDebounce overrides DefaultDebounce.
+func WithDebounce(d time.Duration) Option {
+	return func(w *Watcher) {
+		w.debounce = d
+	}
+}
+
+// Watcher monitors a specification and its overlays for changes.
+type Watcher struct {
+	spec     string
+	overlays []string
+	queries  []Query
+	debounce time.Duration
+	onChange func(Event)
+
+	mu       sync.Mutex
+	previous map[string][]jsonpath.Result
+}
+
+// New creates a watcher for the specification at path. onChange is called once with the
+// initial evaluation when Run starts, and again after every change.
+func New(spec string, onChange func(Event), opts ...Option) *Watcher {
+	w := &Watcher{
+		spec:     spec,
+		debounce: DefaultDebounce,
+		onChange: onChange,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Run watches the files until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fsw.Close()
+
+	// Editors commonly save by renaming a temporary file over the original, which drops
+	// watches on the file itself, so watch the containing directories instead.
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, path := range append([]string{w.spec}, w.overlays...) {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path for %q: %w", path, err)
+		}
+		files[abs] = true
+		dir := filepath.Dir(abs)
+		if !dirs[dir] {
+			if err := fsw.Add(dir); err != nil {
+				return fmt.Errorf("failed to watch %q: %w", dir, err)
+			}
+			dirs[dir] = true
+		}
+	}
+
+	w.onChange(w.Evaluate())
+
+	var timer *time.Timer
+	var fire <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return nil
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			w.onChange(Event{Err: err})
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			abs, err := filepath.Abs(event.Name)
+			if err != nil || !files[abs] || event.Op == fsnotify.Chmod {
+				continue
+			}
+			if timer == nil {
+				timer = time.NewTimer(w.debounce)
+			} else {
+				timer.Reset(w.debounce)
+			}
+			fire = timer.C
+		case <-fire:
+			fire = nil
+			w.onChange(w.Evaluate())
+		}
+	}
+}
+
+// Evaluate loads the specification, applies the overlays and runs the queries once,
+// diffing the results against the previous evaluation.
+func (w *Watcher) Evaluate() Event {
+	document, err := w.load()
+	if err != nil {
+		return Event{Err: err}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	event := Event{
+		Document: document,
+		Results:  make(map[string][]jsonpath.Result, len(w.queries)),
+		Diffs:    make(map[string]Diff, len(w.queries)),
+	}
+	for _, query := range w.queries {
+		results := query.Path.QueryResults(document)
+		event.Results[query.Name] = results
+		event.Diffs[query.Name] = diffResults(w.previous[query.Name], results)
+	}
+	w.previous = event.Results
+	return event
+}
+
+func (w *Watcher) load() (*yaml.Node, error) {
+	document, err := overlay.LoadSpecification(w.spec)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range w.overlays {
+		o, err := overlay.LoadOverlay(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := o.ApplyTo(document); err != nil {
+			return nil, fmt.Errorf("failed to apply overlay %q: %w", path, err)
+		}
+	}
+	return document, nil
+}
+
+func diffResults(before, after []jsonpath.Result) Diff {
+	var diff Diff
+	previous := make(map[string]jsonpath.Result, len(before))
+	for _, result := range before {
+		previous[result.Path] = result
+	}
+	for _, result := range after {
+		old, ok := previous[result.Path]
+		if !ok {
+			diff.Added = append(diff.Added, result)
+			continue
+		}
+		delete(previous, result.Path)
+		if !bytes.Equal(encode(old.Node), encode(result.Node)) {
+			diff.Changed = append(diff.Changed, result)
+		}
+	}
+	for _, result := range before {
+		if _, ok := previous[result.Path]; ok {
+			diff.Removed = append(diff.Removed, result)
+		}
+	}
+	return diff
+}
+
+func encode(node *yaml.Node) []byte {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	if err := enc.Encode(node); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,121 @@
+package watch_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pb33f/jsonpath/pkg/jsonpath"
+	"github.com/pb33f/jsonpath/pkg/watch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const spec = `openapi: 3.1.0
+paths:
+  /users:
+    get:
+      summary: list users
+`
+
+const overlayFile = `overlay: 1.0.0
+info:
+  title: add post
+  version: 1.0.0
+actions:
+  - target: $.paths['/users']
+    update:
+      post:
+        summary: create user
+`
+
+func TestWatcher_Evaluate(t *testing.T) {
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, "openapi.yaml")
+	overlayPath := filepath.Join(dir, "overlay.yaml")
+	require.NoError(t, os.WriteFile(specPath, []byte(spec), 0644))
+	require.NoError(t, os.WriteFile(overlayPath, []byte(overlayFile), 0644))
+
+	summaries, err := jsonpath.NewPath(`$.paths.*.*.summary`)
+	require.NoError(t, err)
+
+	w := watch.New(specPath, func(watch.Event) {}, watch.WithOverlays(overlayPath), watch.WithQuery("summaries", summaries))
+
+	event := w.Evaluate()
+	require.NoError(t, event.Err)
+	assert.Len(t, event.Results["summaries"], 2)
+	assert.Len(t, event.Diffs["summaries"].Added, 2)
+
+	require.NoError(t, os.WriteFile(specPath, []byte(spec+`    delete:
+      summary: delete users
+`), 0644))
+	require.NoError(t, os.WriteFile(overlayPath, []byte(`overlay: 1.0.0
+info:
+  title: change get
+  version: 1.0.0
+actions:
+  - target: $.paths['/users'].get.summary
+    update: list all users
+`), 0644))
+
+	event = w.Evaluate()
+	require.NoError(t, event.Err)
+	diff := event.Diffs["summaries"]
+	require.Len(t, diff.Added, 1)
+	assert.Equal(t, "$['paths']['/users']['delete']['summary']", diff.Added[0].Path)
+	require.Len(t, diff.Removed, 1)
+	assert.Equal(t, "$['paths']['/users']['post']['summary']", diff.Removed[0].Path)
+	require.Len(t, diff.Changed, 1)
+	assert.Equal(t, "list all users", diff.Changed[0].Node.Value)
+
+	event = w.Evaluate()
+	assert.True(t, event.Diffs["summaries"].IsEmpty())
+}
+
+func TestWatcher_EvaluateError(t *testing.T) {
+	w := watch.New(filepath.Join(t.TempDir(), "missing.yaml"), func(watch.Event) {})
+	assert.Error(t, w.Evaluate().Err)
+}
+
+func TestWatcher_Run(t *testing.T) {
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, "openapi.yaml")
+	require.NoError(t, os.WriteFile(specPath, []byte(spec), 0644))
+
+	summaries, err := jsonpath.NewPath(`$.paths.*.*.summary`)
+	require.NoError(t, err)
+
+	events := make(chan watch.Event, 10)
+	w := watch.New(specPath, func(e watch.Event) { events <- e },
+		watch.WithQuery("summaries", summaries), watch.WithDebounce(10*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	select {
+	case event := <-events:
+		require.NoError(t, event.Err)
+		assert.Len(t, event.Results["summaries"], 1)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for initial evaluation")
+	}
+
+	require.NoError(t, os.WriteFile(specPath, []byte(spec+`    post:
+      summary: create user
+`), 0644))
+
+	select {
+	case event := <-events:
+		require.NoError(t, event.Err)
+		require.Len(t, event.Diffs["summaries"].Added, 1)
+		assert.Equal(t, "create user", event.Diffs["summaries"].Added[0].Node.Value)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for change evaluation")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}